@@ -0,0 +1,153 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type anyOfGroup[CT context.Context, C any, T any] struct {
+	members      []*component[CT, C, T]
+	cancelOthers bool
+}
+
+/*
+AddAnyOfDependencies: current component runs as soon as any one of d is DONE, e.g. whichever of two OCR providers
+finishes first. It fails when all of d failed and is SKIPPED when all of d were skipped. The other components of d
+keep running, see AddFirstOfDependencies to cancel them.
+*/
+func (c *component[CT, C, T]) AddAnyOfDependencies(d ...Component[CT, C, T]) {
+	c.addAnyOf(d, false)
+}
+
+/*
+AddFirstOfDependencies: like AddAnyOfDependencies, the other components of d are cancelled once the first one is DONE.
+Not yet started ones are SKIPPED, running ones see dt.Context() cancelled (also their ctx when CT is context.Context)
+and are SKIPPED when they return an error.
+*/
+func (c *component[CT, C, T]) AddFirstOfDependencies(d ...Component[CT, C, T]) {
+	c.addAnyOf(d, true)
+}
+
+func (c *component[CT, C, T]) addAnyOf(d []Component[CT, C, T], cancelOthers bool) {
+	if len(d) == 0 {
+		panic("any of dependencies need at least one component")
+	}
+	group := anyOfGroup[CT, C, T]{cancelOthers: cancelOthers}
+	for _, dep := range d {
+		group.members = append(group.members, dep)
+	}
+	c.anyOf = append(c.anyOf, group)
+	c.addAnyOfLinks(group.members)
+}
+
+/* AddAnyOfLinks: componentId waits for any one of dependencyIds */
+func (d *dependencyManager) AddAnyOfLinks(componentId string, dependencyIds []string) {
+	for _, dependencyId := range dependencyIds {
+		d.AddLink(componentId, dependencyId)
+	}
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	d.anyOf[componentId] = append(d.anyOf[componentId], dependencyIds)
+}
+
+/* waitAnyOf: DONE as soon as one of dependencyIds is DONE, ERROR when none is DONE and one failed, SKIPPED otherwise */
+func (d *dependencyManager) waitAnyOf(dependencyIds []string) Status {
+	statuses := make(chan Status, len(dependencyIds))
+	for _, dependencyId := range dependencyIds {
+		go func(k string) {
+			statuses <- <-d.dependencyChannels[k].Dependency
+		}(dependencyId)
+	}
+	result := SKIPPED
+	for range dependencyIds {
+		switch <-statuses {
+		case DONE:
+			return DONE
+		case ERROR:
+			result = ERROR
+		}
+	}
+	return result
+}
+
+/* cancelAnyOfLosers cancels the other members of the first-of groups of c, called once c is runnable */
+func (wf *Workflow[CT, C, T]) cancelAnyOfLosers(c *component[CT, C, T]) {
+	for _, group := range c.anyOf {
+		if !group.cancelOthers {
+			continue
+		}
+		for _, m := range group.members {
+			m.cancelRun("cancelled, another dependency of " + c.Name + " finished first")
+		}
+	}
+}
+
+type componentCancellation struct {
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	reason string
+	done   bool
+}
+
+/* cancelRun cancels the component unless it already finished */
+func (c *component[CT, C, T]) cancelRun(reason string) {
+	c.cancellation.lock.Lock()
+	defer c.cancellation.lock.Unlock()
+	if c.cancellation.done || c.cancellation.reason != "" {
+		return
+	}
+	c.cancellation.reason = reason
+	if c.cancellation.cancel != nil {
+		c.cancellation.cancel()
+	}
+}
+
+/* startRun registers cancel for the execution, returns the reason if the component was cancelled already */
+func (c *component[CT, C, T]) startRun(cancel context.CancelFunc) string {
+	c.cancellation.lock.Lock()
+	defer c.cancellation.lock.Unlock()
+	c.cancellation.cancel = cancel
+	if c.cancellation.reason != "" {
+		cancel()
+	}
+	return c.cancellation.reason
+}
+
+/* finishRun: later cancellations are ignored, returns the reason if the component was cancelled */
+func (c *component[CT, C, T]) finishRun() string {
+	c.cancellation.lock.Lock()
+	defer c.cancellation.lock.Unlock()
+	c.cancellation.done = true
+	return c.cancellation.reason
+}
+
+type templateAnyOf struct {
+	dependencies []string
+	cancelOthers bool
+}
+
+/* AddAnyOfDependencies: see Component.AddAnyOfDependencies */
+func (tc *TemplateComponent[CT, C, T]) AddAnyOfDependencies(d ...*TemplateComponent[CT, C, T]) {
+	tc.addAnyOf(d, false)
+}
+
+/* AddFirstOfDependencies: see Component.AddFirstOfDependencies */
+func (tc *TemplateComponent[CT, C, T]) AddFirstOfDependencies(d ...*TemplateComponent[CT, C, T]) {
+	tc.addAnyOf(d, true)
+}
+
+func (tc *TemplateComponent[CT, C, T]) addAnyOf(d []*TemplateComponent[CT, C, T], cancelOthers bool) {
+	tc.template.mustNotBeFrozen()
+	if len(d) == 0 {
+		panic("any of dependencies need at least one component")
+	}
+	group := templateAnyOf{cancelOthers: cancelOthers}
+	for _, dep := range d {
+		if dep.template != tc.template {
+			panic(fmt.Sprintf("dependency %s belongs to another template", dep.Name()))
+		}
+		group.dependencies = append(group.dependencies, dep.Name())
+	}
+	tc.anyOf = append(tc.anyOf, group)
+}
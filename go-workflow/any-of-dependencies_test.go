@@ -0,0 +1,87 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func ocrProvider(name string, delay time.Duration, err error) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+	return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		dt.Update(func(d *Data) {
+			if d.A == "" {
+				d.A = name
+			}
+		})
+		return nil
+	}
+}
+
+func TestAnyOfDependencies(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	fast := wf.AddComponent(goworkflow.MakeComponent("FastOCR", nil, ocrProvider("fast", 10*time.Millisecond, nil)))
+	slow := wf.AddComponent(goworkflow.MakeComponent("SlowOCR", nil, ocrProvider("slow", 200*time.Millisecond, nil)))
+	var startedAt time.Time
+	wf.AddComponent(goworkflow.MakeComponent("Parse", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		startedAt = time.Now()
+		return nil
+	})).AddAnyOfDependencies(fast, slow)
+
+	start := time.Now()
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "fast", data.A)
+	assert.Less(t, startedAt.Sub(start), 150*time.Millisecond)
+	// without cancellation the slow provider still completes
+	assert.Equal(t, goworkflow.DONE, slow.Status().Status)
+}
+
+func TestFirstOfDependencies(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	fast := wf.AddComponent(goworkflow.MakeComponent("FastOCR", nil, ocrProvider("fast", 10*time.Millisecond, nil)))
+	slow := wf.AddComponent(goworkflow.MakeComponent("SlowOCR", nil, ocrProvider("slow", 5*time.Second, nil)))
+	wf.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 0, nil))).AddFirstOfDependencies(fast, slow)
+
+	start := time.Now()
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, goworkflow.SKIPPED, slow.Status().Status)
+}
+
+func TestAnyOfDependenciesAllFailed(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("OCR1", nil, ocrProvider("1", 0, errors.New("down"))))
+	b := wf.AddComponent(goworkflow.MakeComponent("OCR2", nil, ocrProvider("2", 0, errors.New("down"))))
+	parse := wf.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 0, nil)))
+	parse.AddAnyOfDependencies(a, b)
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, goworkflow.ERROR, parse.Status().Status)
+}
+
+func TestTemplateFirstOfDependencies(t *testing.T) {
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	fast := tpl.AddComponent(goworkflow.MakeComponent("FastOCR", nil, ocrProvider("fast", 0, nil)))
+	slow := tpl.AddComponent(goworkflow.MakeComponent("SlowOCR", nil, ocrProvider("slow", 5*time.Second, nil)))
+	tpl.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 0, nil))).AddFirstOfDependencies(fast, slow)
+
+	start := time.Now()
+	data, st, _ := tpl.Clone().Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "fast", data.A)
+	assert.Less(t, time.Since(start), time.Second)
+}
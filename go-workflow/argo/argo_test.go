@@ -0,0 +1,103 @@
+package argo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Language string
+}
+
+type Data struct {
+	Document string
+	Text     string
+	Summary  string
+	Error    string
+}
+
+func documentTemplate() *goworkflow.Template[context.Context, Config, Data] {
+	t := goworkflow.NewTemplate[context.Context, Config, Data]("Document Pipeline")
+	t.Version = "v3"
+	ocr := t.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.Text = "text of " + d.Document + " in " + dt.Config.Language })
+		return nil
+	}))
+	summary := t.AddComponent(goworkflow.MakeComponent("Summary_v2", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.Summary = "summary of " + d.Text })
+		return nil
+	}), &goworkflow.ComponentConfig{Optional: true})
+	report := t.AddComponent(goworkflow.MakeComponent("Report", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	summary.AddDependencies(ocr)
+	report.AddDependenciesOn(goworkflow.OnCompletion, summary)
+	report.AddDependencies(ocr)
+	return t
+}
+
+func TestExport(t *testing.T) {
+	out, err := Export(documentTemplate(), Options{Image: "registry/document-runner:v3", Namespace: "batch"})
+	assert.NoError(t, err)
+
+	var m manifest
+	assert.NoError(t, yaml.Unmarshal(out, &m))
+	assert.Equal(t, "Workflow", m.Kind)
+	assert.Equal(t, "document-pipeline-", m.Metadata.GenerateName)
+	assert.Equal(t, "v3", m.Metadata.Labels["goworkflow/version"])
+	assert.Equal(t, "document-pipeline", m.Spec.Entrypoint)
+	assert.Len(t, m.Spec.Templates, 4)
+
+	tasks := m.Spec.Templates[0].DAG.Tasks
+	assert.Equal(t, "ocr", tasks[0].Name)
+	assert.Empty(t, tasks[0].Depends)
+	assert.Equal(t, "summary-v2", tasks[1].Name)
+	assert.Equal(t, "ocr.Succeeded", tasks[1].Depends)
+	assert.True(t, tasks[1].ContinueOn.Failed)
+	assert.Equal(t, "(summary-v2.Succeeded || summary-v2.Failed) && ocr.Succeeded", tasks[2].Depends)
+	assert.Equal(t, []artifact{
+		{Name: "summary-v2", From: "{{tasks.summary-v2.outputs.artifacts.data}}", Optional: true},
+		{Name: "ocr", From: "{{tasks.ocr.outputs.artifacts.data}}"},
+	}, tasks[2].Arguments.Artifacts)
+
+	step := m.Spec.Templates[3]
+	assert.Equal(t, "component-report", step.Name)
+	assert.Equal(t, "registry/document-runner:v3", step.Container.Image)
+	assert.Equal(t, []string{"/runner"}, step.Container.Command)
+	assert.Contains(t, strings.Join(step.Container.Args, " "), "--component Report")
+
+	_, err = Export(documentTemplate(), Options{})
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := documentTemplate()
+	step := func(component string, output string) error {
+		return Run(context.TODO(), tmpl, []string{
+			"--component", component,
+			"--config", `{"Language":"en"}`,
+			"--data", `{"Document":"scan.pdf"}`,
+			"--inputs", filepath.Join(dir, "inputs"),
+			"--output", filepath.Join(dir, output),
+		})
+	}
+	assert.NoError(t, step("OCR", "inputs/ocr.json"))
+	// the summary failed, its optional input is missing
+	assert.NoError(t, step("Report", "report.json"))
+	patch, _ := os.ReadFile(filepath.Join(dir, "report.json"))
+	assert.JSONEq(t, `{"Text":"text of scan.pdf in en"}`, string(patch))
+
+	assert.NoError(t, step("Summary_v2", "inputs/summary-v2.json"))
+	patch, _ = os.ReadFile(filepath.Join(dir, "inputs/summary-v2.json"))
+	assert.JSONEq(t, `{"Text":"text of scan.pdf in en","Summary":"summary of text of scan.pdf in en"}`, string(patch))
+
+	assert.EqualError(t, step("Missing", "missing.json"), "component Missing doesn't exist in template Document Pipeline")
+}
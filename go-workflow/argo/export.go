@@ -0,0 +1,247 @@
+/*
+Package argo exports workflow templates as Argo Workflows manifests, so DAGs built in Go also run on an Argo
+installation, e.g. for huge batch jobs. Every component becomes a container step of the runner image,
+a binary of the workflow which calls Run; the data store travels between the steps as artifacts.
+*/
+package argo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	// Image: runner image, its binary calls Run when started with the Command arguments
+	Image string
+	// Command: entrypoint of the runner in the image, ["/runner"] when empty
+	Command   []string
+	Namespace string
+	// ServiceAccount of the steps, the default one when empty
+	ServiceAccount string
+}
+
+/* paths inside the step containers */
+const (
+	inputsDir  = "/tmp/goworkflow/inputs"
+	outputPath = "/tmp/goworkflow/data.json"
+)
+
+type manifest struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   metadata `yaml:"metadata"`
+	Spec       spec     `yaml:"spec"`
+}
+
+type metadata struct {
+	GenerateName string            `yaml:"generateName"`
+	Namespace    string            `yaml:"namespace,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+}
+
+type spec struct {
+	Entrypoint         string     `yaml:"entrypoint"`
+	ServiceAccountName string     `yaml:"serviceAccountName,omitempty"`
+	Arguments          arguments  `yaml:"arguments"`
+	Templates          []argoStep `yaml:"templates"`
+}
+
+type arguments struct {
+	Parameters []parameter `yaml:"parameters,omitempty"`
+	Artifacts  []artifact  `yaml:"artifacts,omitempty"`
+}
+
+type parameter struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value,omitempty"`
+}
+
+type artifact struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	Optional bool   `yaml:"optional,omitempty"`
+}
+
+type argoStep struct {
+	Name      string     `yaml:"name"`
+	DAG       *dag       `yaml:"dag,omitempty"`
+	Inputs    *arguments `yaml:"inputs,omitempty"`
+	Outputs   *arguments `yaml:"outputs,omitempty"`
+	Container *container `yaml:"container,omitempty"`
+}
+
+type dag struct {
+	Tasks []task `yaml:"tasks"`
+}
+
+type task struct {
+	Name       string      `yaml:"name"`
+	Template   string      `yaml:"template"`
+	Depends    string      `yaml:"depends,omitempty"`
+	ContinueOn *continueOn `yaml:"continueOn,omitempty"`
+	Arguments  *arguments  `yaml:"arguments,omitempty"`
+}
+
+type continueOn struct {
+	Failed bool `yaml:"failed"`
+}
+
+type container struct {
+	Image   string   `yaml:"image"`
+	Command []string `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+/* taskNames: dns compatible, unique names of the components */
+func taskNames[CT context.Context, C any, T any](components []*goworkflow.TemplateComponent[CT, C, T]) map[string]string {
+	names := map[string]string{}
+	used := map[string]bool{}
+	for _, tc := range components {
+		name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(tc.Name()), "-"), "-")
+		if name == "" {
+			name = "component"
+		}
+		unique := name
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", name, i)
+		}
+		used[unique] = true
+		names[tc.Name()] = unique
+	}
+	return names
+}
+
+/*
+Export: Argo Workflow manifest running t. The config and the initial data are the workflow parameters
+config and data (json). Dependency outcomes and any-of groups become depends expressions, first-of groups
+don't cancel the other members; optional components don't fail the workflow.
+*/
+func Export[CT context.Context, C any, T any](t *goworkflow.Template[CT, C, T], opts Options) ([]byte, error) {
+	if opts.Image == "" {
+		return nil, fmt.Errorf("runner image is required")
+	}
+	command := opts.Command
+	if len(command) == 0 {
+		command = []string{"/runner"}
+	}
+	components := t.Components()
+	names := taskNames(components)
+	entrypoint := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(t.Name), "-"), "-")
+	if entrypoint == "" {
+		entrypoint = "workflow"
+	}
+
+	root := argoStep{Name: entrypoint, DAG: &dag{}}
+	steps := []argoStep{}
+	for _, tc := range components {
+		name := names[tc.Name()]
+		stepTemplate := "component-" + name
+		tk := task{Name: name, Template: stepTemplate, Depends: depends(tc, names)}
+		if tc.Config() != nil && tc.Config().Optional {
+			tk.ContinueOn = &continueOn{Failed: true}
+		}
+		inputs := &arguments{}
+		for _, dep := range inputDependencies(tc) {
+			if tk.Arguments == nil {
+				tk.Arguments = &arguments{}
+			}
+			// outputs of failed or skipped dependencies don't exist
+			optional := tc.DependencyOutcome(dep) != goworkflow.OnSuccess || !contains(tc.Dependencies(), dep)
+			tk.Arguments.Artifacts = append(tk.Arguments.Artifacts, artifact{
+				Name: names[dep], From: fmt.Sprintf("{{tasks.%s.outputs.artifacts.data}}", names[dep]), Optional: optional,
+			})
+			inputs.Artifacts = append(inputs.Artifacts, artifact{Name: names[dep], Path: inputsDir + "/" + names[dep] + ".json", Optional: optional})
+		}
+		root.DAG.Tasks = append(root.DAG.Tasks, tk)
+
+		step := argoStep{
+			Name:    stepTemplate,
+			Outputs: &arguments{Artifacts: []artifact{{Name: "data", Path: outputPath}}},
+			Container: &container{
+				Image:   opts.Image,
+				Command: command,
+				Args: []string{
+					"--component", tc.Name(),
+					"--config", "{{workflow.parameters.config}}",
+					"--data", "{{workflow.parameters.data}}",
+					"--inputs", inputsDir,
+					"--output", outputPath,
+				},
+			},
+		}
+		if len(inputs.Artifacts) > 0 {
+			step.Inputs = inputs
+		}
+		steps = append(steps, step)
+	}
+
+	m := manifest{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Workflow",
+		Metadata:   metadata{GenerateName: entrypoint + "-", Namespace: opts.Namespace},
+		Spec: spec{
+			Entrypoint:         entrypoint,
+			ServiceAccountName: opts.ServiceAccount,
+			Arguments:          arguments{Parameters: []parameter{{Name: "config", Value: "{}"}, {Name: "data", Value: "{}"}}},
+			Templates:          append([]argoStep{root}, steps...),
+		},
+	}
+	if t.Version != "" {
+		m.Metadata.Labels = map[string]string{"goworkflow/version": t.Version}
+	}
+	return yaml.Marshal(m)
+}
+
+/* inputDependencies: components whose data tc receives, in declaration order of the dependencies */
+func inputDependencies[CT context.Context, C any, T any](tc *goworkflow.TemplateComponent[CT, C, T]) []string {
+	deps := tc.Dependencies()
+	for _, group := range tc.AnyOf() {
+		for _, dep := range group {
+			if !contains(deps, dep) {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+/* depends: argo depends expression of the dependencies of tc */
+func depends[CT context.Context, C any, T any](tc *goworkflow.TemplateComponent[CT, C, T], names map[string]string) string {
+	terms := []string{}
+	for _, dep := range tc.Dependencies() {
+		name := names[dep]
+		switch tc.DependencyOutcome(dep) {
+		case goworkflow.OnFailure:
+			terms = append(terms, name+".Failed")
+		case goworkflow.OnCompletion:
+			terms = append(terms, fmt.Sprintf("(%s.Succeeded || %s.Failed)", name, name))
+		default:
+			terms = append(terms, name+".Succeeded")
+		}
+	}
+	for _, group := range tc.AnyOf() {
+		alternatives := []string{}
+		for _, dep := range group {
+			alternatives = append(alternatives, names[dep]+".Succeeded")
+		}
+		terms = append(terms, "("+strings.Join(alternatives, " || ")+")")
+	}
+	return strings.Join(terms, " && ")
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
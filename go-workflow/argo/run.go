@@ -0,0 +1,73 @@
+package argo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/internal/mergepatch"
+)
+
+/*
+Run executes one component of t inside a step of the exported workflow, args are the container arguments
+of the step. Every step writes the changes of the data since the start of the workflow (a json merge patch),
+a step applies the patches of its dependencies before executing its component.
+*/
+func Run[CT context.Context, C any, T any](ctx CT, t *goworkflow.Template[CT, C, T], args []string) error {
+	flags := flag.NewFlagSet("goworkflow-argo", flag.ContinueOnError)
+	component := flags.String("component", "", "component to execute")
+	configJSON := flags.String("config", "{}", "config of the run as json")
+	dataJSON := flags.String("data", "{}", "initial data of the run as json")
+	inputs := flags.String("inputs", inputsDir, "directory of the patches of the dependencies")
+	output := flags.String("output", outputPath, "file the patch of the step is written to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	tc := t.Component(*component)
+	if tc == nil {
+		return fmt.Errorf("component %s doesn't exist in template %s", *component, t.Name)
+	}
+
+	var config C
+	if err := json.Unmarshal([]byte(*configJSON), &config); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	var initial, data T
+	if err := json.Unmarshal([]byte(*dataJSON), &initial); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	json.Unmarshal([]byte(*dataJSON), &data)
+
+	names := taskNames(t.Components())
+	for _, dep := range inputDependencies(tc) {
+		patch, err := os.ReadFile(filepath.Join(*inputs, names[dep]+".json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			// optional input of a dependency which failed or was skipped
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := mergepatch.Apply(&data, patch); err != nil {
+			return fmt.Errorf("data of %s: %w", dep, err)
+		}
+	}
+
+	if err := t.ExecuteComponent(ctx, *component, config, &data); err != nil {
+		return err
+	}
+	patch, err := mergepatch.Create(&initial, &data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(*output, patch, 0o644)
+}
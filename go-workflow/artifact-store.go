@@ -0,0 +1,109 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+/* ArtifactRef references an artifact in an artifact store, e.g. s3://bucket/key. Refs are small and can be kept in the data store */
+type ArtifactRef string
+
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+/*
+ArtifactStore keeps large binary component outputs (rendered page images, extracted PDFs) outside of the data store,
+so the DataTracker stays small and checkpoints stay cheap.
+*/
+type ArtifactStore interface {
+	Put(ctx context.Context, key string, content io.Reader) (ArtifactRef, error)
+	Get(ctx context.Context, ref ArtifactRef) (io.ReadCloser, error)
+	Delete(ctx context.Context, ref ArtifactRef) error
+}
+
+/* ComponentInfo identifies the running component, available through the component context */
+type ComponentInfo struct {
+	WorkflowId  string
+	Workflow    string
+	Component   string
+	ComponentId string
+}
+
+type componentInfoContextKey struct{}
+type artifactStoreContextKey struct{}
+
+func ComponentInfoFromContext(ctx context.Context) (ComponentInfo, bool) {
+	info, ok := ctx.Value(componentInfoContextKey{}).(ComponentInfo)
+	return info, ok
+}
+
+func (wf *Workflow[CT, C, T]) SetArtifactStore(store ArtifactStore) {
+	wf.artifactStore = store
+}
+
+/* PutArtifact stores content under <workflow id>/<component>/<name> in the artifact store of the workflow */
+func (d *DataTracker[C, T]) PutArtifact(name string, content []byte) (ArtifactRef, error) {
+	store, ok := d.ctx.Value(artifactStoreContextKey{}).(ArtifactStore)
+	if !ok {
+		return "", errors.New("artifact store is not set")
+	}
+	info, _ := ComponentInfoFromContext(d.ctx)
+	key := fmt.Sprintf("%s/%s/%s", info.WorkflowId, info.Component, name)
+	return store.Put(d.ctx, key, bytes.NewReader(content))
+}
+
+/* GetArtifact reads the artifact from the artifact store of the workflow */
+func (d *DataTracker[C, T]) GetArtifact(ref ArtifactRef) ([]byte, error) {
+	store, ok := d.ctx.Value(artifactStoreContextKey{}).(ArtifactStore)
+	if !ok {
+		return nil, errors.New("artifact store is not set")
+	}
+	r, err := store.Get(d.ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+/* MemoryArtifactStore keeps artifacts in memory, refs look like mem://<key> */
+type MemoryArtifactStore struct {
+	lock      sync.Mutex
+	artifacts map[string][]byte
+}
+
+func NewMemoryArtifactStore() *MemoryArtifactStore {
+	return &MemoryArtifactStore{artifacts: map[string][]byte{}}
+}
+
+func (m *MemoryArtifactStore) Put(ctx context.Context, key string, content io.Reader) (ArtifactRef, error) {
+	b, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.artifacts[key] = b
+	return ArtifactRef("mem://" + key), nil
+}
+
+func (m *MemoryArtifactStore) Get(ctx context.Context, ref ArtifactRef) (io.ReadCloser, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	b, ok := m.artifacts[strings.TrimPrefix(string(ref), "mem://")]
+	if !ok {
+		return nil, ErrArtifactNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *MemoryArtifactStore) Delete(ctx context.Context, ref ArtifactRef) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.artifacts, strings.TrimPrefix(string(ref), "mem://"))
+	return nil
+}
@@ -0,0 +1,60 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifactStore(t *testing.T) {
+	store := goworkflow.NewMemoryArtifactStore()
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetArtifactStore(store)
+
+	render := wf.AddComponent(goworkflow.MakeComponent("Render", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		info, ok := goworkflow.ComponentInfoFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "Render", info.Component)
+		ref, err := dt.PutArtifact("page-1.png", []byte("image bytes"))
+		if err != nil {
+			return err
+		}
+		dt.Update(func(d *Data) {
+			d.A = string(ref)
+		})
+		return nil
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		content, err := dt.GetArtifact(goworkflow.ArtifactRef(dt.GetData().A))
+		if err != nil {
+			return err
+		}
+		dt.Update(func(d *Data) {
+			d.B = string(content)
+		})
+		return nil
+	})).AddDependencies(render)
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "mem://"+wf.Id()+"/Render/page-1.png", data.A)
+	assert.Equal(t, "image bytes", data.B)
+
+	assert.NoError(t, store.Delete(context.TODO(), goworkflow.ArtifactRef(data.A)))
+	_, err = store.Get(context.TODO(), goworkflow.ArtifactRef(data.A))
+	assert.ErrorIs(t, err, goworkflow.ErrArtifactNotFound)
+}
+
+func TestArtifactStoreNotSet(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Render", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		_, err := dt.PutArtifact("page-1.png", []byte("image bytes"))
+		return err
+	}))
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+}
@@ -0,0 +1,101 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* FileStore keeps artifacts below a directory, refs look like file://<dir>/<key> */
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: abs}, nil
+}
+
+func (f *FileStore) path(key string) (string, error) {
+	p := filepath.Join(f.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, f.dir+string(filepath.Separator)) {
+		return "", errors.New("artifact key escapes the store directory: " + key)
+	}
+	return p, nil
+}
+
+func (f *FileStore) Put(ctx context.Context, key string, content io.Reader) (goworkflow.ArtifactRef, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	// write to a temp file first so readers never see a partial artifact
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".artifact-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return goworkflow.ArtifactRef("file://" + filepath.ToSlash(p)), nil
+}
+
+func (f *FileStore) fromRef(ref goworkflow.ArtifactRef) (string, error) {
+	p, ok := strings.CutPrefix(string(ref), "file://")
+	if !ok {
+		return "", errors.New("not a file artifact: " + string(ref))
+	}
+	p = filepath.FromSlash(p)
+	if !strings.HasPrefix(p, f.dir+string(filepath.Separator)) {
+		return "", errors.New("artifact is outside of the store directory: " + string(ref))
+	}
+	return p, nil
+}
+
+func (f *FileStore) Get(ctx context.Context, ref goworkflow.ArtifactRef) (io.ReadCloser, error) {
+	p, err := f.fromRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, goworkflow.ErrArtifactNotFound
+	}
+	return r, err
+}
+
+func (f *FileStore) Delete(ctx context.Context, ref goworkflow.ArtifactRef) error {
+	p, err := f.fromRef(ref)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package artifacts
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	ref, err := store.Put(context.TODO(), "run-1/Render/page-1.png", strings.NewReader("image bytes"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ref), "file://"))
+
+	r, err := store.Get(context.TODO(), ref)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "image bytes", string(content))
+
+	assert.NoError(t, store.Delete(context.TODO(), ref))
+	_, err = store.Get(context.TODO(), ref)
+	assert.ErrorIs(t, err, goworkflow.ErrArtifactNotFound)
+
+	_, err = store.Put(context.TODO(), "../outside", strings.NewReader("x"))
+	assert.Error(t, err)
+	_, err = store.Get(context.TODO(), "file:///etc/passwd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,279 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/*
+S3Options configures an S3Store. GCS works through its S3 compatible XML API:
+Endpoint https://storage.googleapis.com with HMAC keys, Region "auto".
+*/
+type S3Options struct {
+	Endpoint  string // e.g. https://s3.eu-west-1.amazonaws.com
+	Region    string
+	Bucket    string
+	Prefix    string // prepended to every key
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key> instead of <bucket>.<endpoint host>/<key>
+	PathStyle bool
+	Client    *http.Client
+}
+
+/* S3Store keeps artifacts in an S3 compatible bucket, refs look like s3://<bucket>/<key> */
+type S3Store struct {
+	opts     S3Options
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &S3Store{opts: opts, endpoint: endpoint, now: time.Now}, nil
+}
+
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	escaped := (&url.URL{Path: "/" + key}).EscapedPath()
+	if s.opts.PathStyle {
+		u.Path = "/" + s.opts.Bucket + "/" + key
+		u.RawPath = "/" + s.opts.Bucket + escaped
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = escaped
+	}
+	return &u
+}
+
+func (s *S3Store) keyFromRef(ref goworkflow.ArtifactRef) (string, error) {
+	key, ok := strings.CutPrefix(string(ref), "s3://"+s.opts.Bucket+"/")
+	if !ok {
+		return "", errors.New("artifact is not in bucket " + s.opts.Bucket + ": " + string(ref))
+	}
+	return key, nil
+}
+
+func (s *S3Store) do(ctx context.Context, method string, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	payloadHash := sha256.Sum256(body)
+	signV4(req, hex.EncodeToString(payloadHash[:]), s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, "s3", s.now())
+	return s.opts.Client.Do(req)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, content io.Reader) (goworkflow.ArtifactRef, error) {
+	// S3 needs the content length and payload hash up front
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	key = s.opts.Prefix + key
+	resp, err := s.do(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	return goworkflow.ArtifactRef("s3://" + s.opts.Bucket + "/" + key), nil
+}
+
+func (s *S3Store) Get(ctx context.Context, ref goworkflow.ArtifactRef) (io.ReadCloser, error) {
+	key, err := s.keyFromRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, goworkflow.ErrArtifactNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, ref goworkflow.ArtifactRef) error {
+	key, err := s.keyFromRef(ref)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+	return nil
+}
+
+/* S3Object: object listed by S3Store.List, Key includes the prefix of the store */
+type S3Object struct {
+	Ref          goworkflow.ArtifactRef
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+		ETag         string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+/* List: objects whose key starts with the prefix of the store followed by prefix, in key order */
+func (s *S3Store) List(ctx context.Context, prefix string) ([]S3Object, error) {
+	objects := []S3Object{}
+	token := ""
+	for {
+		u := *s.endpoint
+		if s.opts.PathStyle {
+			u.Path = "/" + s.opts.Bucket
+		} else {
+			u.Host = s.opts.Bucket + "." + u.Host
+			u.Path = "/"
+		}
+		query := url.Values{"list-type": {"2"}, "prefix": {s.opts.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		payloadHash := sha256.Sum256(nil)
+		signV4(req, hex.EncodeToString(payloadHash[:]), s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, "s3", s.now())
+		resp, err := s.opts.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, responseError(resp)
+		}
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid list response: %w", err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, S3Object{
+				Ref:          goworkflow.ArtifactRef("s3://" + s.opts.Bucket + "/" + c.Key),
+				Key:          c.Key,
+				Size:         c.Size,
+				LastModified: c.LastModified,
+				ETag:         strings.Trim(c.ETag, `"`),
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("object storage responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+/* signV4 signs the request with AWS signature version 4, signing the host and every header already set */
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		// query values are encoded like paths, spaces as %20
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
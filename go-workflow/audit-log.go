@@ -0,0 +1,199 @@
+package goworkflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+const AuditRunStarted = "run.started"
+const AuditRunFinished = "run.finished"
+const AuditComponentFinished = "component.finished"
+const AuditIntervention = "intervention"
+
+/*
+AuditRecord is one entry of the append-only audit trail of a run.
+Records are hash chained (Hash covers the record and PrevHash), so any modification or removal is detected by VerifyAuditTrail.
+*/
+type AuditRecord struct {
+	Sequence   int       `json:"sequence"`
+	WorkflowId string    `json:"workflowId"`
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	Actor      string    `json:"actor,omitempty"`
+	Component  string    `json:"component,omitempty"`
+	Status     Status    `json:"status,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	ConfigHash string    `json:"configHash,omitempty"`
+	PrevHash   string    `json:"prevHash"`
+	Hash       string    `json:"hash"`
+}
+
+/* AuditSink persists audit records, records are only ever appended */
+type AuditSink interface {
+	Append(record AuditRecord) error
+}
+
+func (r AuditRecord) computeHash() string {
+	r.Hash = ""
+	b, _ := json.Marshal(r)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+/* VerifyAuditTrail checks the hash chain of the records of a single run */
+func VerifyAuditTrail(records []AuditRecord) error {
+	prevHash := ""
+	for i, r := range records {
+		if r.Sequence != i+1 {
+			return fmt.Errorf("audit record %d: unexpected sequence %d", i+1, r.Sequence)
+		}
+		if r.PrevHash != prevHash {
+			return fmt.Errorf("audit record %d: broken chain", r.Sequence)
+		}
+		if r.computeHash() != r.Hash {
+			return fmt.Errorf("audit record %d: hash mismatch", r.Sequence)
+		}
+		prevHash = r.Hash
+	}
+	return nil
+}
+
+/* HashConfig: sha256 of the json encoded config */
+func HashConfig(config any) string {
+	b, err := json.Marshal(config)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%#v", config))
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+type auditLog struct {
+	sink      AuditSink
+	startedBy string
+	sequence  int
+	prevHash  string
+	// queue delivers the records to the sink in sequence, outside the state lock
+	queue *taskQueue
+}
+
+/* should be called with stateLock held, the record is chained under it and appended to the sink in the background */
+func (a *auditLog) append(record AuditRecord) {
+	a.sequence++
+	record.Sequence = a.sequence
+	record.PrevHash = a.prevHash
+	record.Hash = record.computeHash()
+	a.prevHash = record.Hash
+	a.queue.dispatch(func() {
+		if err := a.sink.Append(record); err != nil {
+			log.Println("Workflow.Audit:Error:", err)
+		}
+	})
+}
+
+/*
+SetAuditLog appends the audit trail of the run to sink: who started it, the config hash,
+every component outcome and every manual intervention recorded with RecordIntervention.
+Records are appended in the background, so a slow sink doesn't hold up the run, Execute returns once all are appended.
+*/
+func (wf *Workflow[CT, C, T]) SetAuditLog(sink AuditSink, startedBy string) {
+	if sink == nil {
+		panic("audit sink cannot be nil")
+	}
+	queue := wf.backgroundQueue()
+	wf.stateLock.Lock()
+	if wf.audit != nil {
+		wf.stateLock.Unlock()
+		panic("audit log is already set")
+	}
+	wf.audit = &auditLog{sink: sink, startedBy: startedBy, queue: queue}
+	wf.stateLock.Unlock()
+
+	wf.addStateListener(func(change StateChange) {
+		record := AuditRecord{WorkflowId: wf.id, Time: change.Time, Status: change.NewStatus, Detail: change.Cause}
+		switch {
+		case change.Component == "" && change.NewStatus == RUNNING:
+			record.Kind = AuditRunStarted
+			record.Actor = wf.audit.startedBy
+			record.ConfigHash = HashConfig(wf.config)
+		case change.Component == "":
+			record.Kind = AuditRunFinished
+		case change.NewStatus == DONE || change.NewStatus == ERROR || change.NewStatus == SKIPPED:
+			record.Kind = AuditComponentFinished
+			record.Component = change.Component
+		default:
+			return
+		}
+		wf.audit.append(record)
+	})
+}
+
+/*
+RecordIntervention appends a manual intervention (cancel, approval, ...) by actor to the audit trail, it returns once
+the sink has the record
+*/
+func (wf *Workflow[CT, C, T]) RecordIntervention(actor string, action string, detail string) error {
+	wf.stateLock.Lock()
+	audit := wf.audit
+	if audit == nil {
+		wf.stateLock.Unlock()
+		return errors.New("audit log is not set")
+	}
+	audit.append(AuditRecord{
+		WorkflowId: wf.id,
+		Time:       time.Now(),
+		Kind:       AuditIntervention,
+		Actor:      actor,
+		Detail:     fmt.Sprintf("%s: %s", action, detail),
+	})
+	wf.stateLock.Unlock()
+	audit.queue.flush()
+	return nil
+}
+
+/* MemoryAuditSink keeps the records in memory */
+type MemoryAuditSink struct {
+	lock    sync.Mutex
+	records []AuditRecord
+}
+
+func (m *MemoryAuditSink) Append(record AuditRecord) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.records = append(m.records, record)
+	return nil
+}
+
+func (m *MemoryAuditSink) Records() []AuditRecord {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]AuditRecord{}, m.records...)
+}
+
+/* JSONLinesAuditSink writes every record as one json line, e.g. to an append-only file */
+type JSONLinesAuditSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+func (j *JSONLinesAuditSink) Append(record AuditRecord) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,122 @@
+package goworkflow_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("low confidence")
+	}))
+	b.AddDependencies(a)
+
+	sink := &goworkflow.MemoryAuditSink{}
+	wf.SetAuditLog(sink, "alice")
+
+	_, _, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.NoError(t, wf.RecordIntervention("bob", "approve", "manual review passed"))
+
+	records := sink.Records()
+	assert.Len(t, records, 5)
+	assert.Equal(t, goworkflow.AuditRunStarted, records[0].Kind)
+	assert.Equal(t, "alice", records[0].Actor)
+	assert.Equal(t, goworkflow.HashConfig(Config{}), records[0].ConfigHash)
+	assert.Equal(t, goworkflow.AuditComponentFinished, records[1].Kind)
+	assert.Equal(t, "A", records[1].Component)
+	assert.Equal(t, goworkflow.DONE, records[1].Status)
+	assert.Equal(t, "B", records[2].Component)
+	assert.Equal(t, "low confidence", records[2].Detail)
+	assert.Equal(t, goworkflow.AuditRunFinished, records[3].Kind)
+	assert.Equal(t, goworkflow.ERROR, records[3].Status)
+	assert.Equal(t, goworkflow.AuditIntervention, records[4].Kind)
+	assert.Equal(t, "bob", records[4].Actor)
+
+	assert.NoError(t, goworkflow.VerifyAuditTrail(records))
+
+	tampered := append([]goworkflow.AuditRecord{}, records...)
+	tampered[2].Detail = "ok"
+	assert.Error(t, goworkflow.VerifyAuditTrail(tampered))
+	assert.Error(t, goworkflow.VerifyAuditTrail(append(records[:1:1], records[2:]...)))
+}
+
+/* slowAuditSink blocks every append until released */
+type slowAuditSink struct {
+	goworkflow.MemoryAuditSink
+	release chan struct{}
+}
+
+func (s *slowAuditSink) Append(record goworkflow.AuditRecord) error {
+	<-s.release
+	return s.MemoryAuditSink.Append(record)
+}
+
+func TestAuditLogSlowSink(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	finished := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprint("C", i)
+		wf.AddComponent(goworkflow.MakeComponent(name, nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			finished <- name
+			return nil
+		}))
+	}
+	sink := &slowAuditSink{release: make(chan struct{})}
+	wf.SetAuditLog(sink, "alice")
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+
+	// the components don't wait for the sink
+	for i := 0; i < 10; i++ {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("components blocked by the audit sink")
+		}
+	}
+	assert.Eventually(t, func() bool { return wf.Status() == goworkflow.DONE }, 5*time.Second, time.Millisecond)
+	close(sink.release)
+	assert.Equal(t, goworkflow.DONE, <-done)
+	// Execute returns once the sink has the whole trail, in order
+	records := sink.Records()
+	assert.Len(t, records, 12)
+	assert.NoError(t, goworkflow.VerifyAuditTrail(records))
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	wf.SetAuditLog(goworkflow.NewJSONLinesAuditSink(buf), "scheduler")
+
+	_, _, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+
+	records := []goworkflow.AuditRecord{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		r := goworkflow.AuditRecord{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	assert.Len(t, records, 3)
+	assert.NoError(t, goworkflow.VerifyAuditTrail(records))
+}
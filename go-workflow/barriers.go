@@ -0,0 +1,174 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+Latches and barriers coordinate components beyond the static dependencies, e.g. page components which all
+wait until the first page detected the document language, or which proceed in lock step. They are registered
+on the workflow before Execute and used through the component context. Waiting components hold their limiter
+tickets, so parties of a barrier must be able to run at the same time.
+*/
+type syncPrimitives struct {
+	lock     sync.Mutex
+	latches  map[string]*latch
+	barriers map[string]*barrier
+}
+
+type syncContextKey struct{}
+
+/* latch: open once counted down count times */
+type latch struct {
+	lock  sync.Mutex
+	count int
+	open  chan struct{}
+}
+
+/* barrier: releases its waiters once parties arrived, then starts over */
+type barrier struct {
+	lock    sync.Mutex
+	parties int
+	arrived int
+	release chan struct{}
+}
+
+func (wf *Workflow[CT, C, T]) syncPrimitives() *syncPrimitives {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	if wf.sync == nil {
+		wf.sync = &syncPrimitives{latches: map[string]*latch{}, barriers: map[string]*barrier{}}
+	}
+	return wf.sync
+}
+
+/* AddLatch registers the latch name which opens once CountDownLatch was called count times */
+func (wf *Workflow[CT, C, T]) AddLatch(name string, count int) {
+	if count <= 0 {
+		panic("latch count must be positive")
+	}
+	s := wf.syncPrimitives()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.latches[name] != nil || s.barriers[name] != nil {
+		panic("duplicate latch or barrier: " + name)
+	}
+	s.latches[name] = &latch{count: count, open: make(chan struct{})}
+}
+
+/* AddBarrier registers the barrier name which releases its waiters every time parties components arrived */
+func (wf *Workflow[CT, C, T]) AddBarrier(name string, parties int) {
+	if parties <= 0 {
+		panic("barrier parties must be positive")
+	}
+	s := wf.syncPrimitives()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.latches[name] != nil || s.barriers[name] != nil {
+		panic("duplicate latch or barrier: " + name)
+	}
+	s.barriers[name] = &barrier{parties: parties, release: make(chan struct{})}
+}
+
+func (wf *Workflow[CT, C, T]) contextWithSync(ctx context.Context) context.Context {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	if wf.sync == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, syncContextKey{}, wf.sync)
+}
+
+func findLatch(ctx context.Context, name string) (*latch, error) {
+	s, ok := ctx.Value(syncContextKey{}).(*syncPrimitives)
+	if ok {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if l := s.latches[name]; l != nil {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown latch %s", name)
+}
+
+/* CountDownLatch counts the latch name of the running workflow down, counting an open latch does nothing */
+func CountDownLatch(ctx context.Context, name string) error {
+	l, err := findLatch(ctx, name)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.count > 0 {
+		l.count--
+		if l.count == 0 {
+			close(l.open)
+		}
+	}
+	return nil
+}
+
+/* AwaitLatch blocks until the latch name is open or ctx is done */
+func AwaitLatch(ctx context.Context, name string) error {
+	l, err := findLatch(ctx, name)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-l.open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/* AwaitBarrier arrives at the barrier name and blocks until all its parties arrived or ctx is done */
+func AwaitBarrier(ctx context.Context, name string) error {
+	s, ok := ctx.Value(syncContextKey{}).(*syncPrimitives)
+	var b *barrier
+	if ok {
+		s.lock.Lock()
+		b = s.barriers[name]
+		s.lock.Unlock()
+	}
+	if b == nil {
+		return fmt.Errorf("unknown barrier %s", name)
+	}
+	b.lock.Lock()
+	release := b.release
+	b.arrived++
+	if b.arrived == b.parties {
+		b.arrived = 0
+		b.release = make(chan struct{})
+		close(release)
+		b.lock.Unlock()
+		return nil
+	}
+	b.lock.Unlock()
+	select {
+	case <-release:
+		return nil
+	case <-ctx.Done():
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		// leave the barrier unless it was released meanwhile
+		if b.release == release {
+			b.arrived--
+		}
+		return ctx.Err()
+	}
+}
+
+func (d *DataTracker[C, T]) CountDownLatch(name string) error {
+	return CountDownLatch(d.ctx, name)
+}
+
+func (d *DataTracker[C, T]) AwaitLatch(name string) error {
+	return AwaitLatch(d.ctx, name)
+}
+
+func (d *DataTracker[C, T]) AwaitBarrier(name string) error {
+	return AwaitBarrier(d.ctx, name)
+}
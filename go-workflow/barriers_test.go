@@ -0,0 +1,64 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatchesAndBarriers(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddLatch("language", 1)
+	wf.AddBarrier("step", 3)
+	assert.Panics(t, func() { wf.AddLatch("step", 1) })
+
+	lock := sync.Mutex{}
+	events := []string{}
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	wf.AddComponent(goworkflow.MakeComponent("Detect", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(20 * time.Millisecond)
+		record("detected")
+		return dt.CountDownLatch("language")
+	}))
+	for i := 0; i < 3; i++ {
+		wf.AddComponent(goworkflow.MakeComponent(fmt.Sprint("Page", i), nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			if err := dt.AwaitLatch("language"); err != nil {
+				return err
+			}
+			record("step 1")
+			if err := dt.AwaitBarrier("step"); err != nil {
+				return err
+			}
+			record("step 2")
+			return dt.AwaitBarrier("step")
+		}))
+	}
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, []string{"detected", "step 1", "step 1", "step 1", "step 2", "step 2", "step 2"}, events)
+
+	// unknown names fail, a cancelled wait returns
+	other := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	other.AddBarrier("pair", 2)
+	var errs []error
+	other.AddComponent(goworkflow.MakeComponent("Alone", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		errs = append(errs, dt.AwaitLatch("missing"))
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		errs = append(errs, goworkflow.AwaitBarrier(waitCtx, "pair"))
+		return nil
+	}))
+	other.Execute(context.TODO(), Config{}, &Data{})
+	assert.EqualError(t, errs[0], "unknown latch missing")
+	assert.ErrorIs(t, errs[1], context.DeadlineExceeded)
+}
@@ -0,0 +1,169 @@
+package goworkflow
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type BatchOptions[T any] struct {
+	// Parallelism: runs executing at once, defaults to GOMAXPROCS
+	Parallelism int
+	// Data returns the initial data of run i, defaults to a zero T
+	Data func(i int) *T
+	// OnRunDone is called as soon as a run finishes, e.g. for progress reporting
+	OnRunDone func(run BatchRun[T])
+}
+
+/* BatchRun: outcome of one run of the batch, Index is the index of its config */
+type BatchRun[T any] struct {
+	Index  int
+	Data   *T
+	Status Status
+	Err    error
+	Result RunResult
+}
+
+type BatchSummary struct {
+	Runs     int
+	Done     int
+	Failed   int
+	Duration time.Duration // wall clock time of the whole batch
+	// MinRunDuration, MaxRunDuration and MeanRunDuration are over runs which started
+	MinRunDuration  time.Duration
+	MaxRunDuration  time.Duration
+	MeanRunDuration time.Duration
+	// FailedComponents: number of runs each component failed in
+	FailedComponents map[string]int
+}
+
+type BatchResult[T any] struct {
+	// Runs in the order of the configs
+	Runs    []BatchRun[T]
+	Summary BatchSummary
+}
+
+/*
+ExecuteBatch runs one workflow per config with bounded parallelism, e.g. one per page of a document.
+Runs which did not start before ctx is done fail with the context error.
+*/
+func (t *Template[CT, C, T]) ExecuteBatch(ctx CT, configs []C, opts ...*BatchOptions[T]) BatchResult[T] {
+	var opt BatchOptions[T]
+	if len(opts) > 1 {
+		panic("only one BatchOptions is allowed")
+	}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = *opts[0]
+	}
+	if opt.Parallelism <= 0 {
+		opt.Parallelism = runtime.GOMAXPROCS(0)
+	}
+
+	start := time.Now()
+	result := BatchResult[T]{Runs: make([]BatchRun[T], len(configs))}
+	sem := make(chan struct{}, opt.Parallelism)
+	callbackLock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i, config := range configs {
+		data := new(T)
+		if opt.Data != nil {
+			data = opt.Data(i)
+		}
+		run := BatchRun[T]{Index: i, Data: data}
+
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+				acquired = true
+			case <-ctx.Done():
+			}
+		}
+		if !acquired {
+			run.Status, run.Err = ERROR, ctx.Err()
+			result.Runs[i] = run
+			if opt.OnRunDone != nil {
+				callbackLock.Lock()
+				opt.OnRunDone(run)
+				callbackLock.Unlock()
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(config C, run BatchRun[T]) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			wf := t.NewWorkflow(ctx)
+			run.Data, run.Status, run.Err = wf.Execute(ctx, config, run.Data)
+			run.Result = wf.Result()
+			result.Runs[run.Index] = run
+			if opt.OnRunDone != nil {
+				callbackLock.Lock()
+				opt.OnRunDone(run)
+				callbackLock.Unlock()
+			}
+		}(config, run)
+	}
+	wg.Wait()
+
+	result.Summary = summarizeBatch(result.Runs)
+	result.Summary.Duration = time.Since(start)
+	return result
+}
+
+func summarizeBatch[T any](runs []BatchRun[T]) BatchSummary {
+	summary := BatchSummary{Runs: len(runs), FailedComponents: map[string]int{}}
+	var total time.Duration
+	started := 0
+	for _, run := range runs {
+		if run.Status == DONE || run.Status == DONE_WITH_WARNINGS {
+			summary.Done++
+		} else {
+			summary.Failed++
+		}
+		for _, name := range run.Result.FailedComponents {
+			summary.FailedComponents[name]++
+		}
+		if run.Result.StartedAt.IsZero() {
+			continue
+		}
+		d := run.Result.Duration
+		if started == 0 || d < summary.MinRunDuration {
+			summary.MinRunDuration = d
+		}
+		if d > summary.MaxRunDuration {
+			summary.MaxRunDuration = d
+		}
+		total += d
+		started++
+	}
+	if started > 0 {
+		summary.MeanRunDuration = total / time.Duration(started)
+	}
+	return summary
+}
+
+/*
+MapReduce executes the batch and folds every run into acc with reduce as soon as the run finishes, in completion order.
+reduce calls are serialized and also receive failed runs, check run.Status to skip them.
+*/
+func MapReduce[CT context.Context, C any, T any, R any](ctx CT, t *Template[CT, C, T], configs []C, acc R, reduce func(acc R, run BatchRun[T]) R, opts ...*BatchOptions[T]) (R, BatchResult[T]) {
+	var opt BatchOptions[T]
+	if len(opts) > 1 {
+		panic("only one BatchOptions is allowed")
+	}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = *opts[0]
+	}
+	onRunDone := opt.OnRunDone
+	// OnRunDone calls are serialized by ExecuteBatch
+	opt.OnRunDone = func(run BatchRun[T]) {
+		acc = reduce(acc, run)
+		if onRunDone != nil {
+			onRunDone(run)
+		}
+	}
+	result := t.ExecuteBatch(ctx, configs, &opt)
+	return acc, result
+}
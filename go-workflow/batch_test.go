@@ -0,0 +1,110 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type pageConfig struct {
+	Page int
+}
+
+func TestExecuteBatch(t *testing.T) {
+	var running, maxRunning int32
+	tpl := goworkflow.NewTemplate[context.Context, pageConfig, Data]("pages")
+	tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[pageConfig, Data]) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if dt.Config.Page == 3 {
+			return errors.New("unreadable page")
+		}
+		dt.Update(func(d *Data) {
+			d.A = fmt.Sprint("page ", dt.Config.Page)
+		})
+		return nil
+	}))
+
+	configs := []pageConfig{}
+	for i := 0; i < 8; i++ {
+		configs = append(configs, pageConfig{Page: i})
+	}
+	var done int32
+	result := tpl.ExecuteBatch(context.TODO(), configs, &goworkflow.BatchOptions[Data]{
+		Parallelism: 2,
+		OnRunDone: func(run goworkflow.BatchRun[Data]) {
+			atomic.AddInt32(&done, 1)
+		},
+	})
+
+	assert.Equal(t, int32(8), done)
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	assert.Len(t, result.Runs, 8)
+	assert.Equal(t, "page 5", result.Runs[5].Data.A)
+	assert.Equal(t, goworkflow.ERROR, result.Runs[3].Status)
+	assert.Equal(t, 8, result.Summary.Runs)
+	assert.Equal(t, 7, result.Summary.Done)
+	assert.Equal(t, 1, result.Summary.Failed)
+	assert.Equal(t, map[string]int{"OCR": 1}, result.Summary.FailedComponents)
+	assert.GreaterOrEqual(t, result.Summary.MinRunDuration, 10*time.Millisecond)
+	assert.LessOrEqual(t, result.Summary.MinRunDuration, result.Summary.MeanRunDuration)
+	assert.LessOrEqual(t, result.Summary.MeanRunDuration, result.Summary.MaxRunDuration)
+}
+
+func TestExecuteBatchCancelled(t *testing.T) {
+	tpl := goworkflow.NewTemplate[context.Context, pageConfig, Data]("pages")
+	tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[pageConfig, Data]) error {
+		return nil
+	}))
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	result := tpl.ExecuteBatch(ctx, []pageConfig{{Page: 1}, {Page: 2}}, &goworkflow.BatchOptions[Data]{Parallelism: 1})
+	assert.Equal(t, 2, result.Summary.Runs)
+	failed := 0
+	for _, run := range result.Runs {
+		if errors.Is(run.Err, context.Canceled) {
+			failed++
+		}
+	}
+	assert.Equal(t, 2, failed)
+}
+
+func TestMapReduce(t *testing.T) {
+	tpl := goworkflow.NewTemplate[context.Context, pageConfig, Data]("pages")
+	tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[pageConfig, Data]) error {
+		if dt.Config.Page == 2 {
+			return errors.New("unreadable page")
+		}
+		dt.Update(func(d *Data) {
+			d.A = fmt.Sprint(dt.Config.Page)
+		})
+		return nil
+	}))
+
+	seen := 0
+	pages, result := goworkflow.MapReduce(context.TODO(), tpl, []pageConfig{{Page: 1}, {Page: 2}, {Page: 3}}, map[string]bool{},
+		func(acc map[string]bool, run goworkflow.BatchRun[Data]) map[string]bool {
+			if run.Status == goworkflow.DONE {
+				acc[run.Data.A] = true
+			}
+			return acc
+		}, &goworkflow.BatchOptions[Data]{OnRunDone: func(run goworkflow.BatchRun[Data]) { seen++ }})
+
+	assert.Equal(t, map[string]bool{"1": true, "3": true}, pages)
+	assert.Equal(t, 3, seen)
+	assert.Equal(t, 1, result.Summary.Failed)
+}
@@ -0,0 +1,121 @@
+package goworkflow
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/*
+Bulkheads: named pools of execution slots, independent of each other, so a flood of slow components
+in one branch can't starve another branch. Runs sharing the Bulkheads share the pools.
+*/
+type Bulkheads struct {
+	pools map[string]*limiter.ConcurrencyLimiter
+}
+
+/* NewBulkheads: one pool per name with the given number of slots */
+func NewBulkheads(capacities map[string]int) *Bulkheads {
+	b := &Bulkheads{pools: map[string]*limiter.ConcurrencyLimiter{}}
+	for name, capacity := range capacities {
+		if capacity <= 0 {
+			panic(fmt.Sprintf("bulkhead %s needs a positive capacity", name))
+		}
+		b.pools[name] = limiter.NewConcurrencyLimiter(capacity)
+	}
+	return b
+}
+
+/* Limiter: the pool of the bulkhead, e.g. for RegisterLimiter or an AutoTuner, nil when unknown */
+func (b *Bulkheads) Limiter(name string) *limiter.ConcurrencyLimiter {
+	return b.pools[name]
+}
+
+/* Names of the bulkheads, sorted */
+func (b *Bulkheads) Names() []string {
+	names := make([]string, 0, len(b.pools))
+	for name := range b.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/* SetBulkheads: pools of the bulkheads assigned with ComponentConfig.Bulkhead and AssignBulkhead */
+func (wf *Workflow[CT, C, T]) SetBulkheads(bulkheads *Bulkheads) {
+	wf.bulkheads = bulkheads
+}
+
+/*
+AssignBulkhead assigns the branches starting at roots to the bulkhead: the roots and the components depending
+on them (transitively) hold a slot of the bulkhead while they execute. Components in the branches of several
+bulkheads, e.g. the one joining them, don't belong to any; ComponentConfig.Bulkhead takes precedence.
+*/
+func (wf *Workflow[CT, C, T]) AssignBulkhead(name string, roots ...Component[CT, C, T]) {
+	if wf.branchBulkheads == nil {
+		wf.branchBulkheads = map[string][]string{}
+	}
+	for _, root := range roots {
+		wf.branchBulkheads[name] = append(wf.branchBulkheads[name], root.id)
+	}
+	wf.addBulkheadCheck(name)
+}
+
+func (wf *Workflow[CT, C, T]) addBulkheadCheck(name string) {
+	wf.addBuildCheck(func() error {
+		if wf.bulkheads == nil || wf.bulkheads.Limiter(name) == nil {
+			return fmt.Errorf("unknown bulkhead %s", name)
+		}
+		return nil
+	})
+}
+
+type componentBulkhead struct {
+	name string
+	pool *limiter.ConcurrencyLimiter
+}
+
+/* resolveBulkheads: pool of every component assigned to a bulkhead, called once the dependencies are final */
+func (wf *Workflow[CT, C, T]) resolveBulkheads() {
+	wf.componentBulkheads = map[string]componentBulkhead{}
+	if wf.bulkheads == nil {
+		return
+	}
+	// componentId -> bulkheads of the branches it belongs to
+	branches := map[string]map[string]bool{}
+	var visit func(name, componentId string)
+	visit = func(name, componentId string) {
+		if branches[componentId][name] {
+			return
+		}
+		if branches[componentId] == nil {
+			branches[componentId] = map[string]bool{}
+		}
+		branches[componentId][name] = true
+		for dependentId, dep := range wf.dependencyManager.dependencyGraph[componentId] {
+			if dep {
+				visit(name, dependentId)
+			}
+		}
+	}
+	for name, rootIds := range wf.branchBulkheads {
+		for _, rootId := range rootIds {
+			visit(name, rootId)
+		}
+	}
+	for componentId, c := range wf.componentsMap {
+		name := ""
+		if len(branches[componentId]) == 1 {
+			for n := range branches[componentId] {
+				name = n
+			}
+		}
+		if c.addComponentCfg != nil && c.addComponentCfg.Bulkhead != "" {
+			name = c.addComponentCfg.Bulkhead
+		}
+		if pool := wf.bulkheads.Limiter(name); pool != nil {
+			wf.componentBulkheads[componentId] = componentBulkhead{name: name, pool: pool}
+		}
+	}
+}
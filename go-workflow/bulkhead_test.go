@@ -0,0 +1,78 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkheads(t *testing.T) {
+	running, maxRunning := int32(0), int32(0)
+	visual := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for m := atomic.LoadInt32(&maxRunning); n > m && !atomic.CompareAndSwapInt32(&maxRunning, m, n); m = atomic.LoadInt32(&maxRunning) {
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetBulkheads(goworkflow.NewBulkheads(map[string]int{"visual": 1, "text": 1}))
+	split := wf.AddComponent(goworkflow.MakeComponent("SplitVisual", nil, visual))
+	for i := 0; i < 4; i++ {
+		wf.AddComponent(goworkflow.MakeComponent(fmt.Sprintf("Visual%d", i), nil, visual)).AddDependencies(split)
+	}
+	text := wf.AddComponent(goworkflow.MakeComponent("TextExtractor", nil, ocrProvider("text", 0, nil)),
+		&goworkflow.ComponentConfig{Bulkhead: "text"})
+	wf.AssignBulkhead("visual", split)
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, int32(1), maxRunning)
+	for _, timing := range wf.Timeline() {
+		if timing.Component == text.Name {
+			assert.Less(t, timing.Wait(), 15*time.Millisecond)
+		}
+	}
+}
+
+func TestBulkheadsUnknown(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 0, nil)), &goworkflow.ComponentConfig{Bulkhead: "ocr"})
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.ErrorContains(t, err, "unknown bulkhead ocr")
+}
+
+func TestBulkheadsJoin(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	bulkheads := goworkflow.NewBulkheads(map[string]int{"visual": 1, "text": 1})
+	wf.SetBulkheads(bulkheads)
+	textOnly := &goworkflow.ComponentConfig{Bulkhead: "text"}
+	visual := wf.AddComponent(goworkflow.MakeComponent("Visual", nil, ocrProvider("visual", 0, nil)), textOnly)
+	text := wf.AddComponent(goworkflow.MakeComponent("Text", nil, ocrProvider("text", 0, nil)), textOnly)
+	wf.AddComponent(goworkflow.MakeComponent("Join", nil, ocrProvider("join", 0, nil))).AddDependencies(visual, text)
+	wf.AssignBulkhead("visual", visual)
+	wf.AssignBulkhead("text", text)
+
+	// the join is in the branches of both bulkheads, so it doesn't need the exhausted visual pool
+	bulkheads.Limiter("visual").Acquire()
+	defer bulkheads.Limiter("visual").Release()
+	done := make(chan goworkflow.Status, 1)
+	go func() {
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+	select {
+	case st := <-done:
+		assert.Equal(t, goworkflow.DONE, st)
+	case <-time.After(time.Second):
+		t.Fatal("join waited for the visual bulkhead")
+	}
+}
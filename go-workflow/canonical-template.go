@@ -0,0 +1,346 @@
+package goworkflow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+CanonicalTemplate: deterministic description of a template (components, edges, configs, version), to be stored
+in git or a config system next to the code and compared with the deployed template, see Template.Canonical and
+Template.Drift. Components, dependencies and any-of groups are sorted, inputs are normalized through json.
+Hooks and runtime objects of ComponentConfig (limiters, caches, retry callbacks, ...) can't be serialized,
+only their presence is recorded in Runtime.
+*/
+type CanonicalTemplate struct {
+	Name       string
+	Version    string
+	Components []CanonicalComponent
+}
+
+type CanonicalComponent struct {
+	Name                string
+	Input               any        `json:",omitempty"`
+	DependsOn           []string   `json:",omitempty"`
+	DependsOnFailure    []string   `json:",omitempty"`
+	DependsOnCompletion []string   `json:",omitempty"`
+	AnyOf               [][]string `json:",omitempty"`
+	FirstOf             [][]string `json:",omitempty"`
+	Optional            bool       `json:",omitempty"`
+	Reads               []string   `json:",omitempty"`
+	Writes              []string   `json:",omitempty"`
+	ConfigReads         []string   `json:",omitempty"`
+	Bulkhead            string     `json:",omitempty"`
+	Flag                string     `json:",omitempty"`
+	// durations e.g. "1m30s"
+	MinDuration      string          `json:",omitempty"`
+	ExpectedDuration string          `json:",omitempty"`
+	Timeout          string          `json:",omitempty"`
+	Retry            *CanonicalRetry `json:",omitempty"`
+	SLO              *CanonicalSLO   `json:",omitempty"`
+	// Runtime: ComponentConfig fields set to values which can't be serialized, e.g. ["Cache", "Limiter"]
+	Runtime []string `json:",omitempty"`
+}
+
+type CanonicalRetry struct {
+	MaxAttempts int
+	Backoff     string `json:",omitempty"`
+	MaxBackoff  string `json:",omitempty"`
+}
+
+type CanonicalSLO struct {
+	MaxDuration string `json:",omitempty"`
+	NoErrors    bool   `json:",omitempty"`
+}
+
+/* Canonical: canonical description of the template */
+func (t *Template[CT, C, T]) Canonical() (CanonicalTemplate, error) {
+	ct := CanonicalTemplate{Name: t.Name, Version: t.Version, Components: []CanonicalComponent{}}
+	for _, tc := range t.components {
+		cc, err := tc.canonical()
+		if err != nil {
+			return CanonicalTemplate{}, err
+		}
+		ct.Components = append(ct.Components, cc)
+	}
+	slices.SortFunc(ct.Components, func(a, b CanonicalComponent) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ct, nil
+}
+
+/* canonicalDependencies sets the dependency fields of cc, sorted */
+func (tc *TemplateComponent[CT, C, T]) canonicalDependencies(cc *CanonicalComponent) {
+	for _, dep := range tc.dependencies {
+		switch tc.DependencyOutcome(dep) {
+		case OnFailure:
+			cc.DependsOnFailure = append(cc.DependsOnFailure, dep)
+		case OnCompletion:
+			cc.DependsOnCompletion = append(cc.DependsOnCompletion, dep)
+		default:
+			cc.DependsOn = append(cc.DependsOn, dep)
+		}
+	}
+	for _, group := range tc.anyOf {
+		sorted := slices.Clone(group.dependencies)
+		slices.Sort(sorted)
+		if group.cancelOthers {
+			cc.FirstOf = append(cc.FirstOf, sorted)
+		} else {
+			cc.AnyOf = append(cc.AnyOf, sorted)
+		}
+	}
+	slices.Sort(cc.DependsOn)
+	slices.Sort(cc.DependsOnFailure)
+	slices.Sort(cc.DependsOnCompletion)
+	slices.SortFunc(cc.AnyOf, slices.Compare[[]string])
+	slices.SortFunc(cc.FirstOf, slices.Compare[[]string])
+}
+
+func (tc *TemplateComponent[CT, C, T]) canonical() (CanonicalComponent, error) {
+	cc := CanonicalComponent{Name: tc.Name()}
+	if tc.definition.Input != nil {
+		encoded, err := json.Marshal(tc.definition.Input)
+		if err != nil {
+			return cc, fmt.Errorf("component %s: input: %w", tc.Name(), err)
+		}
+		// maps are encoded with sorted keys, whatever the input type
+		if err := json.Unmarshal(encoded, &cc.Input); err != nil {
+			return cc, fmt.Errorf("component %s: input: %w", tc.Name(), err)
+		}
+	}
+	tc.canonicalDependencies(&cc)
+
+	cfg := tc.config
+	if cfg == nil {
+		return cc, nil
+	}
+	cc.Optional = cfg.Optional
+	cc.Reads = sortedCopy(cfg.Reads)
+	cc.Writes = sortedCopy(cfg.Writes)
+	cc.ConfigReads = sortedCopy(cfg.ConfigReads)
+	cc.Bulkhead = cfg.Bulkhead
+	cc.Flag = cfg.Flag
+	cc.MinDuration = canonicalDuration(cfg.MinDuration)
+	cc.ExpectedDuration = canonicalDuration(cfg.ExpectedDuration)
+	cc.Timeout = canonicalDuration(cfg.Timeout)
+	if cfg.Retry != nil {
+		cc.Retry = &CanonicalRetry{cfg.Retry.MaxAttempts, canonicalDuration(cfg.Retry.Backoff), canonicalDuration(cfg.Retry.MaxBackoff)}
+		if cfg.Retry.Retryable != nil {
+			cc.Runtime = append(cc.Runtime, "Retry.Retryable")
+		}
+		if cfg.Retry.BeforeRetry != nil {
+			cc.Runtime = append(cc.Runtime, "Retry.BeforeRetry")
+		}
+	}
+	if cfg.SLO != nil {
+		cc.SLO = &CanonicalSLO{canonicalDuration(cfg.SLO.MaxDuration), cfg.SLO.NoErrors}
+	}
+	v := reflect.ValueOf(*cfg)
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Func:
+			name := v.Type().Field(i).Name
+			if !f.IsNil() && name != "Retry" && name != "SLO" {
+				cc.Runtime = append(cc.Runtime, name)
+			}
+		}
+	}
+	slices.Sort(cc.Runtime)
+	return cc, nil
+}
+
+func sortedCopy(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
+
+func canonicalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+/* MarshalCanonical: canonical json of the template, identical for identical templates whatever the declaration order */
+func (t *Template[CT, C, T]) MarshalCanonical() ([]byte, error) {
+	ct, err := t.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	return ct.Marshal()
+}
+
+/* Marshal: indented json, keys in declaration order, ending with a newline so the file diffs well in git */
+func (ct CanonicalTemplate) Marshal() ([]byte, error) {
+	encoded, err := json.MarshalIndent(ct, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+/* Hash: "sha256:<hex>" of the canonical json */
+func (ct CanonicalTemplate) Hash() (string, error) {
+	encoded, err := json.Marshal(ct)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+/* Hash: hash of the canonical description of the template, e.g. to label deployments */
+func (t *Template[CT, C, T]) Hash() (string, error) {
+	ct, err := t.Canonical()
+	if err != nil {
+		return "", err
+	}
+	return ct.Hash()
+}
+
+/* ParseCanonicalTemplate: canonical json written by MarshalCanonical */
+func ParseCanonicalTemplate(canonical []byte) (CanonicalTemplate, error) {
+	ct := CanonicalTemplate{}
+	decoder := json.NewDecoder(bytes.NewReader(canonical))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&ct); err != nil {
+		return ct, fmt.Errorf("invalid canonical template: %w", err)
+	}
+	return ct, nil
+}
+
+/* TemplateDrift: differences between the declared and the deployed template */
+type TemplateDrift struct {
+	// DeclaredVersion, DeployedVersion: set when the versions differ
+	DeclaredVersion string
+	DeployedVersion string
+	// components and edges going from the declared to the deployed template
+	TemplateDiff
+}
+
+func (d TemplateDrift) Empty() bool {
+	return d.DeclaredVersion == d.DeployedVersion && d.TemplateDiff.Empty()
+}
+
+func (d TemplateDrift) String() string {
+	lines := []string{}
+	if d.DeclaredVersion != d.DeployedVersion {
+		lines = append(lines, fmt.Sprintf("~ version %s -> %s", d.DeclaredVersion, d.DeployedVersion))
+	}
+	if !d.TemplateDiff.Empty() {
+		lines = append(lines, d.TemplateDiff.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+/* Drift: differences between the template declared in git or a config system and the deployed template t */
+func (t *Template[CT, C, T]) Drift(declared CanonicalTemplate) (TemplateDrift, error) {
+	deployed, err := t.Canonical()
+	if err != nil {
+		return TemplateDrift{}, err
+	}
+	return declared.Drift(deployed), nil
+}
+
+/* withoutDependencies: cc without its dependencies, which are compared as edges */
+func withoutDependencies(cc CanonicalComponent) CanonicalComponent {
+	cc.DependsOn, cc.DependsOnFailure, cc.DependsOnCompletion, cc.AnyOf, cc.FirstOf = nil, nil, nil, nil, nil
+	return cc
+}
+
+/* Drift: differences going from ct to deployed */
+func (ct CanonicalTemplate) Drift(deployed CanonicalTemplate) TemplateDrift {
+	drift := TemplateDrift{}
+	if ct.Version != deployed.Version {
+		drift.DeclaredVersion, drift.DeployedVersion = ct.Version, deployed.Version
+	}
+	declared := map[string]CanonicalComponent{}
+	for _, cc := range ct.Components {
+		declared[cc.Name] = cc
+	}
+	names := map[string]bool{}
+	for _, cc := range deployed.Components {
+		names[cc.Name] = true
+		old, ok := declared[cc.Name]
+		if !ok {
+			drift.AddedComponents = append(drift.AddedComponents, cc.Name)
+			continue
+		}
+		a, _ := json.Marshal(withoutDependencies(old))
+		b, _ := json.Marshal(withoutDependencies(cc))
+		if !bytes.Equal(a, b) {
+			drift.ChangedComponents = append(drift.ChangedComponents, cc.Name)
+		}
+	}
+	for _, cc := range ct.Components {
+		if !names[cc.Name] {
+			drift.RemovedComponents = append(drift.RemovedComponents, cc.Name)
+		}
+	}
+	declaredEdges, deployedEdges := ct.edges(), deployed.edges()
+	for e := range deployedEdges {
+		if !declaredEdges[e] {
+			drift.AddedEdges = append(drift.AddedEdges, e)
+		}
+	}
+	for e := range declaredEdges {
+		if !deployedEdges[e] {
+			drift.RemovedEdges = append(drift.RemovedEdges, e)
+		}
+	}
+	slices.Sort(drift.AddedComponents)
+	slices.Sort(drift.RemovedComponents)
+	slices.Sort(drift.ChangedComponents)
+	sortEdges(drift.AddedEdges)
+	sortEdges(drift.RemovedEdges)
+	return drift
+}
+
+func (ct CanonicalTemplate) edges() map[Edge]bool {
+	edges := map[Edge]bool{}
+	for _, cc := range ct.Components {
+		for _, e := range cc.edges() {
+			edges[e] = true
+		}
+	}
+	return edges
+}
+
+/*
+edges: dependency edges of cc, of the kind of the dependency. Groups are numbered when cc has several of the kind,
+so moving a dependency between groups changes its edge
+*/
+func (cc CanonicalComponent) edges() []Edge {
+	edges := []Edge{}
+	add := func(kind string, deps []string) {
+		for _, dep := range deps {
+			edges = append(edges, Edge{From: dep, To: cc.Name, Kind: kind})
+		}
+	}
+	add("", cc.DependsOn)
+	add(string(OnFailure), cc.DependsOnFailure)
+	add(string(OnCompletion), cc.DependsOnCompletion)
+	for kind, groups := range map[string][][]string{"any of": cc.AnyOf, "first of": cc.FirstOf} {
+		for i, group := range groups {
+			if len(groups) > 1 {
+				add(fmt.Sprintf("%s %d", kind, i+1), group)
+			} else {
+				add(kind, group)
+			}
+		}
+	}
+	return edges
+}
@@ -0,0 +1,67 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalTemplate(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+
+	a := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	a.Version = "2"
+	ocr := a.AddComponent(goworkflow.MakeComponent("OCR", any(map[string]any{"dpi": 300, "language": "de"}), noop),
+		&goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3, Backoff: time.Second}, Writes: []string{"B", "A"}})
+	layout := a.AddComponent(goworkflow.MakeComponent("Layout", nil, noop))
+	a.AddComponent(goworkflow.MakeComponent("Parameter", nil, noop)).AddDependencies(ocr, layout)
+
+	// same template declared in another order
+	b := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	b.Version = "2"
+	layout = b.AddComponent(goworkflow.MakeComponent("Layout", nil, noop))
+	ocr = b.AddComponent(goworkflow.MakeComponent("OCR", any(map[string]any{"language": "de", "dpi": 300}), noop),
+		&goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3, Backoff: time.Second}, Writes: []string{"A", "B"}})
+	b.AddComponent(goworkflow.MakeComponent("Parameter", nil, noop)).AddDependencies(layout, ocr)
+
+	canonical, err := a.MarshalCanonical()
+	assert.NoError(t, err)
+	other, err := b.MarshalCanonical()
+	assert.NoError(t, err)
+	assert.Equal(t, string(canonical), string(other))
+	assert.Contains(t, string(canonical), `"Retry": {
+        "MaxAttempts": 3,
+        "Backoff": "1s"
+      }`)
+	hash, err := a.Hash()
+	assert.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", hash)
+	otherHash, _ := b.Hash()
+	assert.Equal(t, hash, otherHash)
+
+	declared, err := goworkflow.ParseCanonicalTemplate(canonical)
+	assert.NoError(t, err)
+	declaredHash, _ := declared.Hash()
+	assert.Equal(t, hash, declaredHash)
+	drift, err := b.Drift(declared)
+	assert.NoError(t, err)
+	assert.True(t, drift.Empty())
+
+	// the deployed code drifted from the declared definition
+	b.Version = "3"
+	b.Component("Layout").SetConfig(&goworkflow.ComponentConfig{ConcurrencyLimiter: limiter.NewConcurrencyLimiter(2)})
+	b.AddComponent(goworkflow.MakeComponent("Tables", nil, noop)).AddDependencies(b.Component("OCR"))
+	otherHash, _ = b.Hash()
+	assert.NotEqual(t, hash, otherHash)
+	drift, err = b.Drift(declared)
+	assert.NoError(t, err)
+	assert.False(t, drift.Empty())
+	assert.Equal(t, "~ version 2 -> 3\n+ component Tables\n~ component Layout\n+ edge OCR -> Tables", drift.String())
+
+	_, err = goworkflow.ParseCanonicalTemplate([]byte(`{"Name": "document", "Steps": []}`))
+	assert.ErrorContains(t, err, "invalid canonical template")
+}
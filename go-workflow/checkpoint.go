@@ -0,0 +1,275 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+/* Checkpoint: progress of a run, completed components and a snapshot of the data store */
+type Checkpoint[T any] struct {
+	WorkflowId string
+	Template   string
+	Version    string
+	// names of the components which are DONE
+	Completed []string
+	// deep copy of the data store (through json, unexported fields are not copied), components keep updating the
+	// data while the checkpoint is saved
+	Data T
+	Time time.Time
+}
+
+/* Version: version of the template the workflow was created from */
+func (wf *Workflow[CT, C, T]) Version() string {
+	return wf.version
+}
+
+/* should be called with stateLock held */
+func (wf *Workflow[CT, C, T]) checkpoint() Checkpoint[T] {
+	cp := Checkpoint[T]{WorkflowId: wf.id, Template: wf.name, Version: wf.version, Completed: []string{}, Time: time.Now()}
+	for _, c := range wf.sortedComponents() {
+		if c.Status().Status == DONE {
+			cp.Completed = append(cp.Completed, c.Name)
+		}
+	}
+	if wf.store != nil {
+		wf.store.lock.Lock()
+		data, err := copyData(wf.store.data)
+		if err != nil {
+			log.Println("Workflow.Checkpoint:Error:", err)
+			data = wf.store.data
+		}
+		cp.Data = *data
+		wf.store.lock.Unlock()
+	}
+	return cp
+}
+
+/* Checkpoint: current progress of the run */
+func (wf *Workflow[CT, C, T]) Checkpoint() Checkpoint[T] {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	return wf.checkpoint()
+}
+
+/* OnCheckpoint registers hook, invoked with a fresh checkpoint every time a component is DONE */
+func (wf *Workflow[CT, C, T]) OnCheckpoint(hook func(Checkpoint[T])) {
+	wf.addStateListener(func(change StateChange) {
+		if change.Component != "" && change.NewStatus == DONE {
+			hook(wf.checkpoint())
+		}
+	})
+}
+
+var ErrVersionMismatch = errors.New("checkpoint was created by another template version")
+
+type ResumeOptions[T any] struct {
+	// AllowVersionChange: resume checkpoints of other template versions, completed components which were removed are
+	// skipped and added components are executed
+	AllowVersionChange bool
+	// MigrateData is called with the checkpoint version before resuming a checkpoint of another version, e.g. to set
+	// defaults for fields introduced by the new version
+	MigrateData func(fromVersion string, data *T) error
+	// CheckpointStore: store the checkpoint was loaded or claimed from, the resumed run keeps saving its checkpoints
+	// under the id of the checkpoint (renewing its lease, see CheckpointLeaser) and deletes it once DONE
+	CheckpointStore CheckpointStore[T]
+}
+
+/* Resume continues a checkpointed run: completed components are restored as DONE, all others are executed */
+func (t *Template[CT, C, T]) Resume(ctx CT, cp Checkpoint[T], config C, opts ...*ResumeOptions[T]) (*T, Status, error) {
+	var opt ResumeOptions[T]
+	if len(opts) > 1 {
+		panic("only one ResumeOptions is allowed")
+	}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = *opts[0]
+	}
+	if cp.Template != t.Name {
+		return nil, ERROR, fmt.Errorf("checkpoint belongs to template %s", cp.Template)
+	}
+
+	data := cp.Data
+	if cp.Version != t.Version {
+		if !opt.AllowVersionChange {
+			return nil, ERROR, fmt.Errorf("%w: %s, template version %s", ErrVersionMismatch, cp.Version, t.Version)
+		}
+		log.Printf("Template.Resume:Migrating checkpoint %s from version %s to %s", cp.WorkflowId, cp.Version, t.Version)
+		if opt.MigrateData != nil {
+			if err := opt.MigrateData(cp.Version, &data); err != nil {
+				return nil, ERROR, err
+			}
+		}
+	}
+
+	wf := t.NewWorkflow(ctx)
+	wf.restored = map[string]bool{}
+	for _, name := range cp.Completed {
+		// components removed by the new version are skipped
+		if t.Component(name) != nil {
+			wf.restored[name] = true
+		}
+	}
+	if opt.CheckpointStore != nil {
+		wf.checkpointTo(opt.CheckpointStore, cp.WorkflowId)
+	}
+	return wf.Execute(ctx, config, &data)
+}
+
+/* NeedsMigration: checkpoint was created by another version of the template */
+func (t *Template[CT, C, T]) NeedsMigration(cp Checkpoint[T]) bool {
+	return cp.Version != t.Version
+}
+
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+/* CheckpointStore persists checkpoints of runs, so they can be resumed after a restart */
+type CheckpointStore[T any] interface {
+	SaveCheckpoint(ctx context.Context, cp Checkpoint[T]) error
+	LoadCheckpoint(ctx context.Context, workflowId string) (Checkpoint[T], error)
+	DeleteCheckpoint(ctx context.Context, workflowId string) error
+}
+
+/*
+CheckpointLeaser: optional interface of checkpoint stores shared by service instances, e.g. pgstore. The instance
+saving the checkpoints of a run holds a lease on them, so other instances only claim the checkpoints of runs whose
+instance died
+*/
+type CheckpointLeaser interface {
+	// CheckpointLease: duration of the lease taken by SaveCheckpoint, it is renewed every third of it while the run is live
+	CheckpointLease() time.Duration
+	RenewCheckpoint(ctx context.Context, workflowId string) error
+	// FinishCheckpoint: the run ended with ERROR, the checkpoint is kept for an explicit Resume but can't be claimed anymore
+	FinishCheckpoint(ctx context.Context, workflowId string) error
+}
+
+/*
+SetCheckpointStore saves a checkpoint to store every time a component is DONE. Checkpoints are saved in the
+background, outside the state lock, only the latest one is saved when the store falls behind. The checkpoint is
+deleted when the run is DONE (or DONE_WITH_WARNINGS, QUARANTINED) and kept when it fails, so it can be resumed.
+Execute returns once the store is up to date.
+*/
+func (wf *Workflow[CT, C, T]) SetCheckpointStore(store CheckpointStore[T]) {
+	wf.checkpointTo(store, "")
+}
+
+/* checkpointTo: checkpoints are saved as workflowId (the id of the run when empty) */
+func (wf *Workflow[CT, C, T]) checkpointTo(store CheckpointStore[T], workflowId string) {
+	saver := &checkpointSaver[T]{store: store, queue: wf.backgroundQueue(), workflowId: workflowId}
+	saver.leaser, _ = store.(CheckpointLeaser)
+	wf.OnCheckpoint(saver.save)
+	wf.addStateListener(func(change StateChange) {
+		if change.Component == "" {
+			saver.runStatus(change)
+		}
+	})
+}
+
+/* checkpointSaver saves the latest checkpoint of the run on its queue */
+type checkpointSaver[T any] struct {
+	store      CheckpointStore[T]
+	leaser     CheckpointLeaser
+	queue      *taskQueue
+	workflowId string
+	lock       sync.Mutex
+	pending    *Checkpoint[T]
+	saved      bool
+	ended      bool
+	// stopRenewal ends the lease renewals of the live run
+	stopRenewal chan struct{}
+}
+
+func (s *checkpointSaver[T]) save(cp Checkpoint[T]) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.workflowId != "" {
+		cp.WorkflowId = s.workflowId
+	}
+	if s.pending == nil {
+		s.queue.dispatch(s.savePending)
+	}
+	s.pending = &cp
+}
+
+func (s *checkpointSaver[T]) savePending() {
+	s.lock.Lock()
+	cp := s.pending
+	s.pending = nil
+	s.lock.Unlock()
+	if err := s.store.SaveCheckpoint(context.Background(), *cp); err != nil {
+		log.Println("Workflow.SaveCheckpoint:Error:", err)
+		return
+	}
+	s.lock.Lock()
+	s.saved = true
+	s.lock.Unlock()
+}
+
+/* runStatus: renews the lease while the run is live, deletes or finishes the checkpoint when it ends. Called with stateLock held */
+func (s *checkpointSaver[T]) runStatus(change StateChange) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	workflowId := change.WorkflowId
+	if s.workflowId != "" {
+		workflowId = s.workflowId
+	}
+	switch change.NewStatus {
+	case RUNNING:
+		if s.leaser != nil && s.stopRenewal == nil {
+			s.stopRenewal = make(chan struct{})
+			go s.renew(workflowId, s.stopRenewal)
+		}
+		return
+	case DONE, DONE_WITH_WARNINGS, QUARANTINED:
+		s.queue.dispatch(func() {
+			if err := s.store.DeleteCheckpoint(context.Background(), workflowId); err != nil && !errors.Is(err, ErrCheckpointNotFound) {
+				log.Println("Workflow.DeleteCheckpoint:Error:", err)
+			}
+		})
+	case ERROR:
+		if s.leaser != nil {
+			s.queue.dispatch(func() {
+				if err := s.leaser.FinishCheckpoint(context.Background(), workflowId); err != nil && !errors.Is(err, ErrCheckpointNotFound) {
+					log.Println("Workflow.FinishCheckpoint:Error:", err)
+				}
+			})
+		}
+	default:
+		return
+	}
+	s.ended = true
+	if s.stopRenewal != nil {
+		close(s.stopRenewal)
+		s.stopRenewal = nil
+	}
+}
+
+func (s *checkpointSaver[T]) renew(workflowId string, stop chan struct{}) {
+	interval := s.leaser.CheckpointLease() / 3
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.queue.dispatch(func() {
+				s.lock.Lock()
+				saved, ended := s.saved, s.ended
+				s.lock.Unlock()
+				// resumed runs hold the lease of the claimed checkpoint before saving their own
+				if ended || !saved && s.workflowId == "" {
+					return
+				}
+				if err := s.leaser.RenewCheckpoint(context.Background(), workflowId); err != nil {
+					log.Println("Workflow.RenewCheckpoint:Error:", err)
+				}
+			})
+		}
+	}
+}
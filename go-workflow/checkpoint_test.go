@@ -0,0 +1,290 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	runs := map[string]int{}
+	failB := true
+	newTemplate := func(version string, withC bool) *goworkflow.Template[context.Context, Config, Data] {
+		tpl := goworkflow.NewTemplate[context.Context, Config, Data]("doc")
+		tpl.Version = version
+		a := tpl.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			runs["A"]++
+			dt.Update(func(d *Data) {
+				d.A = "A"
+			})
+			return nil
+		}))
+		b := tpl.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			runs["B"]++
+			if failB {
+				return errors.New("provider outage")
+			}
+			dt.Update(func(d *Data) {
+				d.B = dt.GetData().A + "B"
+			})
+			return nil
+		}))
+		b.AddDependencies(a)
+		if withC {
+			c := tpl.AddComponent(goworkflow.MakeComponent("C", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+				runs["C"]++
+				dt.Update(func(d *Data) {
+					d.C = d.C + "C"
+				})
+				return nil
+			}))
+			c.AddDependencies(a)
+		}
+		return tpl
+	}
+
+	v1 := newTemplate("v1", false)
+	wf := v1.NewWorkflow(context.TODO())
+	checkpoints := []goworkflow.Checkpoint[Data]{}
+	wf.OnCheckpoint(func(cp goworkflow.Checkpoint[Data]) {
+		checkpoints = append(checkpoints, cp)
+	})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "v1", wf.Version())
+	assert.Len(t, checkpoints, 1)
+
+	cp := wf.Checkpoint()
+	assert.Equal(t, []string{"A"}, cp.Completed)
+	assert.Equal(t, "A", cp.Data.A)
+
+	failB = false
+	data, st, err := v1.Resume(context.TODO(), cp, Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "AB", data.B)
+	assert.Equal(t, 1, runs["A"], "completed components should not run again")
+
+	v2 := newTemplate("v2", true)
+	assert.True(t, v2.NeedsMigration(cp))
+	_, _, err = v2.Resume(context.TODO(), cp, Config{})
+	assert.ErrorIs(t, err, goworkflow.ErrVersionMismatch)
+
+	data, st, err = v2.Resume(context.TODO(), cp, Config{}, &goworkflow.ResumeOptions[Data]{
+		AllowVersionChange: true,
+		MigrateData: func(fromVersion string, d *Data) error {
+			assert.Equal(t, "v1", fromVersion)
+			d.C = "default-"
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "default-C", data.C)
+	assert.Equal(t, 1, runs["A"])
+	assert.Equal(t, 1, runs["C"])
+}
+
+/* slowCheckpointStore blocks the first save until released */
+type slowCheckpointStore struct {
+	lock    sync.Mutex
+	release chan struct{}
+	saves   []goworkflow.Checkpoint[Data]
+	deleted []string
+}
+
+func (s *slowCheckpointStore) SaveCheckpoint(ctx context.Context, cp goworkflow.Checkpoint[Data]) error {
+	<-s.release
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.saves = append(s.saves, cp)
+	return nil
+}
+
+func (s *slowCheckpointStore) LoadCheckpoint(ctx context.Context, workflowId string) (goworkflow.Checkpoint[Data], error) {
+	return goworkflow.Checkpoint[Data]{}, goworkflow.ErrCheckpointNotFound
+}
+
+func (s *slowCheckpointStore) DeleteCheckpoint(ctx context.Context, workflowId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deleted = append(s.deleted, workflowId)
+	return nil
+}
+
+func TestCheckpointStoreInBackground(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	finished := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprint("C", i)
+		wf.AddComponent(goworkflow.MakeComponent(name, nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			finished <- name
+			return nil
+		}))
+	}
+	store := &slowCheckpointStore{release: make(chan struct{})}
+	wf.SetCheckpointStore(store)
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+
+	// the components don't wait for the store
+	for i := 0; i < 20; i++ {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("components blocked by the checkpoint store")
+		}
+	}
+	assert.Eventually(t, func() bool { return wf.Checkpoint().Completed != nil && len(wf.Checkpoint().Completed) == 20 }, 5*time.Second, time.Millisecond)
+	close(store.release)
+	assert.Equal(t, goworkflow.DONE, <-done)
+	// only the latest checkpoint is saved once the store catches up
+	assert.LessOrEqual(t, len(store.saves), 2)
+	assert.Len(t, store.saves[len(store.saves)-1].Completed, 20)
+}
+
+type Pages struct {
+	Text map[string]string
+}
+
+/* blockingCheckpointStore signals the first save and blocks it until released */
+type blockingCheckpointStore struct {
+	lock    sync.Mutex
+	entered chan struct{}
+	release chan struct{}
+	saves   []goworkflow.Checkpoint[Pages]
+}
+
+func (s *blockingCheckpointStore) SaveCheckpoint(ctx context.Context, cp goworkflow.Checkpoint[Pages]) error {
+	s.lock.Lock()
+	first := len(s.saves) == 0
+	s.saves = append(s.saves, cp)
+	s.lock.Unlock()
+	if first {
+		close(s.entered)
+		<-s.release
+	}
+	return nil
+}
+
+func (s *blockingCheckpointStore) LoadCheckpoint(ctx context.Context, workflowId string) (goworkflow.Checkpoint[Pages], error) {
+	return goworkflow.Checkpoint[Pages]{}, goworkflow.ErrCheckpointNotFound
+}
+
+func (s *blockingCheckpointStore) DeleteCheckpoint(ctx context.Context, workflowId string) error {
+	return nil
+}
+
+func TestCheckpointDataIsCopied(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Pages](context.TODO())
+	store := &blockingCheckpointStore{entered: make(chan struct{}), release: make(chan struct{})}
+	wf.SetCheckpointStore(store)
+	first := wf.AddComponent(goworkflow.MakeComponent("Page1", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Pages]) error {
+		dt.Update(func(p *Pages) { p.Text["1"] = "one" })
+		return nil
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("Page2", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Pages]) error {
+		// written while the checkpoint of Page1 is saved
+		<-store.entered
+		for i := 0; i < 100; i++ {
+			dt.Update(func(p *Pages) { p.Text[fmt.Sprint("2.", i)] = "two" })
+		}
+		close(store.release)
+		return nil
+	})).AddDependencies(first)
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Pages{Text: map[string]string{}})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Len(t, data.Text, 101)
+	assert.Equal(t, map[string]string{"1": "one"}, store.saves[0].Data.Text)
+}
+
+/* leasingCheckpointStore records the lease calls of the workflow */
+type leasingCheckpointStore struct {
+	lock     sync.Mutex
+	calls    []string
+	renewals int
+}
+
+func (s *leasingCheckpointStore) record(call string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *leasingCheckpointStore) SaveCheckpoint(ctx context.Context, cp goworkflow.Checkpoint[Data]) error {
+	s.record("save " + cp.WorkflowId)
+	return nil
+}
+
+func (s *leasingCheckpointStore) LoadCheckpoint(ctx context.Context, workflowId string) (goworkflow.Checkpoint[Data], error) {
+	return goworkflow.Checkpoint[Data]{}, goworkflow.ErrCheckpointNotFound
+}
+
+func (s *leasingCheckpointStore) DeleteCheckpoint(ctx context.Context, workflowId string) error {
+	s.record("delete " + workflowId)
+	return nil
+}
+
+func (s *leasingCheckpointStore) CheckpointLease() time.Duration {
+	return 30 * time.Millisecond
+}
+
+func (s *leasingCheckpointStore) RenewCheckpoint(ctx context.Context, workflowId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.renewals++
+	return nil
+}
+
+func (s *leasingCheckpointStore) FinishCheckpoint(ctx context.Context, workflowId string) error {
+	s.record("finish " + workflowId)
+	return nil
+}
+
+func TestCheckpointLease(t *testing.T) {
+	failB := true
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("doc")
+	a := tpl.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	tpl.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(100 * time.Millisecond)
+		if failB {
+			return errors.New("provider outage")
+		}
+		return nil
+	})).AddDependencies(a)
+
+	store := &leasingCheckpointStore{}
+	wf := tpl.NewWorkflow(context.TODO())
+	wf.SetCheckpointStore(store)
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	// the lease is renewed while B runs, the failed run can be resumed but not claimed
+	assert.Greater(t, store.renewals, 0)
+	assert.Equal(t, []string{"save " + wf.Id(), "finish " + wf.Id()}, store.calls)
+
+	// resumed by another instance which claimed the checkpoint
+	failB = false
+	resumed := &leasingCheckpointStore{}
+	_, st, err := tpl.Resume(context.TODO(), wf.Checkpoint(), Config{}, &goworkflow.ResumeOptions[Data]{CheckpointStore: resumed})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Greater(t, resumed.renewals, 0)
+	// checkpoints are saved under the id of the claimed checkpoint, deleted once DONE
+	assert.Equal(t, "delete "+wf.Id(), resumed.calls[len(resumed.calls)-1])
+	for _, call := range resumed.calls[:len(resumed.calls)-1] {
+		assert.Equal(t, "save "+wf.Id(), call)
+	}
+}
@@ -0,0 +1,177 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+/* ViewOptions: options of the timeline and graph exports of a run */
+type ViewOptions struct {
+	// ExpandChildren includes the runs started inside components (see NewWorkflow) inline, their components are
+	// prefixed with <component>/<child workflow name>/, numbered (#1, #2...) when a component started several
+	ExpandChildren bool
+}
+
+func expandChildren(opts []*ViewOptions) bool {
+	if len(opts) > 1 {
+		panic("only one ViewOptions is allowed")
+	}
+	return len(opts) == 1 && opts[0] != nil && opts[0].ExpandChildren
+}
+
+/* childRun: workflow started inside a component, whatever its types */
+type childRun interface {
+	Name() string
+	Timeline(opts ...*ViewOptions) []ComponentTiming
+	graph(expand bool) ([]string, []graphEdge)
+}
+
+type startedChild struct {
+	component string
+	run       childRun
+	// prefix of the components of the child in expanded views
+	prefix string
+}
+
+type childRunsContextKey struct{}
+
+/* graphEdge: Label is empty for dependencies requiring success */
+type graphEdge struct {
+	Edge
+	Label string
+}
+
+func (wf *Workflow[CT, C, T]) contextWithChildRuns(ctx context.Context, c *component[CT, C, T]) context.Context {
+	return context.WithValue(ctx, childRunsContextKey{}, func(child childRun) {
+		wf.stateLock.Lock()
+		defer wf.stateLock.Unlock()
+		wf.children = append(wf.children, startedChild{component: c.Name, run: child})
+	})
+}
+
+func registerChildRun(ctx context.Context, child childRun) {
+	if register, ok := ctx.Value(childRunsContextKey{}).(func(childRun)); ok {
+		register(child)
+	}
+}
+
+/* childViews: started children with the prefix of their components, in start order */
+func (wf *Workflow[CT, C, T]) childViews() []startedChild {
+	wf.stateLock.Lock()
+	children := slices.Clone(wf.children)
+	wf.stateLock.Unlock()
+	started := map[string]int{}
+	for _, child := range children {
+		started[child.component]++
+	}
+	seen := map[string]int{}
+	for i, child := range children {
+		name := child.run.Name()
+		if name == "" {
+			name = "run"
+		}
+		if started[child.component] > 1 {
+			seen[child.component]++
+			name = fmt.Sprintf("%s#%d", name, seen[child.component])
+		}
+		children[i].prefix = child.component + "/" + name + "/"
+	}
+	return children
+}
+
+/* graph: component names and dependency edges, children inline when expand */
+func (wf *Workflow[CT, C, T]) graph(expand bool) ([]string, []graphEdge) {
+	nodes := []string{}
+	for _, c := range wf.sortedComponents() {
+		nodes = append(nodes, c.Name)
+	}
+	edges := []graphEdge{}
+	d := wf.dependencyManager
+	d.lk.Lock()
+	for dependencyId, dependents := range d.dependencyGraph {
+		for componentId, dep := range dependents {
+			if !dep {
+				continue
+			}
+			label := ""
+			if outcome, ok := d.outcomes[dependencyId][componentId]; ok && outcome != OnSuccess {
+				label = string(outcome)
+			}
+			for _, group := range d.anyOf[componentId] {
+				if slices.Contains(group, dependencyId) {
+					label = "any of"
+				}
+			}
+			edges = append(edges, graphEdge{Edge: Edge{From: d.componentIdToName[dependencyId], To: d.componentIdToName[componentId]}, Label: label})
+		}
+	}
+	d.lk.Unlock()
+	slices.SortFunc(edges, func(a, b graphEdge) int {
+		if a.From != b.From {
+			return strings.Compare(a.From, b.From)
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	if !expand {
+		return nodes, edges
+	}
+
+	for _, child := range wf.childViews() {
+		childNodes, childEdges := child.run.graph(true)
+		hasDependencies := map[string]bool{}
+		for _, e := range childEdges {
+			hasDependencies[e.To] = true
+			edges = append(edges, graphEdge{Edge: Edge{From: child.prefix + e.From, To: child.prefix + e.To}, Label: e.Label})
+		}
+		for _, node := range childNodes {
+			nodes = append(nodes, child.prefix+node)
+			// the component starting the child run leads to the roots of the child
+			if !hasDependencies[node] {
+				edges = append(edges, graphEdge{Edge: Edge{From: child.component, To: child.prefix + node}, Label: "runs"})
+			}
+		}
+	}
+	return nodes, edges
+}
+
+/* ExportDot writes the dependency graph of the run in the graphviz dot format */
+func (wf *Workflow[CT, C, T]) ExportDot(w io.Writer, opts ...*ViewOptions) error {
+	nodes, edges := wf.graph(expandChildren(opts))
+	lines := []string{fmt.Sprintf("digraph %q {", wf.name), "  rankdir=LR;", "  node [shape=box];"}
+	for _, node := range nodes {
+		lines = append(lines, fmt.Sprintf("  %q;", node))
+	}
+	for _, e := range edges {
+		attributes := ""
+		if e.Label != "" {
+			attributes = fmt.Sprintf(" [label=%q, style=dashed]", e.Label)
+		}
+		lines = append(lines, fmt.Sprintf("  %q -> %q%s;", e.From, e.To, attributes))
+	}
+	lines = append(lines, "}")
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+/* ExportMermaid writes the dependency graph of the run as a mermaid flowchart */
+func (wf *Workflow[CT, C, T]) ExportMermaid(w io.Writer, opts ...*ViewOptions) error {
+	nodes, edges := wf.graph(expandChildren(opts))
+	ids := map[string]string{}
+	lines := []string{"flowchart LR"}
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("c%d", i+1)
+		lines = append(lines, fmt.Sprintf("  %s[\"%s\"]", ids[node], strings.ReplaceAll(node, `"`, "#quot;")))
+	}
+	for _, e := range edges {
+		if e.Label == "" {
+			lines = append(lines, fmt.Sprintf("  %s --> %s", ids[e.From], ids[e.To]))
+		} else {
+			lines = append(lines, fmt.Sprintf("  %s -. %s .-> %s", ids[e.From], e.Label, ids[e.To]))
+		}
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
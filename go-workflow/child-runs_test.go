@@ -0,0 +1,63 @@
+package goworkflow_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandChildRuns(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}
+	page := goworkflow.NewTemplate[context.Context, Config, Data]("page")
+	analyze := page.AddComponent(goworkflow.MakeComponent("Analyze", nil, noop))
+	page.AddComponent(goworkflow.MakeComponent("Summarize", nil, noop)).AddDependencies(analyze)
+
+	document := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	document.SetName("document")
+	ocr := document.AddComponent(goworkflow.MakeComponent("OCR", nil, noop))
+	document.AddComponent(goworkflow.MakeComponent("Pages", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		for i := 0; i < 2; i++ {
+			if _, _, err := page.Execute(ctx, Config{}, &Data{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})).AddDependencies(ocr)
+	_, st, err := document.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	assert.Len(t, document.Timeline(), 2)
+	components := []string{}
+	for _, ct := range document.Timeline(&goworkflow.ViewOptions{ExpandChildren: true}) {
+		components = append(components, ct.Component)
+	}
+	assert.Equal(t, []string{
+		"OCR", "Pages",
+		"Pages/page#1/Analyze", "Pages/page#1/Summarize",
+		"Pages/page#2/Analyze", "Pages/page#2/Summarize",
+	}, components)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, document.ExportDot(buf))
+	assert.Equal(t, `digraph "document" {
+  rankdir=LR;
+  node [shape=box];
+  "OCR";
+  "Pages";
+  "OCR" -> "Pages";
+}
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, document.ExportMermaid(buf, &goworkflow.ViewOptions{ExpandChildren: true}))
+	assert.Contains(t, buf.String(), "c3[\"Pages/page#1/Analyze\"]")
+	assert.Contains(t, buf.String(), "c2 -. runs .-> c3")
+	assert.Contains(t, buf.String(), "c3 --> c4")
+	assert.Contains(t, buf.String(), "c2 -. runs .-> c5")
+}
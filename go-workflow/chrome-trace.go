@@ -0,0 +1,108 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+/* chromeTraceEvent: see the Trace Event Format, "X" are complete events with timestamps in microseconds */
+type chromeTraceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"`
+	Duration  int64             `json:"dur"`
+	Pid       int               `json:"pid"`
+	Tid       int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+/*
+ExportChromeTrace writes the timeline of the run in the Chrome trace_event json format, to be opened in
+Perfetto or chrome://tracing. Every component is one "component" event, time spent waiting for limiters
+is a separate "wait" event, nesting a "limiter" event per limiter it waited for. Components are spread over
+lanes (tids) so that events in a lane don't overlap.
+*/
+func (wf *Workflow[CT, C, T]) ExportChromeTrace(w io.Writer, opts ...*ViewOptions) error {
+	timeline := wf.Timeline(opts...)
+	var origin time.Time
+	for _, ct := range timeline {
+		if !ct.ReadyAt.IsZero() && (origin.IsZero() || ct.ReadyAt.Before(origin)) {
+			origin = ct.ReadyAt
+		}
+	}
+
+	trace := chromeTrace{TraceEvents: []chromeTraceEvent{}, DisplayTimeUnit: "ms"}
+	laneFreeAt := []time.Time{}
+	for _, ct := range timeline {
+		if ct.ReadyAt.IsZero() || ct.FinishedAt.IsZero() {
+			continue
+		}
+		lane := -1
+		for i, freeAt := range laneFreeAt {
+			if !freeAt.After(ct.ReadyAt) {
+				lane = i
+				break
+			}
+		}
+		if lane == -1 {
+			lane = len(laneFreeAt)
+			laneFreeAt = append(laneFreeAt, time.Time{})
+		}
+		laneFreeAt[lane] = ct.FinishedAt
+
+		args := map[string]string{"componentId": ct.ComponentId, "status": string(ct.Status)}
+		if ct.Wait() > 0 {
+			trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+				Name:      ct.Component,
+				Category:  "wait",
+				Phase:     "X",
+				Timestamp: ct.ReadyAt.Sub(origin).Microseconds(),
+				Duration:  ct.Wait().Microseconds(),
+				Pid:       1,
+				Tid:       lane + 1,
+				Args:      args,
+			})
+		}
+		for _, lw := range ct.LimiterWaits {
+			if lw.Wait <= 0 {
+				continue
+			}
+			trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+				Name:      lw.Limiter,
+				Category:  "limiter",
+				Phase:     "X",
+				Timestamp: lw.StartedAt.Sub(origin).Microseconds(),
+				Duration:  lw.Wait.Microseconds(),
+				Pid:       1,
+				Tid:       lane + 1,
+				Args:      map[string]string{"componentId": ct.ComponentId},
+			})
+		}
+		start := ct.StartedAt
+		if start.IsZero() {
+			// never executed, e.g. a dependency failed
+			start = ct.ReadyAt
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name:      ct.Component,
+			Category:  "component",
+			Phase:     "X",
+			Timestamp: start.Sub(origin).Microseconds(),
+			Duration:  ct.FinishedAt.Sub(start).Microseconds(),
+			Pid:       1,
+			Tid:       lane + 1,
+			Args:      args,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(trace)
+}
@@ -0,0 +1,62 @@
+package goworkflow_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimelineAndChromeTrace(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	single := limiter.NewConcurrencyLimiter(1)
+	sleep := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, sleep), &goworkflow.ComponentConfig{ConcurrencyLimiter: single})
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, sleep), &goworkflow.ComponentConfig{ConcurrencyLimiter: single})
+	c := wf.AddComponent(goworkflow.MakeComponent("C", nil, sleep))
+	c.AddDependencies(a, b)
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	timeline := wf.Timeline()
+	assert.Len(t, timeline, 3)
+	assert.Equal(t, "C", timeline[2].Component)
+	waited := timeline[0].Wait() + timeline[1].Wait()
+	assert.GreaterOrEqual(t, waited, 15*time.Millisecond, "one of A and B waits for the limiter")
+	assert.GreaterOrEqual(t, timeline[2].Duration(), 20*time.Millisecond)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, wf.ExportChromeTrace(buf))
+	trace := struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Cat  string `json:"cat"`
+			Ph   string `json:"ph"`
+			Ts   int64  `json:"ts"`
+			Dur  int64  `json:"dur"`
+			Tid  int    `json:"tid"`
+		} `json:"traceEvents"`
+	}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+	categories := map[string]int{}
+	for _, e := range trace.TraceEvents {
+		assert.Equal(t, "X", e.Ph)
+		if e.Cat == "component" || e.Dur > 10000 {
+			categories[e.Cat]++
+		}
+		if e.Name == "C" {
+			assert.GreaterOrEqual(t, e.Ts, int64(40000))
+		}
+	}
+	assert.Equal(t, map[string]int{"component": 3, "wait": 1, "limiter": 1}, categories)
+}
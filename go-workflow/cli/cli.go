@@ -0,0 +1,305 @@
+/*
+Package cli implements the goworkflow command line tool for YAML defined workflows (see
+goworkflow.WorkflowDefinition) whose executors are registered in a ComponentRegistry:
+
+	goworkflow run -f invoices.yaml -config config.yaml -set ocr.dpi=600 -report run.json
+	goworkflow plan -f invoices.yaml
+	goworkflow graph -f invoices.yaml -format mermaid
+	goworkflow import invoices.mmd > invoices.yaml
+	goworkflow schema -f invoices.yaml -type config
+	goworkflow report run.json
+	goworkflow debug -i run.json
+	goworkflow compare before.json after.json
+
+Services ship their own binary with their components registered:
+
+	func main() {
+		r := goworkflow.NewComponentRegistry[context.Context, Config, Data]()
+		goworkflow.Register(r, "ocr", ocr)
+		cli.Main(r)
+	}
+
+cmd/goworkflow is the stock binary, loading its executors from Go plugins.
+*/
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `usage: %[1]s <command> [flags]
+
+commands:
+  run     execute a workflow, progress is written to stderr and the final data to stdout
+  plan    validate a workflow and print its execution stages
+  graph   print the dependency graph of a workflow (dot or mermaid)
+  import  print the definition skeleton of a dot or mermaid graph whose nodes are named after executors
+  schema  print the JSON schema of the config or data of a workflow
+  report  print the report of a run written by run -report
+  debug   step through a run recorded with run -record
+  compare compare two runs written by run -report
+
+"%[1]s <command> -h" describes the flags of a command.
+`
+
+/* exit codes */
+const (
+	ExitOK     = 0
+	ExitFailed = 1
+	ExitUsage  = 2
+)
+
+/* Report: result and timeline of a run, written by run -report */
+type Report struct {
+	Result   goworkflow.RunResult
+	Timeline []goworkflow.ComponentTiming
+	// DataChanges: fields changed by every component, recorded with run -data-changes
+	DataChanges map[string][]goworkflow.FieldChange `json:",omitempty"`
+	// Recording: data store before and after every component, recorded with run -record, see the debug command
+	Recording *goworkflow.RunRecording `json:",omitempty"`
+	// Data: final data of the run, compared by the compare command
+	Data json.RawMessage `json:",omitempty"`
+}
+
+/* Main runs the command of os.Args and exits, the run is cancelled on interrupt */
+func Main[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T]) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := Run(ctx, r, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+/* Run executes the command of args and returns the exit code */
+func Run[C any, T any](ctx context.Context, r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) int {
+	name := "goworkflow"
+	if len(os.Args) > 0 {
+		name = os.Args[0]
+		if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+			name = name[i+1:]
+		}
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, usage, name)
+		return ExitUsage
+	}
+	var err error
+	switch args[0] {
+	case "run":
+		return runCommand(ctx, r, args[1:], stdout, stderr)
+	case "plan":
+		err = planCommand(r, args[1:], stdout, stderr)
+	case "graph":
+		err = graphCommand(r, args[1:], stdout, stderr)
+	case "import":
+		err = importCommand(r, args[1:], stdout, stderr)
+	case "schema":
+		err = schemaCommand(r, args[1:], stdout, stderr)
+	case "report":
+		err = reportCommand(args[1:], stdout, stderr)
+	case "debug":
+		err = debugCommand(args[1:], stdout, stderr)
+	case "compare":
+		err = compareCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintf(stdout, usage, name)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %s\n\n"+usage, args[0], name)
+		return ExitUsage
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return ExitUsage
+	}
+	return ExitOK
+}
+
+/* setFlags: repeated -set path=value flags */
+type setFlags []string
+
+func (s *setFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *setFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected path=value, got %s", value)
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+func newFlagSet(command string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+func loadDefinition[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T], path string) (*goworkflow.WorkflowDefinition, *goworkflow.Template[context.Context, C, T], error) {
+	if path == "" {
+		return nil, nil, errors.New("the workflow definition is required (-f)")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	def, err := goworkflow.ParseWorkflowDefinition(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := goworkflow.TemplateFromDefinition(r, def)
+	return def, t, err
+}
+
+/* decodeDocument: YAML or JSON document of path, with the -set overrides applied, converted to V through json */
+func decodeDocument[V any](path string, overrides []string, value *V) error {
+	doc := map[string]any{}
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, override := range overrides {
+		path, raw, _ := strings.Cut(override, "=")
+		var v any
+		if err := yaml.Unmarshal([]byte(raw), &v); err != nil || v == nil {
+			v = raw
+		}
+		setPath(doc, strings.Split(path, "."), v)
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, value)
+}
+
+func setPath(doc map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			doc[key] = next
+		}
+		doc = next
+	}
+	doc[path[len(path)-1]] = value
+}
+
+func runCommand[C any, T any](ctx context.Context, r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("run", stderr)
+	file := flags.String("f", "", "workflow definition (yaml)")
+	configFile := flags.String("config", "", "config of the run (yaml or json)")
+	overrides := setFlags{}
+	flags.Var(&overrides, "set", "config override path=value, e.g. ocr.dpi=600 (repeatable)")
+	dataFile := flags.String("data", "", "initial data (yaml or json)")
+	output := flags.String("o", "", "file the final data is written to, stdout when empty")
+	reportFile := flags.String("report", "", "file the run report is written to, see the report command")
+	timeout := flags.Duration("timeout", 0, "cancel the run after this duration")
+	quiet := flags.Bool("q", false, "don't write progress to stderr")
+	dataChanges := flags.Bool("data-changes", false, "record the data fields changed by every component in the report")
+	record := flags.Bool("record", false, "record the data store before and after every component in the report, see the debug command")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	_, t, err := loadDefinition(r, *file)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return ExitUsage
+	}
+	var config C
+	if err := decodeDocument(*configFile, overrides, &config); err != nil {
+		fmt.Fprintln(stderr, "error: invalid config:", err)
+		return ExitUsage
+	}
+	data := new(T)
+	if err := decodeDocument(*dataFile, nil, data); err != nil {
+		fmt.Fprintln(stderr, "error: invalid data:", err)
+		return ExitUsage
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	wf := t.NewWorkflow(ctx)
+	wf.SetRecordDataChanges(*dataChanges)
+	wf.SetRecording(*record)
+	if !*quiet {
+		wf.OnStateChange(func(change goworkflow.StateChange) {
+			writeProgress(stderr, t.Name, change)
+		})
+	}
+	result, status, err := wf.Execute(ctx, config, data)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return ExitFailed
+	}
+
+	if *reportFile != "" {
+		report := Report{Result: wf.Result(), Timeline: wf.Timeline(), DataChanges: wf.DataChanges()}
+		report.Data, _ = json.Marshal(result)
+		if *record {
+			recording := wf.Recording()
+			report.Recording = &recording
+		}
+		encoded, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*reportFile, encoded, 0o644); err != nil {
+			fmt.Fprintln(stderr, "error: writing the report:", err)
+		}
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, "error: encoding the data:", err)
+		return ExitFailed
+	}
+	if *output != "" {
+		err = os.WriteFile(*output, append(out, '\n'), 0o644)
+	} else {
+		_, err = fmt.Fprintln(stdout, string(out))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error: writing the data:", err)
+		return ExitFailed
+	}
+	if status == goworkflow.ERROR {
+		return ExitFailed
+	}
+	return ExitOK
+}
+
+func writeProgress(w io.Writer, workflow string, change goworkflow.StateChange) {
+	subject := workflow
+	if change.Component != "" {
+		subject = change.Component
+	}
+	line := fmt.Sprintf("%s %-20s %s", change.Time.Format(time.TimeOnly), subject, change.NewStatus)
+	if change.Cause != "" {
+		line += ": " + change.Cause
+	}
+	fmt.Fprintln(w, line)
+}
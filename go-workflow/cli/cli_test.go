@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct {
+	OCR struct {
+		DPI      int
+		Language string
+	}
+}
+
+type Data struct {
+	Text   string
+	Fields map[string]string
+}
+
+const definition = `
+name: invoices
+version: "3"
+components:
+  - name: OCR
+    executor: ocr
+  - name: Extract
+    executor: extract
+    dependsOn: [OCR]
+    retry: {maxAttempts: 2}
+  - name: Alert
+    executor: alert
+    dependsOnFailure: [Extract]
+`
+
+func registry(extractErr error) *goworkflow.ComponentRegistry[context.Context, Config, Data] {
+	r := goworkflow.NewComponentRegistry[context.Context, Config, Data]()
+	goworkflow.Register(r, "ocr", func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.Text = dt.Config.OCR.Language })
+		return nil
+	})
+	goworkflow.Register(r, "extract", func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return extractErr
+	})
+	goworkflow.Register(r, "alert", func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	})
+	return r
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRunAndReport(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	config := writeFile(t, "config.yaml", "OCR: {DPI: 300, Language: en}\n")
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := Run(context.TODO(), registry(nil), []string{"run", "-f", file, "-config", config, "-set", "OCR.Language=de", "-report", reportFile, "-data-changes"}, stdout, stderr)
+	assert.Equal(t, ExitOK, code, stderr.String())
+	data := Data{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &data))
+	assert.Equal(t, "de", data.Text)
+	assert.Contains(t, stderr.String(), "OCR                  RUNNING")
+	assert.Contains(t, stderr.String(), "invoices             DONE")
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"report", reportFile}, stdout, stderr))
+	assert.Contains(t, stdout.String(), "invoices (version 3) run ")
+	assert.Contains(t, stdout.String(), "COMPONENT")
+	assert.Regexp(t, `Alert\s+SKIPPED`, stdout.String())
+	assert.Regexp(t, `OCR\s+Text\s+""\s+"de"`, stdout.String())
+}
+
+func TestRunFailure(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := Run(context.TODO(), registry(errors.New("no table found")), []string{"run", "-f", file, "-q"}, stdout, stderr)
+	assert.Equal(t, ExitFailed, code)
+	assert.Empty(t, stderr.String())
+
+	code = Run(context.TODO(), registry(nil), []string{"run", "-f", writeFile(t, "bad.yaml", "name: x\ncomponents: [{name: A, executor: missing}]\n")}, stdout, stderr)
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr.String(), "unknown executor missing")
+}
+
+func TestPlan(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	stdout := &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"plan", "-f", file}, stdout, &bytes.Buffer{}))
+	assert.Equal(t, "invoices (version 3): 3 components in 3 stages\n\n"+
+		"STAGE  COMPONENT  EXECUTOR  OPTIONS     AFTER\n"+
+		"1      OCR        ocr                   \n"+
+		"2      Extract    extract   2 attempts  OCR\n"+
+		"3      Alert      alert                 Extract (failure)\n", stdout.String())
+
+	redundant := writeFile(t, "redundant.yaml", definition+"  - name: Store\n    executor: alert\n    dependsOn: [OCR, Extract]\n")
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"plan", "-f", redundant}, stdout, &bytes.Buffer{}))
+	assert.Contains(t, stdout.String(), "\nsuggestions:\n  redundant-edge: dependencies of Store on OCR are implied by its other dependencies; remove them or call Workflow.ReduceEdges\n")
+}
+
+func TestGraph(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	stdout := &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"graph", "-f", file, "-format", "mermaid"}, stdout, &bytes.Buffer{}))
+	assert.Equal(t, `flowchart LR
+  c1["OCR"]
+  c2["Extract"]
+  c3["Alert"]
+  c1 --> c2
+  c2 -. failure .-> c3
+`, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"graph", "-f", file}, stdout, &bytes.Buffer{}))
+	assert.Contains(t, stdout.String(), `"Extract" -> "Alert" [label="failure", style=dashed];`)
+
+	stderr := &bytes.Buffer{}
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"graph", "-f", file, "-format", "svg"}, stdout, stderr))
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"deploy"}, stdout, stderr))
+}
+
+func TestDebug(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"run", "-f", file, "-q", "-set", "OCR.Language=de", "-report", reportFile, "-record"}, stdout, stderr))
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"debug", reportFile}, stdout, stderr), stderr.String())
+	assert.Regexp(t, `1\s+OCR\s+DONE`, stdout.String())
+	assert.Regexp(t, `3\s+Alert\s+SKIPPED`, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"debug", "-component", "OCR", reportFile}, stdout, stderr))
+	assert.Contains(t, stdout.String(), "step 1/3: OCR DONE\ndata at start:\n")
+	assert.Regexp(t, `Text\s+""\s+"de"`, stdout.String())
+
+	stdin = strings.NewReader("n\nn\np\ng Alert\nd\nq\n")
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"debug", "-i", reportFile}, stdout, stderr))
+	assert.Contains(t, stdout.String(), "step 2/3: Extract DONE")
+	assert.Contains(t, stdout.String(), "step 3/3: Alert SKIPPED: dependency outcome not met\nthe component did not run\n")
+	assert.Contains(t, stdout.String(), `"Text": "de"`)
+
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"debug", "-step", "4", reportFile}, stdout, stderr))
+	assert.Contains(t, stderr.String(), "step 4 out of range 1-3")
+}
+
+func TestCompare(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	dir := t.TempDir()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"run", "-f", file, "-q", "-set", "OCR.Language=en", "-report", filepath.Join(dir, "before.json")}, stdout, stderr))
+	assert.Equal(t, ExitFailed, Run(context.TODO(), registry(errors.New("no table found")), []string{"run", "-f", file, "-q", "-set", "OCR.Language=de", "-report", filepath.Join(dir, "after.json")}, stdout, stderr))
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"compare", filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")}, stdout, stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "invoices: run ")
+	assert.Regexp(t, `!\s+Extract\s+DONE -> ERROR\s+.*1 -> 2`, stdout.String())
+	assert.Regexp(t, `Alert\s+SKIPPED -> DONE`, stdout.String())
+	assert.Regexp(t, `Text\s+"en"\s+"de"`, stdout.String())
+
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"compare", filepath.Join(dir, "before.json")}, stdout, stderr))
+}
+
+func TestSchema(t *testing.T) {
+	file := writeFile(t, "invoices.yaml", definition)
+	stdout := &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"schema", "-f", file}, stdout, &bytes.Buffer{}))
+	schema := goworkflow.JSONSchema{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &schema))
+	assert.Equal(t, "invoices config", schema.Title)
+	assert.Equal(t, "integer", schema.Properties["OCR"].Properties["DPI"].Type)
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"schema", "-f", file, "-type", "data"}, stdout, &bytes.Buffer{}))
+	assert.Contains(t, stdout.String(), `"Fields": {`)
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"schema", "-f", file, "-type", "secrets"}, stdout, &bytes.Buffer{}))
+}
+
+func TestImport(t *testing.T) {
+	file := writeFile(t, "invoices.mmd", "flowchart LR\n  c1[ocr] --> c2[extract]\n  c2 -. failure .-> c3[alert]\n")
+	stdout := &bytes.Buffer{}
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"import", file}, stdout, &bytes.Buffer{}))
+	assert.Equal(t, `name: invoices
+components:
+    - name: ocr
+    - name: extract
+      dependsOn:
+        - ocr
+    - name: alert
+      dependsOnFailure:
+        - extract
+`, stdout.String())
+
+	imported := writeFile(t, "imported.yaml", stdout.String())
+	assert.Equal(t, ExitOK, Run(context.TODO(), registry(nil), []string{"plan", "-f", imported}, &bytes.Buffer{}, &bytes.Buffer{}))
+
+	unknown := writeFile(t, "unknown.gv", `digraph "invoices" { ocr -> classify; }`)
+	stderr := &bytes.Buffer{}
+	assert.Equal(t, ExitUsage, Run(context.TODO(), registry(nil), []string{"import", unknown}, stdout, stderr))
+	assert.Contains(t, stderr.String(), "component classify: unknown executor classify")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+func readReport(path string) (Report, error) {
+	report := Report{}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return report, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+func compareCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("compare", stderr)
+	tolerance := flags.Float64("tolerance", 0.2, "relative slowdown of a component reported as regression")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expected the before and after report files written by run -report")
+	}
+	if *tolerance < 0 {
+		return errors.New("the tolerance cannot be negative")
+	}
+	before, err := readReport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := readReport(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	comparison, err := goworkflow.CompareRuns(
+		goworkflow.RunSnapshot{Result: before.Result, Timeline: before.Timeline, Data: before.Data},
+		goworkflow.RunSnapshot{Result: after.Result, Timeline: after.Timeline, Data: after.Data},
+	)
+	if err != nil {
+		return err
+	}
+	writeComparison(stdout, comparison, *tolerance)
+	return nil
+}
+
+/* writeComparison: components table, regressions are marked with ! */
+func writeComparison(w io.Writer, comparison goworkflow.RunComparison, tolerance float64) {
+	fmt.Fprintf(w, "%s: run %s (%s) -> run %s (%s), duration %+v\n", comparison.Workflow, comparison.Before, comparison.BeforeStatus,
+		comparison.After, comparison.AfterStatus, comparison.DurationDelta.Round(time.Millisecond))
+	if len(comparison.AddedComponents) > 0 {
+		fmt.Fprintf(w, "added: %s\n", strings.Join(comparison.AddedComponents, ", "))
+	}
+	if len(comparison.RemovedComponents) > 0 {
+		fmt.Fprintf(w, "removed: %s\n", strings.Join(comparison.RemovedComponents, ", "))
+	}
+	regressions := map[string]bool{}
+	for _, c := range comparison.Regressions(tolerance) {
+		regressions[c.Component] = true
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tCOMPONENT\tSTATUS\tDURATION\tCHANGE\tATTEMPTS")
+	for _, c := range comparison.Components {
+		mark := ""
+		if regressions[c.Component] {
+			mark = "!"
+		}
+		status := string(c.AfterStatus)
+		if c.BeforeStatus != c.AfterStatus {
+			status = fmt.Sprintf("%s -> %s", orNone(c.BeforeStatus), orNone(c.AfterStatus))
+		}
+		attempts := fmt.Sprint(c.AfterAttempts)
+		if c.BeforeAttempts != c.AfterAttempts {
+			attempts = fmt.Sprintf("%d -> %d", c.BeforeAttempts, c.AfterAttempts)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s -> %s\t%+.0f%%\t%s\n", mark, c.Component, status,
+			c.BeforeDuration.Round(time.Millisecond), c.AfterDuration.Round(time.Millisecond), 100*c.DurationChange(), attempts)
+	}
+	tw.Flush()
+	if len(comparison.DataChanges) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tBEFORE\tAFTER")
+	for _, change := range comparison.DataChanges {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", change.Path, reportValue(change.Before), reportValue(change.After))
+	}
+	tw.Flush()
+}
+
+func orNone(status goworkflow.Status) string {
+	if status == "" {
+		return "-"
+	}
+	return string(status)
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* stdin: commands of debug -i */
+var stdin io.Reader = os.Stdin
+
+const debugHelp = `commands:
+  n          next step
+  p          previous step
+  g <step>   go to a step, by number or component name
+  d          data after the current step
+  l          list the steps
+  q          quit
+`
+
+func debugCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("debug", stderr)
+	step := flags.Int("step", 0, "show the step with this number")
+	component := flags.String("component", "", "show the step of this component")
+	interactive := flags.Bool("i", false, "step through the run interactively, commands are read from stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected the report file written by run -report -record")
+	}
+	report, err := readReport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if report.Recording == nil {
+		return errors.New("the run was not recorded, run it with -record")
+	}
+	d := goworkflow.NewRunDebugger(*report.Recording)
+	switch {
+	case *interactive:
+		return debugSession(d, stdin, stdout)
+	case *component != "":
+		if _, ok := d.SeekComponent(*component); !ok {
+			return fmt.Errorf("component %s did not finish in the run", *component)
+		}
+		writeStep(stdout, d)
+	case *step != 0:
+		if _, ok := d.Seek(*step - 1); !ok {
+			return fmt.Errorf("step %d out of range 1-%d", *step, len(d.Steps()))
+		}
+		writeStep(stdout, d)
+	default:
+		writeSteps(stdout, d)
+	}
+	return nil
+}
+
+func debugSession(d *goworkflow.RunDebugger, in io.Reader, w io.Writer) error {
+	fmt.Fprintf(w, "%d steps, n: next, p: previous, h: help\n", len(d.Steps()))
+	scanner := bufio.NewScanner(in)
+	for fmt.Fprint(w, "> "); scanner.Scan(); fmt.Fprint(w, "> ") {
+		command, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		switch command {
+		case "":
+		case "n":
+			if _, ok := d.Next(); !ok {
+				fmt.Fprintln(w, "end of the run")
+				continue
+			}
+			writeStep(w, d)
+		case "p":
+			// moving before the first step returns no step
+			if _, ok := d.Prev(); !ok {
+				fmt.Fprintln(w, "start of the run")
+				continue
+			}
+			writeStep(w, d)
+		case "g":
+			_, ok := d.SeekComponent(arg)
+			if n, err := strconv.Atoi(arg); !ok && err == nil {
+				_, ok = d.Seek(n - 1)
+			}
+			if !ok {
+				fmt.Fprintln(w, "unknown step", arg)
+				continue
+			}
+			writeStep(w, d)
+		case "d":
+			writeData(w, d.Data())
+		case "l":
+			writeSteps(w, d)
+		case "q":
+			return nil
+		default:
+			fmt.Fprint(w, debugHelp)
+		}
+	}
+	fmt.Fprintln(w)
+	return scanner.Err()
+}
+
+func writeSteps(w io.Writer, d *goworkflow.RunDebugger) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tCOMPONENT\tSTATUS\tFINISHED\tCAUSE")
+	for i, step := range d.Steps() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, step.Component, step.Status, step.FinishedAt.Format(time.TimeOnly), step.Cause)
+	}
+	tw.Flush()
+}
+
+/* writeStep: the current step, the data as the component saw it and the fields it changed */
+func writeStep(w io.Writer, d *goworkflow.RunDebugger) {
+	step, _ := d.Current()
+	fmt.Fprintf(w, "step %d/%d: %s %s", d.Position()+1, len(d.Steps()), step.Component, step.Status)
+	if step.Cause != "" {
+		fmt.Fprintf(w, ": %s", step.Cause)
+	}
+	fmt.Fprintln(w)
+	if step.Before == nil {
+		fmt.Fprintln(w, "the component did not run")
+		return
+	}
+	fmt.Fprintln(w, "data at start:")
+	writeData(w, step.Before)
+	changes, err := d.Changes()
+	if err != nil {
+		fmt.Fprintln(w, "invalid recording:", err)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tBEFORE\tAFTER")
+	for _, change := range changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", change.Path, reportValue(change.Before), reportValue(change.After))
+	}
+	tw.Flush()
+}
+
+func writeData(w io.Writer, data json.RawMessage) {
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, data, "", "  "); err != nil {
+		out.Reset()
+		out.Write(data)
+	}
+	fmt.Fprintln(w, out.String())
+}
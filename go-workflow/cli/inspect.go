@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"gopkg.in/yaml.v3"
+)
+
+/* stages: components by stage, a component runs in the stage after the last of its dependencies */
+func stages[C any, T any](t *goworkflow.Template[context.Context, C, T]) [][]*goworkflow.TemplateComponent[context.Context, C, T] {
+	stageOf := map[string]int{}
+	var stage func(tc *goworkflow.TemplateComponent[context.Context, C, T]) int
+	stage = func(tc *goworkflow.TemplateComponent[context.Context, C, T]) int {
+		if s, ok := stageOf[tc.Name()]; ok {
+			return s
+		}
+		s := 0
+		deps := tc.Dependencies()
+		for _, group := range tc.AnyOf() {
+			deps = append(deps, group...)
+		}
+		for _, dep := range deps {
+			s = max(s, stage(t.Component(dep))+1)
+		}
+		stageOf[tc.Name()] = s
+		return s
+	}
+	result := [][]*goworkflow.TemplateComponent[context.Context, C, T]{}
+	for _, tc := range t.Components() {
+		s := stage(tc)
+		for len(result) <= s {
+			result = append(result, nil)
+		}
+		result[s] = append(result[s], tc)
+	}
+	return result
+}
+
+func planCommand[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("plan", stderr)
+	file := flags.String("f", "", "workflow definition (yaml)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	def, t, err := loadDefinition(r, *file)
+	if err != nil {
+		return err
+	}
+	if err := t.ValidateInputs(); err != nil {
+		return err
+	}
+	executors := map[string]string{}
+	for _, cd := range def.Components {
+		executors[cd.Name] = cd.Executor
+		if cd.Executor == "" {
+			executors[cd.Name] = cd.Name
+		}
+	}
+
+	plan := stages(t)
+	title := t.Name
+	if t.Version != "" {
+		title += " (version " + t.Version + ")"
+	}
+	fmt.Fprintf(stdout, "%s: %d components in %d stages\n\n", title, len(t.Components()), len(plan))
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tCOMPONENT\tEXECUTOR\tOPTIONS\tAFTER")
+	for i, stage := range plan {
+		for _, tc := range stage {
+			options := []string{}
+			if cfg := tc.Config(); cfg != nil {
+				if cfg.Retry != nil && cfg.Retry.MaxAttempts > 1 {
+					options = append(options, fmt.Sprintf("%d attempts", cfg.Retry.MaxAttempts))
+				}
+				if cfg.Optional {
+					options = append(options, "optional")
+				}
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, tc.Name(), executors[tc.Name()], strings.Join(options, ", "), dependencySummary(tc))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if findings := t.Lint(); len(findings) > 0 {
+		fmt.Fprintln(stdout, "\nsuggestions:")
+		for _, finding := range findings {
+			fmt.Fprintln(stdout, "  "+finding.String())
+		}
+	}
+	return nil
+}
+
+func dependencySummary[C any, T any](tc *goworkflow.TemplateComponent[context.Context, C, T]) string {
+	terms := []string{}
+	for _, dep := range tc.Dependencies() {
+		if outcome := tc.DependencyOutcome(dep); outcome != goworkflow.OnSuccess {
+			dep += " (" + string(outcome) + ")"
+		}
+		terms = append(terms, dep)
+	}
+	for _, group := range tc.AnyOf() {
+		terms = append(terms, "any of "+strings.Join(group, " | "))
+	}
+	return strings.Join(terms, ", ")
+}
+
+func graphCommand[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("graph", stderr)
+	file := flags.String("f", "", "workflow definition (yaml)")
+	format := flags.String("format", "dot", "dot (graphviz) or mermaid")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	_, t, err := loadDefinition(r, *file)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "dot":
+		writeDot(stdout, t)
+	case "mermaid":
+		writeMermaid(stdout, t)
+	default:
+		return fmt.Errorf("unknown graph format %s, expected dot or mermaid", *format)
+	}
+	return nil
+}
+
+func importCommand[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("import", stderr)
+	format := flags.String("format", "", "dot (graphviz) or mermaid, from the file extension when empty")
+	name := flags.String("name", "", "workflow name, the graph name or the file name when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected the graph file")
+	}
+	path := flags.Arg(0)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+		if *format == "gv" {
+			*format = "dot"
+		}
+		if *format == "mmd" {
+			*format = "mermaid"
+		}
+	}
+	var def *goworkflow.WorkflowDefinition
+	switch *format {
+	case "dot":
+		def, err = goworkflow.ParseDot(raw)
+	case "mermaid":
+		def, err = goworkflow.ParseMermaid(*name, raw)
+	default:
+		return fmt.Errorf("unknown graph format %s, expected dot or mermaid", *format)
+	}
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		def.Name = *name
+	}
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	// the skeleton must bind to the registered executors
+	if _, err := goworkflow.TemplateFromDefinition(r, def); err != nil {
+		return err
+	}
+	encoded, err := yaml.Marshal(def)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(encoded)
+	return err
+}
+
+func schemaCommand[C any, T any](r *goworkflow.ComponentRegistry[context.Context, C, T], args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("schema", stderr)
+	file := flags.String("f", "", "workflow definition (yaml)")
+	kind := flags.String("type", "config", "config or data")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	_, t, err := loadDefinition(r, *file)
+	if err != nil {
+		return err
+	}
+	var schema *goworkflow.JSONSchema
+	switch *kind {
+	case "config":
+		schema = t.ConfigSchema()
+	case "data":
+		schema = t.DataSchema()
+	default:
+		return fmt.Errorf("unknown schema type %s, expected config or data", *kind)
+	}
+	encoded, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, string(encoded))
+	return err
+}
+
+/* edge of the dependency graph, label is empty for dependencies requiring success */
+type edge struct {
+	from, to, label string
+}
+
+func edges[C any, T any](t *goworkflow.Template[context.Context, C, T]) []edge {
+	result := []edge{}
+	for _, tc := range t.Components() {
+		for _, dep := range tc.Dependencies() {
+			label := ""
+			if outcome := tc.DependencyOutcome(dep); outcome != goworkflow.OnSuccess {
+				label = string(outcome)
+			}
+			result = append(result, edge{dep, tc.Name(), label})
+		}
+		for _, group := range tc.AnyOf() {
+			for _, dep := range group {
+				result = append(result, edge{dep, tc.Name(), "any of"})
+			}
+		}
+	}
+	return result
+}
+
+func writeDot[C any, T any](w io.Writer, t *goworkflow.Template[context.Context, C, T]) {
+	fmt.Fprintf(w, "digraph %q {\n  rankdir=LR;\n  node [shape=box];\n", t.Name)
+	for _, tc := range t.Components() {
+		style := ""
+		if cfg := tc.Config(); cfg != nil && cfg.Optional {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(w, "  %q%s;\n", tc.Name(), style)
+	}
+	for _, e := range edges(t) {
+		attributes := ""
+		if e.label != "" {
+			attributes = fmt.Sprintf(" [label=%q, style=dashed]", e.label)
+		}
+		fmt.Fprintf(w, "  %q -> %q%s;\n", e.from, e.to, attributes)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeMermaid[C any, T any](w io.Writer, t *goworkflow.Template[context.Context, C, T]) {
+	ids := map[string]string{}
+	fmt.Fprintln(w, "flowchart LR")
+	for i, tc := range t.Components() {
+		ids[tc.Name()] = fmt.Sprintf("c%d", i+1)
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[tc.Name()], strings.ReplaceAll(tc.Name(), `"`, "#quot;"))
+	}
+	for _, e := range edges(t) {
+		if e.label == "" {
+			fmt.Fprintf(w, "  %s --> %s\n", ids[e.from], ids[e.to])
+		} else {
+			fmt.Fprintf(w, "  %s -. %s .-> %s\n", ids[e.from], e.label, ids[e.to])
+		}
+	}
+}
+
+func reportCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("report", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected the report file written by run -report")
+	}
+	raw, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	report := Report{}
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return fmt.Errorf("invalid report: %w", err)
+	}
+	writeReport(stdout, report)
+	return nil
+}
+
+func writeReport(w io.Writer, report Report) {
+	result := report.Result
+	title := result.Workflow
+	if result.Version != "" {
+		title += " (version " + result.Version + ")"
+	}
+	fmt.Fprintf(w, "%s run %s: %s in %s\n", title, result.WorkflowId, result.Status, result.Duration.Round(time.Millisecond))
+	if len(result.FailedComponents) > 0 {
+		fmt.Fprintf(w, "failed: %s\n", strings.Join(result.FailedComponents, ", "))
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tSTATUS\tWAIT\tDURATION\tATTEMPTS\tERROR")
+	for _, timing := range report.Timeline {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", timing.Component, timing.Status,
+			timing.Wait().Round(time.Millisecond), timing.Duration().Round(time.Millisecond), timing.Attempts,
+			result.Errors[timing.Component])
+	}
+	tw.Flush()
+	if len(report.DataChanges) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tFIELD\tBEFORE\tAFTER")
+	for _, timing := range report.Timeline {
+		for _, change := range report.DataChanges[timing.Component] {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", timing.Component, change.Path, reportValue(change.Before), reportValue(change.After))
+		}
+	}
+	tw.Flush()
+}
+
+/* reportValue: json of a changed value, shortened to fit a table */
+func reportValue(value any) string {
+	raw, _ := json.Marshal(value)
+	if len(raw) > 60 {
+		return string(raw[:57]) + "..."
+	}
+	return string(raw)
+}
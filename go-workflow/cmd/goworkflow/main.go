@@ -0,0 +1,30 @@
+/*
+Command goworkflow runs and inspects YAML defined workflows, see the cli package for the commands.
+Its config and data are generic json objects, the executors are loaded from the Go plugins listed in
+GOWORKFLOW_PLUGINS (separated like PATH), which register them with
+
+	func RegisterComponents(r *goworkflow.ComponentRegistry[context.Context, map[string]any, map[string]any])
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/cli"
+	"github.com/metaphi-org/go-workflow/go-workflow/plugins"
+)
+
+func main() {
+	r := goworkflow.NewComponentRegistry[context.Context, map[string]any, map[string]any]()
+	for _, path := range filepath.SplitList(os.Getenv("GOWORKFLOW_PLUGINS")) {
+		if err := plugins.Load(r, path); err != nil {
+			fmt.Fprintln(os.Stderr, "error: loading plugin:", err)
+			os.Exit(cli.ExitUsage)
+		}
+	}
+	cli.Main(r)
+}
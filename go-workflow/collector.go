@@ -0,0 +1,95 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+/*
+Collector gathers the results of fan-out components, e.g. one per page:
+
+	type Data struct {
+		Pages *goworkflow.Collector[PageAnalysis]
+	}
+	// page component
+	dt.GetData().Pages.Set(input.Index, analysis)
+	// aggregator
+	for _, page := range dt.GetData().Pages.Items() { ... }
+
+It grows as needed, so it doesn't have to be sized before the fan-out, and it has its own lock, so writers don't
+contend for the data store lock with the rest of the run. It encodes as a json array, so checkpoints, reports and
+remote components see the items. Create it with NewCollector before the run, the data store holds the pointer.
+*/
+type Collector[V any] struct {
+	lock  sync.RWMutex
+	items []V
+}
+
+func NewCollector[V any]() *Collector[V] {
+	return &Collector[V]{}
+}
+
+/* Set stores v at index, the indexes below which aren't set yet hold zero values */
+func (c *Collector[V]) Set(index int, v V) {
+	if index < 0 {
+		panic("collector index cannot be negative")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if index >= len(c.items) {
+		c.items = slices.Grow(c.items, index+1-len(c.items))[:index+1]
+	}
+	c.items[index] = v
+}
+
+/* Append adds v after the last item and returns its index */
+func (c *Collector[V]) Append(v V) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items = append(c.items, v)
+	return len(c.items) - 1
+}
+
+/* Get: item at index, false when index is beyond the items */
+func (c *Collector[V]) Get(index int) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if index < 0 || index >= len(c.items) {
+		var zero V
+		return zero, false
+	}
+	return c.items[index], true
+}
+
+func (c *Collector[V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.items)
+}
+
+/* Items: copy of the items in index order */
+func (c *Collector[V]) Items() []V {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return slices.Clone(c.items)
+}
+
+func (c *Collector[V]) MarshalJSON() ([]byte, error) {
+	items := c.Items()
+	if items == nil {
+		items = []V{}
+	}
+	return json.Marshal(items)
+}
+
+func (c *Collector[V]) UnmarshalJSON(raw []byte) error {
+	items := []V{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items = items
+	return nil
+}
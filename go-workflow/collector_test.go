@@ -0,0 +1,67 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type pageAnalysis struct {
+	Page  int
+	Words int
+}
+
+type documentData struct {
+	Pages *goworkflow.Collector[pageAnalysis]
+	Total int
+}
+
+func TestCollector(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, documentData](context.TODO())
+	pages := []goworkflow.Component[context.Context, Config, documentData]{}
+	for i := 0; i < 50; i++ {
+		pages = append(pages, wf.AddComponent(goworkflow.MakeComponent(fmt.Sprintf("Page%d", i), i, func(ctx context.Context, index int, dt *goworkflow.DataTracker[Config, documentData]) error {
+			dt.GetData().Pages.Set(index, pageAnalysis{Page: index, Words: index * 10})
+			return nil
+		})))
+	}
+	wf.AddComponent(goworkflow.MakeComponent("Aggregate", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, documentData]) error {
+		total := 0
+		for _, page := range dt.GetData().Pages.Items() {
+			total += page.Words
+		}
+		dt.Update(func(d *documentData) { d.Total = total })
+		return nil
+	})).AddDependencies(pages...)
+	data, st, err := wf.Execute(context.TODO(), Config{}, &documentData{Pages: goworkflow.NewCollector[pageAnalysis]()})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, 50, data.Pages.Len())
+	assert.Equal(t, 12250, data.Total)
+	page, ok := data.Pages.Get(49)
+	assert.True(t, ok)
+	assert.Equal(t, pageAnalysis{Page: 49, Words: 490}, page)
+	_, ok = data.Pages.Get(50)
+	assert.False(t, ok)
+}
+
+func TestCollectorJSON(t *testing.T) {
+	c := goworkflow.NewCollector[string]()
+	c.Set(2, "c")
+	assert.Equal(t, 3, c.Append("a"))
+	raw, err := json.Marshal(documentData{Pages: goworkflow.NewCollector[pageAnalysis]()})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Pages":[],"Total":0}`, string(raw))
+
+	raw, err = json.Marshal(c)
+	assert.NoError(t, err)
+	assert.Equal(t, `["","","c","a"]`, string(raw))
+	decoded := &goworkflow.Collector[string]{}
+	assert.NoError(t, json.Unmarshal(raw, decoded))
+	assert.Equal(t, []string{"", "", "c", "a"}, decoded.Items())
+	assert.Panics(t, func() { c.Set(-1, "x") })
+}
@@ -0,0 +1,59 @@
+package goworkflow
+
+import (
+	"errors"
+)
+
+/*
+ComponentError: failure with a machine readable Code, so callers branch on codes instead of parsing messages.
+It survives wrapping (timeouts, retry budget), is kept on the failed component and in RunResult.ComponentErrors,
+stored runs, reports and remote component responses. Err is the internal cause, it is never serialized.
+*/
+type ComponentError struct {
+	Code string `json:"code"`
+	// Retryable: false makes the error permanent, it is never retried whatever the RetryPolicy
+	Retryable bool `json:"retryable"`
+	// Message for end users, e.g. "the document is password protected"
+	Message string            `json:"message,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	Err     error             `json:"-"`
+}
+
+func (e *ComponentError) Error() string {
+	msg := e.Code
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+/* AsComponentError: the ComponentError in the chain of err */
+func AsComponentError(err error) (*ComponentError, bool) {
+	var ce *ComponentError
+	ok := errors.As(err, &ce)
+	return ce, ok
+}
+
+/* ComponentError: structured error of a failed component, nil when it didn't fail with a ComponentError */
+func (c *component[CT, C, T]) ComponentError() *ComponentError {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	return c.failure
+}
+
+func (c *component[CT, C, T]) setFailure(err error) {
+	ce, ok := AsComponentError(err)
+	if !ok {
+		return
+	}
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	c.failure = ce
+}
@@ -0,0 +1,52 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentError(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	retry := &goworkflow.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	rateLimited := 0
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		rateLimited++
+		return &goworkflow.ComponentError{Code: "RATE_LIMITED", Retryable: true, Err: errors.New("429 from model api")}
+	}), &goworkflow.ComponentConfig{Retry: retry})
+	protected := 0
+	wf.AddComponent(goworkflow.MakeComponent("Open", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		protected++
+		return &goworkflow.ComponentError{Code: "PASSWORD_PROTECTED", Message: "the document is password protected", Details: map[string]string{"file": "a.pdf"}}
+	}), &goworkflow.ComponentConfig{Retry: retry})
+	wf.AddComponent(goworkflow.MakeComponent("Plain", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("boom")
+	}))
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 3, rateLimited)
+	assert.Equal(t, 1, protected, "non retryable errors are not retried")
+
+	result := wf.Result()
+	assert.Len(t, result.ComponentErrors, 2)
+	assert.Equal(t, "RATE_LIMITED", result.ComponentErrors["Extract"].Code)
+	assert.Equal(t, "RATE_LIMITED: 429 from model api", result.Errors["Extract"])
+	assert.Equal(t, "PASSWORD_PROTECTED: the document is password protected", result.Errors["Open"])
+
+	// the cause is internal, it is not serialized
+	encoded, err := json.Marshal(result.ComponentErrors["Open"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code": "PASSWORD_PROTECTED", "retryable": false, "message": "the document is password protected", "details": {"file": "a.pdf"}}`, string(encoded))
+
+	wrapped := errors.Join(errors.New("timeout"), result.ComponentErrors["Open"])
+	ce, ok := goworkflow.AsComponentError(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, "PASSWORD_PROTECTED", ce.Code)
+}
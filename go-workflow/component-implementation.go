@@ -0,0 +1,50 @@
+package goworkflow
+
+import (
+	"context"
+)
+
+/*
+ComponentImplementation: component type with its own state and constructor, an alternative to closures, e.g.
+
+	type OCR struct{ client *ocr.Client; dpi int }
+	func (o *OCR) Name() string { return "OCR" }
+	func (o *OCR) Execute(ctx context.Context, page PageInput, dt *DataTracker[Config, Data]) error { ... }
+
+Implementations can also implement RetryableComponent and Validate() error (see FromImplementation).
+*/
+type ComponentImplementation[CT context.Context, I any, C any, T any] interface {
+	Name() string
+	Execute(ctx CT, input I, dt *DataTracker[C, T]) error
+}
+
+/* RetryableComponent: implementations deciding which errors are retried, false makes the failure permanent */
+type RetryableComponent interface {
+	Retryable(err error) bool
+}
+
+/*
+FromImplementation makes a component of impl executed with input, like MakeComponent. Implementations with a
+Validate() error method are validated before the run, like inputs (see InputValidator). The type parameters
+can't be inferred from impl: FromImplementation[context.Context, PageInput, Config, Data](&OCR{}, page)
+*/
+func FromImplementation[CT context.Context, I any, C any, T any](impl ComponentImplementation[CT, I, C, T], input I) makeComponentConfig[CT, C, T] {
+	if impl == nil {
+		panic("implementation cannot be nil")
+	}
+	componentCfg := MakeComponent(impl.Name(), input, impl.Execute)
+	if retryable, ok := impl.(RetryableComponent); ok {
+		execute := componentCfg.Executor
+		componentCfg.Executor = func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+			err := execute(ctx, ci, dt)
+			if err != nil && !retryable.Retryable(err) {
+				return Permanent(err)
+			}
+			return err
+		}
+	}
+	if v, ok := impl.(InputValidator); ok {
+		componentCfg.Validate = v.Validate
+	}
+	return componentCfg
+}
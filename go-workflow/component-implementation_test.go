@@ -0,0 +1,76 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnreadable = errors.New("unreadable page")
+
+type pageOCR struct {
+	dpi      int
+	attempts int
+	err      error
+}
+
+func (o *pageOCR) Name() string {
+	return "OCR"
+}
+
+func (o *pageOCR) Execute(ctx context.Context, page int, dt *goworkflow.DataTracker[Config, Data]) error {
+	o.attempts++
+	if o.err != nil {
+		return o.err
+	}
+	dt.Update(func(d *Data) { d.A = "page 3" })
+	return nil
+}
+
+func (o *pageOCR) Retryable(err error) bool {
+	return !errors.Is(err, errUnreadable)
+}
+
+func (o *pageOCR) Validate() error {
+	if o.dpi < 72 {
+		return errors.New("dpi must be at least 72")
+	}
+	return nil
+}
+
+func TestFromImplementation(t *testing.T) {
+	retry := &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}}
+	run := func(ocr *pageOCR) (*Data, goworkflow.Status, error) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.FromImplementation[context.Context, int, Config, Data](ocr, 3), retry)
+		return wf.Execute(context.TODO(), Config{}, &Data{})
+	}
+
+	data, st, err := run(&pageOCR{dpi: 300})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "page 3", data.A)
+
+	unreadable := &pageOCR{dpi: 300, err: errUnreadable}
+	_, st, _ = run(unreadable)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 1, unreadable.attempts)
+
+	timeout := &pageOCR{dpi: 300, err: errors.New("timeout")}
+	run(timeout)
+	assert.Equal(t, 3, timeout.attempts)
+
+	invalid := &pageOCR{dpi: 10}
+	_, st, err = run(invalid)
+	assert.ErrorContains(t, err, "invalid component OCR: dpi must be at least 72")
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 0, invalid.attempts)
+
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("ocr")
+	template.AddComponent(goworkflow.FromImplementation[context.Context, int, Config, Data](invalid, 3))
+	assert.ErrorContains(t, template.ValidateInputs(), "dpi must be at least 72")
+}
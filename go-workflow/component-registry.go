@@ -0,0 +1,109 @@
+package goworkflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/* ComponentResolver resolves executors the registry doesn't know, e.g. functions exported by WASM modules */
+type ComponentResolver[CT context.Context, C any, T any] func(name string) (ComponentFunction[CT, any, C, T], bool)
+
+/*
+ComponentRegistry resolves component executors by name at runtime, so workflows defined outside the code
+or executors loaded from plugins (see the plugins package) can be wired without rebuilding the host binary.
+*/
+type ComponentRegistry[CT context.Context, C any, T any] struct {
+	lock      sync.Mutex
+	executors map[string]componentFunctionInternal[CT, C, T]
+	resolvers []ComponentResolver[CT, C, T]
+}
+
+func NewComponentRegistry[CT context.Context, C any, T any]() *ComponentRegistry[CT, C, T] {
+	return &ComponentRegistry[CT, C, T]{executors: map[string]componentFunctionInternal[CT, C, T]{}}
+}
+
+/*
+Register adds executor under name. Inputs of another type than I, e.g. maps decoded from a workflow definition,
+are converted to I through json.
+*/
+func Register[CT context.Context, I any, C any, T any](r *ComponentRegistry[CT, C, T], name string, executor ComponentFunction[CT, I, C, T]) {
+	if executor == nil {
+		panic("executor cannot be nil")
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.executors[name]; ok {
+		panic("executor already registered: " + name)
+	}
+	r.executors[name] = func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+		input, err := convertInput[I](ci)
+		if err != nil {
+			return fmt.Errorf("invalid input of %s: %w", name, err)
+		}
+		return executor(ctx, input, dt)
+	}
+}
+
+func convertInput[I any](ci ComponentInput) (I, error) {
+	var input I
+	if ci == nil {
+		return input, nil
+	}
+	if typed, ok := ci.(I); ok {
+		return typed, nil
+	}
+	raw, err := json.Marshal(ci)
+	if err != nil {
+		return input, err
+	}
+	return input, json.Unmarshal(raw, &input)
+}
+
+/* AddResolver: resolver asked, in order, for executors which are not registered */
+func (r *ComponentRegistry[CT, C, T]) AddResolver(resolver ComponentResolver[CT, C, T]) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resolvers = append(r.resolvers, resolver)
+}
+
+/* Names of the registered executors, sorted */
+func (r *ComponentRegistry[CT, C, T]) Names() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	names := make([]string, 0, len(r.executors))
+	for name := range r.executors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *ComponentRegistry[CT, C, T]) executor(name string) (componentFunctionInternal[CT, C, T], bool) {
+	r.lock.Lock()
+	executor, ok := r.executors[name]
+	resolvers := r.resolvers
+	r.lock.Unlock()
+	if ok {
+		return executor, true
+	}
+	for _, resolve := range resolvers {
+		if executor, ok := resolve(name); ok {
+			return func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+				return executor(ctx, ci, dt)
+			}, true
+		}
+	}
+	return nil, false
+}
+
+/* Component: component named componentName executing the executor registered as executorName, to pass to AddComponent */
+func (r *ComponentRegistry[CT, C, T]) Component(componentName string, executorName string, input any) (makeComponentConfig[CT, C, T], error) {
+	executor, ok := r.executor(executorName)
+	if !ok {
+		return makeComponentConfig[CT, C, T]{}, fmt.Errorf("unknown executor %s", executorName)
+	}
+	return makeComponentConfig[CT, C, T]{Name: componentName, Input: input, Executor: executor}, nil
+}
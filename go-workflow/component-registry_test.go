@@ -0,0 +1,60 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type ocrInput struct {
+	Language string
+	Pages    int
+}
+
+func TestComponentRegistry(t *testing.T) {
+	registry := goworkflow.NewComponentRegistry[context.Context, Config, Data]()
+	goworkflow.Register(registry, "ocr", func(ctx context.Context, input ocrInput, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = input.Language })
+		return nil
+	})
+	registry.AddResolver(func(name string) (goworkflow.ComponentFunction[context.Context, any, Config, Data], bool) {
+		if name != "wasm:summarize" {
+			return nil, false
+		}
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.B = "summary" })
+			return nil
+		}, true
+	})
+	assert.Equal(t, []string{"ocr"}, registry.Names())
+
+	_, err := registry.Component("Summary", "missing", nil)
+	assert.EqualError(t, err, "unknown executor missing")
+
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	// inputs decoded from a definition file are converted to the input type of the executor
+	ocr, err := registry.Component("OCR", "ocr", map[string]any{"Language": "de", "Pages": 3})
+	assert.NoError(t, err)
+	summary, err := registry.Component("Summary", "wasm:summarize", nil)
+	assert.NoError(t, err)
+	wf.AddComponent(summary).AddDependencies(wf.AddComponent(ocr))
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "de", data.A)
+	assert.Equal(t, "summary", data.B)
+}
+
+func TestComponentRegistryInvalidInput(t *testing.T) {
+	registry := goworkflow.NewComponentRegistry[context.Context, Config, Data]()
+	goworkflow.Register(registry, "ocr", func(ctx context.Context, input ocrInput, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	})
+	ocr, _ := registry.Component("OCR", "ocr", map[string]any{"Pages": "three"})
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	c := wf.AddComponent(ocr)
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Contains(t, c.Status().ErrorMessage, "invalid input of ocr")
+}
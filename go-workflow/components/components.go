@@ -0,0 +1,110 @@
+/*
+Package components ships generic glue components, so joins, delays, logging, invariants and metrics don't
+need bespoke closures in every project. They are component functions, added like any other:
+
+	join := wf.AddComponent(goworkflow.MakeComponent("Join", nil, components.Noop[context.Context, any, Config, Data]()))
+	join.AddDependencies(ocr, layout)
+*/
+package components
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* Noop does nothing. With dependencies it is a join: its dependents wait for all of them through one component */
+func Noop[CT context.Context, I any, C any, T any]() goworkflow.ComponentFunction[CT, I, C, T] {
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		return nil
+	}
+}
+
+/* Delay waits for d, it fails when the run is cancelled meanwhile */
+func Delay[CT context.Context, I any, C any, T any](d time.Duration) goworkflow.ComponentFunction[CT, I, C, T] {
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-dt.Context().Done():
+			return dt.Context().Err()
+		}
+	}
+}
+
+/* Log writes the message built from config and data to logger, the standard logger when nil */
+func Log[CT context.Context, I any, C any, T any](logger *log.Logger, message func(config C, data T) string) goworkflow.ComponentFunction[CT, I, C, T] {
+	if message == nil {
+		panic("message cannot be nil")
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		info, _ := goworkflow.ComponentInfoFromContext(dt.Context())
+		logger.Println(info.Workflow+"/"+info.Component+":", "run:", info.WorkflowId, message(dt.Config, dt.GetData()))
+		return nil
+	}
+}
+
+/*
+Assert fails the component permanently with message when predicate doesn't hold, to encode invariants of the
+pipeline at a point of the graph (e.g. before the results are stored).
+*/
+func Assert[CT context.Context, I any, C any, T any](message string, predicate func(config C, data T) bool) goworkflow.ComponentFunction[CT, I, C, T] {
+	if predicate == nil {
+		panic("predicate cannot be nil")
+	}
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		if predicate(dt.Config, dt.GetData()) {
+			return nil
+		}
+		return goworkflow.Permanent(fmt.Errorf("assertion failed: %s", message))
+	}
+}
+
+/* Recorder receives the values of Metrics components, e.g. an adapter to prometheus or statsd */
+type Recorder interface {
+	Record(ctx context.Context, name string, value float64, labels map[string]string)
+}
+
+/* Metrics records the values measured on config and data, labelled with the workflow and the component */
+func Metrics[CT context.Context, I any, C any, T any](recorder Recorder, measure func(config C, data T) map[string]float64) goworkflow.ComponentFunction[CT, I, C, T] {
+	if recorder == nil || measure == nil {
+		panic("recorder and measure cannot be nil")
+	}
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		info, ok := goworkflow.ComponentInfoFromContext(dt.Context())
+		if !ok {
+			return errors.New("metrics: not running in a workflow")
+		}
+		labels := map[string]string{"workflow": info.Workflow, "component": info.Component}
+		for name, value := range measure(dt.Config, dt.GetData()) {
+			recorder.Record(dt.Context(), name, value, labels)
+		}
+		return nil
+	}
+}
+
+/* ExpvarRecorder publishes the last value of every metric in an expvar map, keyed <workflow>.<component>.<name> */
+type ExpvarRecorder struct {
+	values *expvar.Map
+}
+
+/* NewExpvarRecorder publishes the map under name, names can only be published once per process */
+func NewExpvarRecorder(name string) *ExpvarRecorder {
+	return &ExpvarRecorder{values: expvar.NewMap(name)}
+}
+
+func (r *ExpvarRecorder) Record(ctx context.Context, name string, value float64, labels map[string]string) {
+	v := new(expvar.Float)
+	v.Set(value)
+	r.values.Set(labels["workflow"]+"."+labels["component"]+"."+name, v)
+}
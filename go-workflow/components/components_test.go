@@ -0,0 +1,111 @@
+package components
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"log"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct {
+	MinPages int
+}
+
+type Data struct {
+	Pages []string
+}
+
+func TestComponents(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("invoices")
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.Pages = append(d.Pages, "a") })
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, Delay[context.Context, any, Config, Data](10*time.Millisecond)))
+	join := wf.AddComponent(goworkflow.MakeComponent("Join", nil, Noop[context.Context, any, Config, Data]()))
+	join.AddDependencies(a, b)
+
+	out := &bytes.Buffer{}
+	logged := wf.AddComponent(goworkflow.MakeComponent("Log", nil, Log[context.Context, any, Config, Data](log.New(out, "", 0), func(config Config, data Data) string {
+		return "pages: " + data.Pages[0]
+	})))
+	recorder := NewExpvarRecorder("components_test")
+	metrics := wf.AddComponent(goworkflow.MakeComponent("Metrics", nil, Metrics[context.Context, any, Config, Data](recorder, func(config Config, data Data) map[string]float64 {
+		return map[string]float64{"pages": float64(len(data.Pages))}
+	})))
+	check := wf.AddComponent(goworkflow.MakeComponent("Check", nil, Assert[context.Context, any, Config, Data]("page count below the minimum", func(config Config, data Data) bool {
+		return len(data.Pages) >= config.MinPages
+	})), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+	logged.AddDependencies(join)
+	metrics.AddDependencies(join)
+	check.AddDependencies(join)
+
+	_, st, err := wf.Execute(context.TODO(), Config{MinPages: 2}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "assertion failed: page count below the minimum", wf.Result().Errors["Check"])
+	assert.Equal(t, 1, wf.Timeline()[5].Attempts)
+	assert.Equal(t, "invoices/Log: run: "+wf.Id()+" pages: a\n", out.String())
+	assert.Equal(t, "1", expvar.Get("components_test").(*expvar.Map).Get("invoices.Metrics.pages").String())
+	assert.True(t, wf.Timeline()[2].StartedAt.Sub(wf.Timeline()[1].StartedAt) >= 10*time.Millisecond)
+}
+
+func TestDelayCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	wf.AddComponent(goworkflow.MakeComponent("Wait", nil, Delay[context.Context, any, Config, Data](time.Hour)))
+	_, st, _ := wf.Execute(ctx, Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+}
+
+func TestInvariants(t *testing.T) {
+	type parameters struct {
+		Pages int
+		Lang  string
+	}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Invariants", nil, Invariants[context.Context, any, Config, Data](
+		Invariant[Config, Data]{
+			Name:     "page count matches parameter count",
+			Expected: func(config Config, data Data) any { return config.MinPages },
+			Actual:   func(config Config, data Data) any { return len(data.Pages) },
+		},
+		Invariant[Config, Data]{
+			Name:     "first page",
+			Expected: func(config Config, data Data) any { return "a" },
+			Actual:   func(config Config, data Data) any { return data.Pages[0] },
+		},
+		Invariant[Config, Data]{
+			Name:     "parameters",
+			Expected: func(config Config, data Data) any { return parameters{Pages: config.MinPages, Lang: "en"} },
+			Actual:   func(config Config, data Data) any { return parameters{Pages: len(data.Pages), Lang: "en"} },
+		},
+	)), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+
+	_, st, err := wf.Execute(context.TODO(), Config{MinPages: 3}, &Data{Pages: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 1, wf.Timeline()[0].Attempts)
+	ce := wf.Result().ComponentErrors["Invariants"]
+	assert.Equal(t, InvariantViolated, ce.Code)
+	assert.Equal(t, map[string]string{"invariants": "page count matches parameter count, parameters"}, ce.Details)
+	assert.Equal(t, "invariant page count matches parameter count violated\n--- expected\n+++ actual\n- 3\n+ 2\n"+
+		"invariant parameters violated\n--- expected\n+++ actual\n- Pages: 3\n+ Pages: 2", ce.Message)
+
+	wf = goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Invariants", nil, Invariants[context.Context, any, Config, Data](Invariant[Config, Data]{
+		Name:     "page count matches parameter count",
+		Expected: func(config Config, data Data) any { return config.MinPages },
+		Actual:   func(config Config, data Data) any { return len(data.Pages) },
+	})))
+	_, st, err = wf.Execute(context.TODO(), Config{MinPages: 2}, &Data{Pages: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+}
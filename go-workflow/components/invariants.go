@@ -0,0 +1,87 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* InvariantViolated: ComponentError code of Invariants components */
+const InvariantViolated = "INVARIANT_VIOLATED"
+
+/*
+Invariant: Expected and Actual are computed from config and data and compared through their json encoding,
+e.g. Name "page count matches parameter count", Expected the number of page parameters and Actual len(data.Pages).
+*/
+type Invariant[C any, T any] struct {
+	Name     string
+	Expected func(config C, data T) any
+	Actual   func(config C, data T) any
+}
+
+/*
+Invariants checks all invariants at its point of the graph. Violations fail the component permanently with a
+goworkflow.ComponentError (code INVARIANT_VIOLATED, details "invariants": the violated names) whose message
+is a diff of every violated invariant:
+
+	invariant page count matches parameter count violated
+	--- expected
+	+++ actual
+	- 3
+	+ 2
+*/
+func Invariants[CT context.Context, I any, C any, T any](invariants ...Invariant[C, T]) goworkflow.ComponentFunction[CT, I, C, T] {
+	for _, inv := range invariants {
+		if inv.Name == "" || inv.Expected == nil || inv.Actual == nil {
+			panic("invariants need a name, Expected and Actual")
+		}
+	}
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		data := dt.GetData()
+		violated, messages := []string{}, []string{}
+		for _, inv := range invariants {
+			diff, err := invariantDiff(inv.Expected(dt.Config, data), inv.Actual(dt.Config, data))
+			if err != nil {
+				return fmt.Errorf("invariant %s: %w", inv.Name, err)
+			}
+			if diff == "" {
+				continue
+			}
+			violated = append(violated, inv.Name)
+			messages = append(messages, fmt.Sprintf("invariant %s violated\n--- expected\n+++ actual\n%s", inv.Name, diff))
+		}
+		if len(violated) == 0 {
+			return nil
+		}
+		return &goworkflow.ComponentError{
+			Code:    InvariantViolated,
+			Message: strings.Join(messages, "\n"),
+			Details: map[string]string{"invariants": strings.Join(violated, ", ")},
+		}
+	}
+}
+
+/* invariantDiff: -/+ lines of the differing fields, empty when expected and actual are equal */
+func invariantDiff(expected any, actual any) (string, error) {
+	changes, err := goworkflow.DiffData(&expected, &actual)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{}
+	for _, change := range changes {
+		path := ""
+		if change.Path != "" {
+			path = change.Path + ": "
+		}
+		lines = append(lines, "- "+path+diffValue(change.Before), "+ "+path+diffValue(change.After))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func diffValue(value any) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
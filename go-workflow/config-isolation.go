@@ -0,0 +1,60 @@
+package goworkflow
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/* ConfigIsolation: how components share the config of the run, see Workflow.SetConfigIsolation */
+type ConfigIsolation int
+
+const (
+	// ConfigShared: all components get the same config, pointers, maps and slices included
+	ConfigShared ConfigIsolation = iota
+	// ConfigCopied: every execution gets a deep copy (through json), mutations stay in the component
+	ConfigCopied
+	// ConfigChecked: debug mode, a component which mutates the config fails naming the changed fields.
+	// Components running concurrently with the mutating one fail as well when they share the mutated values
+	ConfigChecked
+)
+
+/*
+SetConfigIsolation protects the config from components mutating it, e.g. a component changing the page
+range of a shared document config. Copied and checked configs must encode through json.
+*/
+func (wf *Workflow[CT, C, T]) SetConfigIsolation(isolation ConfigIsolation) {
+	wf.configIsolation = isolation
+}
+
+/* isolateConfig: tracker of an execution with the config isolated, check reports mutations after the execution */
+func (wf *Workflow[CT, C, T]) isolateConfig(c *component[CT, C, T], dt *DataTracker[C, T]) (*DataTracker[C, T], func() error) {
+	noCheck := func() error { return nil }
+	switch wf.configIsolation {
+	case ConfigCopied:
+		copied, err := copyData(&dt.Config)
+		if err != nil {
+			log.Println("Workflow.Execute:Error:Config cannot be copied for component:", c.id, err)
+			return dt, noCheck
+		}
+		dt.Config = *copied
+	case ConfigChecked:
+		before, err := copyData(&dt.Config)
+		if err != nil {
+			log.Println("Workflow.Execute:Error:Config cannot be copied for component:", c.id, err)
+			return dt, noCheck
+		}
+		return dt, func() error {
+			changes, err := DiffData(before, &dt.Config)
+			if err != nil || len(changes) == 0 {
+				return nil
+			}
+			paths := make([]string, len(changes))
+			for i, change := range changes {
+				paths[i] = change.Path
+			}
+			return Permanent(fmt.Errorf("component %s mutated the config: %s", c.Name, strings.Join(paths, ", ")))
+		}
+	}
+	return dt, noCheck
+}
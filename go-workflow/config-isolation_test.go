@@ -0,0 +1,58 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type pdfDocument struct {
+	Pages []int
+}
+
+type documentConfig struct {
+	PdfDocument *pdfDocument
+}
+
+func TestConfigIsolation(t *testing.T) {
+	run := func(isolation goworkflow.ConfigIsolation) (documentConfig, goworkflow.Status, []int) {
+		wf := goworkflow.NewWorkflow[context.Context, documentConfig, Data](context.TODO())
+		wf.SetConfigIsolation(isolation)
+		var seen []int
+		trim := wf.AddComponent(goworkflow.MakeComponent("Trim", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[documentConfig, Data]) error {
+			dt.Config.PdfDocument.Pages = dt.Config.PdfDocument.Pages[:1]
+			return nil
+		}), &goworkflow.ComponentConfig{Optional: true})
+		wf.AddComponent(goworkflow.MakeComponent("Render", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[documentConfig, Data]) error {
+			seen = dt.Config.PdfDocument.Pages
+			return nil
+		})).AddDependenciesOn(goworkflow.OnCompletion, trim)
+		config := documentConfig{PdfDocument: &pdfDocument{Pages: []int{1, 2, 3}}}
+		_, st, err := wf.Execute(context.TODO(), config, &Data{})
+		assert.NoError(t, err)
+		return config, st, seen
+	}
+
+	config, st, seen := run(goworkflow.ConfigShared)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, []int{1}, seen)
+	assert.Equal(t, []int{1}, config.PdfDocument.Pages)
+
+	config, st, seen = run(goworkflow.ConfigCopied)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, []int{1, 2, 3}, seen)
+	assert.Equal(t, []int{1, 2, 3}, config.PdfDocument.Pages)
+
+	wf := goworkflow.NewWorkflow[context.Context, documentConfig, Data](context.TODO())
+	wf.SetConfigIsolation(goworkflow.ConfigChecked)
+	c := wf.AddComponent(goworkflow.MakeComponent("Trim", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[documentConfig, Data]) error {
+		dt.Config.PdfDocument.Pages = dt.Config.PdfDocument.Pages[:1]
+		return nil
+	}), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+	_, st, _ = wf.Execute(context.TODO(), documentConfig{PdfDocument: &pdfDocument{Pages: []int{1, 2, 3}}}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "component Trim mutated the config: PdfDocument.Pages", c.Status().ErrorMessage)
+	assert.Equal(t, 1, wf.Timeline()[0].Attempts)
+}
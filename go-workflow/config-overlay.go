@@ -0,0 +1,189 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+/*
+ConfigOverlay overrides fields of the base config, keys are the json names of the config fields and nested
+objects are merged field by field. Overlays are applied by ascending Priority, for equal priority in the order
+they were added, so the last applied overlay wins.
+*/
+type ConfigOverlay struct {
+	Name     string
+	Priority int
+	Values   map[string]any
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+/*
+mergeOverlay merges values into target (settable) field by field, keys are validated against the type of target.
+Fields the overlay doesn't name are kept as they are, including json:"-" and unexported fields. Pointers and maps
+on the way are copied, so the base config is never modified.
+*/
+func mergeOverlay(name string, path string, target reflect.Value, values map[string]any, validationErr *ValidationError) {
+	switch target.Kind() {
+	case reflect.Pointer:
+		copied := reflect.New(target.Type().Elem())
+		if !target.IsNil() {
+			copied.Elem().Set(target.Elem())
+		}
+		target.Set(copied)
+		mergeOverlay(name, path, copied.Elem(), values, validationErr)
+	case reflect.Interface:
+		copied := reflect.New(target.Elem().Type()).Elem()
+		copied.Set(target.Elem())
+		mergeOverlay(name, path, copied, values, validationErr)
+		target.Set(copied)
+	case reflect.Map:
+		copied := reflect.MakeMapWithSize(target.Type(), target.Len()+len(values))
+		iter := target.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), iter.Value())
+		}
+		target.Set(copied)
+		for key, value := range values {
+			k := reflect.ValueOf(key).Convert(target.Type().Key())
+			elem := reflect.New(target.Type().Elem()).Elem()
+			if current := copied.MapIndex(k); current.IsValid() {
+				elem.Set(current)
+			}
+			setOverlayValue(name, path+key, elem, value, validationErr)
+			copied.SetMapIndex(k, elem)
+		}
+	case reflect.Struct:
+		for key, value := range values {
+			index, ok := overlayField(target.Type(), key)
+			if !ok {
+				validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("overlay %s: unknown field %s%s", name, path, key))
+				continue
+			}
+			setOverlayValue(name, path+key, fieldByIndex(target, index), value, validationErr)
+		}
+	}
+}
+
+/* setOverlayValue: objects are merged into objects, other values replace the current value of target */
+func setOverlayValue(name string, path string, target reflect.Value, value any, validationErr *ValidationError) {
+	if values, ok := value.(map[string]any); ok && overlayObject(target) {
+		mergeOverlay(name, path+".", target, values, validationErr)
+		return
+	}
+	decoded := reflect.New(target.Type())
+	b, _ := json.Marshal(value)
+	if err := json.Unmarshal(b, decoded.Interface()); err != nil {
+		validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("overlay %s: field %s: %s", name, path, err))
+		return
+	}
+	target.Set(decoded.Elem())
+}
+
+/* overlayObject: whether overlays merge into v field by field (structs and maps with string keys) */
+func overlayObject(v reflect.Value) bool {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Interface:
+		return !v.IsNil() && v.Elem().Kind() == reflect.Map && overlayObject(v.Elem())
+	case reflect.Pointer:
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return false
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+/* overlayField: index of the field encoded as key by encoding/json, embedded structs are flattened */
+func overlayField(t reflect.Type, key string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if index, ok := overlayField(ft, key); ok {
+					return append([]int{i}, index...), true
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return []int{i}, true
+		}
+	}
+	return nil, false
+}
+
+/* fieldByIndex: settable field of v, embedded pointers on the way are copied (allocated when nil) */
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, fi := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			copied := reflect.New(v.Type().Elem())
+			if !v.IsNil() {
+				copied.Elem().Set(v.Elem())
+			}
+			v.Set(copied)
+			v = copied.Elem()
+		}
+		v = v.Field(fi)
+	}
+	return v
+}
+
+/* MergeConfig applies the overlays on top of a copy of base, all unknown fields and type mismatches are reported as a *ValidationError */
+func MergeConfig[C any](base C, overlays ...ConfigOverlay) (C, error) {
+	if len(overlays) == 0 {
+		return base, nil
+	}
+	var merged C
+	config := reflect.ValueOf(&merged).Elem()
+	config.Set(reflect.ValueOf(&base).Elem())
+	if !overlayObject(config) {
+		return merged, fmt.Errorf("config should be a json object, got %s", config.Type())
+	}
+
+	ordered := append([]ConfigOverlay{}, overlays...)
+	slices.SortStableFunc(ordered, func(a, b ConfigOverlay) int {
+		return a.Priority - b.Priority
+	})
+	validationErr := &ValidationError{}
+	for _, overlay := range ordered {
+		// round trip through json so the overlay values have the shape of decoded json
+		ob, err := json.Marshal(overlay.Values)
+		if err != nil {
+			validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("overlay %s: %s", overlay.Name, err))
+			continue
+		}
+		overlayValues := map[string]any{}
+		_ = json.Unmarshal(ob, &overlayValues)
+		mergeOverlay(overlay.Name, "", config, overlayValues, validationErr)
+	}
+	if len(validationErr.Problems) > 0 {
+		var zero C
+		return zero, validationErr
+	}
+	return merged, nil
+}
+
+/* AddConfigOverlay registers an overlay merged into the config passed to Execute, before config validation */
+func (wf *Workflow[CT, C, T]) AddConfigOverlay(overlay ConfigOverlay) {
+	wf.configOverlays = append(wf.configOverlays, overlay)
+}
@@ -0,0 +1,96 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type OCRSettings struct {
+	Provider string `json:"provider"`
+	DPI      int    `json:"dpi"`
+}
+
+type TenantConfig struct {
+	Model    string      `json:"model"`
+	MaxPages int         `json:"maxPages"`
+	OCR      OCRSettings `json:"ocr"`
+}
+
+func TestMergeConfig(t *testing.T) {
+	base := TenantConfig{Model: "fast", MaxPages: 10, OCR: OCRSettings{Provider: "aws", DPI: 150}}
+
+	merged, err := goworkflow.MergeConfig(base,
+		goworkflow.ConfigOverlay{Name: "emergency", Priority: 10, Values: map[string]any{"model": "fallback"}},
+		goworkflow.ConfigOverlay{Name: "tenant", Values: map[string]any{"model": "accurate", "ocr": map[string]any{"dpi": 300}}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, TenantConfig{Model: "fallback", MaxPages: 10, OCR: OCRSettings{Provider: "aws", DPI: 300}}, merged)
+	assert.Equal(t, "fast", base.Model, "base config should not be modified")
+
+	_, err = goworkflow.MergeConfig(base,
+		goworkflow.ConfigOverlay{Name: "tenant", Values: map[string]any{"modle": "accurate", "ocr": map[string]any{"zoom": 2}}},
+	)
+	validationErr := &goworkflow.ValidationError{}
+	assert.ErrorAs(t, err, &validationErr)
+	assert.ElementsMatch(t, []string{"overlay tenant: unknown field modle", "overlay tenant: unknown field ocr.zoom"}, validationErr.Problems)
+
+	_, err = goworkflow.MergeConfig(base, goworkflow.ConfigOverlay{Name: "tenant", Values: map[string]any{"maxPages": "many"}})
+	assert.Error(t, err)
+}
+
+type OverlaidConfig struct {
+	Model   string            `json:"model,omitempty"`
+	Secret  string            `json:"-"`
+	OCR     *OCRSettings      `json:"ocr,omitempty"`
+	Limits  map[string]int    `json:"limits"`
+	Tenant  string            `json:"tenant"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	counter int
+}
+
+func TestMergeConfigKeepsFields(t *testing.T) {
+	base := OverlaidConfig{Secret: "s3cr3t", OCR: &OCRSettings{Provider: "aws", DPI: 150}, Limits: map[string]int{"pages": 10}, counter: 7}
+
+	merged, err := goworkflow.MergeConfig(base, goworkflow.ConfigOverlay{Name: "t", Values: map[string]any{
+		"model":  "accurate",
+		"ocr":    map[string]any{"dpi": 300},
+		"limits": map[string]any{"tables": 2},
+		"labels": map[string]any{"team": "billing"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, OverlaidConfig{
+		Model:   "accurate",
+		Secret:  "s3cr3t",
+		OCR:     &OCRSettings{Provider: "aws", DPI: 300},
+		Limits:  map[string]int{"pages": 10, "tables": 2},
+		Labels:  map[string]string{"team": "billing"},
+		counter: 7,
+	}, merged)
+	assert.Equal(t, 150, base.OCR.DPI, "base config should not be modified")
+	assert.Equal(t, map[string]int{"pages": 10}, base.Limits, "base config should not be modified")
+
+	_, err = goworkflow.MergeConfig(base, goworkflow.ConfigOverlay{Name: "t", Values: map[string]any{"Secret": "x", "limits": map[string]any{"pages": "many"}}})
+	validationErr := &goworkflow.ValidationError{}
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 2)
+	assert.Contains(t, validationErr.Problems, "overlay t: unknown field Secret")
+}
+
+func TestConfigOverlayAtExecute(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, TenantConfig, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[TenantConfig, Data]) error {
+		dt.Update(func(d *Data) {
+			d.A = dt.Config.Model
+		})
+		return nil
+	}))
+	wf.AddConfigOverlay(goworkflow.ConfigOverlay{Name: "tenant", Values: map[string]any{"model": "accurate"}})
+
+	data, st, err := wf.Execute(context.TODO(), TenantConfig{Model: "fast"}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "accurate", data.A)
+}
@@ -0,0 +1,71 @@
+package goworkflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* ValidationError lists all problems found, instead of stopping at the first one */
+type ValidationError struct {
+	Problems []string
+	// errs: the problems as errors, so typed ones like *InputError are found with errors.As
+	errs []error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s", strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.errs
+}
+
+func (e *ValidationError) add(err error) {
+	switch v := err.(type) {
+	case *ValidationError:
+		e.Problems = append(e.Problems, v.Problems...)
+		e.errs = append(e.errs, v.errs...)
+	case interface{ Unwrap() []error }:
+		for _, inner := range v.Unwrap() {
+			e.add(inner)
+		}
+	default:
+		e.Problems = append(e.Problems, err.Error())
+		e.errs = append(e.errs, err)
+	}
+}
+
+/*
+AddConfigValidator registers a validator which runs before any component is executed,
+so a bad config fails the run before money is spent on early components.
+Validators can report several problems at once by returning a *ValidationError or errors.Join.
+*/
+func (wf *Workflow[CT, C, T]) AddConfigValidator(validator func(C) error) {
+	if validator == nil {
+		panic("validator cannot be nil")
+	}
+	wf.configValidators = append(wf.configValidators, validator)
+}
+
+/* build checks validate the wiring of the workflow itself, they run together with the config validators */
+func (wf *Workflow[CT, C, T]) addBuildCheck(check func() error) {
+	wf.buildChecks = append(wf.buildChecks, check)
+}
+
+func (wf *Workflow[CT, C, T]) validateConfig(config C) error {
+	validationErr := &ValidationError{}
+	for _, check := range wf.buildChecks {
+		if err := check(); err != nil {
+			validationErr.add(err)
+		}
+	}
+	for _, validator := range wf.configValidators {
+		if err := validator(config); err != nil {
+			validationErr.add(err)
+		}
+	}
+	if len(validationErr.Problems) > 0 {
+		return validationErr
+	}
+	return nil
+}
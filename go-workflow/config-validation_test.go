@@ -0,0 +1,56 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type ValidatedConfig struct {
+	Model    string
+	MaxPages int
+	Language string
+}
+
+func TestConfigValidation(t *testing.T) {
+	executed := false
+	newWorkflow := func() *goworkflow.Workflow[context.Context, ValidatedConfig, Data] {
+		wf := goworkflow.NewWorkflow[context.Context, ValidatedConfig, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[ValidatedConfig, Data]) error {
+			executed = true
+			return nil
+		}))
+		wf.AddConfigValidator(func(c ValidatedConfig) error {
+			var errs []error
+			if c.Model == "" {
+				errs = append(errs, errors.New("model is required"))
+			}
+			if c.MaxPages <= 0 {
+				errs = append(errs, errors.New("maxPages should be positive"))
+			}
+			return errors.Join(errs...)
+		})
+		wf.AddConfigValidator(func(c ValidatedConfig) error {
+			if c.Language != "en" && c.Language != "de" {
+				return &goworkflow.ValidationError{Problems: []string{"unsupported language: " + c.Language}}
+			}
+			return nil
+		})
+		return wf
+	}
+
+	_, st, err := newWorkflow().Execute(context.TODO(), ValidatedConfig{Language: "fr"}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	validationErr := &goworkflow.ValidationError{}
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"model is required", "maxPages should be positive", "unsupported language: fr"}, validationErr.Problems)
+	assert.False(t, executed, "no component should run with an invalid config")
+
+	_, st, err = newWorkflow().Execute(context.TODO(), ValidatedConfig{Model: "fast", MaxPages: 10, Language: "en"}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.True(t, executed)
+}
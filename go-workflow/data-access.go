@@ -0,0 +1,14 @@
+package goworkflow
+
+/*
+DataAccessObserver wraps every DataTracker.Update of a run, see wftest.CheckDataAccess.
+ObserveUpdate must apply update to data, it is called with the data store locked.
+*/
+type DataAccessObserver[T any] interface {
+	ObserveUpdate(component string, data *T, update func(*T))
+}
+
+/* SetDataAccessObserver: testing hook, observes which component updates the data and how */
+func (wf *Workflow[CT, C, T]) SetDataAccessObserver(observer DataAccessObserver[T]) {
+	wf.dataAccessObserver = observer
+}
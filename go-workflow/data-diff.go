@@ -0,0 +1,135 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+/*
+FieldChange: value of a data store field before and after. Path is the dot separated json path of the field,
+elements of slices of unchanged length by index (pages.3.text). Values are json decoded, nil for absent fields.
+*/
+type FieldChange struct {
+	Path   string
+	Before any
+	After  any
+}
+
+/* DiffData: changed fields between before and after, sorted by path */
+func DiffData[T any](before, after *T) ([]FieldChange, error) {
+	beforeValue, err := jsonValue(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValue, err := jsonValue(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []FieldChange{}
+	diffValues("", beforeValue, afterValue, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+/* diffJSON: changed fields between two json documents, sorted by path, empty documents are null */
+func diffJSON(before, after json.RawMessage) ([]FieldChange, error) {
+	var beforeValue, afterValue any
+	if before != nil {
+		if err := json.Unmarshal(before, &beforeValue); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &afterValue); err != nil {
+			return nil, err
+		}
+	}
+	changes := []FieldChange{}
+	diffValues("", beforeValue, afterValue, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func jsonValue(data any) (any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	return value, json.Unmarshal(raw, &value)
+}
+
+/* copyData: deep copy of data through json, unexported fields are not copied */
+func copyData[T any](data *T) (*T, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	copied := new(T)
+	return copied, json.Unmarshal(raw, copied)
+}
+
+func diffValues(path string, before any, after any, changes *[]FieldChange) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			for key, value := range a {
+				diffValues(join(key), b[key], value, changes)
+			}
+			for key, value := range b {
+				if _, ok := a[key]; !ok {
+					diffValues(join(key), value, nil, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if a, ok := after.([]any); ok && len(a) == len(b) {
+			for i := range a {
+				diffValues(join(strconv.Itoa(i)), b[i], a[i], changes)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, FieldChange{Path: path, Before: before, After: after})
+	}
+}
+
+/*
+SetRecordDataChanges records the fields every component changes, see DataChanges. Every Update copies the data
+store through json, keep it for debugging runs and tests.
+*/
+func (wf *Workflow[CT, C, T]) SetRecordDataChanges(record bool) {
+	wf.recordDataChanges = record
+}
+
+/* DataChanges: fields changed by the components (by name) in the order of their updates, see SetRecordDataChanges */
+func (wf *Workflow[CT, C, T]) DataChanges() map[string][]FieldChange {
+	wf.dataChangesLock.Lock()
+	defer wf.dataChangesLock.Unlock()
+	changes := maps.Clone(wf.dataChanges)
+	for component := range changes {
+		changes[component] = slices.Clone(changes[component])
+	}
+	return changes
+}
+
+func (wf *Workflow[CT, C, T]) addDataChanges(component string, changes []FieldChange) {
+	wf.dataChangesLock.Lock()
+	defer wf.dataChangesLock.Unlock()
+	if wf.dataChanges == nil {
+		wf.dataChanges = map[string][]FieldChange{}
+	}
+	wf.dataChanges[component] = append(wf.dataChanges[component], changes...)
+}
@@ -0,0 +1,89 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffData(t *testing.T) {
+	type page struct {
+		Text string `json:"text"`
+	}
+	type doc struct {
+		Pages []page         `json:"pages"`
+		Meta  map[string]int `json:"meta"`
+		Tags  []string       `json:"tags"`
+	}
+	before := &doc{Pages: []page{{"a"}, {"b"}}, Meta: map[string]int{"x": 1, "y": 2}, Tags: []string{"t"}}
+	after := &doc{Pages: []page{{"a"}, {"c"}}, Meta: map[string]int{"x": 1, "z": 3}, Tags: []string{"t", "u"}}
+	changes, err := goworkflow.DiffData(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, []goworkflow.FieldChange{
+		{Path: "meta.y", Before: float64(2), After: nil},
+		{Path: "meta.z", Before: nil, After: float64(3)},
+		{Path: "pages.1.text", Before: "b", After: "c"},
+		{Path: "tags", Before: []any{"t"}, After: []any{"t", "u"}},
+	}, changes)
+}
+
+func TestRecordDataChanges(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	parameter := func(value string) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = value })
+			return nil
+		}
+	}
+	first := wf.AddComponent(goworkflow.MakeComponent("DPI", nil, parameter("300")))
+	second := wf.AddComponent(goworkflow.MakeComponent("Language", nil, parameter("300")))
+	wf.AddComponent(goworkflow.MakeComponent("Region", nil, parameter("eu"))).AddDependencies(first, second)
+	wf.SetRecordDataChanges(true)
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	changes := wf.DataChanges()
+	// only the first of DPI and Language changed A, Region clobbered it
+	assert.Len(t, append(changes["DPI"], changes["Language"]...), 1)
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "A", Before: "300", After: "eu"}}, changes["Region"])
+
+	wf = goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("DPI", nil, parameter("300")))
+	wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Empty(t, wf.DataChanges())
+}
+
+func TestRecordDataChangesWithCheckpoints(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	for i := 0; i < 64; i++ {
+		value := fmt.Sprint(i)
+		wf.AddComponent(goworkflow.MakeComponent("C"+value, nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			for j := 0; j < 10; j++ {
+				dt.Update(func(d *Data) { d.A, d.Combined = value, d.Combined+value })
+			}
+			return nil
+		}))
+	}
+	wf.SetRecordDataChanges(true)
+	checkpoints := 0
+	wf.OnCheckpoint(func(cp goworkflow.Checkpoint[Data]) { checkpoints++ })
+
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+	select {
+	case st := <-done:
+		assert.Equal(t, goworkflow.DONE, st)
+		assert.Equal(t, 64, checkpoints)
+		assert.Len(t, wf.DataChanges(), 64)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock between recording data changes and checkpoints")
+	}
+}
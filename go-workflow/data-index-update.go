@@ -0,0 +1,24 @@
+package goworkflow
+
+const dataStoreStripes = 64
+
+/*
+UpdateIndex updates the element index of a data store slice, e.g. data.Pages[index] of a fan-out component.
+Updates of different indexes run concurrently instead of one at a time like Update, Update still waits for all
+of them. cb must only touch the element of index: the slice has to be sized before the fan-out, appends and
+other fields need Update. With data change recording, watchers or an access observer it is a plain Update.
+*/
+func (d *DataTracker[C, T]) UpdateIndex(index int, cb func(*T)) {
+	d.store.lock.RLock()
+	if d.store.observer != nil || d.store.recordChanges != nil || len(d.store.watchers) > 0 {
+		d.store.lock.RUnlock()
+		d.Update(cb)
+		return
+	}
+	defer d.store.lock.RUnlock()
+	stripe := &d.store.stripes[uint(index)%dataStoreStripes]
+	stripe.Lock()
+	defer stripe.Unlock()
+	defer d.store.generation.Add(1)
+	cb(d.store.data)
+}
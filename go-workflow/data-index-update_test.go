@@ -0,0 +1,48 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type pagesData struct {
+	Pages []string
+	Done  int
+}
+
+func TestUpdateIndex(t *testing.T) {
+	run := func(record bool) pagesData {
+		wf := goworkflow.NewWorkflow[context.Context, Config, pagesData](context.TODO())
+		wf.SetRecordDataChanges(record)
+		pages := []goworkflow.Component[context.Context, Config, pagesData]{}
+		for i := 0; i < 100; i++ {
+			i := i
+			pages = append(pages, wf.AddComponent(goworkflow.MakeComponent(fmt.Sprintf("Page%d", i), nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, pagesData]) error {
+				dt.UpdateIndex(i, func(d *pagesData) { d.Pages[i] = fmt.Sprint("page ", i) })
+				dt.Update(func(d *pagesData) { d.Done++ })
+				return nil
+			})))
+		}
+		wf.AddComponent(goworkflow.MakeComponent("Aggregate", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, pagesData]) error {
+			assert.Equal(t, 100, dt.GetData().Done)
+			return nil
+		})).AddDependencies(pages...)
+		data, st, err := wf.Execute(context.TODO(), Config{}, &pagesData{Pages: make([]string, 100)})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		if record {
+			assert.Equal(t, []goworkflow.FieldChange{{Path: "Pages.7", Before: "", After: "page 7"}}, wf.DataChanges()["Page7"][:1])
+		}
+		return *data
+	}
+	for _, record := range []bool{false, true} {
+		data := run(record)
+		assert.Equal(t, 100, data.Done)
+		assert.Equal(t, "page 0", data.Pages[0])
+		assert.Equal(t, "page 99", data.Pages[99])
+	}
+}
@@ -0,0 +1,167 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+type DataSizePolicy string
+
+const (
+	// DataSizeError fails the component which pushed the data store over the limit
+	DataSizeError DataSizePolicy = "error"
+	// DataSizeTruncate truncates large string and []byte fields
+	DataSizeTruncate DataSizePolicy = "truncate"
+	// DataSizeSpill moves large string and []byte fields to the artifact store, the field holds the ArtifactRef instead
+	DataSizeSpill DataSizePolicy = "spill"
+)
+
+var ErrDataSizeLimit = errors.New("data store size limit exceeded")
+
+/* DataSizeLimit: limit on the JSON serialized size of the data store, checked after every component */
+type DataSizeLimit struct {
+	MaxBytes int
+	Policy   DataSizePolicy
+	// MaxFieldBytes: string and []byte fields above this size are truncated or spilled, defaults to MaxBytes/4
+	MaxFieldBytes int
+}
+
+func (wf *Workflow[CT, C, T]) SetDataSizeLimit(limit DataSizeLimit) {
+	if limit.MaxBytes <= 0 {
+		panic("data size limit needs MaxBytes")
+	}
+	if limit.Policy == "" {
+		limit.Policy = DataSizeError
+	}
+	if limit.MaxFieldBytes <= 0 {
+		limit.MaxFieldBytes = limit.MaxBytes / 4
+	}
+	wf.dataSizeLimit = &limit
+}
+
+/* DataSize: JSON serialized size of data */
+func DataSize[T any](data *T) (int, error) {
+	b, err := json.Marshal(data)
+	return len(b), err
+}
+
+/* enforceDataSizeLimit applies the policy after component c finished */
+func (wf *Workflow[CT, C, T]) enforceDataSizeLimit(ctx context.Context, c *component[CT, C, T]) error {
+	limit := wf.dataSizeLimit
+	if limit == nil {
+		return nil
+	}
+	wf.store.lock.Lock()
+	defer wf.store.lock.Unlock()
+
+	size, err := DataSize(wf.store.data)
+	if err != nil {
+		return err
+	}
+	if size <= limit.MaxBytes {
+		return nil
+	}
+	if limit.Policy == DataSizeError {
+		return fmt.Errorf("%w: %d bytes after %s, limit is %d", ErrDataSizeLimit, size, c.Name, limit.MaxBytes)
+	}
+	if limit.Policy == DataSizeSpill && wf.artifactStore == nil {
+		return errors.New("artifact store is not set, cannot spill data store fields")
+	}
+
+	var shrinkErr error
+	walkLargeFields(reflect.ValueOf(wf.store.data).Elem(), "", limit.MaxFieldBytes, func(path string, v reflect.Value) {
+		if shrinkErr != nil {
+			return
+		}
+		if limit.Policy == DataSizeTruncate {
+			log.Println("Workflow.enforceDataSizeLimit:Truncating field:", path, "after", c.Name)
+			truncateField(v, limit.MaxFieldBytes)
+			return
+		}
+		log.Println("Workflow.enforceDataSizeLimit:Spilling field:", path, "after", c.Name)
+		var content []byte
+		if v.Kind() == reflect.String {
+			content = []byte(v.String())
+		} else {
+			content = v.Bytes()
+		}
+		ref, err := wf.artifactStore.Put(ctx, fmt.Sprintf("%s/spill/%s/%s", wf.id, c.Name, path), bytes.NewReader(content))
+		if err != nil {
+			shrinkErr = err
+			return
+		}
+		if v.Kind() == reflect.String {
+			v.SetString(string(ref))
+		} else {
+			v.SetBytes([]byte(ref))
+		}
+	})
+	if shrinkErr != nil {
+		return shrinkErr
+	}
+
+	if size, err = DataSize(wf.store.data); err != nil {
+		return err
+	}
+	if size > limit.MaxBytes {
+		return fmt.Errorf("%w: %d bytes after %s and %s policy, limit is %d", ErrDataSizeLimit, size, c.Name, limit.Policy, limit.MaxBytes)
+	}
+	return nil
+}
+
+/* walkLargeFields calls fn with every settable string or []byte above maxBytes, reachable through exported fields, pointers, slices and arrays */
+func walkLargeFields(v reflect.Value, path string, maxBytes int, fn func(path string, v reflect.Value)) {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkLargeFields(v.Elem(), path, maxBytes, fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkLargeFields(v.Field(i), join(v.Type().Field(i).Name), maxBytes, fn)
+			}
+		}
+	case reflect.String:
+		if v.CanSet() && v.Len() > maxBytes {
+			fn(path, v)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.CanSet() && v.Len() > maxBytes {
+				fn(path, v)
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkLargeFields(v.Index(i), join(strconv.Itoa(i)), maxBytes, fn)
+		}
+	}
+}
+
+func truncateField(v reflect.Value, maxBytes int) {
+	if v.Kind() == reflect.String {
+		s := v.String()
+		// don't cut a multi byte character in half
+		n := maxBytes
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		v.SetString(s[:n])
+		return
+	}
+	v.SetBytes(v.Bytes()[:maxBytes])
+}
@@ -0,0 +1,66 @@
+package goworkflow_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func addLargeComponent(wf *goworkflow.Workflow[context.Context, Config, Data]) {
+	wf.AddComponent(goworkflow.MakeComponent("Render", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.A = strings.Repeat("é", 500)
+			d.B = "small"
+		})
+		return nil
+	}))
+}
+
+func TestDataSizeLimitError(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetDataSizeLimit(goworkflow.DataSizeLimit{MaxBytes: 200})
+	addLargeComponent(wf)
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Contains(t, wf.Result().Errors["Render"], goworkflow.ErrDataSizeLimit.Error())
+}
+
+func TestDataSizeLimitTruncate(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetDataSizeLimit(goworkflow.DataSizeLimit{MaxBytes: 200, Policy: goworkflow.DataSizeTruncate, MaxFieldBytes: 51})
+	addLargeComponent(wf)
+
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, strings.Repeat("é", 25), data.A)
+	assert.Equal(t, "small", data.B)
+}
+
+func TestDataSizeLimitSpill(t *testing.T) {
+	store := goworkflow.NewMemoryArtifactStore()
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetArtifactStore(store)
+	wf.SetDataSizeLimit(goworkflow.DataSizeLimit{MaxBytes: 200, Policy: goworkflow.DataSizeSpill})
+	addLargeComponent(wf)
+
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "mem://"+wf.Id()+"/spill/Render/A", data.A)
+
+	r, err := store.Get(context.TODO(), goworkflow.ArtifactRef(data.A))
+	assert.NoError(t, err)
+	r.Close()
+}
+
+func TestDataSizeLimitSpillWithoutArtifactStore(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetDataSizeLimit(goworkflow.DataSizeLimit{MaxBytes: 200, Policy: goworkflow.DataSizeSpill})
+	addLargeComponent(wf)
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+}
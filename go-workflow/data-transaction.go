@@ -0,0 +1,112 @@
+package goworkflow
+
+import (
+	"reflect"
+)
+
+/*
+Transact updates the data store like Update, an error returned by cb discards all its changes so dependents
+never see a partially updated store. The store is copied reflectively before cb and restored in place on error:
+pointers keep their identity and the values they point to are restored, except objects with unexported state
+(e.g. a Collector) which manage their own changes. The error of cb is returned.
+*/
+func (d *DataTracker[C, T]) Transact(cb func(*T) error) error {
+	var err error
+	d.Update(func(data *T) {
+		saved := snapshotData(reflect.ValueOf(data).Elem())
+		if err = cb(data); err != nil {
+			saved.restore(reflect.ValueOf(data).Elem())
+		}
+	})
+	return err
+}
+
+/* dataSnapshot: deep copy of a value, pointers are kept and the values they point to are copied separately */
+type dataSnapshot struct {
+	value reflect.Value
+	// pointer -> copy of the value it points to
+	pointees map[any]reflect.Value
+}
+
+func snapshotData(v reflect.Value) *dataSnapshot {
+	s := &dataSnapshot{pointees: map[any]reflect.Value{}}
+	s.value = s.copy(v)
+	return s
+}
+
+func (s *dataSnapshot) copy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() && plainData(v.Type().Elem()) {
+			if _, ok := s.pointees[v.Interface()]; !ok {
+				// placeholder, for cycles
+				s.pointees[v.Interface()] = reflect.Value{}
+				s.pointees[v.Interface()] = s.copy(v.Elem())
+			}
+		}
+		return v
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(s.copy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		// unexported fields are copied shallowly
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				c.Field(i).Set(s.copy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(s.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(s.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), s.copy(iter.Value()))
+		}
+		return c
+	}
+	return v
+}
+
+func (s *dataSnapshot) restore(target reflect.Value) {
+	for pointer, saved := range s.pointees {
+		reflect.ValueOf(pointer).Elem().Set(saved)
+	}
+	target.Set(s.value)
+}
+
+/* plainData: values of t can be restored in place, structs with unexported fields are objects managing their own state */
+func plainData(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
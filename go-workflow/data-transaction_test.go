@@ -0,0 +1,60 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransact(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Totals", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		assert.NoError(t, dt.Transact(func(d *Data) error {
+			d.A, d.B = "net 10", "tax 2"
+			return nil
+		}))
+		return dt.Transact(func(d *Data) error {
+			d.A, d.Combined = "net 12", "net 12 + tax 2"
+			return errors.New("currency missing")
+		})
+	}), &goworkflow.ComponentConfig{Optional: true})
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{C: "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	// the failed transaction left no trace
+	assert.Equal(t, Data{A: "net 10", B: "tax 2", C: "EUR"}, *data)
+}
+
+type Ledger struct {
+	Totals map[string]int `json:"-"`
+	Pages  *goworkflow.Collector[string]
+	Header *struct{ Currency string }
+	Lines  []string
+}
+
+func TestTransactRollbackInPlace(t *testing.T) {
+	pages := goworkflow.NewCollector[string]()
+	header := &struct{ Currency string }{"EUR"}
+	ledger := &Ledger{Totals: map[string]int{"net": 10}, Pages: pages, Header: header, Lines: []string{"a"}}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Ledger](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Totals", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Ledger]) error {
+		return dt.Transact(func(l *Ledger) error {
+			l.Totals["net"] = 12
+			l.Header.Currency = "USD"
+			l.Lines[0], l.Lines = "b", append(l.Lines, "c")
+			l.Pages = goworkflow.NewCollector[string]()
+			return errors.New("currency missing")
+		})
+	}))
+	_, st, _ := wf.Execute(context.TODO(), Config{}, ledger)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, map[string]int{"net": 10}, ledger.Totals)
+	assert.Same(t, pages, ledger.Pages, "writers holding the collector must keep writing into the store")
+	assert.Same(t, header, ledger.Header)
+	assert.Equal(t, "EUR", header.Currency)
+	assert.Equal(t, []string{"a"}, ledger.Lines)
+	ledger.Totals["tax"] = 2
+}
@@ -0,0 +1,54 @@
+package goworkflow
+
+import (
+	"reflect"
+	"slices"
+)
+
+type dataWatcher[T any] struct {
+	selector func(*T) any
+	onChange func(old any, new any)
+	last     any
+}
+
+/*
+Watch calls onChange after every Update which changed the value selected from the data store, e.g. to keep
+progress or a derived cache current without a component depending on everything. Values are compared with
+reflect.DeepEqual, so selectors must return copies of slices and maps, not the data store ones.
+onChange runs while the data store is locked, it must not call Update. The watch lasts until the returned
+function is called or the run ends.
+*/
+func (d *DataTracker[C, T]) Watch(selector func(*T) any, onChange func(old any, new any)) func() {
+	if selector == nil || onChange == nil {
+		panic("selector and onChange cannot be nil")
+	}
+	d.store.lock.Lock()
+	defer d.store.lock.Unlock()
+	w := &dataWatcher[T]{selector: selector, onChange: onChange, last: selector(d.store.data)}
+	d.store.watchers = append(d.store.watchers, w)
+	return func() {
+		d.store.lock.Lock()
+		defer d.store.lock.Unlock()
+		d.store.watchers = slices.DeleteFunc(d.store.watchers, func(existing *dataWatcher[T]) bool { return existing == w })
+	}
+}
+
+/* Watch: like DataTracker.Watch for the whole run, the selected value starts from the initial data. Call it before Execute */
+func (wf *Workflow[CT, C, T]) Watch(selector func(*T) any, onChange func(old any, new any)) {
+	if selector == nil || onChange == nil {
+		panic("selector and onChange cannot be nil")
+	}
+	wf.watchers = append(wf.watchers, &dataWatcher[T]{selector: selector, onChange: onChange})
+}
+
+/* notifyWatchers: called with the lock held after every update */
+func (s *dataStore[T]) notifyWatchers() {
+	for _, w := range s.watchers {
+		current := w.selector(s.data)
+		if !reflect.DeepEqual(w.last, current) {
+			old := w.last
+			w.last = current
+			w.onChange(old, current)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	changes := []string{}
+	wf.Watch(func(d *Data) any { return d.A }, func(old any, new any) {
+		changes = append(changes, old.(string)+"->"+new.(string))
+	})
+	var componentChanges []any
+	first := wf.AddComponent(goworkflow.MakeComponent("First", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		stop := dt.Watch(func(d *Data) any { return d.B }, func(old any, new any) {
+			componentChanges = append(componentChanges, new)
+		})
+		dt.Update(func(d *Data) { d.A = "a" })
+		dt.Update(func(d *Data) { d.B = "b" })
+		// unchanged values aren't reported
+		dt.Update(func(d *Data) { d.A = "a" })
+		stop()
+		dt.Update(func(d *Data) { d.B = "c" })
+		return nil
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("Second", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "aa" })
+		return nil
+	})).AddDependencies(first)
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{A: "initial"})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, []string{"initial->a", "a->aa"}, changes)
+	assert.Equal(t, []any{"b"}, componentChanges)
+}
@@ -0,0 +1,34 @@
+package goworkflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInsufficientTime = errors.New("not enough time left before the deadline")
+
+/* Deadline: deadline of the run, from the context passed to Execute */
+func (d *DataTracker[C, T]) Deadline() (time.Time, bool) {
+	return d.ctx.Deadline()
+}
+
+/* Remaining: time left before the deadline, ok is false when the run has no deadline */
+func (d *DataTracker[C, T]) Remaining() (remaining time.Duration, ok bool) {
+	deadline, ok := d.ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+/* checkMinDuration: error when the component cannot finish its declared MinDuration before the deadline of the run */
+func (c *component[CT, C, T]) checkMinDuration(deadline time.Time, hasDeadline bool) error {
+	if !hasDeadline || c.addComponentCfg == nil || c.addComponentCfg.MinDuration <= 0 {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining < c.addComponentCfg.MinDuration {
+		return fmt.Errorf("%w: %s left, %s needs at least %s", ErrInsufficientTime, remaining.Round(time.Millisecond), c.Name, c.addComponentCfg.MinDuration)
+	}
+	return nil
+}
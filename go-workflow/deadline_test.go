@@ -0,0 +1,50 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		remaining, ok := dt.Remaining()
+		assert.True(t, ok)
+		model := "large"
+		if remaining < time.Second {
+			model = "fast"
+		}
+		dt.Update(func(d *Data) {
+			d.A = model
+		})
+		return nil
+	}))
+	slow := wf.AddComponent(goworkflow.MakeComponent("Summarize", nil, ocrProvider("summary", 0, nil)),
+		&goworkflow.ComponentConfig{MinDuration: time.Minute})
+	slow.AddDependencies(ocr)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 500*time.Millisecond)
+	defer cancel()
+	data, st, _ := wf.Execute(ctx, Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "fast", data.A)
+	assert.Contains(t, slow.Status().ErrorMessage, goworkflow.ErrInsufficientTime.Error())
+}
+
+func TestNoDeadline(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		_, ok := dt.Remaining()
+		assert.False(t, ok)
+		_, ok = dt.Deadline()
+		assert.False(t, ok)
+		return nil
+	}), &goworkflow.ComponentConfig{MinDuration: time.Hour})
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+}
@@ -0,0 +1,134 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"slices"
+)
+
+/* DebugSampledMetadataKey: run metadata set to "true" on the runs sampled for debugging, see SetDebugSampling */
+const DebugSampledMetadataKey = "debugSampled"
+
+/* DebugSampling: which runs record a DebugTrace, see SetDebugSampling */
+type DebugSampling struct {
+	// Rate: fraction of the runs sampled, e.g. 0.01 for 1%
+	Rate float64
+	// Key: unit of sampling, the flag key (see SetFlagKey) or the run id when empty. A key is always or never
+	// sampled for a given rate, e.g. a retried document is debugged again
+	Key string
+}
+
+/*
+DebugTrace: everything recorded for a sampled run, the recording of the data store (see SetRecording), the
+fields changed by every component (see SetRecordDataChanges) and all state changes of the run.
+*/
+type DebugTrace struct {
+	WorkflowId  string
+	Workflow    string
+	Recording   RunRecording
+	DataChanges map[string][]FieldChange
+	Events      []StateChange
+}
+
+type debugSampling struct {
+	opts    DebugSampling
+	sampled bool
+	events  []StateChange
+	ref     ArtifactRef
+}
+
+/*
+SetDebugSampling turns on verbose recording for a sample of the runs, to debug production behavior without
+paying for it on every run. Sampled runs get the DebugSampledMetadataKey metadata and, when an artifact store is
+set, their DebugTrace is stored at <run id>/debug-trace.json (see RunResult.DebugTrace).
+*/
+func (wf *Workflow[CT, C, T]) SetDebugSampling(sampling DebugSampling) {
+	if sampling.Rate < 0 || sampling.Rate > 1 {
+		panic("debug sampling rate must be between 0 and 1")
+	}
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.debugSampling = &debugSampling{opts: sampling}
+}
+
+/* DebugSampled: whether the run was sampled for debugging, known once Execute started */
+func (wf *Workflow[CT, C, T]) DebugSampled() bool {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	return wf.debugSampling != nil && wf.debugSampling.sampled
+}
+
+/* DebugTrace: trace of the run so far, empty unless the run was sampled */
+func (wf *Workflow[CT, C, T]) DebugTrace() DebugTrace {
+	if !wf.DebugSampled() {
+		return DebugTrace{}
+	}
+	trace := DebugTrace{WorkflowId: wf.id, Workflow: wf.name, Recording: wf.Recording(), DataChanges: wf.DataChanges()}
+	wf.stateLock.Lock()
+	trace.Events = slices.Clone(wf.debugSampling.events)
+	wf.stateLock.Unlock()
+	return trace
+}
+
+/* debugSampled: bucket of key in [0, 1), stable for a key */
+func debugSampled(key string, rate float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte("debug\x00" + key))
+	return float64(h.Sum32()%10000)/10000 < rate
+}
+
+/* sampleDebug: decides if the run is sampled and turns on its recording, called before the data store is built */
+func (wf *Workflow[CT, C, T]) sampleDebug() {
+	wf.stateLock.Lock()
+	sampling := wf.debugSampling
+	wf.stateLock.Unlock()
+	if sampling == nil {
+		return
+	}
+	key := sampling.opts.Key
+	if key == "" {
+		key = wf.flagKey
+	}
+	if key == "" {
+		key = wf.id
+	}
+	if !debugSampled(key, sampling.opts.Rate) {
+		return
+	}
+	wf.SetRecording(true)
+	wf.recordDataChanges = true
+	wf.SetMetadata(DebugSampledMetadataKey, "true")
+	wf.stateLock.Lock()
+	sampling.sampled = true
+	wf.stateLock.Unlock()
+	wf.addStateListener(func(change StateChange) {
+		sampling.events = append(sampling.events, change)
+	})
+}
+
+/* storeDebugTrace: uploads the trace of a sampled run, failures are only logged */
+func (wf *Workflow[CT, C, T]) storeDebugTrace(ctx context.Context) {
+	if !wf.DebugSampled() {
+		return
+	}
+	if wf.artifactStore == nil {
+		log.Println("Workflow.Execute:Debug trace of run:", wf.id, "kept in memory, artifact store is not set")
+		return
+	}
+	content, err := json.Marshal(wf.DebugTrace())
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Debug trace encoding failed for run:", wf.id, err)
+		return
+	}
+	ref, err := wf.artifactStore.Put(context.WithoutCancel(ctx), wf.id+"/debug-trace.json", bytes.NewReader(content))
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Debug trace upload failed for run:", wf.id, err)
+		return
+	}
+	wf.stateLock.Lock()
+	wf.debugSampling.ref = ref
+	wf.stateLock.Unlock()
+}
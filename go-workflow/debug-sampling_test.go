@@ -0,0 +1,70 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugSampling(t *testing.T) {
+	build := func(sampling goworkflow.DebugSampling) (*goworkflow.Workflow[context.Context, Config, Data], *goworkflow.MemoryArtifactStore) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		store := goworkflow.NewMemoryArtifactStore()
+		wf.SetArtifactStore(store)
+		wf.SetDebugSampling(sampling)
+		a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = "a" })
+			return nil
+		}))
+		b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.B = "b" })
+			return nil
+		}))
+		b.AddDependencies(a)
+		return wf, store
+	}
+
+	wf, store := build(goworkflow.DebugSampling{Rate: 1})
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.True(t, wf.DebugSampled())
+	result := wf.Result()
+	assert.Equal(t, "true", result.Metadata[goworkflow.DebugSampledMetadataKey])
+	assert.NotEmpty(t, result.DebugTrace)
+
+	reader, err := store.Get(context.TODO(), result.DebugTrace)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	trace := goworkflow.DebugTrace{}
+	assert.NoError(t, json.Unmarshal(content, &trace))
+	assert.Equal(t, wf.Id(), trace.WorkflowId)
+	assert.Len(t, trace.Recording.Steps, 2)
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "B", Before: "", After: "b"}}, trace.DataChanges["B"])
+	// RUNNING and DONE of the run and of both components
+	assert.Len(t, trace.Events, 6)
+
+	wf, _ = build(goworkflow.DebugSampling{Rate: 0})
+	_, _, err = wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.False(t, wf.DebugSampled())
+	assert.Empty(t, wf.Result().DebugTrace)
+	assert.Empty(t, wf.Recording().Steps)
+	assert.Empty(t, wf.DataChanges())
+
+	// a key is sampled the same way in every run
+	sampled := 0
+	for i := 0; i < 10; i++ {
+		wf, _ = build(goworkflow.DebugSampling{Rate: 0.5, Key: "document-42"})
+		_, _, err = wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		if wf.DebugSampled() {
+			sampled++
+		}
+	}
+	assert.Contains(t, []int{0, 10}, sampled)
+}
@@ -0,0 +1,249 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+/* DedupMetadataKey: run metadata holding the dedup key, see SetDedupKey */
+const DedupMetadataKey = "dedupKey"
+
+/*
+DedupExpiresMetadataKey: run metadata holding the time (RFC 3339) until which the claim of a running run is valid.
+The run renews it while it executes, claims which are not renewed belong to dead processes and count as failed.
+*/
+const DedupExpiresMetadataKey = "dedupExpires"
+
+/*
+RunClaimer: run stores which save a run only when no run of the same workflow with the same dedup key is
+running (with an unexpired claim) or done, atomically. Other stores are checked with QueryRuns before the run is
+saved, two runs started at the same time may then both execute.
+*/
+type RunClaimer interface {
+	// ClaimRun saves result and returns true, or returns the run which result duplicates
+	ClaimRun(ctx context.Context, result RunResult) (RunResult, bool, error)
+}
+
+type DedupOptions struct {
+	// PollInterval of the run store while waiting for a duplicate run in progress, 1s when 0
+	PollInterval time.Duration
+	// ClaimTTL: a running run which didn't renew its claim for ClaimTTL is considered dead, 1m when 0.
+	// Claims are renewed every ClaimTTL/3, the TTL must exceed the clock skew between the processes
+	ClaimTTL time.Duration
+}
+
+type dedup struct {
+	key          string
+	pollInterval time.Duration
+	claimTTL     time.Duration
+	// duplicateOf: id of the run this run was deduplicated to
+	duplicateOf string
+	// stopRenewal stops the renewal of the claim of the executing run, nil when the run didn't claim
+	stopRenewal func()
+}
+
+/*
+SetDedupKey makes Execute idempotent by a business key, e.g. the hash of a document: when a run of the workflow
+with the same key is in progress or done in the run store (see SetRunStore), Execute doesn't run the components.
+It waits for a run in progress to finish and returns the status of the existing run, data is not modified,
+DuplicateOf returns the id of the existing run. Failed runs don't count, the document can be submitted again.
+Runs with a dedup key are saved as RUNNING when they start and renew their claim while they execute, a run whose
+process died stops deduplicating after DedupOptions.ClaimTTL and a waiting run executes instead.
+Keys are scoped to the workflow name, see SetName, which is required.
+*/
+func (wf *Workflow[CT, C, T]) SetDedupKey(key string, opts ...*DedupOptions) {
+	if len(opts) > 1 {
+		panic("only one DedupOptions is allowed")
+	}
+	if key == "" {
+		panic("dedup key cannot be empty")
+	}
+	d := &dedup{key: key, pollInterval: time.Second, claimTTL: time.Minute}
+	if len(opts) == 1 && opts[0] != nil && opts[0].PollInterval > 0 {
+		d.pollInterval = opts[0].PollInterval
+	}
+	if len(opts) == 1 && opts[0] != nil && opts[0].ClaimTTL > 0 {
+		d.claimTTL = opts[0].ClaimTTL
+	}
+	wf.SetMetadata(DedupMetadataKey, key)
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.dedup = d
+}
+
+/* DuplicateOf: id of the existing run Execute returned instead of executing, empty when the run executed */
+func (wf *Workflow[CT, C, T]) DuplicateOf() string {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	if wf.dedup == nil {
+		return ""
+	}
+	return wf.dedup.duplicateOf
+}
+
+/* deduplicates: r is a run a new run with the same key is a duplicate of, at now */
+func deduplicates(r RunResult, now time.Time) bool {
+	switch r.Status {
+	case DONE, DONE_WITH_WARNINGS:
+		return true
+	case PENDING, RUNNING:
+		return !claimExpired(r, now)
+	}
+	return false
+}
+
+/* claimExpired: the process of the running run r stopped renewing its claim, claims without expiry don't expire */
+func claimExpired(r RunResult, now time.Time) bool {
+	expires, err := time.Parse(time.RFC3339Nano, r.Metadata[DedupExpiresMetadataKey])
+	return err == nil && now.After(expires)
+}
+
+/* withClaimExpiry: copy of result with its claim valid for ttl */
+func withClaimExpiry(result RunResult, ttl time.Duration) RunResult {
+	metadata := make(map[string]string, len(result.Metadata)+1)
+	for k, v := range result.Metadata {
+		metadata[k] = v
+	}
+	metadata[DedupExpiresMetadataKey] = time.Now().Add(ttl).UTC().Format(time.RFC3339Nano)
+	result.Metadata = metadata
+	return result
+}
+
+/* claimRun saves the run as RUNNING, or returns the run it duplicates */
+func (wf *Workflow[CT, C, T]) claimRun(ctx context.Context, d *dedup) (RunResult, bool, error) {
+	result := wf.Result()
+	result.Status = RUNNING
+	result.StartedAt = time.Now()
+	result = withClaimExpiry(result, d.claimTTL)
+	if claimer, ok := wf.runStore.(RunClaimer); ok {
+		return claimer.ClaimRun(ctx, result)
+	}
+	query := RunQuery{Workflow: wf.name, Metadata: map[string]string{DedupMetadataKey: d.key}}
+	for {
+		page, err := wf.runStore.QueryRuns(ctx, query)
+		if err != nil {
+			return RunResult{}, false, err
+		}
+		for _, r := range page.Runs {
+			if deduplicates(r, time.Now()) {
+				return r, false, nil
+			}
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+	return result, true, wf.runStore.SaveRun(ctx, result)
+}
+
+/* renewClaim saves claim with a new expiry every claimTTL/3 until the returned func is called */
+func (wf *Workflow[CT, C, T]) renewClaim(ctx context.Context, d *dedup, claim RunResult) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(d.claimTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := wf.runStore.SaveRun(ctx, withClaimExpiry(claim, d.claimTTL)); err != nil {
+					log.Println("Workflow.RenewClaim:Error:", err)
+				}
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+		})
+	}
+}
+
+/* releaseClaim stops renewing the claim of the run, before the finished run is saved */
+func (wf *Workflow[CT, C, T]) releaseClaim() {
+	wf.stateLock.Lock()
+	d := wf.dedup
+	wf.stateLock.Unlock()
+	if d != nil && d.stopRenewal != nil {
+		d.stopRenewal()
+	}
+}
+
+/*
+deduplicate: claims the run, false when Execute returns the status of an existing run instead.
+Runs in progress are awaited by claiming again, so a run whose claim expired is replaced.
+*/
+func (wf *Workflow[CT, C, T]) deduplicate(ctx context.Context) (Status, bool, error) {
+	wf.stateLock.Lock()
+	d := wf.dedup
+	wf.stateLock.Unlock()
+	if d == nil {
+		return "", true, nil
+	}
+	if wf.runStore == nil {
+		return "", false, errors.New("dedup key requires a run store")
+	}
+	if wf.name == "" {
+		return "", false, errors.New("dedup key requires a workflow name, see SetName")
+	}
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	duplicateOf := ""
+	for {
+		existing, claimed, err := wf.claimRun(ctx, d)
+		if err != nil {
+			if duplicateOf != "" {
+				err = fmt.Errorf("waiting for duplicate run %s: %w", duplicateOf, err)
+			}
+			return "", false, err
+		}
+		if claimed {
+			stop := wf.renewClaim(ctx, d, existing)
+			wf.stateLock.Lock()
+			d.stopRenewal = stop
+			wf.stateLock.Unlock()
+			return "", true, nil
+		}
+		if existing.WorkflowId != duplicateOf {
+			log.Println("Workflow.Execute:Run", wf.id, "is a duplicate of run", existing.WorkflowId, "with dedup key", d.key)
+			duplicateOf = existing.WorkflowId
+		}
+		if finished(existing) {
+			wf.stateLock.Lock()
+			d.duplicateOf = duplicateOf
+			wf.stateLock.Unlock()
+			return existing.Status, false, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", false, fmt.Errorf("waiting for duplicate run %s: %w", duplicateOf, ctx.Err())
+		}
+	}
+}
+
+/* ClaimRun: see RunClaimer */
+func (m *MemoryRunStore) ClaimRun(ctx context.Context, result RunResult) (RunResult, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := result.Metadata[DedupMetadataKey]
+	for _, r := range m.runs {
+		if r.Workflow == result.Workflow && r.Metadata[DedupMetadataKey] == key && deduplicates(r, time.Now()) {
+			return r, false, nil
+		}
+	}
+	m.runs[result.WorkflowId] = result
+	return result, true, nil
+}
@@ -0,0 +1,146 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupKey(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+	executions := 0
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	newRun := func(err error) *goworkflow.Workflow[context.Context, Config, Data] {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("invoices")
+		wf.SetRunStore(store)
+		wf.SetDedupKey("invoice-42", &goworkflow.DedupOptions{PollInterval: time.Millisecond})
+		wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			executions++
+			started <- struct{}{}
+			<-release
+			dt.Update(func(d *Data) { d.A = "a" })
+			return err
+		}))
+		return wf
+	}
+
+	// a failed run doesn't count
+	failed := newRun(errors.New("boom"))
+	close(release)
+	_, st, err := failed.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	<-started
+
+	release = make(chan struct{})
+	first := newRun(nil)
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := first.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+	<-started
+
+	// attaches to the run in progress
+	second := newRun(nil)
+	attached := make(chan goworkflow.Status)
+	go func() {
+		data, st, err := second.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, "", data.A)
+		attached <- st
+	}()
+	time.Sleep(10 * time.Millisecond)
+	stored, err := store.GetRun(context.TODO(), first.Id())
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.RUNNING, stored.Status)
+	close(release)
+	assert.Equal(t, goworkflow.DONE, <-done)
+	assert.Equal(t, goworkflow.DONE, <-attached)
+	assert.Equal(t, first.Id(), second.DuplicateOf())
+	assert.Equal(t, goworkflow.DONE, second.Status())
+
+	// returns the status of the finished run
+	third := newRun(nil)
+	_, st, err = third.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, first.Id(), third.DuplicateOf())
+	assert.Equal(t, 2, executions)
+	assert.Empty(t, first.DuplicateOf())
+}
+
+func TestDedupKeyWithoutRunStore(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetDedupKey("invoice-42")
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.EqualError(t, err, "dedup key requires a run store")
+	assert.Equal(t, goworkflow.ERROR, st)
+}
+
+func TestDedupKeyStaleClaim(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+	executions := 0
+	newRun := func(key string, block time.Duration) *goworkflow.Workflow[context.Context, Config, Data] {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("invoices")
+		wf.SetRunStore(store)
+		wf.SetDedupKey(key, &goworkflow.DedupOptions{PollInterval: time.Millisecond, ClaimTTL: 30 * time.Millisecond})
+		wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			executions++
+			time.Sleep(block)
+			return nil
+		}))
+		return wf
+	}
+
+	// the process of the claiming run died
+	assert.NoError(t, store.SaveRun(context.TODO(), goworkflow.RunResult{WorkflowId: "dead", Workflow: "invoices", Status: goworkflow.RUNNING,
+		Metadata: map[string]string{goworkflow.DedupMetadataKey: "invoice-42", goworkflow.DedupExpiresMetadataKey: time.Now().Add(20 * time.Millisecond).Format(time.RFC3339Nano)}}))
+	wf := newRun("invoice-42", 0)
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Empty(t, wf.DuplicateOf())
+	assert.Equal(t, 1, executions)
+	stored, err := store.GetRun(context.TODO(), wf.Id())
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, stored.Status)
+
+	// live runs renew their claim past the TTL
+	first := newRun("invoice-43", 150*time.Millisecond)
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := first.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+	time.Sleep(10 * time.Millisecond)
+	second := newRun("invoice-43", 0)
+	_, st, err = second.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, first.Id(), second.DuplicateOf())
+	assert.Equal(t, goworkflow.DONE, <-done)
+	assert.Equal(t, 2, executions)
+}
+
+func TestDedupKeyWithoutName(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetRunStore(goworkflow.NewMemoryRunStore())
+	wf.SetDedupKey("invoice-42")
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.EqualError(t, err, "dedup key requires a workflow name, see SetName")
+	assert.Equal(t, goworkflow.ERROR, st)
+}
@@ -0,0 +1,77 @@
+package goworkflow
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/* fieldExists: path is a dot separated field path into the struct type t */
+func fieldExists(t reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return false
+		}
+		t = f.Type
+	}
+	return true
+}
+
+/*
+InferDependencies (opt-in) derives dependency edges from the data store fields declared in ComponentConfig.Reads and
+ComponentConfig.Writes: a component reading a field depends on every component writing it.
+Components reading fields that nothing writes, and field paths which don't exist on the data store, are reported as a *ValidationError.
+*/
+func (wf *Workflow[CT, C, T]) InferDependencies() error {
+	dataType := reflect.TypeOf((*T)(nil)).Elem()
+	validationErr := &ValidationError{}
+
+	writers := map[string][]*component[CT, C, T]{}
+	components := wf.sortedComponents()
+	for _, c := range components {
+		if c.addComponentCfg == nil {
+			continue
+		}
+		for _, field := range c.addComponentCfg.Writes {
+			if !fieldExists(dataType, field) {
+				validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("%s writes unknown field %s", c.Name, field))
+				continue
+			}
+			writers[field] = append(writers[field], c)
+		}
+	}
+
+	for _, c := range components {
+		if c.addComponentCfg == nil {
+			continue
+		}
+		for _, field := range c.addComponentCfg.Reads {
+			if !fieldExists(dataType, field) {
+				validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("%s reads unknown field %s", c.Name, field))
+				continue
+			}
+			found := false
+			for _, w := range writers[field] {
+				if w != c {
+					c.AddDependencies(w)
+					found = true
+				}
+			}
+			if !found {
+				validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("%s reads %s but no component writes it", c.Name, field))
+			}
+		}
+	}
+
+	if len(validationErr.Problems) > 0 {
+		return validationErr
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferDependencies(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.A = "A"
+		})
+		return nil
+	}), &goworkflow.ComponentConfig{Writes: []string{"A"}})
+	wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.B = "B"
+		})
+		return nil
+	}), &goworkflow.ComponentConfig{Writes: []string{"B"}})
+	wf.AddComponent(goworkflow.MakeComponent("Combine", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.Combined = d.A + d.B
+		})
+		return nil
+	}), &goworkflow.ComponentConfig{Reads: []string{"A", "B"}, Writes: []string{"Combined"}})
+	wf.AddComponent(goworkflow.MakeComponent("Other", nil, noop))
+
+	assert.NoError(t, wf.InferDependencies())
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "AB", data.Combined)
+}
+
+func TestInferDependenciesReportsProblems(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+
+	wf.AddComponent(goworkflow.MakeComponent("Parameter", nil, noop), &goworkflow.ComponentConfig{Reads: []string{"C", "Missing"}})
+	wf.AddComponent(goworkflow.MakeComponent("Writer", nil, noop), &goworkflow.ComponentConfig{Writes: []string{"A.Length"}})
+
+	err := wf.InferDependencies()
+	validationErr := &goworkflow.ValidationError{}
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"Writer writes unknown field A.Length",
+		"Parameter reads C but no component writes it",
+		"Parameter reads unknown field Missing",
+	}, validationErr.Problems)
+}
@@ -0,0 +1,38 @@
+package goworkflow
+
+/* DependencyOutcome: outcome of a dependency required for the dependent component to run */
+type DependencyOutcome string
+
+const (
+	// OnSuccess: the default, the dependent fails when the dependency failed
+	OnSuccess DependencyOutcome = "success"
+	// OnFailure: the dependent runs only when the dependency failed, e.g. error notification or remediation
+	OnFailure DependencyOutcome = "failure"
+	// OnCompletion: the dependent runs whatever the dependency outcome
+	OnCompletion DependencyOutcome = "completion"
+)
+
+/*
+AddDependenciesOn: current component runs only when all d finish with outcome, otherwise it is SKIPPED.
+Components depending on a SKIPPED component with OnSuccess are SKIPPED as well.
+*/
+func (c *component[CT, C, T]) AddDependenciesOn(outcome DependencyOutcome, d ...Component[CT, C, T]) {
+	for _, dep := range d {
+		c.addDependency(dep, outcome)
+	}
+}
+
+/* AddDependenciesOn: see Component.AddDependenciesOn */
+func (tc *TemplateComponent[CT, C, T]) AddDependenciesOn(outcome DependencyOutcome, d ...*TemplateComponent[CT, C, T]) {
+	tc.AddDependencies(d...)
+	if tc.outcomes == nil {
+		tc.outcomes = map[string]DependencyOutcome{}
+	}
+	for _, dep := range d {
+		if outcome == OnSuccess {
+			delete(tc.outcomes, dep.Name())
+		} else {
+			tc.outcomes[dep.Name()] = outcome
+		}
+	}
+}
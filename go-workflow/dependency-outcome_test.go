@@ -0,0 +1,76 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func outcomeWorkflow(failOCR bool) (*goworkflow.Workflow[context.Context, Config, Data], map[string]bool) {
+	ran := map[string]bool{}
+	record := func(name string, err error) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) {
+				ran[name] = true
+			})
+			return err
+		}
+	}
+	var ocrErr error
+	if failOCR {
+		ocrErr = errors.New("ocr failed")
+	}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, record("OCR", ocrErr)))
+	parse := wf.AddComponent(goworkflow.MakeComponent("Parse", nil, record("Parse", nil)))
+	parse.AddDependencies(ocr)
+	notify := wf.AddComponent(goworkflow.MakeComponent("NotifyFailure", nil, record("NotifyFailure", nil)))
+	notify.AddDependenciesOn(goworkflow.OnFailure, ocr)
+	// depends on a component which is skipped when OCR succeeds
+	wf.AddComponent(goworkflow.MakeComponent("Escalate", nil, record("Escalate", nil))).AddDependencies(notify)
+	wf.AddComponent(goworkflow.MakeComponent("Cleanup", nil, record("Cleanup", nil))).AddDependenciesOn(goworkflow.OnCompletion, ocr)
+	return wf, ran
+}
+
+func TestOnFailureDependency(t *testing.T) {
+	wf, ran := outcomeWorkflow(true)
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, map[string]bool{"OCR": true, "NotifyFailure": true, "Escalate": true, "Cleanup": true}, ran)
+	assert.Equal(t, []string{"OCR", "Parse"}, wf.Result().FailedComponents)
+}
+
+func TestOnFailureDependencySkipped(t *testing.T) {
+	wf, ran := outcomeWorkflow(false)
+	skipped := []string{}
+	wf.OnStateChange(func(change goworkflow.StateChange) {
+		if change.NewStatus == goworkflow.SKIPPED {
+			skipped = append(skipped, change.Component)
+		}
+	})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, map[string]bool{"OCR": true, "Parse": true, "Cleanup": true}, ran)
+	assert.ElementsMatch(t, []string{"NotifyFailure", "Escalate"}, skipped)
+}
+
+func TestTemplateDependencyOutcome(t *testing.T) {
+	handled := false
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("ocr failed")
+	}))
+	tpl.AddComponent(goworkflow.MakeComponent("Remediate", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		handled = true
+		return nil
+	})).AddDependenciesOn(goworkflow.OnFailure, ocr)
+
+	_, st, _ := tpl.Clone().Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.True(t, handled)
+}
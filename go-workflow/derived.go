@@ -0,0 +1,52 @@
+package goworkflow
+
+import "sync"
+
+/*
+Derived is a value computed from the data store, like a computed property: aggregations (totals, page counts,
+the merged text...) are declared once and read by any component, instead of a dedicated component wired to
+all the ones it reads from.
+
+	var totals = goworkflow.NewDerived(func(d *Data) Totals { ... })
+	// in a component
+	t := goworkflow.Derive(dt, totals)
+
+It is evaluated lazily and cached until the next update of the data store, so compute must be pure and only
+read data. One Derived can be shared by runs and templates, the cache belongs to the data store it was last
+computed for.
+*/
+type Derived[T any, V any] struct {
+	compute func(*T) V
+
+	lock       sync.Mutex
+	store      *dataStore[T]
+	generation uint64
+	value      V
+}
+
+func NewDerived[T any, V any](compute func(*T) V) *Derived[T, V] {
+	if compute == nil {
+		panic("compute cannot be nil")
+	}
+	return &Derived[T, V]{compute: compute}
+}
+
+/* Derive: value of d for the data store of dt, computed again only when the store was updated since. Not from Update callbacks */
+func Derive[C any, T any, V any](dt *DataTracker[C, T], d *Derived[T, V]) V {
+	store := dt.store
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	generation := store.generation.Load()
+	d.lock.Lock()
+	if d.store == store && d.generation == generation {
+		defer d.lock.Unlock()
+		return d.value
+	}
+	d.lock.Unlock()
+
+	value := d.compute(store.data)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.store, d.generation, d.value = store, generation, value
+	return value
+}
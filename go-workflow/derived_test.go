@@ -0,0 +1,54 @@
+package goworkflow_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerived(t *testing.T) {
+	var computed atomic.Int32
+	combined := goworkflow.NewDerived(func(d *Data) string {
+		computed.Add(1)
+		return d.A + d.B
+	})
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "a" })
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		assert.Equal(t, "a", goworkflow.Derive(dt, combined))
+		// cached until the next update
+		assert.Equal(t, "a", goworkflow.Derive(dt, combined))
+		assert.Equal(t, int32(1), computed.Load())
+		dt.Update(func(d *Data) { d.B = "b" })
+		return nil
+	}))
+	c := wf.AddComponent(goworkflow.MakeComponent("C", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		combined := goworkflow.Derive(dt, combined)
+		dt.Update(func(d *Data) { d.Combined = combined })
+		return nil
+	}))
+	b.AddDependencies(a)
+	c.AddDependencies(b)
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "ab", data.Combined)
+	assert.Equal(t, int32(2), computed.Load())
+
+	// another run has its own data store
+	other := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	other.AddComponent(goworkflow.MakeComponent("C", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		combined := goworkflow.Derive(dt, combined)
+		dt.Update(func(d *Data) { d.Combined = combined })
+		return nil
+	}))
+	data, _, _ = other.Execute(context.TODO(), Config{}, &Data{A: "x", B: "y"})
+	assert.Equal(t, "xy", data.Combined)
+}
@@ -0,0 +1,42 @@
+package goworkflow
+
+/*
+SetDeterministic runs the components one at a time, in a stable topological order (declaration order among
+the components whose dependencies finished) instead of concurrently, so failures of tests which depend on
+the scheduling reproduce. The components execute the same code, only the scheduling differs; first-of
+dependencies don't cancel the other members of the group since all of them finished before the dependent starts.
+*/
+func (wf *Workflow[CT, C, T]) SetDeterministic(deterministic bool) {
+	wf.deterministic = deterministic
+}
+
+/* executionOrder: components in topological order, ties broken by declaration order */
+func (wf *Workflow[CT, C, T]) executionOrder() []*component[CT, C, T] {
+	pending := map[string]int{}
+	for _, dependents := range wf.dependencyManager.dependencyGraph {
+		for componentId, dep := range dependents {
+			if dep {
+				pending[componentId]++
+			}
+		}
+	}
+	components := wf.sortedComponents()
+	order := make([]*component[CT, C, T], 0, len(components))
+	done := map[string]bool{}
+	for len(order) < len(components) {
+		for _, c := range components {
+			if done[c.id] || pending[c.id] > 0 {
+				continue
+			}
+			done[c.id] = true
+			order = append(order, c)
+			for componentId, dep := range wf.dependencyManager.dependencyGraph[c.id] {
+				if dep {
+					pending[componentId]--
+				}
+			}
+			break
+		}
+	}
+	return order
+}
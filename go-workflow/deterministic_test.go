@@ -0,0 +1,54 @@
+package goworkflow_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDeterministic(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		order := []string{}
+		running, maxRunning := int32(0), int32(0)
+		step := func(name string) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+			return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+				if n := atomic.AddInt32(&running, 1); n > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, n)
+				}
+				defer atomic.AddInt32(&running, -1)
+				time.Sleep(time.Millisecond)
+				dt.Update(func(d *Data) { order = append(order, name) })
+				return nil
+			}
+		}
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetDeterministic(true)
+		combine := wf.AddComponent(goworkflow.MakeComponent("Combine", nil, step("Combine")))
+		a := wf.AddComponent(goworkflow.MakeComponent("A", nil, step("A")))
+		b := wf.AddComponent(goworkflow.MakeComponent("B", nil, step("B")))
+		wf.AddComponent(goworkflow.MakeComponent("C", nil, step("C")))
+		combine.AddDependencies(a, b)
+		b.AddDependencies(a)
+
+		_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		assert.Equal(t, []string{"A", "B", "Combine", "C"}, order)
+		assert.Equal(t, int32(1), maxRunning)
+	}
+}
+
+func TestSetDeterministicDependencyFailure(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetDeterministic(true)
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("", 0, assert.AnError)))
+	parse := wf.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 0, nil)))
+	parse.AddDependencies(ocr)
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, goworkflow.ERROR, parse.Status().Status)
+}
@@ -0,0 +1,137 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+/* DurationStats: distribution of the durations of a component across runs */
+type DurationStats struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+/* DurationStatsStore keeps per component durations across runs, e.g. in memory or a database shared by all instances */
+type DurationStatsStore interface {
+	RecordDuration(ctx context.Context, workflow string, component string, d time.Duration) error
+	// DurationStats: Count is 0 when nothing was recorded
+	DurationStats(ctx context.Context, workflow string, component string) (DurationStats, error)
+}
+
+/*
+SetDurationStats records the duration of every executed DONE component in store, in the background. Components
+restored from a checkpoint or from the cache didn't execute and are not recorded. Unless another estimator is set,
+the P50 of the store is used as duration estimate for Progress.
+*/
+func (wf *Workflow[CT, C, T]) SetDurationStats(store DurationStatsStore) {
+	wf.durationStats = store
+	if wf.durationEstimator == nil {
+		wf.durationEstimator = p50Estimator{store}
+	}
+	queue := wf.backgroundQueue()
+	wf.addStateListener(func(change StateChange) {
+		if change.Component == "" || change.NewStatus != DONE {
+			return
+		}
+		c, ok := wf.componentsMap[change.ComponentId]
+		if !ok {
+			return
+		}
+		c.statusLock.Lock()
+		d, executed := c.timing.Duration(), !c.timing.StartedAt.IsZero() && !c.timing.Cached
+		c.statusLock.Unlock()
+		if !executed || wf.restored[c.Name] {
+			return
+		}
+		workflow := wf.name
+		queue.dispatch(func() {
+			if err := store.RecordDuration(context.Background(), workflow, c.Name, d); err != nil {
+				log.Println("Workflow.SetDurationStats:Error:", err)
+			}
+		})
+	})
+}
+
+/* ComponentDurationStats: duration distribution of the component across runs, from the store set with SetDurationStats */
+func (wf *Workflow[CT, C, T]) ComponentDurationStats(ctx context.Context, component string) (DurationStats, error) {
+	if wf.durationStats == nil {
+		return DurationStats{}, errors.New("duration stats store is not set")
+	}
+	return wf.durationStats.DurationStats(ctx, wf.name, component)
+}
+
+type p50Estimator struct {
+	store DurationStatsStore
+}
+
+func (e p50Estimator) EstimateDuration(workflow string, component string) (time.Duration, bool) {
+	stats, err := e.store.DurationStats(context.Background(), workflow, component)
+	if err != nil || stats.Count == 0 {
+		return 0, false
+	}
+	return stats.P50, true
+}
+
+/* ComputeDurationStats: stats of samples, percentiles use the nearest rank */
+func ComputeDurationStats(samples []time.Duration) DurationStats {
+	stats := DurationStats{Count: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	stats.Mean = total / time.Duration(len(sorted))
+	stats.P50 = rank(0.50)
+	stats.P95 = rank(0.95)
+	stats.P99 = rank(0.99)
+	return stats
+}
+
+/* MemoryDurationStats keeps the latest samples of every component in memory */
+type MemoryDurationStats struct {
+	lock       sync.Mutex
+	maxSamples int
+	samples    map[[2]string][]time.Duration
+}
+
+/* NewMemoryDurationStats: maxSamples latest durations are kept per component, older ones are dropped */
+func NewMemoryDurationStats(maxSamples int) *MemoryDurationStats {
+	if maxSamples <= 0 {
+		panic("maxSamples must be positive")
+	}
+	return &MemoryDurationStats{maxSamples: maxSamples, samples: map[[2]string][]time.Duration{}}
+}
+
+func (m *MemoryDurationStats) RecordDuration(ctx context.Context, workflow string, component string, d time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := [2]string{workflow, component}
+	samples := append(m.samples[key], d)
+	if len(samples) > m.maxSamples {
+		samples = samples[len(samples)-m.maxSamples:]
+	}
+	m.samples[key] = samples
+	return nil
+}
+
+func (m *MemoryDurationStats) DurationStats(ctx context.Context, workflow string, component string) (DurationStats, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return ComputeDurationStats(m.samples[[2]string{workflow, component}]), nil
+}
@@ -0,0 +1,86 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDurationStats(t *testing.T) {
+	samples := []time.Duration{}
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	stats := goworkflow.ComputeDurationStats(samples)
+	assert.Equal(t, 100, stats.Count)
+	assert.Equal(t, 50*time.Millisecond, stats.P50)
+	assert.Equal(t, 95*time.Millisecond, stats.P95)
+	assert.Equal(t, 99*time.Millisecond, stats.P99)
+	assert.Equal(t, 50500*time.Microsecond, stats.Mean)
+
+	assert.Equal(t, goworkflow.DurationStats{}, goworkflow.ComputeDurationStats(nil))
+}
+
+func TestMemoryDurationStats(t *testing.T) {
+	store := goworkflow.NewMemoryDurationStats(2)
+	ctx := context.TODO()
+	for _, d := range []time.Duration{time.Second, 10 * time.Millisecond, 20 * time.Millisecond} {
+		assert.NoError(t, store.RecordDuration(ctx, "document", "OCR", d))
+	}
+	stats, err := store.DurationStats(ctx, "document", "OCR")
+	assert.NoError(t, err)
+	// the oldest sample was dropped
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 20*time.Millisecond, stats.P99)
+}
+
+func TestWorkflowDurationStats(t *testing.T) {
+	store := goworkflow.NewMemoryDurationStats(100)
+	for i := 0; i < 3; i++ {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("document")
+		wf.SetDurationStats(store)
+		wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 10*time.Millisecond, nil)))
+		if i > 0 {
+			assert.Greater(t, wf.Progress().Remaining, 5*time.Millisecond)
+		}
+		wf.Execute(context.TODO(), Config{}, &Data{})
+
+		stats, err := wf.ComponentDurationStats(context.TODO(), "OCR")
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, stats.Count)
+		assert.GreaterOrEqual(t, stats.P95, 10*time.Millisecond)
+	}
+
+	_, err := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO()).ComponentDurationStats(context.TODO(), "OCR")
+	assert.Error(t, err)
+}
+
+func TestDurationStatsSkipCached(t *testing.T) {
+	store := goworkflow.NewMemoryDurationStats(100)
+	cache := goworkflow.NewLRUCache(10)
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := tpl.AddComponent(goworkflow.MakeComponent("OCR", "invoice.pdf", func(ctx context.Context, input string, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(10 * time.Millisecond)
+		dt.Update(func(d *Data) { d.A = "text" })
+		return nil
+	}), &goworkflow.ComponentConfig{Writes: []string{"A"}, Cache: &goworkflow.CachePolicy{Cache: cache, Key: func(input goworkflow.ComponentInput) string { return input.(string) }}})
+	tpl.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 10*time.Millisecond, nil))).AddDependencies(ocr)
+
+	for i := 0; i < 2; i++ {
+		wf := tpl.NewWorkflow(context.TODO())
+		wf.SetDurationStats(store)
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.Equal(t, goworkflow.DONE, st)
+	}
+	// the second run hit the cache
+	stats, _ := store.DurationStats(context.TODO(), "document", "OCR")
+	assert.Equal(t, 1, stats.Count)
+	assert.GreaterOrEqual(t, stats.P50, 10*time.Millisecond)
+	stats, _ = store.DurationStats(context.TODO(), "document", "Parse")
+	assert.Equal(t, 2, stats.Count)
+	assert.GreaterOrEqual(t, stats.P50, 10*time.Millisecond)
+}
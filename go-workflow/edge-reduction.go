@@ -0,0 +1,24 @@
+package goworkflow
+
+/*
+ReduceEdges removes the dependencies implied by other dependencies (transitive reduction), e.g. C -> A when
+C -> B -> A, so generated graphs with many redundant edges schedule and render faster. Only dependencies which
+require success are removed, outcome and any-of dependencies are kept. It must be called before Execute and
+returns the removed edges, sorted.
+*/
+func (wf *Workflow[CT, C, T]) ReduceEdges() []Edge {
+	g := wf.topology()
+	removed := []Edge{}
+	d := wf.dependencyManager
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	// redundancy is decided on the full graph: removing an edge never changes the ancestors of a node
+	for _, node := range g.nodes {
+		for _, dep := range g.redundantDependencies(node) {
+			delete(d.dependencyGraph[dep], node)
+			removed = append(removed, Edge{From: g.name(dep), To: g.name(node)})
+		}
+	}
+	sortEdges(removed)
+	return removed
+}
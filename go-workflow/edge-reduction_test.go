@@ -0,0 +1,60 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReduceEdges(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	order := []string{}
+	step := func(name string) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { order = append(order, name) })
+			return nil
+		}
+	}
+	// generated chain where every step depends on all previous ones
+	steps := []goworkflow.Component[context.Context, Config, Data]{}
+	for i := 0; i < 5; i++ {
+		s := wf.AddComponent(goworkflow.MakeComponent(fmt.Sprint("Step", i), nil, step(fmt.Sprint("Step", i))))
+		s.AddDependencies(steps...)
+		steps = append(steps, s)
+	}
+	notify := wf.AddComponent(goworkflow.MakeComponent("Notify", nil, step("Notify")))
+	notify.AddDependenciesOn(goworkflow.OnCompletion, steps[0])
+	notify.AddDependencies(steps[1])
+	assert.Len(t, wf.Edges(), 12)
+
+	removed := wf.ReduceEdges()
+	assert.Len(t, removed, 6)
+	assert.Contains(t, removed, goworkflow.Edge{From: "Step0", To: "Step4"})
+	assert.Equal(t, []goworkflow.Edge{
+		{From: "Step0", To: "Notify"},
+		{From: "Step0", To: "Step1"},
+		{From: "Step1", To: "Notify"},
+		{From: "Step1", To: "Step2"},
+		{From: "Step2", To: "Step3"},
+		{From: "Step3", To: "Step4"},
+	}, wf.Edges())
+	assert.Empty(t, wf.ReduceEdges())
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, []string{"Step0", "Step1", "Step2", "Step3", "Step4"}, without(order, "Notify"))
+}
+
+func without(s []string, v string) []string {
+	result := []string{}
+	for _, e := range s {
+		if e != v {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,287 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+/* KMS manages the key encryption keys, e.g. AWS KMS, GCP KMS or Vault transit */
+type KMS interface {
+	// GenerateDataKey returns a fresh 256 bit data key, in plaintext and wrapped by the current key encryption key
+	GenerateDataKey(ctx context.Context) (plaintext []byte, wrapped []byte, keyId string, err error)
+	// DecryptDataKey unwraps a data key wrapped by the key encryption key keyId
+	DecryptDataKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error)
+}
+
+var ErrNotSealed = errors.New("not sealed by an envelope")
+
+/* ErrPlaintext: a store with an envelope read a payload which isn't encrypted, see EnvelopeOptions.Migration */
+var ErrPlaintext = errors.New("payload is not encrypted")
+
+type EnvelopeOptions struct {
+	// Migration: stores read payloads written before encryption was enabled (plaintext) and by the first envelope
+	// version (unauthenticated header) while they are rewritten, e.g. by filestore.Store.Compact. Otherwise anyone who
+	// can write to the store could plant data, disable it once migrated
+	Migration bool
+}
+
+/*
+Envelope encrypts payloads with AES-256-GCM under a fresh data key, the data key is stored wrapped by the KMS next to
+the ciphertext. The header (key id, wrapped key) is authenticated as additional data. Used for checkpoints, run
+history and artifacts at rest.
+*/
+type Envelope struct {
+	kms       KMS
+	migration bool
+}
+
+func NewEnvelope(kms KMS, opts ...*EnvelopeOptions) *Envelope {
+	if len(opts) > 1 {
+		panic("only one EnvelopeOptions is allowed")
+	}
+	e := &Envelope{kms: kms}
+	if len(opts) == 1 && opts[0] != nil {
+		e.migration = opts[0].Migration
+	}
+	return e
+}
+
+/* Migrating: plaintext payloads are accepted, see EnvelopeOptions.Migration */
+func (e *Envelope) Migrating() bool {
+	return e.migration
+}
+
+/* envelopeVersion 2 authenticates the header, version 1 envelopes are only opened while migrating */
+const envelopeVersion = 2
+const legacyEnvelopeVersion = 1
+
+/*
+Seal layout: version | key id length | key id | wrapped key length (2 bytes) | wrapped key | nonce | ciphertext,
+the fields before the nonce are the additional data of the ciphertext
+*/
+func (e *Envelope) Seal(ctx context.Context, plaintext []byte) ([]byte, error) {
+	key, wrapped, keyId, err := e.kms.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyId) > 255 || len(wrapped) > 65535 {
+		return nil, errors.New("key id or wrapped data key too long")
+	}
+	header := make([]byte, 0, 4+len(keyId)+len(wrapped))
+	header = append(header, envelopeVersion, byte(len(keyId)))
+	header = append(header, keyId...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	nonce, ciphertext, err := aesGCMSeal(key, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(ciphertext))
+	out = append(append(out, header...), nonce...)
+	return append(out, ciphertext...), nil
+}
+
+func (e *Envelope) Open(ctx context.Context, sealed []byte) ([]byte, error) {
+	if len(sealed) < 2 || sealed[0] != envelopeVersion && sealed[0] != legacyEnvelopeVersion {
+		return nil, ErrNotSealed
+	}
+	if sealed[0] == legacyEnvelopeVersion && !e.migration {
+		return nil, errors.New("envelope version 1 doesn't authenticate its header, see EnvelopeOptions.Migration")
+	}
+	r := bytes.NewReader(sealed[1:])
+	keyIdLen, _ := r.ReadByte()
+	keyId := make([]byte, keyIdLen)
+	var wrappedLen uint16
+	if _, err := io.ReadFull(r, keyId); err != nil {
+		return nil, ErrNotSealed
+	}
+	if err := binary.Read(r, binary.BigEndian, &wrappedLen); err != nil {
+		return nil, ErrNotSealed
+	}
+	wrapped := make([]byte, wrappedLen)
+	if _, err := io.ReadFull(r, wrapped); err != nil {
+		return nil, ErrNotSealed
+	}
+	header := sealed[:len(sealed)-r.Len()]
+	if sealed[0] == legacyEnvelopeVersion {
+		header = nil
+	}
+	rest, _ := io.ReadAll(r)
+	key, err := e.kms.DecryptDataKey(ctx, string(keyId), wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCMOpen(key, rest, header)
+}
+
+const sealedTextPrefix = "gwenc1:"
+
+/* SealText: Seal as a single line of text, for text based stores */
+func (e *Envelope) SealText(ctx context.Context, plaintext []byte) (string, error) {
+	sealed, err := e.Seal(ctx, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return sealedTextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *Envelope) OpenText(ctx context.Context, text string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(text, sealedTextPrefix)
+	if !ok {
+		return nil, ErrNotSealed
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return e.Open(ctx, sealed)
+}
+
+func IsSealedText(text string) bool {
+	return strings.HasPrefix(text, sealedTextPrefix)
+}
+
+func aesGCMSeal(key []byte, plaintext []byte, additionalData []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+/* aesGCMOpen: sealed is nonce followed by the ciphertext */
+func aesGCMOpen(key []byte, sealed []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrNotSealed
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
+
+/*
+LocalKMS wraps data keys with 256 bit master keys held in memory, e.g. loaded from a secrets provider.
+Rotate by adding a new key, data keys wrapped by older keys can still be decrypted as long as those keys are kept.
+*/
+type LocalKMS struct {
+	lock    sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+func NewLocalKMS(keyId string, masterKey []byte) (*LocalKMS, error) {
+	k := &LocalKMS{keys: map[string][]byte{}}
+	return k, k.AddKey(keyId, masterKey)
+}
+
+/* AddKey adds a master key and makes it the current one */
+func (k *LocalKMS) AddKey(keyId string, masterKey []byte) error {
+	if len(masterKey) != 32 {
+		return fmt.Errorf("master key %s must be 32 bytes, got %d", keyId, len(masterKey))
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.keys[keyId] = append([]byte{}, masterKey...)
+	k.current = keyId
+	return nil
+}
+
+func (k *LocalKMS) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	k.lock.RLock()
+	keyId, masterKey := k.current, k.keys[k.current]
+	k.lock.RUnlock()
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, "", err
+	}
+	nonce, wrapped, err := aesGCMSeal(masterKey, dataKey, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return dataKey, append(nonce, wrapped...), keyId, nil
+}
+
+func (k *LocalKMS) DecryptDataKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error) {
+	k.lock.RLock()
+	masterKey, ok := k.keys[keyId]
+	k.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown master key: %s", keyId)
+	}
+	return aesGCMOpen(masterKey, wrapped, nil)
+}
+
+/* EncryptedArtifactStore seals artifacts before they reach store, refs are the refs of store */
+type EncryptedArtifactStore struct {
+	store    ArtifactStore
+	envelope *Envelope
+}
+
+func NewEncryptedArtifactStore(store ArtifactStore, envelope *Envelope) *EncryptedArtifactStore {
+	return &EncryptedArtifactStore{store: store, envelope: envelope}
+}
+
+func (s *EncryptedArtifactStore) Put(ctx context.Context, key string, content io.Reader) (ArtifactRef, error) {
+	plaintext, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := s.envelope.Seal(ctx, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return s.store.Put(ctx, key, bytes.NewReader(sealed))
+}
+
+func (s *EncryptedArtifactStore) Get(ctx context.Context, ref ArtifactRef) (io.ReadCloser, error) {
+	r, err := s.store.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.envelope.Open(ctx, sealed)
+	if errors.Is(err, ErrNotSealed) && s.envelope.Migrating() {
+		// stored before encryption was enabled
+		return io.NopCloser(bytes.NewReader(sealed)), nil
+	}
+	if errors.Is(err, ErrNotSealed) {
+		return nil, fmt.Errorf("%w: artifact %s", ErrPlaintext, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+func (s *EncryptedArtifactStore) Delete(ctx context.Context, ref ArtifactRef) error {
+	return s.store.Delete(ctx, ref)
+}
@@ -0,0 +1,86 @@
+package goworkflow_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	kms, err := goworkflow.NewLocalKMS("key-1", bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	envelope := goworkflow.NewEnvelope(kms)
+
+	sealed, err := envelope.Seal(context.TODO(), []byte("patient name"))
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(sealed, []byte("patient name")))
+
+	// rotation: old payloads can still be opened
+	assert.NoError(t, kms.AddKey("key-2", bytes.Repeat([]byte{2}, 32)))
+	plaintext, err := envelope.Open(context.TODO(), sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "patient name", string(plaintext))
+
+	text, err := envelope.SealText(context.TODO(), []byte("patient name"))
+	assert.NoError(t, err)
+	assert.True(t, goworkflow.IsSealedText(text))
+	plaintext, err = envelope.OpenText(context.TODO(), text)
+	assert.NoError(t, err)
+	assert.Equal(t, "patient name", string(plaintext))
+
+	// the header is authenticated: a downgrade to version 1 doesn't open, even while migrating
+	downgraded := append([]byte{1}, sealed[1:]...)
+	_, err = envelope.Open(context.TODO(), downgraded)
+	assert.Error(t, err)
+	migrating := goworkflow.NewEnvelope(kms, &goworkflow.EnvelopeOptions{Migration: true})
+	assert.True(t, migrating.Migrating())
+	_, err = migrating.Open(context.TODO(), downgraded)
+	assert.Error(t, err)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = envelope.Open(context.TODO(), sealed)
+	assert.Error(t, err)
+	_, err = envelope.Open(context.TODO(), []byte("plain"))
+	assert.ErrorIs(t, err, goworkflow.ErrNotSealed)
+
+	other, _ := goworkflow.NewLocalKMS("key-3", bytes.Repeat([]byte{3}, 32))
+	_, err = goworkflow.NewEnvelope(other).OpenText(context.TODO(), text)
+	assert.Error(t, err)
+
+	_, err = goworkflow.NewLocalKMS("short", []byte("too short"))
+	assert.Error(t, err)
+}
+
+func TestEncryptedArtifactStore(t *testing.T) {
+	kms, _ := goworkflow.NewLocalKMS("key-1", bytes.Repeat([]byte{1}, 32))
+	inner := goworkflow.NewMemoryArtifactStore()
+	store := goworkflow.NewEncryptedArtifactStore(inner, goworkflow.NewEnvelope(kms))
+
+	ref, err := store.Put(context.TODO(), "run-1/OCR/page.txt", strings.NewReader("patient name"))
+	assert.NoError(t, err)
+
+	r, _ := inner.Get(context.TODO(), ref)
+	raw, _ := io.ReadAll(r)
+	assert.NotContains(t, string(raw), "patient name")
+
+	r, err = store.Get(context.TODO(), ref)
+	assert.NoError(t, err)
+	plaintext, _ := io.ReadAll(r)
+	assert.Equal(t, "patient name", string(plaintext))
+	assert.NoError(t, store.Delete(context.TODO(), ref))
+
+	// artifacts stored before encryption was enabled
+	ref, _ = inner.Put(context.TODO(), "run-1/OCR/old.txt", strings.NewReader("old page"))
+	_, err = store.Get(context.TODO(), ref)
+	assert.ErrorIs(t, err, goworkflow.ErrPlaintext)
+	migrating := goworkflow.NewEncryptedArtifactStore(inner, goworkflow.NewEnvelope(kms, &goworkflow.EnvelopeOptions{Migration: true}))
+	r, err = migrating.Get(context.TODO(), ref)
+	assert.NoError(t, err)
+	plaintext, _ = io.ReadAll(r)
+	assert.Equal(t, "old page", string(plaintext))
+}
@@ -0,0 +1,255 @@
+/*
+Package eventbus publishes the lifecycle events of workflow runs (workflow and component started, succeeded,
+failed, skipped) to sinks, e.g. an HTTP endpoint or a Kafka topic. Events are encoded once per event with the
+Encoding of the bus: plain JSON or CloudEvents, so downstream systems consume them without a custom schema.
+SSEHandler streams them (and partial results, see AttachPartialResults) to browsers as Server-Sent Events.
+*/
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+const (
+	WorkflowStarted    = "workflow.started"
+	WorkflowSucceeded  = "workflow.succeeded"
+	WorkflowFailed     = "workflow.failed"
+	ComponentStarted   = "component.started"
+	ComponentSucceeded = "component.succeeded"
+	ComponentFailed    = "component.failed"
+	ComponentSkipped   = "component.skipped"
+	// ComponentResult: partial result of a finished component, see AttachPartialResults
+	ComponentResult = "component.result"
+)
+
+/* Event is a lifecycle event of a workflow run (Component is empty) or of one of its components */
+type Event struct {
+	Type       string `json:"type"`
+	Workflow   string `json:"workflow"`
+	WorkflowId string `json:"workflowId"`
+	// ParentWorkflowId: run in which the workflow was started, see goworkflow.ParentRunId
+	ParentWorkflowId string            `json:"parentWorkflowId,omitempty"`
+	Component        string            `json:"component,omitempty"`
+	ComponentId      string            `json:"componentId,omitempty"`
+	Status           goworkflow.Status `json:"status"`
+	OldStatus        goworkflow.Status `json:"oldStatus"`
+	Cause            string            `json:"cause,omitempty"`
+	Time             time.Time         `json:"time"`
+	// Result: json encoded PartialResult of ComponentResult events
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+/* Message is an encoded event, Key is the partition key of the event (the workflow id) */
+type Message struct {
+	Type        string
+	Key         string
+	ContentType string
+	Body        []byte
+}
+
+type Encoding interface {
+	Encode(event Event) (Message, error)
+}
+
+/* JSON encodes the events as plain json */
+type JSON struct{}
+
+func (JSON) Encode(event Event) (Message, error) {
+	body, err := json.Marshal(event)
+	return Message{Type: event.Type, Key: event.WorkflowId, ContentType: "application/json", Body: body}, err
+}
+
+/* Sink delivers the messages, e.g. HTTPSink or KafkaSink */
+type Sink interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+type Options struct {
+	// Encoding of the events, JSON when nil
+	Encoding Encoding
+	// QueueSize: events buffered for the sinks, 1024 when 0. Events are dropped when the queue is full
+	QueueSize int
+	// Timeout of a delivery to a sink, 10s when 0
+	Timeout time.Duration
+}
+
+/* Bus delivers the events to its sinks in order, without blocking the workflows */
+type Bus struct {
+	encoding Encoding
+	timeout  time.Duration
+	lock     sync.RWMutex
+	sinks    []Sink
+	closed   bool
+	events   chan Event
+	done     chan struct{}
+}
+
+func NewBus(opts ...*Options) *Bus {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	opt := &Options{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	b := &Bus{encoding: opt.Encoding, timeout: opt.Timeout, done: make(chan struct{})}
+	if b.encoding == nil {
+		b.encoding = JSON{}
+	}
+	if b.timeout == 0 {
+		b.timeout = 10 * time.Second
+	}
+	queueSize := opt.QueueSize
+	if queueSize == 0 {
+		queueSize = 1024
+	}
+	b.events = make(chan Event, queueSize)
+	go b.deliver()
+	return b
+}
+
+func (b *Bus) Subscribe(sink Sink) {
+	if sink == nil {
+		panic("sink cannot be nil")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+/* Publish queues event for the sinks, it returns false when the queue is full or the bus is closed */
+func (b *Bus) Publish(event Event) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.events <- event:
+		return true
+	default:
+		log.Println("Bus.Publish:Error:queue full, dropping event", event.Type, event.WorkflowId)
+		return false
+	}
+}
+
+/* Close delivers the queued events and stops the bus, it returns when the events were delivered or ctx is done */
+func (b *Bus) Close(ctx context.Context) error {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.events)
+	}
+	b.lock.Unlock()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) deliver() {
+	defer close(b.done)
+	for event := range b.events {
+		message, err := b.encoding.Encode(event)
+		if err != nil {
+			log.Println("Bus.deliver:Error:", err)
+			continue
+		}
+		b.lock.RLock()
+		sinks := b.sinks
+		b.lock.RUnlock()
+		for _, sink := range sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+			if err := sink.Publish(ctx, message); err != nil {
+				log.Println("Bus.deliver:Error:", err)
+			}
+			cancel()
+		}
+	}
+}
+
+/* Attach publishes the lifecycle events of wf on bus, it must be called before wf.Execute */
+func Attach[CT context.Context, C any, T any](bus *Bus, wf *goworkflow.Workflow[CT, C, T]) {
+	wf.AddStateListener(func(change goworkflow.StateChange) {
+		eventType := lifecycleEvent(change)
+		if eventType == "" {
+			return
+		}
+		bus.Publish(Event{
+			Type:             eventType,
+			Workflow:         wf.Name(),
+			WorkflowId:       change.WorkflowId,
+			ParentWorkflowId: goworkflow.ParentRunId(change.WorkflowId),
+			Component:        change.Component,
+			ComponentId:      change.ComponentId,
+			Status:           change.NewStatus,
+			OldStatus:        change.OldStatus,
+			Cause:            change.Cause,
+			Time:             change.Time,
+		})
+	})
+}
+
+type partialResult[T any] struct {
+	Snapshot *T                       `json:"snapshot,omitempty"`
+	Changes  []goworkflow.FieldChange `json:"changes,omitempty"`
+}
+
+/*
+AttachPartialResults publishes a ComponentResult event with the data store snapshot (and the changed fields with
+opts Changes) every time a component of wf finished, e.g. for SSEHandler clients rendering results progressively.
+It must be called before wf.Execute.
+*/
+func AttachPartialResults[CT context.Context, C any, T any](bus *Bus, wf *goworkflow.Workflow[CT, C, T], opts ...*goworkflow.PartialResultOptions) {
+	wf.OnPartialResult(func(result goworkflow.PartialResult[T]) {
+		body, err := json.Marshal(partialResult[T]{Snapshot: result.Snapshot, Changes: result.Changes})
+		if err != nil {
+			log.Println("AttachPartialResults:Error:", err)
+			return
+		}
+		bus.Publish(Event{
+			Type:             ComponentResult,
+			Workflow:         wf.Name(),
+			WorkflowId:       wf.Id(),
+			ParentWorkflowId: goworkflow.ParentRunId(wf.Id()),
+			Component:        result.Component,
+			ComponentId:      result.ComponentId,
+			Status:           result.Status,
+			Time:             time.Now(),
+			Result:           body,
+		})
+	}, opts...)
+}
+
+func lifecycleEvent(change goworkflow.StateChange) string {
+	if change.Component == "" {
+		switch change.NewStatus {
+		case goworkflow.RUNNING:
+			return WorkflowStarted
+		case goworkflow.DONE, goworkflow.DONE_WITH_WARNINGS:
+			return WorkflowSucceeded
+		case goworkflow.ERROR, goworkflow.QUARANTINED:
+			return WorkflowFailed
+		}
+		return ""
+	}
+	switch change.NewStatus {
+	case goworkflow.RUNNING:
+		return ComponentStarted
+	case goworkflow.DONE, goworkflow.DONE_WITH_WARNINGS:
+		return ComponentSucceeded
+	case goworkflow.ERROR:
+		return ComponentFailed
+	case goworkflow.SKIPPED:
+		return ComponentSkipped
+	}
+	return ""
+}
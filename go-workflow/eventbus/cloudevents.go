@@ -0,0 +1,61 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const DefaultTypePrefix = "org.metaphi.goworkflow."
+
+/*
+CloudEvents encodes the events as CloudEvents 1.0 in structured mode (application/cloudevents+json), which the
+HTTP and the Kafka protocol bindings both support. The event id is unique per state change, the subject is
+the component, the workflow id is the partition key and the goworkflowid extension.
+*/
+type CloudEvents struct {
+	// Source: URI reference of the producer, e.g. /services/document-processing
+	Source string
+	// TypePrefix of the event types, DefaultTypePrefix when empty
+	TypePrefix string
+}
+
+type cloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	Id              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time"`
+	DataContentType string `json:"datacontenttype"`
+	WorkflowId      string `json:"goworkflowid"`
+	PartitionKey    string `json:"partitionkey"`
+	Data            Event  `json:"data"`
+}
+
+func (c CloudEvents) Encode(event Event) (Message, error) {
+	if c.Source == "" {
+		return Message{}, fmt.Errorf("cloudevents source is required")
+	}
+	prefix := c.TypePrefix
+	if prefix == "" {
+		prefix = DefaultTypePrefix
+	}
+	id := event.WorkflowId
+	if event.ComponentId != "" {
+		id = event.ComponentId
+	}
+	body, err := json.Marshal(cloudEvent{
+		SpecVersion:     "1.0",
+		Id:              fmt.Sprintf("%s-%s-%d", id, event.Status, event.Time.UnixNano()),
+		Source:          c.Source,
+		Type:            prefix + event.Type,
+		Subject:         event.Component,
+		Time:            event.Time.UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		WorkflowId:      event.WorkflowId,
+		PartitionKey:    event.WorkflowId,
+		Data:            event,
+	})
+	return Message{Type: event.Type, Key: event.WorkflowId, ContentType: "application/cloudevents+json", Body: body}, err
+}
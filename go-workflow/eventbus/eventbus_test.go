@@ -0,0 +1,158 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{}
+
+type Data struct{}
+
+type record struct {
+	topic   string
+	key     string
+	value   []byte
+	headers map[string]string
+}
+
+type fakeProducer struct {
+	lock    sync.Mutex
+	records []record
+}
+
+func (p *fakeProducer) Produce(ctx context.Context, topic string, key []byte, value []byte, headers map[string]string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.records = append(p.records, record{topic, string(key), value, headers})
+	return nil
+}
+
+func newWorkflow() *goworkflow.Workflow[context.Context, Config, Data] {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("invoices")
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("scan unreadable")
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	})).AddDependencies(ocr)
+	return wf
+}
+
+func TestKafkaCloudEvents(t *testing.T) {
+	producer := &fakeProducer{}
+	bus := NewBus(&Options{Encoding: CloudEvents{Source: "/services/documents"}})
+	bus.Subscribe(&KafkaSink{Topic: "workflow-events", Producer: producer})
+	wf := newWorkflow()
+	Attach(bus, wf)
+	wf.Execute(context.TODO(), Config{}, &Data{})
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	assert.NoError(t, bus.Close(ctx))
+
+	types := []string{}
+	for _, r := range producer.records {
+		assert.Equal(t, "workflow-events", r.topic)
+		assert.Equal(t, wf.Id(), r.key)
+		assert.Equal(t, "application/cloudevents+json", r.headers["content-type"])
+		var ce map[string]any
+		assert.NoError(t, json.Unmarshal(r.value, &ce))
+		assert.Equal(t, "1.0", ce["specversion"])
+		assert.Equal(t, "/services/documents", ce["source"])
+		assert.NotEmpty(t, ce["id"])
+		types = append(types, ce["type"].(string))
+	}
+	// Extract never starts, it fails because of its dependency
+	assert.Equal(t, []string{
+		"org.metaphi.goworkflow.workflow.started", "org.metaphi.goworkflow.component.started",
+		"org.metaphi.goworkflow.component.failed", "org.metaphi.goworkflow.component.failed",
+		"org.metaphi.goworkflow.workflow.failed",
+	}, types)
+
+	var failed map[string]any
+	json.Unmarshal(producer.records[2].value, &failed)
+	assert.Equal(t, "OCR", failed["subject"])
+	assert.Equal(t, "scan unreadable", failed["data"].(map[string]any)["cause"])
+	assert.False(t, bus.Publish(Event{Type: WorkflowStarted}))
+}
+
+func TestHTTPSink(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	bus := NewBus()
+	bus.Subscribe(&HTTPSink{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	assert.True(t, bus.Publish(Event{Type: WorkflowStarted, Workflow: "invoices", WorkflowId: "run-1", Status: goworkflow.RUNNING}))
+	assert.NoError(t, bus.Close(context.TODO()))
+
+	var event Event
+	assert.NoError(t, json.Unmarshal(<-bodies, &event))
+	assert.Equal(t, WorkflowStarted, event.Type)
+	assert.Equal(t, "run-1", event.WorkflowId)
+}
+
+func TestCloudEventsRequiresSource(t *testing.T) {
+	_, err := CloudEvents{}.Encode(Event{Type: WorkflowStarted})
+	assert.Error(t, err)
+}
+
+func TestSSEHandler(t *testing.T) {
+	handler := &SSEHandler{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	bus := NewBus()
+	bus.Subscribe(handler)
+	wf := newWorkflow()
+	Attach(bus, wf)
+	AttachPartialResults(bus, wf)
+
+	resp, err := http.Get(server.URL + "?workflowId=" + wf.Id())
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	// events of other runs are filtered out
+	bus.Publish(Event{Type: WorkflowStarted, WorkflowId: "other"})
+	wf.Execute(context.TODO(), Config{}, &Data{})
+
+	// the stream ends after the workflow event
+	names := []string{}
+	results := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			names = append(names, name)
+		}
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var event Event
+			assert.NoError(t, json.Unmarshal([]byte(data), &event))
+			assert.Equal(t, wf.Id(), event.WorkflowId)
+			if event.Type == ComponentResult {
+				results++
+				assert.JSONEq(t, `{"snapshot": {}}`, string(event.Result))
+			}
+		}
+	}
+	assert.Equal(t, WorkflowStarted, names[0])
+	assert.Equal(t, WorkflowFailed, names[len(names)-1])
+	assert.Equal(t, 2, results)
+	assert.NoError(t, bus.Close(context.TODO()))
+}
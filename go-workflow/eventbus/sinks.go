@@ -0,0 +1,56 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+/* HTTPSink posts the messages to URL, e.g. a webhook or a Knative broker */
+type HTTPSink struct {
+	URL string
+	// Headers added to the requests, e.g. authorization
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, message Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(message.Body))
+	if err != nil {
+		return err
+	}
+	for key, value := range s.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", message.ContentType)
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink %s returned status %d", s.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+/* KafkaProducer writes a record to a topic, implemented with the Kafka client of the service (kafka-go, sarama...) */
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key []byte, value []byte, headers map[string]string) error
+}
+
+/* KafkaSink produces the messages to Topic, keyed by workflow id so the events of a run stay ordered */
+type KafkaSink struct {
+	Topic    string
+	Producer KafkaProducer
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, message Message) error {
+	headers := map[string]string{"content-type": message.ContentType}
+	return s.Producer.Produce(ctx, s.Topic, []byte(message.Key), message.Body, headers)
+}
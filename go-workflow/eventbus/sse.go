@@ -0,0 +1,115 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+/*
+SSEHandler is a Sink streaming the events to HTTP clients as Server-Sent Events, e.g. for frontends showing the
+progress of a run. GET ?workflowId=<id> streams the events of one run and ends after its workflow event
+(succeeded or failed), without workflowId all events are streamed until the client disconnects.
+The SSE event name is the event type, the data the encoded event. Clients only receive the events published
+after they connected, events for clients which don't keep up with ClientBuffer are dropped.
+*/
+type SSEHandler struct {
+	// ClientBuffer: events buffered per client, 256 when 0
+	ClientBuffer int
+
+	lock    sync.Mutex
+	clients map[*sseClient]bool
+}
+
+type sseClient struct {
+	workflowId string
+	messages   chan Message
+}
+
+func (h *SSEHandler) Publish(ctx context.Context, message Message) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for client := range h.clients {
+		if client.workflowId != "" && client.workflowId != message.Key {
+			continue
+		}
+		select {
+		case client.messages <- message:
+		default:
+			log.Println("SSEHandler.Publish:Error:client too slow, dropping event", message.Type, message.Key)
+		}
+	}
+	return nil
+}
+
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	client := h.subscribe(r.URL.Query().Get("workflowId"))
+	defer h.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message := <-client.messages:
+			if err := writeSSE(w, message); err != nil {
+				return
+			}
+			flusher.Flush()
+			if client.workflowId != "" && (message.Type == WorkflowSucceeded || message.Type == WorkflowFailed) {
+				return
+			}
+		}
+	}
+}
+
+func (h *SSEHandler) subscribe(workflowId string) *sseClient {
+	buffer := h.ClientBuffer
+	if buffer == 0 {
+		buffer = 256
+	}
+	client := &sseClient{workflowId: workflowId, messages: make(chan Message, buffer)}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.clients == nil {
+		h.clients = map[*sseClient]bool{}
+	}
+	h.clients[client] = true
+	return client
+}
+
+func (h *SSEHandler) unsubscribe(client *sseClient) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.clients, client)
+}
+
+/* writeSSE: one event, multi line bodies are sent as several data lines */
+func writeSSE(w http.ResponseWriter, message Message) error {
+	if _, err := fmt.Fprintf(w, "event: %s\n", message.Type); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(message.Body), "\n") {
+		if _, err := fmt.Fprintf(w, "data: %s\n", line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, "\n")
+	return err
+}
@@ -0,0 +1,218 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+)
+
+/* ExecTemplateData is what the command and stdin templates are executed with */
+type ExecTemplateData[C any, T any] struct {
+	Config C
+	Data   T
+	Input  ComponentInput
+}
+
+/* ExecResult: outcome of a command, passed to ExecOptions.Output */
+type ExecResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+type ExecOptions[T any] struct {
+	// Stdin: text/template of the standard input, like the command. No input when empty
+	Stdin string
+	Dir   string
+	// Env: additional environment variables (key=value), the environment of the process is inherited
+	Env []string
+	// Timeout of the command, none when 0. The command gets SIGTERM on timeout or cancellation, then KillGrace
+	// to exit before it is killed
+	Timeout   time.Duration
+	KillGrace time.Duration
+	// Output stores the result of a successful command in the data store, e.g. the extracted text of stdout
+	Output func(data *T, result ExecResult)
+	// RetryableExitCodes: exit codes of transient failures, all other failures are permanent (see PermanentError).
+	// All failures are retryable when empty, except FatalExitCodes
+	RetryableExitCodes []int
+	FatalExitCodes     []int
+}
+
+/* ExecError: the command failed, Stderr is the end of its standard error */
+type ExecError struct {
+	Command  string
+	ExitCode int
+	TimedOut bool
+	Stderr   string
+}
+
+func (e *ExecError) Error() string {
+	cause := fmt.Sprintf("exit code %d", e.ExitCode)
+	if e.TimedOut {
+		cause = "timed out"
+	}
+	if e.Stderr == "" {
+		return fmt.Sprintf("command %s failed: %s", e.Command, cause)
+	}
+	return fmt.Sprintf("command %s failed: %s: %s", e.Command, cause, e.Stderr)
+}
+
+const execStderrTail = 2048
+
+/*
+MakeExecComponent: component running an external command, e.g. "pdftotext -r {{.Config.DPI}} {{.Data.Path}} -".
+The command is split into arguments (single and double quotes group words) before every argument is executed as
+text/template over ExecTemplateData, so templated values never become separate arguments and no shell is involved.
+Invalid templates panic.
+*/
+func MakeExecComponent[CT context.Context, C any, T any](name string, cmdTemplate string, opts ...*ExecOptions[T]) makeComponentConfig[CT, C, T] {
+	if len(opts) > 1 {
+		panic("only one ExecOptions is allowed")
+	}
+	opt := &ExecOptions[T]{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	words, err := splitCommand(cmdTemplate)
+	if err != nil {
+		panic(fmt.Sprintf("invalid command of %s: %v", name, err))
+	}
+	if len(words) == 0 {
+		panic(fmt.Sprintf("command of %s cannot be empty", name))
+	}
+	args := make([]*template.Template, len(words))
+	for i, word := range words {
+		args[i] = template.Must(template.New(fmt.Sprintf("%s arg %d", name, i)).Option("missingkey=error").Parse(word))
+	}
+	var stdin *template.Template
+	if opt.Stdin != "" {
+		stdin = template.Must(template.New(name + " stdin").Option("missingkey=error").Parse(opt.Stdin))
+	}
+
+	return makeComponentConfig[CT, C, T]{
+		Name: name,
+		Executor: func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+			templateData := ExecTemplateData[C, T]{Config: dt.Config, Data: dt.GetData(), Input: ci}
+			argv := make([]string, len(args))
+			for i, arg := range args {
+				rendered := &strings.Builder{}
+				if err := arg.Execute(rendered, templateData); err != nil {
+					return Permanent(fmt.Errorf("command of %s: %w", name, err))
+				}
+				argv[i] = rendered.String()
+			}
+			runCtx := dt.Context()
+			if opt.Timeout > 0 {
+				var cancel context.CancelFunc
+				runCtx, cancel = context.WithTimeout(runCtx, opt.Timeout)
+				defer cancel()
+			}
+			cmd := exec.CommandContext(runCtx, argv[0], argv[1:]...)
+			cmd.Dir = opt.Dir
+			if len(opt.Env) > 0 {
+				cmd.Env = append(cmd.Environ(), opt.Env...)
+			}
+			cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+			cmd.WaitDelay = opt.KillGrace
+			if cmd.WaitDelay == 0 {
+				cmd.WaitDelay = 5 * time.Second
+			}
+			if stdin != nil {
+				input := &bytes.Buffer{}
+				if err := stdin.Execute(input, templateData); err != nil {
+					return Permanent(fmt.Errorf("stdin of %s: %w", name, err))
+				}
+				cmd.Stdin = input
+			}
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd.Stdout, cmd.Stderr = stdout, stderr
+
+			started := time.Now()
+			err := cmd.Run()
+			result := ExecResult{ExitCode: cmd.ProcessState.ExitCode(), Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), Duration: time.Since(started)}
+			if err != nil {
+				var exitErr *exec.ExitError
+				if cmd.ProcessState == nil || (!errors.As(err, &exitErr) && runCtx.Err() == nil) {
+					// the command doesn't exist or can't be executed
+					return Permanent(fmt.Errorf("command %s of %s: %w", argv[0], name, err))
+				}
+				tail := stderr.Bytes()
+				if len(tail) > execStderrTail {
+					tail = tail[len(tail)-execStderrTail:]
+				}
+				execErr := &ExecError{Command: argv[0], ExitCode: result.ExitCode, TimedOut: runCtx.Err() != nil, Stderr: strings.TrimSpace(string(tail))}
+				if !execErr.TimedOut && !opt.retryable(execErr.ExitCode) {
+					return Permanent(execErr)
+				}
+				return execErr
+			}
+			if opt.Output != nil {
+				dt.Update(func(data *T) {
+					opt.Output(data, result)
+				})
+			}
+			return nil
+		},
+	}
+}
+
+func (o *ExecOptions[T]) retryable(exitCode int) bool {
+	if slices.Contains(o.FatalExitCodes, exitCode) {
+		return false
+	}
+	return len(o.RetryableExitCodes) == 0 || slices.Contains(o.RetryableExitCodes, exitCode)
+}
+
+/* splitCommand splits a command into words, quotes group words and are removed, template actions are kept intact */
+func splitCommand(command string) ([]string, error) {
+	words := []string{}
+	word := &strings.Builder{}
+	inWord := false
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		if strings.HasPrefix(command[i:], "{{") {
+			end := strings.Index(command[i:], "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated template action")
+			}
+			word.WriteString(command[i : i+end+2])
+			inWord = true
+			i += end + 1
+			continue
+		}
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
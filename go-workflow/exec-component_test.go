@@ -0,0 +1,92 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecComponent(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	c := wf.AddComponent(goworkflow.MakeExecComponent[context.Context, Config]("Shout", `sh -c 'tr a-z A-Z; echo "$0" >&2' "{{.Data.A}} {{.Data.B}}"`, &goworkflow.ExecOptions[Data]{
+		Stdin: "{{.Data.A}}",
+		Output: func(data *Data, result goworkflow.ExecResult) {
+			data.Combined = string(result.Stdout)
+			data.C = string(result.Stderr)
+		},
+	}))
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{A: "hello", B: "world; rm -rf /"})
+	assert.Equal(t, goworkflow.DONE, st, c.Status().ErrorMessage)
+	assert.Equal(t, "HELLO", data.Combined)
+	// templated values stay one argument, no shell interprets them
+	assert.Equal(t, "hello world; rm -rf /\n", data.C)
+}
+
+func TestExecComponentExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "attempts")
+	run := func(exitCode string, opts *goworkflow.ExecOptions[Data]) int {
+		os.Remove(counter)
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		c := wf.AddComponent(goworkflow.MakeExecComponent[context.Context, Config]("Convert", `sh -c 'echo x >> `+counter+`; echo "failed with {{.Data.A}}" >&2; exit {{.Data.A}}'`, opts),
+			&goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}})
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{A: exitCode})
+		assert.Equal(t, goworkflow.ERROR, st)
+		raw, _ := os.ReadFile(counter)
+		assert.Contains(t, c.Status().ErrorMessage, "failed with "+exitCode)
+		return len(raw) / 2
+	}
+
+	attempts := run("75", &goworkflow.ExecOptions[Data]{RetryableExitCodes: []int{75}})
+	assert.Equal(t, 3, attempts)
+	attempts = run("2", &goworkflow.ExecOptions[Data]{RetryableExitCodes: []int{75}})
+	assert.Equal(t, 1, attempts)
+	attempts = run("2", &goworkflow.ExecOptions[Data]{FatalExitCodes: []int{2}})
+	assert.Equal(t, 1, attempts)
+	attempts = run("1", nil)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestExecComponentTimeout(t *testing.T) {
+	var execErr *goworkflow.ExecError
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeExecComponent[context.Context, Config]("Sleep", "sleep 10", &goworkflow.ExecOptions[Data]{
+		Timeout:   50 * time.Millisecond,
+		KillGrace: 50 * time.Millisecond,
+	}), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{
+		MaxAttempts: 2,
+		Retryable: func(err error) bool {
+			assert.True(t, errors.As(err, &execErr))
+			return false
+		},
+	}})
+	started := time.Now()
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.True(t, execErr.TimedOut)
+}
+
+func TestExecComponentInvalid(t *testing.T) {
+	assert.Panics(t, func() { goworkflow.MakeExecComponent[context.Context, Config, Data]("Bad", "echo {{.Data.A") })
+	assert.Panics(t, func() { goworkflow.MakeExecComponent[context.Context, Config, Data]("Bad", `echo "unterminated`) })
+
+	attempts := 0
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	c := wf.AddComponent(goworkflow.MakeExecComponent[context.Context, Config, Data]("Missing", "./does-not-exist"),
+		&goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool {
+			attempts++
+			return true
+		}}})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	// a missing command is permanent, it is never retried
+	assert.Equal(t, 0, attempts)
+	assert.Contains(t, c.Status().ErrorMessage, "does-not-exist")
+}
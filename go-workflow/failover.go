@@ -0,0 +1,170 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+/* FailoverOptions: health tracking of the endpoints of a Failover */
+type FailoverOptions struct {
+	// FailureThreshold: consecutive failures after which an endpoint is unhealthy, 1 when 0
+	FailureThreshold int
+	// Cooldown: how long an unhealthy endpoint is skipped before it is tried again, 30s when 0
+	Cooldown time.Duration
+}
+
+/*
+Failover routes the executions of components to an ordered list of endpoints, e.g. the regions of an OCR
+provider: every attempt gets the first healthy endpoint (see EndpointFromContext), so an outage of the
+primary region shifts the traffic to the next one until its cooldown ended. Retries of a failed attempt go to
+the next endpoint it hasn't tried yet. Share one Failover between the runs calling the same provider, see
+ComponentConfig.Failover.
+*/
+type Failover struct {
+	lock      sync.Mutex
+	opts      FailoverOptions
+	endpoints []string
+	health    map[string]*EndpointHealth
+}
+
+/* EndpointHealth: health of an endpoint of a Failover */
+type EndpointHealth struct {
+	Endpoint            string
+	Healthy             bool
+	ConsecutiveFailures int
+	// UnhealthyUntil: end of the cooldown of an unhealthy endpoint
+	UnhealthyUntil time.Time
+}
+
+func NewFailover(endpoints []string, opts ...*FailoverOptions) *Failover {
+	if len(opts) > 1 {
+		panic("only one FailoverOptions is allowed")
+	}
+	if len(endpoints) == 0 {
+		panic("failover needs at least one endpoint")
+	}
+	f := &Failover{endpoints: slices.Clone(endpoints), health: map[string]*EndpointHealth{}}
+	if len(opts) == 1 && opts[0] != nil {
+		f.opts = *opts[0]
+	}
+	if f.opts.FailureThreshold <= 0 {
+		f.opts.FailureThreshold = 1
+	}
+	if f.opts.Cooldown <= 0 {
+		f.opts.Cooldown = 30 * time.Second
+	}
+	for _, endpoint := range endpoints {
+		if f.health[endpoint] != nil {
+			panic("duplicate endpoint: " + endpoint)
+		}
+		f.health[endpoint] = &EndpointHealth{Endpoint: endpoint, Healthy: true}
+	}
+	return f
+}
+
+/* clone: failover with the endpoints and options of f, starting from its current health */
+func (f *Failover) clone() *Failover {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c := &Failover{opts: f.opts, endpoints: slices.Clone(f.endpoints), health: map[string]*EndpointHealth{}}
+	for endpoint, health := range f.health {
+		h := *health
+		c.health[endpoint] = &h
+	}
+	return c
+}
+
+/*
+Pick: first healthy endpoint not in tried. Endpoints whose cooldown ended are healthy again until they fail.
+When none is left, the untried endpoint with the earliest end of cooldown, when all were tried the first
+healthy one.
+*/
+func (f *Failover) Pick(tried ...string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	now := time.Now()
+	var fallback *EndpointHealth
+	for _, endpoint := range f.endpoints {
+		h := f.health[endpoint]
+		if !h.Healthy && !now.Before(h.UnhealthyUntil) {
+			h.Healthy = true
+		}
+		if slices.Contains(tried, endpoint) {
+			continue
+		}
+		if h.Healthy {
+			return endpoint
+		}
+		if fallback == nil || h.UnhealthyUntil.Before(fallback.UnhealthyUntil) {
+			fallback = h
+		}
+	}
+	if fallback != nil {
+		return fallback.Endpoint
+	}
+	for _, endpoint := range f.endpoints {
+		if f.health[endpoint].Healthy {
+			return endpoint
+		}
+	}
+	return f.endpoints[0]
+}
+
+/* ReportSuccess marks the endpoint healthy */
+func (f *Failover) ReportSuccess(endpoint string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if h := f.health[endpoint]; h != nil {
+		h.Healthy, h.ConsecutiveFailures, h.UnhealthyUntil = true, 0, time.Time{}
+	}
+}
+
+/* ReportFailure marks the endpoint unhealthy for the cooldown once it failed FailureThreshold times in a row */
+func (f *Failover) ReportFailure(endpoint string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	h := f.health[endpoint]
+	if h == nil {
+		return
+	}
+	h.ConsecutiveFailures++
+	if h.ConsecutiveFailures >= f.opts.FailureThreshold {
+		h.Healthy, h.UnhealthyUntil = false, time.Now().Add(f.opts.Cooldown)
+	}
+}
+
+/* Health of the endpoints, in failover order */
+func (f *Failover) Health() []EndpointHealth {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	health := make([]EndpointHealth, 0, len(f.endpoints))
+	for _, endpoint := range f.endpoints {
+		health = append(health, *f.health[endpoint])
+	}
+	return health
+}
+
+type endpointContextKey struct{}
+
+/* EndpointFromContext: endpoint the running attempt of the component must call, see ComponentConfig.Failover */
+func EndpointFromContext(ctx context.Context) (string, bool) {
+	endpoint, ok := ctx.Value(endpointContextKey{}).(string)
+	return endpoint, ok
+}
+
+/* endpointFailure: whether err of an attempt counts against its endpoint, invalid inputs don't */
+func endpointFailure(err error) bool {
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	ce, ok := AsComponentError(err)
+	return !ok || ce.Retryable
+}
+
+func (d *DataTracker[C, T]) Endpoint() (string, bool) {
+	return EndpointFromContext(d.ctx)
+}
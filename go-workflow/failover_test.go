@@ -0,0 +1,84 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailover(t *testing.T) {
+	failover := goworkflow.NewFailover([]string{"eu", "us", "ap"}, &goworkflow.FailoverOptions{Cooldown: 50 * time.Millisecond})
+	lock := sync.Mutex{}
+	down := map[string]bool{"eu": true}
+	called := []string{}
+	run := func() goworkflow.Status {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			endpoint, ok := goworkflow.EndpointFromContext(ctx)
+			assert.True(t, ok)
+			lock.Lock()
+			defer lock.Unlock()
+			called = append(called, endpoint)
+			if down[endpoint] {
+				return errors.New(endpoint + " unavailable")
+			}
+			dt.Update(func(d *Data) { d.A = endpoint })
+			return nil
+		}), &goworkflow.ComponentConfig{Failover: failover, Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+		_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		return st
+	}
+
+	// the retry shifts to the next region, later runs skip the unhealthy one
+	assert.Equal(t, goworkflow.DONE, run())
+	assert.Equal(t, goworkflow.DONE, run())
+	assert.Equal(t, []string{"eu", "us", "us"}, called)
+	health := failover.Health()
+	assert.False(t, health[0].Healthy)
+	assert.Equal(t, 1, health[0].ConsecutiveFailures)
+	assert.True(t, health[1].Healthy)
+
+	// after the cooldown the primary is tried again
+	time.Sleep(60 * time.Millisecond)
+	down["eu"] = false
+	called = nil
+	assert.Equal(t, goworkflow.DONE, run())
+	assert.Equal(t, []string{"eu"}, called)
+	assert.True(t, failover.Health()[0].Healthy)
+
+	// all regions down
+	down = map[string]bool{"eu": true, "us": true, "ap": true}
+	called = nil
+	assert.Equal(t, goworkflow.ERROR, run())
+	assert.Equal(t, []string{"eu", "us", "ap"}, called)
+	assert.Equal(t, "eu", failover.Pick())
+	assert.Equal(t, "us", failover.Pick("eu"))
+
+	assert.Panics(t, func() { goworkflow.NewFailover(nil) })
+	assert.Panics(t, func() { goworkflow.NewFailover([]string{"eu", "eu"}) })
+}
+
+func TestFailoverThreshold(t *testing.T) {
+	failover := goworkflow.NewFailover([]string{"eu", "us"}, &goworkflow.FailoverOptions{FailureThreshold: 2})
+	failover.ReportFailure("eu")
+	assert.Equal(t, "eu", failover.Pick())
+	failover.ReportFailure("eu")
+	assert.Equal(t, "us", failover.Pick())
+	failover.ReportSuccess("eu")
+	assert.Equal(t, "eu", failover.Pick())
+
+	// invalid inputs don't count against the endpoint
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return goworkflow.Permanent(errors.New("corrupt document"))
+	}), &goworkflow.ComponentConfig{Failover: failover})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 0, failover.Health()[0].ConsecutiveFailures)
+}
@@ -0,0 +1,153 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+)
+
+/* FailureDumpOptions: see SetFailureDumps */
+type FailureDumpOptions struct {
+	// Events: latest state changes of the run kept in a dump, 50 when 0
+	Events int
+	// Stacks includes the stacks of all goroutines of the process
+	Stacks bool
+}
+
+/*
+FailureDump: state of the run when a component failed, for the postmortem of failures which are hard to reproduce.
+Data is the data store as JSON at the time of the failure, it may contain personal data: wrap the artifact store
+with NewEncryptedArtifactStore where needed. Secrets are never part of a dump.
+*/
+type FailureDump struct {
+	WorkflowId     string          `json:"workflowId"`
+	Workflow       string          `json:"workflow,omitempty"`
+	Component      string          `json:"component"`
+	ComponentId    string          `json:"componentId"`
+	Time           time.Time       `json:"time"`
+	Error          string          `json:"error"`
+	ComponentError *ComponentError `json:"componentError,omitempty"`
+	Input          json.RawMessage `json:"input,omitempty"`
+	ConfigHash     string          `json:"configHash"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	Events         []StateChange   `json:"events"`
+	Goroutines     string          `json:"goroutines,omitempty"`
+}
+
+type failureDumps struct {
+	opts FailureDumpOptions
+	// events: latest state changes, guarded by the workflow stateLock
+	events []StateChange
+}
+
+/*
+SetFailureDumps captures a FailureDump every time a component fails (after its retries) and stores it in the
+artifact store of the workflow under <workflow id>/<component>/failure-dump.json. Refs are listed in
+RunResult.FailureDumps. Requires SetArtifactStore, call before Execute.
+*/
+func (wf *Workflow[CT, C, T]) SetFailureDumps(opts ...*FailureDumpOptions) {
+	if len(opts) > 1 {
+		panic("only one FailureDumpOptions is allowed")
+	}
+	dumps := &failureDumps{}
+	if len(opts) == 1 && opts[0] != nil {
+		dumps.opts = *opts[0]
+	}
+	if dumps.opts.Events <= 0 {
+		dumps.opts.Events = 50
+	}
+	wf.stateLock.Lock()
+	wf.failureDumps = dumps
+	wf.stateLock.Unlock()
+	wf.addStateListener(func(change StateChange) {
+		dumps.events = append(dumps.events, change)
+		if len(dumps.events) > dumps.opts.Events {
+			dumps.events = dumps.events[len(dumps.events)-dumps.opts.Events:]
+		}
+	})
+}
+
+/* FailureDump: ref of the dump of the last failure of the component, empty when none was captured */
+func (c *component[CT, C, T]) FailureDump() ArtifactRef {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	return c.failureDump
+}
+
+/* captureFailure: stores the dump of the failure of c, failures to capture are only logged */
+func (wf *Workflow[CT, C, T]) captureFailure(ctx context.Context, c *component[CT, C, T], dataTracker *DataTracker[C, T], err error) {
+	wf.stateLock.Lock()
+	dumps := wf.failureDumps
+	var events []StateChange
+	if dumps != nil {
+		events = append(events, dumps.events...)
+	}
+	wf.stateLock.Unlock()
+	if dumps == nil {
+		return
+	}
+	if wf.artifactStore == nil {
+		log.Println("Workflow.Execute:Error:Failure dump skipped, artifact store is not set:", c.id)
+		return
+	}
+	dump := FailureDump{
+		WorkflowId:  wf.id,
+		Workflow:    wf.name,
+		Component:   c.Name,
+		ComponentId: c.id,
+		Time:        time.Now(),
+		Error:       err.Error(),
+		ConfigHash:  HashConfig(dataTracker.Config),
+		Events:      events,
+	}
+	dump.ComponentError, _ = AsComponentError(err)
+	if input, err := json.Marshal(c.input); err == nil {
+		dump.Input = input
+	} else {
+		dump.Input, _ = json.Marshal(fmt.Sprintf("%#v", c.input))
+	}
+	store := dataTracker.store
+	store.lock.Lock()
+	data, marshalErr := json.Marshal(store.data)
+	store.lock.Unlock()
+	if marshalErr != nil {
+		log.Println("Workflow.Execute:Error:Failure dump data snapshot failed for component:", c.id, marshalErr)
+	} else {
+		dump.Data = data
+	}
+	if dumps.opts.Stacks {
+		dump.Goroutines = goroutineStacks()
+	}
+
+	content, marshalErr := json.MarshalIndent(dump, "", "  ")
+	if marshalErr != nil {
+		log.Println("Workflow.Execute:Error:Failure dump encoding failed for component:", c.id, marshalErr)
+		return
+	}
+	// the component context may be cancelled already
+	ref, putErr := wf.artifactStore.Put(context.WithoutCancel(ctx), fmt.Sprintf("%s/%s/failure-dump.json", wf.id, c.Name), bytes.NewReader(content))
+	if putErr != nil {
+		log.Println("Workflow.Execute:Error:Failure dump upload failed for component:", c.id, putErr)
+		return
+	}
+	log.Println("Workflow.Execute:Failure dump of component:", c.id, "stored at:", ref)
+	c.statusLock.Lock()
+	c.failureDump = ref
+	c.statusLock.Unlock()
+}
+
+/* goroutineStacks: stacks of all goroutines, truncated to 8MB */
+func goroutineStacks() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 8*1024*1024 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,65 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureDump(t *testing.T) {
+	store := goworkflow.NewMemoryArtifactStore()
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetArtifactStore(store)
+	wf.SetFailureDumps(&goworkflow.FailureDumpOptions{Events: 3, Stacks: true})
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "a" })
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", map[string]string{"page": "2"}, func(ctx context.Context, input map[string]string, dt *goworkflow.DataTracker[Config, Data]) error {
+		return &goworkflow.ComponentError{Code: "OCR_FAILED", Err: errors.New("empty page")}
+	}))
+	b.AddDependencies(a)
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+
+	result := wf.Result()
+	assert.Equal(t, map[string]goworkflow.ArtifactRef{"B": goworkflow.ArtifactRef("mem://" + wf.Id() + "/B/failure-dump.json")}, result.FailureDumps)
+	r, err := store.Get(context.TODO(), result.FailureDumps["B"])
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	var dump goworkflow.FailureDump
+	assert.NoError(t, json.Unmarshal(content, &dump))
+	assert.Equal(t, wf.Id(), dump.WorkflowId)
+	assert.Equal(t, "B", dump.Component)
+	assert.Equal(t, "OCR_FAILED: empty page", dump.Error)
+	assert.Equal(t, "OCR_FAILED", dump.ComponentError.Code)
+	assert.JSONEq(t, `{"page": "2"}`, string(dump.Input))
+	assert.Equal(t, goworkflow.HashConfig(Config{}), dump.ConfigHash)
+	var data Data
+	assert.NoError(t, json.Unmarshal(dump.Data, &data))
+	assert.Equal(t, "a", data.A)
+	// only the latest events are kept, the last one is B starting
+	assert.Len(t, dump.Events, 3)
+	assert.Equal(t, "B", dump.Events[2].Component)
+	assert.Equal(t, goworkflow.RUNNING, dump.Events[2].NewStatus)
+	assert.Contains(t, dump.Goroutines, "goroutine ")
+}
+
+func TestFailureDumpDisabled(t *testing.T) {
+	store := goworkflow.NewMemoryArtifactStore()
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetArtifactStore(store)
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("boom")
+	}))
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Nil(t, wf.Result().FailureDumps)
+}
@@ -0,0 +1,159 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"maps"
+)
+
+/*
+FlagContext: subject a flag is evaluated for. Key is the unit of percentage rollouts, the run id unless set with
+Workflow.SetFlagKey (e.g. a document id, so retried documents keep their variant). Attributes are the run
+metadata, e.g. the tenant.
+*/
+type FlagContext struct {
+	Key        string
+	Workflow   string
+	Component  string
+	Attributes map[string]string
+}
+
+/* FlagProvider evaluates feature flags, e.g. an adapter of LaunchDarkly, Unleash or OpenFeature */
+type FlagProvider interface {
+	// Variant of flag for fc: "on" or "off" for switches, the name of the implementation to route to otherwise
+	Variant(ctx context.Context, flag string, fc FlagContext) (string, error)
+}
+
+const (
+	FlagOn  = "on"
+	FlagOff = "off"
+)
+
+var ErrFlagProviderNotSet = errors.New("flag provider is not set")
+
+type flagsContextKey struct{}
+
+type flagEvaluator struct {
+	provider FlagProvider
+	fc       FlagContext
+}
+
+func (wf *Workflow[CT, C, T]) SetFlagProvider(provider FlagProvider) {
+	wf.flagProvider = provider
+}
+
+/* SetFlagKey: key of the run for percentage rollouts, the run id when not set */
+func (wf *Workflow[CT, C, T]) SetFlagKey(key string) {
+	wf.flagKey = key
+}
+
+func (wf *Workflow[CT, C, T]) flagContext(c *component[CT, C, T]) FlagContext {
+	fc := FlagContext{Key: wf.flagKey, Workflow: wf.name, Component: c.Name}
+	if fc.Key == "" {
+		fc.Key = wf.id
+	}
+	wf.stateLock.Lock()
+	fc.Attributes = maps.Clone(wf.metadata)
+	wf.stateLock.Unlock()
+	if fc.Attributes == nil {
+		fc.Attributes = map[string]string{}
+	}
+	return fc
+}
+
+func (wf *Workflow[CT, C, T]) contextWithFlags(ctx context.Context, c *component[CT, C, T]) context.Context {
+	if wf.flagProvider == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, flagsContextKey{}, flagEvaluator{provider: wf.flagProvider, fc: wf.flagContext(c)})
+}
+
+/* FlagVariant evaluates flag for the running component with the flag provider of its workflow */
+func FlagVariant(ctx context.Context, flag string) (string, error) {
+	evaluator, ok := ctx.Value(flagsContextKey{}).(flagEvaluator)
+	if !ok {
+		return "", ErrFlagProviderNotSet
+	}
+	return evaluator.provider.Variant(ctx, flag, evaluator.fc)
+}
+
+func (d *DataTracker[C, T]) FlagVariant(flag string) (string, error) {
+	return FlagVariant(d.ctx, flag)
+}
+
+/*
+flagDisabled: reason to skip c when the flag of its config is not on. Evaluation errors disable the component,
+gated components are typically new code which is safer off.
+*/
+func (wf *Workflow[CT, C, T]) flagDisabled(ctx context.Context, c *component[CT, C, T]) string {
+	if c.addComponentCfg == nil || c.addComponentCfg.Flag == "" {
+		return ""
+	}
+	flag := c.addComponentCfg.Flag
+	if wf.flagProvider == nil {
+		return fmt.Sprintf("disabled by flag %s: %s", flag, ErrFlagProviderNotSet)
+	}
+	variant, err := wf.flagProvider.Variant(ctx, flag, wf.flagContext(c))
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Flag evaluation failed for component:", c.id, err)
+		return fmt.Sprintf("disabled by flag %s: %s", flag, err)
+	}
+	if variant != FlagOn {
+		return "disabled by flag " + flag
+	}
+	return ""
+}
+
+/*
+RouteByFlag: executor running the implementation named by the variant of flag, e.g. the new extraction model for
+the runs in its rollout. Unknown variants and evaluation errors run the fallback implementation.
+*/
+func RouteByFlag[CT context.Context, I any, C any, T any](flag string, fallback string, implementations map[string]ComponentFunction[CT, I, C, T]) ComponentFunction[CT, I, C, T] {
+	if implementations[fallback] == nil {
+		panic("fallback implementation is required: " + fallback)
+	}
+	return func(ctx CT, input I, dt *DataTracker[C, T]) error {
+		variant, err := dt.FlagVariant(flag)
+		if err != nil {
+			log.Println("RouteByFlag:Error:", flag, err)
+		}
+		implementation, ok := implementations[variant]
+		if !ok || err != nil {
+			implementation = implementations[fallback]
+		}
+		return implementation(ctx, input, dt)
+	}
+}
+
+/* FlagRule: variant of a flag for the subjects having all Attributes, of which Percentage (0-100) are selected by key */
+type FlagRule struct {
+	Attributes map[string]string
+	Percentage float64
+	Variant    string
+}
+
+/*
+RolloutFlags: static flag provider, the rules of a flag are evaluated in order and the first matching one wins,
+the variant is "off" when none matches. The percentage bucket of a key is stable, so raising the percentage of a
+rollout keeps the subjects which already had the variant.
+*/
+type RolloutFlags map[string][]FlagRule
+
+func (r RolloutFlags) Variant(ctx context.Context, flag string, fc FlagContext) (string, error) {
+	h := fnv.New32a()
+	h.Write([]byte(flag + "\x00" + fc.Key))
+	bucket := float64(h.Sum32()%10000) / 100
+	for _, rule := range r[flag] {
+		matches := bucket < rule.Percentage
+		for k, v := range rule.Attributes {
+			matches = matches && fc.Attributes[k] == v
+		}
+		if matches {
+			return rule.Variant, nil
+		}
+	}
+	return FlagOff, nil
+}
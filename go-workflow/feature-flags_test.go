@@ -0,0 +1,94 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagGatedComponent(t *testing.T) {
+	flags := goworkflow.RolloutFlags{"new-extraction": {
+		{Attributes: map[string]string{"tenant": "acme"}, Percentage: 100, Variant: goworkflow.FlagOn},
+	}}
+	run := func(tenant string, provider goworkflow.FlagProvider) (Data, string) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = "extracted" })
+			return nil
+		}), &goworkflow.ComponentConfig{Flag: "new-extraction"})
+		if provider != nil {
+			wf.SetFlagProvider(provider)
+		}
+		wf.SetMetadata("tenant", tenant)
+		reason := ""
+		wf.AddStateListener(func(change goworkflow.StateChange) {
+			if change.NewStatus == goworkflow.SKIPPED {
+				reason = change.Cause
+			}
+		})
+		data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		return *data, reason
+	}
+
+	data, _ := run("acme", flags)
+	assert.Equal(t, "extracted", data.A)
+
+	data, reason := run("globex", flags)
+	assert.Equal(t, "", data.A)
+	assert.Equal(t, "disabled by flag new-extraction", reason)
+
+	// no provider: gated components stay off
+	data, reason = run("acme", nil)
+	assert.Equal(t, "", data.A)
+	assert.Equal(t, "disabled by flag new-extraction: flag provider is not set", reason)
+}
+
+func TestRouteByFlag(t *testing.T) {
+	flags := goworkflow.RolloutFlags{"extraction-model": {{Percentage: 5, Variant: "v2"}}}
+	model := func(name string) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = name })
+			return nil
+		}
+	}
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("Extract", nil, goworkflow.RouteByFlag("extraction-model", "v1", map[string]goworkflow.ComponentFunction[context.Context, any, Config, Data]{
+			"v1": model("v1"),
+			"v2": model("v2"),
+		})))
+		wf.SetFlagProvider(flags)
+		wf.SetFlagKey(fmt.Sprintf("document-%d", i))
+		data, _, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		counts[data.A]++
+	}
+	// about 5% of the documents get the new model
+	assert.InDelta(t, 100, counts["v2"], 40)
+	assert.Equal(t, 2000, counts["v1"]+counts["v2"])
+
+	assert.Panics(t, func() {
+		goworkflow.RouteByFlag[context.Context, any, Config, Data]("extraction-model", "v3", nil)
+	})
+}
+
+func TestRolloutFlagsStable(t *testing.T) {
+	small := goworkflow.RolloutFlags{"f": {{Percentage: 10, Variant: goworkflow.FlagOn}}}
+	large := goworkflow.RolloutFlags{"f": {{Percentage: 50, Variant: goworkflow.FlagOn}}}
+	for i := 0; i < 200; i++ {
+		fc := goworkflow.FlagContext{Key: fmt.Sprint(i)}
+		before, _ := small.Variant(context.TODO(), "f", fc)
+		after, _ := large.Variant(context.TODO(), "f", fc)
+		if before == goworkflow.FlagOn {
+			assert.Equal(t, goworkflow.FlagOn, after)
+		}
+	}
+	variant, err := small.Variant(context.TODO(), "unknown", goworkflow.FlagContext{Key: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.FlagOff, variant)
+}
@@ -0,0 +1,300 @@
+/*
+Package filestore is an embedded single-file backend for checkpoints and run history, for small deployments and
+CLI tools which need durability without a database server. It only depends on the standard library.
+
+The file is an append-only log of json records, replayed into memory on Open. Compact rewrites it with the live records only.
+A final record left half-written by a crash is dropped on Open, corrupted records before it fail Open.
+With Options.Envelope every record is encrypted, plaintext records written before are only read by envelopes in
+migration mode (see goworkflow.EnvelopeOptions), Compact encrypts them.
+*/
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+const kindRun = "run"
+const kindCheckpoint = "checkpoint"
+const kindDeleteCheckpoint = "delete-checkpoint"
+
+type record[T any] struct {
+	Kind       string                    `json:"kind"`
+	WorkflowId string                    `json:"workflowId"`
+	Run        *goworkflow.RunResult     `json:"run,omitempty"`
+	Checkpoint *goworkflow.Checkpoint[T] `json:"checkpoint,omitempty"`
+}
+
+type Options struct {
+	// Envelope encrypts the records at rest
+	Envelope *goworkflow.Envelope
+}
+
+/* Store implements goworkflow.RunStore and goworkflow.CheckpointStore[T] */
+type Store[T any] struct {
+	lock        sync.Mutex
+	path        string
+	file        *os.File
+	runs        *goworkflow.MemoryRunStore
+	checkpoints map[string]goworkflow.Checkpoint[T]
+	envelope    *goworkflow.Envelope
+}
+
+/* Open opens or creates the store file at path */
+func Open[T any](path string, opts ...*Options) (*Store[T], error) {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	s := &Store[T]{path: path, runs: goworkflow.NewMemoryRunStore(), checkpoints: map[string]goworkflow.Checkpoint[T]{}}
+	if len(opts) == 1 && opts[0] != nil {
+		s.envelope = opts[0].Envelope
+	}
+	size, terminate, err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	if err := s.repair(size, terminate); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+/*
+replay applies the records of the file. size: length of the valid log, shorter than the file when the final record
+is half-written. terminate: the final record is complete but lacks its newline
+*/
+func (s *Store[T]) replay() (size int64, terminate bool, err error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	line := 0
+	for {
+		b, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(b) == 0 {
+			return size, false, nil
+		}
+		if err != nil && err != io.EOF {
+			return size, false, err
+		}
+		line++
+		// records are written with their newline at once, only the final record of a crashed append lacks it
+		partial := err == io.EOF
+		r, err := s.decode(bytes.TrimSuffix(b, []byte("\n")))
+		if err != nil && partial {
+			log.Printf("FileStore.Open:Dropping the half-written record %s:%d: %v", s.path, line, err)
+			return size, false, nil
+		}
+		if err != nil {
+			return size, false, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		s.apply(r)
+		size += int64(len(b))
+		if partial {
+			return size, true, nil
+		}
+	}
+}
+
+/* repair cuts the file to the valid log of size, terminating its final record, so appends start on a new line */
+func (s *Store[T]) repair(size int64, terminate bool) error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > size {
+		if err := s.file.Truncate(size); err != nil {
+			return err
+		}
+	}
+	if terminate {
+		if _, err := s.file.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+	if info.Size() > size || terminate {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *Store[T]) apply(r record[T]) {
+	switch r.Kind {
+	case kindRun:
+		s.runs.SaveRun(context.Background(), *r.Run)
+	case kindCheckpoint:
+		s.checkpoints[r.WorkflowId] = *r.Checkpoint
+	case kindDeleteCheckpoint:
+		delete(s.checkpoints, r.WorkflowId)
+	}
+}
+
+func (s *Store[T]) encode(r record[T]) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil || s.envelope == nil {
+		return b, err
+	}
+	sealed, err := s.envelope.SealText(context.Background(), b)
+	return []byte(sealed), err
+}
+
+func (s *Store[T]) decode(line []byte) (record[T], error) {
+	r := record[T]{}
+	if goworkflow.IsSealedText(string(line)) {
+		if s.envelope == nil {
+			return r, errors.New("record is encrypted, open the store with an Envelope")
+		}
+		plaintext, err := s.envelope.OpenText(context.Background(), string(line))
+		if err != nil {
+			return r, err
+		}
+		line = plaintext
+	} else if s.envelope != nil && !s.envelope.Migrating() {
+		return r, goworkflow.ErrPlaintext
+	}
+	return r, json.Unmarshal(line, &r)
+}
+
+/* should be called with lock held */
+func (s *Store[T]) append(r record[T]) error {
+	b, err := s.encode(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.apply(r)
+	return nil
+}
+
+func (s *Store[T]) SaveRun(ctx context.Context, result goworkflow.RunResult) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.append(record[T]{Kind: kindRun, WorkflowId: result.WorkflowId, Run: &result})
+}
+
+func (s *Store[T]) GetRun(ctx context.Context, workflowId string) (goworkflow.RunResult, error) {
+	return s.runs.GetRun(ctx, workflowId)
+}
+
+func (s *Store[T]) QueryRuns(ctx context.Context, query goworkflow.RunQuery) (goworkflow.RunPage, error) {
+	return s.runs.QueryRuns(ctx, query)
+}
+
+func (s *Store[T]) SaveCheckpoint(ctx context.Context, cp goworkflow.Checkpoint[T]) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.append(record[T]{Kind: kindCheckpoint, WorkflowId: cp.WorkflowId, Checkpoint: &cp})
+}
+
+func (s *Store[T]) LoadCheckpoint(ctx context.Context, workflowId string) (goworkflow.Checkpoint[T], error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cp, ok := s.checkpoints[workflowId]
+	if !ok {
+		return cp, goworkflow.ErrCheckpointNotFound
+	}
+	return cp, nil
+}
+
+func (s *Store[T]) DeleteCheckpoint(ctx context.Context, workflowId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.checkpoints[workflowId]; !ok {
+		return nil
+	}
+	return s.append(record[T]{Kind: kindDeleteCheckpoint, WorkflowId: workflowId})
+}
+
+/* Compact rewrites the file with the live records only, replacing it atomically */
+func (s *Store[T]) Compact() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	write := func(r record[T]) error {
+		b, err := s.encode(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	}
+	token := ""
+	for {
+		page, err := s.runs.QueryRuns(context.Background(), goworkflow.RunQuery{PageSize: 1000, PageToken: token})
+		if err != nil {
+			return err
+		}
+		for i := range page.Runs {
+			if err := write(record[T]{Kind: kindRun, WorkflowId: page.Runs[i].WorkflowId, Run: &page.Runs[i]}); err != nil {
+				return err
+			}
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	for id, cp := range s.checkpoints {
+		cp := cp
+		if err := write(record[T]{Kind: kindCheckpoint, WorkflowId: id, Checkpoint: &cp}); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.file.Close()
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	return err
+}
+
+func (s *Store[T]) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.Close()
+}
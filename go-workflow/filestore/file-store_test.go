@@ -0,0 +1,197 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{}
+
+type Data struct {
+	Text   string
+	Parsed string
+}
+
+func newTemplate(failParse bool) *goworkflow.Template[context.Context, Config, Data] {
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.Text = "hello"
+		})
+		return nil
+	}))
+	tpl.AddComponent(goworkflow.MakeComponent("Parse", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		if failParse {
+			return errors.New("parser crashed")
+		}
+		dt.Update(func(d *Data) {
+			d.Parsed = strings.ToUpper(d.Text)
+		})
+		return nil
+	})).AddDependencies(ocr)
+	return tpl
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "workflows.db")
+
+	store, err := Open[Data](path)
+	assert.NoError(t, err)
+	wf := newTemplate(true).NewWorkflow(ctx)
+	wf.SetRunStore(store)
+	wf.SetCheckpointStore(store)
+	_, st, _ := wf.Execute(ctx, Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.NoError(t, store.Close())
+
+	// reopen, as after a restart
+	store, err = Open[Data](path)
+	assert.NoError(t, err)
+	run, err := store.GetRun(ctx, wf.Id())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Parse"}, run.FailedComponents)
+	page, err := store.QueryRuns(ctx, goworkflow.RunQuery{Workflow: "document", Status: goworkflow.ERROR})
+	assert.NoError(t, err)
+	assert.Len(t, page.Runs, 1)
+
+	cp, err := store.LoadCheckpoint(ctx, wf.Id())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OCR"}, cp.Completed)
+	data, st, err := newTemplate(false).Resume(ctx, cp, Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "HELLO", data.Parsed)
+
+	assert.NoError(t, store.DeleteCheckpoint(ctx, wf.Id()))
+	_, err = store.LoadCheckpoint(ctx, wf.Id())
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+
+	before, _ := os.Stat(path)
+	assert.NoError(t, store.Compact())
+	after, _ := os.Stat(path)
+	assert.Less(t, after.Size(), before.Size())
+	assert.NoError(t, store.SaveRun(ctx, goworkflow.RunResult{WorkflowId: "other", Workflow: "invoice"}))
+	assert.NoError(t, store.Close())
+
+	store, err = Open[Data](path)
+	assert.NoError(t, err)
+	defer store.Close()
+	_, err = store.GetRun(ctx, wf.Id())
+	assert.NoError(t, err)
+	_, err = store.GetRun(ctx, "other")
+	assert.NoError(t, err)
+	_, err = store.LoadCheckpoint(ctx, wf.Id())
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+}
+
+func TestFileStoreEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflows.db")
+	kms, _ := goworkflow.NewLocalKMS("key-1", []byte("0123456789abcdef0123456789abcdef"))
+	opts := &Options{Envelope: goworkflow.NewEnvelope(kms)}
+
+	store, err := Open[Data](path, opts)
+	assert.NoError(t, err)
+	cp := goworkflow.Checkpoint[Data]{WorkflowId: "run-1", Template: "document", Completed: []string{"OCR"}, Data: Data{Text: "patient name"}}
+	assert.NoError(t, store.SaveCheckpoint(context.TODO(), cp))
+	assert.NoError(t, store.SaveRun(context.TODO(), goworkflow.RunResult{WorkflowId: "run-1", Workflow: "document", Status: goworkflow.DONE}))
+	assert.NoError(t, store.Compact())
+	assert.NoError(t, store.Close())
+
+	raw, _ := os.ReadFile(path)
+	assert.NotContains(t, string(raw), "patient name")
+
+	_, err = Open[Data](path)
+	assert.Error(t, err)
+
+	store, err = Open[Data](path, opts)
+	assert.NoError(t, err)
+	defer store.Close()
+	loaded, err := store.LoadCheckpoint(context.TODO(), "run-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "patient name", loaded.Data.Text)
+	_, err = store.GetRun(context.TODO(), "run-1")
+	assert.NoError(t, err)
+}
+
+func TestFileStoreEncryptionMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflows.db")
+	kms, _ := goworkflow.NewLocalKMS("key-1", []byte("0123456789abcdef0123456789abcdef"))
+
+	store, err := Open[Data](path)
+	assert.NoError(t, err)
+	cp := goworkflow.Checkpoint[Data]{WorkflowId: "run-1", Template: "document", Completed: []string{"OCR"}, Data: Data{Text: "patient name"}}
+	assert.NoError(t, store.SaveCheckpoint(context.TODO(), cp))
+	assert.NoError(t, store.Close())
+
+	// plaintext records are rejected unless migrating
+	_, err = Open[Data](path, &Options{Envelope: goworkflow.NewEnvelope(kms)})
+	assert.ErrorIs(t, err, goworkflow.ErrPlaintext)
+
+	store, err = Open[Data](path, &Options{Envelope: goworkflow.NewEnvelope(kms, &goworkflow.EnvelopeOptions{Migration: true})})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Compact())
+	assert.NoError(t, store.Close())
+
+	store, err = Open[Data](path, &Options{Envelope: goworkflow.NewEnvelope(kms)})
+	assert.NoError(t, err)
+	defer store.Close()
+	loaded, err := store.LoadCheckpoint(context.TODO(), "run-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "patient name", loaded.Data.Text)
+}
+
+func TestFileStoreHalfWrittenRecord(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "workflows.db")
+	appendRaw := func(raw string) {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		assert.NoError(t, err)
+		_, err = file.WriteString(raw)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	store, err := Open[Data](path)
+	assert.NoError(t, err)
+	assert.NoError(t, store.SaveRun(ctx, goworkflow.RunResult{WorkflowId: "run-1", Status: goworkflow.DONE}))
+	assert.NoError(t, store.Close())
+
+	// the process crashed while appending
+	appendRaw(`{"kind":"run","workflowId":"run-2","run":{"Workfl`)
+	store, err = Open[Data](path)
+	assert.NoError(t, err)
+	_, err = store.GetRun(ctx, "run-1")
+	assert.NoError(t, err)
+	_, err = store.GetRun(ctx, "run-2")
+	assert.ErrorIs(t, err, goworkflow.ErrRunNotFound)
+	assert.NoError(t, store.SaveRun(ctx, goworkflow.RunResult{WorkflowId: "run-3", Status: goworkflow.DONE}))
+	assert.NoError(t, store.Close())
+
+	// a complete record which lacks its newline is kept
+	appendRaw(`{"kind":"run","workflowId":"run-4","run":{"WorkflowId":"run-4","Status":"DONE"}}`)
+	store, err = Open[Data](path)
+	assert.NoError(t, err)
+	assert.NoError(t, store.SaveRun(ctx, goworkflow.RunResult{WorkflowId: "run-5", Status: goworkflow.DONE}))
+	assert.NoError(t, store.Close())
+	store, err = Open[Data](path)
+	assert.NoError(t, err)
+	for _, id := range []string{"run-1", "run-3", "run-4", "run-5"} {
+		_, err = store.GetRun(ctx, id)
+		assert.NoError(t, err, id)
+	}
+	assert.NoError(t, store.Close())
+
+	// corrupted records before the final one fail Open
+	appendRaw("{\"kind\":\n")
+	appendRaw(`{"kind":"run","workflowId":"run-6","run":{"WorkflowId":"run-6","Status":"DONE"}}` + "\n")
+	_, err = Open[Data](path)
+	assert.ErrorContains(t, err, "workflows.db:5")
+}
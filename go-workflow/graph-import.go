@@ -0,0 +1,335 @@
+package goworkflow
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+/*
+Graph import: DAGs drawn in graphviz dot or mermaid, e.g. by teammates who don't write Go, become the skeleton
+of a workflow definition. Node names bind to the executors registered under the same name (see
+TemplateFromDefinition), edges are dependencies. Edge labels written by ExportDot and ExportMermaid keep their
+meaning: "failure" and "completion" edges are dependencies on these outcomes, "any of" edges into a component
+form one any-of group, other labels are plain dependencies. Dashed nodes are optional components.
+*/
+
+/* graphBuilder: components and dependencies in the order they appear in the graph */
+type graphBuilder struct {
+	def   *WorkflowDefinition
+	index map[string]int
+	anyOf map[string][]string
+}
+
+func newGraphBuilder(name string) *graphBuilder {
+	return &graphBuilder{def: &WorkflowDefinition{Name: name}, index: map[string]int{}, anyOf: map[string][]string{}}
+}
+
+func (g *graphBuilder) node(name string) *ComponentDefinition {
+	i, ok := g.index[name]
+	if !ok {
+		i = len(g.def.Components)
+		g.index[name] = i
+		g.def.Components = append(g.def.Components, ComponentDefinition{Name: name})
+	}
+	return &g.def.Components[i]
+}
+
+func (g *graphBuilder) edge(from string, to string, label string) {
+	g.node(from)
+	target := g.node(to)
+	switch strings.TrimSpace(label) {
+	case string(OnFailure):
+		target.DependsOnFailure = append(target.DependsOnFailure, from)
+	case string(OnCompletion):
+		target.DependsOnCompletion = append(target.DependsOnCompletion, from)
+	case "any of":
+		g.anyOf[to] = append(g.anyOf[to], from)
+	default:
+		target.DependsOn = append(target.DependsOn, from)
+	}
+}
+
+func (g *graphBuilder) definition() (*WorkflowDefinition, error) {
+	if len(g.def.Components) == 0 {
+		return nil, errors.New("invalid graph: no components")
+	}
+	for name, group := range g.anyOf {
+		cd := g.node(name)
+		cd.AnyOf = append(cd.AnyOf, group)
+	}
+	return g.def, nil
+}
+
+/* ParseDot: skeleton of the workflow drawn as a graphviz digraph, named after the graph */
+func ParseDot(graph []byte) (*WorkflowDefinition, error) {
+	tokens, err := dotTokens(string(graph))
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{tokens: tokens}
+	if p.peek() == "strict" {
+		p.next()
+	}
+	if p.next() != "digraph" {
+		return nil, errors.New("invalid dot graph: expected digraph")
+	}
+	name := ""
+	if p.peek() != "{" {
+		name = p.next()
+	}
+	if p.next() != "{" {
+		return nil, errors.New("invalid dot graph: expected {")
+	}
+	g := newGraphBuilder(name)
+	for {
+		token := p.next()
+		switch token {
+		case "":
+			return nil, errors.New("invalid dot graph: missing }")
+		case "}":
+			return g.definition()
+		case ";", ",":
+			continue
+		case "graph", "node", "edge":
+			if p.peek() == "[" {
+				if _, err := p.attributes(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		case "subgraph", "{", "[", "=", "->":
+			return nil, fmt.Errorf("invalid dot graph: unsupported %s", token)
+		}
+		if p.peek() == "=" {
+			// graph attribute, e.g. rankdir=LR
+			p.next()
+			p.next()
+			continue
+		}
+		nodes := []string{token}
+		for p.peek() == "->" {
+			p.next()
+			node := p.next()
+			if node == "" || strings.Contains("{}[];,=->", node) {
+				return nil, errors.New("invalid dot graph: edge without target")
+			}
+			nodes = append(nodes, node)
+		}
+		attributes := map[string]string{}
+		if p.peek() == "[" {
+			if attributes, err = p.attributes(); err != nil {
+				return nil, err
+			}
+		}
+		if len(nodes) == 1 {
+			cd := g.node(token)
+			cd.Optional = cd.Optional || attributes["style"] == "dashed"
+			continue
+		}
+		for i := 1; i < len(nodes); i++ {
+			g.edge(nodes[i-1], nodes[i], attributes["label"])
+		}
+	}
+}
+
+type dotParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *dotParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *dotParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+/* attributes: [k=v, ...] */
+func (p *dotParser) attributes() (map[string]string, error) {
+	p.next()
+	attributes := map[string]string{}
+	for {
+		key := p.next()
+		switch key {
+		case "]":
+			return attributes, nil
+		case ",", ";":
+			continue
+		case "":
+			return nil, errors.New("invalid dot graph: missing ]")
+		}
+		if p.peek() == "=" {
+			p.next()
+			attributes[key] = p.next()
+		}
+	}
+}
+
+/* dotTokens: ids, quoted strings (unquoted), ->, and punctuation, comments dropped */
+func dotTokens(graph string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(graph)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/', r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, errors.New("invalid dot graph: unterminated comment")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+		case r == '"':
+			value := strings.Builder{}
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errors.New("invalid dot graph: unterminated string")
+			}
+			i++
+			tokens = append(tokens, value.String())
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, "->")
+			i += 2
+		case strings.ContainsRune("{}[];,=", r):
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("invalid dot graph: unexpected %q", r)
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens, nil
+}
+
+var (
+	// mermaidArrow: -->, ---, ==>, -.->, with an optional label as in "-- text -->", "-. text .->" or "-->|text|"
+	mermaidArrow = regexp.MustCompile(`\s*(?:--\s+([^>|]+?)\s+-->|-\.\s+([^>|]+?)\s+\.->|==\s+([^>|]+?)\s+==>|-->|---|==>|-\.->)\s*(?:\|([^|]*)\|)?\s*`)
+	// mermaidNode: id with an optional shape carrying the label, e.g. A, A[OCR], A("OCR"), A{Valid?}
+	mermaidNode = regexp.MustCompile(`^([\p{L}\p{N}_]+)\s*(?:\[\[(.*)\]\]|\(\((.*)\)\)|\[\((.*)\)\]|\[(.*)\]|\((.*)\)|\{(.*)\}|>(.*)\])?$`)
+)
+
+/*
+ParseMermaid: skeleton of the workflow drawn as a mermaid flowchart. Nodes are named after their label, or
+their id when they have none. Subgraphs are flattened.
+*/
+func ParseMermaid(name string, graph []byte) (*WorkflowDefinition, error) {
+	g := newGraphBuilder(name)
+	// node id -> component name
+	names := map[string]string{}
+	node := func(text string) (string, error) {
+		m := mermaidNode.FindStringSubmatch(strings.TrimSpace(text))
+		if m == nil {
+			return "", fmt.Errorf("invalid mermaid node %q", strings.TrimSpace(text))
+		}
+		id, label := m[1], ""
+		for _, l := range m[2:] {
+			if l != "" {
+				label = l
+			}
+		}
+		if label != "" {
+			label = strings.ReplaceAll(strings.Trim(strings.TrimSpace(label), `"`), "#quot;", `"`)
+			names[id] = label
+		}
+		if _, ok := names[id]; !ok {
+			names[id] = id
+		}
+		return id, nil
+	}
+	started := false
+	// nodes in the order they appear (without target), then edges
+	edges := [][3]string{}
+	for _, line := range strings.Split(string(graph), "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ";"))
+		keyword := strings.Fields(line + " ")
+		switch {
+		case line == "" || strings.HasPrefix(line, "%%"):
+			continue
+		case !started:
+			if keyword[0] != "flowchart" && keyword[0] != "graph" {
+				return nil, errors.New("invalid mermaid graph: expected flowchart or graph")
+			}
+			started = true
+			continue
+		case keyword[0] == "subgraph" || keyword[0] == "end" || keyword[0] == "direction" ||
+			keyword[0] == "classDef" || keyword[0] == "class" || keyword[0] == "style" || keyword[0] == "linkStyle" || keyword[0] == "click":
+			continue
+		}
+		arrows := mermaidArrow.FindAllStringSubmatchIndex(line, -1)
+		groups := []string{}
+		labels := []string{}
+		start := 0
+		for _, a := range arrows {
+			groups = append(groups, line[start:a[0]])
+			label := ""
+			for k := 2; k+1 < len(a); k += 2 {
+				if a[k] >= 0 {
+					label = line[a[k]:a[k+1]]
+				}
+			}
+			labels = append(labels, label)
+			start = a[1]
+		}
+		groups = append(groups, line[start:])
+		ids := make([][]string, len(groups))
+		for i, group := range groups {
+			for _, text := range strings.Split(group, "&") {
+				id, err := node(text)
+				if err != nil {
+					return nil, err
+				}
+				ids[i] = append(ids[i], id)
+			}
+		}
+		for _, group := range ids {
+			for _, id := range group {
+				edges = append(edges, [3]string{id, "", ""})
+			}
+		}
+		for i, label := range labels {
+			for _, from := range ids[i] {
+				for _, to := range ids[i+1] {
+					edges = append(edges, [3]string{from, to, label})
+				}
+			}
+		}
+	}
+	if !started {
+		return nil, errors.New("invalid mermaid graph: expected flowchart or graph")
+	}
+	// labels may be declared after the first use of a node
+	for _, e := range edges {
+		if e[1] == "" {
+			g.node(names[e[0]])
+			continue
+		}
+		g.edge(names[e[0]], names[e[1]], e[2])
+	}
+	return g.definition()
+}
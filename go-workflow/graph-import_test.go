@@ -0,0 +1,81 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDot(t *testing.T) {
+	def, err := goworkflow.ParseDot([]byte(`
+// drawn by ops
+digraph "invoices" {
+  rankdir=LR;
+  node [shape=box];
+  ocr;
+  fail [style=dashed];
+  ocr -> fail;
+  /* alert on failures */
+  fail -> alert [label="failure", style=dashed];
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "invoices", def.Name)
+	assert.Len(t, def.Components, 3)
+	assert.Equal(t, []string{"ocr"}, def.Components[1].DependsOn)
+	assert.True(t, def.Components[1].Optional)
+	assert.Equal(t, []string{"fail"}, def.Components[2].DependsOnFailure)
+
+	template, err := goworkflow.TemplateFromDefinition(definitionRegistry(), def)
+	assert.NoError(t, err)
+	data, st, _ := template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	assert.Equal(t, "alerted", data.C)
+
+	def, err = goworkflow.ParseDot([]byte(`digraph { a -> b -> c; d -> c [label="any of"]; b -> c [label="any of"] }`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, def.Components[1].DependsOn)
+	assert.Equal(t, []string{"b"}, def.Components[2].DependsOn)
+	assert.Equal(t, [][]string{{"d", "b"}}, def.Components[2].AnyOf)
+
+	_, err = goworkflow.ParseDot([]byte(`graph { a; b }`))
+	assert.ErrorContains(t, err, "expected digraph")
+	_, err = goworkflow.ParseDot([]byte(`digraph { a -> }`))
+	assert.ErrorContains(t, err, "edge without target")
+}
+
+func TestParseMermaid(t *testing.T) {
+	def, err := goworkflow.ParseMermaid("invoices", []byte(`flowchart LR
+  %% drawn by ops
+  c1["ocr"]
+  c2("fail") -. failure .-> c3[alert]
+  c1 --> c2
+  subgraph review
+    c4{review} & c5 -->|any of| c6
+  end
+  c6 -- completion --> c7
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "invoices", def.Name)
+	names := []string{}
+	for _, cd := range def.Components {
+		names = append(names, cd.Name)
+	}
+	assert.Equal(t, []string{"ocr", "fail", "alert", "review", "c5", "c6", "c7"}, names)
+	assert.Equal(t, []string{"fail"}, def.Components[2].DependsOnFailure)
+	assert.Equal(t, []string{"ocr"}, def.Components[1].DependsOn)
+	assert.Equal(t, [][]string{{"review", "c5"}}, def.Components[5].AnyOf)
+	assert.Equal(t, []string{"c6"}, def.Components[6].DependsOnCompletion)
+
+	def, err = goworkflow.ParseMermaid("invoices", []byte("graph TD\n  ocr --> fail\n  fail -. failure .-> alert\n"))
+	assert.NoError(t, err)
+	template, err := goworkflow.TemplateFromDefinition(definitionRegistry(), def)
+	assert.NoError(t, err)
+	data, st, _ := template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "alerted", data.C)
+
+	_, err = goworkflow.ParseMermaid("invoices", []byte("sequenceDiagram\n  a->>b: hi\n"))
+	assert.ErrorContains(t, err, "expected flowchart or graph")
+}
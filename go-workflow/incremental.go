@@ -0,0 +1,128 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+/*
+Affected: names of the components whose result may change when the config of a run changes from previous to
+config, in declaration order. A component is affected when a config field it declares in
+ComponentConfig.ConfigReads changed, then every component depending on an affected one or reading a data store
+field one writes (ComponentConfig.Reads and Writes). Components which don't declare their config reads are
+affected by any change.
+*/
+func (t *Template[CT, C, T]) Affected(previous C, config C) ([]string, error) {
+	affected := map[string]bool{}
+	for _, tc := range t.components {
+		changed, err := configReadsChanged(tc.config, &previous, &config)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", tc.Name(), err)
+		}
+		if changed {
+			affected[tc.Name()] = true
+		}
+	}
+	// propagate until no further component is affected
+	for grown := true; grown; {
+		grown = false
+		for _, tc := range t.components {
+			if affected[tc.Name()] {
+				continue
+			}
+			upstream := slices.Clone(tc.dependencies)
+			for _, group := range tc.anyOf {
+				upstream = append(upstream, group.dependencies...)
+			}
+			for _, other := range t.components {
+				if affected[other.Name()] && readsWritesOf(tc.config, other.config) {
+					upstream = append(upstream, other.Name())
+				}
+			}
+			for _, name := range upstream {
+				if affected[name] {
+					affected[tc.Name()], grown = true, true
+					break
+				}
+			}
+		}
+	}
+	names := []string{}
+	for _, tc := range t.components {
+		if affected[tc.Name()] {
+			names = append(names, tc.Name())
+		}
+	}
+	return names, nil
+}
+
+/*
+Rerun re-executes a finished run with a new config incrementally, like make: the components affected by the
+config change (see Affected) and the ones which didn't complete are executed, all others keep their results
+from the checkpoint of the run (see OnCheckpoint) and are restored as DONE.
+*/
+func (t *Template[CT, C, T]) Rerun(ctx CT, cp Checkpoint[T], previous C, config C) (*T, Status, error) {
+	if cp.Template != t.Name {
+		return nil, ERROR, fmt.Errorf("checkpoint belongs to template %s", cp.Template)
+	}
+	if cp.Version != t.Version {
+		return nil, ERROR, fmt.Errorf("%w: %s, template version %s", ErrVersionMismatch, cp.Version, t.Version)
+	}
+	affected, err := t.Affected(previous, config)
+	if err != nil {
+		return nil, ERROR, err
+	}
+	data := cp.Data
+	wf := t.NewWorkflow(ctx)
+	wf.restored = map[string]bool{}
+	for _, name := range cp.Completed {
+		if t.Component(name) != nil && !slices.Contains(affected, name) {
+			wf.restored[name] = true
+		}
+	}
+	return wf.Execute(ctx, config, &data)
+}
+
+/* configReadsChanged: whether a config field read by the component differs between previous and config */
+func configReadsChanged[C any](cfg *ComponentConfig, previous *C, config *C) (bool, error) {
+	if cfg == nil || cfg.ConfigReads == nil {
+		before, err := json.Marshal(previous)
+		if err != nil {
+			return false, err
+		}
+		after, err := json.Marshal(config)
+		return string(before) != string(after), err
+	}
+	for _, path := range cfg.ConfigReads {
+		before, ok := field(reflect.ValueOf(previous).Elem(), path, false)
+		after, ok2 := field(reflect.ValueOf(config).Elem(), path, false)
+		if ok != ok2 {
+			return true, nil
+		}
+		if !ok && !fieldExists(reflect.TypeOf(previous).Elem(), path) {
+			return false, fmt.Errorf("unknown config field %s", path)
+		}
+		if ok && !reflect.DeepEqual(before.Interface(), after.Interface()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/* readsWritesOf: the component of cfg reads a data store field written by the one of writer */
+func readsWritesOf(cfg *ComponentConfig, writer *ComponentConfig) bool {
+	if cfg == nil || writer == nil {
+		return false
+	}
+	for _, read := range cfg.Reads {
+		for _, write := range writer.Writes {
+			if read == write || strings.HasPrefix(read, write+".") || strings.HasPrefix(write, read+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type PipelineConfig struct {
+	OCR      struct{ Dpi int }
+	Language string
+}
+
+func TestIncrementalRerun(t *testing.T) {
+	executions := map[string]int{}
+	template := goworkflow.NewTemplate[context.Context, PipelineConfig, Data]("pipeline")
+	component := func(name string, update func(config PipelineConfig, d *Data)) goworkflow.ComponentFunction[context.Context, any, PipelineConfig, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[PipelineConfig, Data]) error {
+			executions[name]++
+			dt.Update(func(d *Data) { update(dt.Config, d) })
+			return nil
+		}
+	}
+	ocr := template.AddComponent(goworkflow.MakeComponent("OCR", nil, component("OCR", func(config PipelineConfig, d *Data) {
+		d.A = fmt.Sprint("text@", config.OCR.Dpi)
+	})), &goworkflow.ComponentConfig{ConfigReads: []string{"OCR.Dpi"}, Writes: []string{"A"}})
+	classify := template.AddComponent(goworkflow.MakeComponent("Classify", nil, component("Classify", func(config PipelineConfig, d *Data) {
+		d.B = "invoice"
+	})), &goworkflow.ComponentConfig{ConfigReads: []string{}, Writes: []string{"B"}})
+	translate := template.AddComponent(goworkflow.MakeComponent("Translate", nil, component("Translate", func(config PipelineConfig, d *Data) {
+		d.C = d.A + " in " + config.Language
+	})), &goworkflow.ComponentConfig{ConfigReads: []string{"Language"}, Reads: []string{"A"}, Writes: []string{"C"}})
+	combine := template.AddComponent(goworkflow.MakeComponent("Combine", nil, component("Combine", func(config PipelineConfig, d *Data) {
+		d.Combined = d.B + ": " + d.C
+	})), &goworkflow.ComponentConfig{ConfigReads: []string{}})
+	template.AddComponent(goworkflow.MakeComponent("Audit", nil, component("Audit", func(config PipelineConfig, d *Data) {})))
+	translate.AddDependencies(ocr)
+	combine.AddDependencies(classify, translate)
+
+	config := PipelineConfig{Language: "en"}
+	config.OCR.Dpi = 150
+	wf := template.NewWorkflow(context.TODO())
+	_, st, err := wf.Execute(context.TODO(), config, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	cp := wf.Checkpoint()
+
+	german := config
+	german.Language = "de"
+	affected, err := template.Affected(config, german)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Translate", "Combine", "Audit"}, affected)
+
+	sharper := config
+	sharper.OCR.Dpi = 300
+	affected, err = template.Affected(config, sharper)
+	assert.NoError(t, err)
+	// Translate reads the text written by OCR
+	assert.Equal(t, []string{"OCR", "Translate", "Combine", "Audit"}, affected)
+
+	affected, err = template.Affected(config, config)
+	assert.NoError(t, err)
+	assert.Empty(t, affected)
+
+	executions = map[string]int{}
+	data, st, err := template.Rerun(context.TODO(), cp, config, german)
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, map[string]int{"Translate": 1, "Combine": 1, "Audit": 1}, executions)
+	assert.Equal(t, "invoice: text@150 in de", data.Combined)
+
+	broken := goworkflow.NewTemplate[context.Context, PipelineConfig, Data]("broken")
+	broken.AddComponent(goworkflow.MakeComponent("OCR", nil, component("OCR", func(config PipelineConfig, d *Data) {})), &goworkflow.ComponentConfig{ConfigReads: []string{"Dpi"}})
+	_, err = broken.Affected(config, german)
+	assert.EqualError(t, err, "component OCR: unknown config field Dpi")
+}
@@ -0,0 +1,62 @@
+package goworkflow
+
+import (
+	"fmt"
+)
+
+/* InputValidator: inputs implementing it (e.g. DocumentAnalysisInput checking Index >= 0) are validated before the run */
+type InputValidator interface {
+	Validate() error
+}
+
+/* InputError: the input of Component is invalid, find it with errors.As in the error of Execute or ValidateInputs */
+type InputError struct {
+	Component string
+	Input     ComponentInput
+	Err       error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("invalid input of %s: %v", e.Component, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+/* validateInput: the component itself (see ComponentImplementation), InputValidator of the input, then ComponentConfig.ValidateInput */
+func validateInput(name string, validate func() error, input ComponentInput, cfg *ComponentConfig) error {
+	if validate != nil {
+		if err := validate(); err != nil {
+			return fmt.Errorf("invalid component %s: %w", name, err)
+		}
+	}
+	if v, ok := input.(InputValidator); ok {
+		if err := v.Validate(); err != nil {
+			return &InputError{Component: name, Input: input, Err: err}
+		}
+	}
+	if cfg != nil && cfg.ValidateInput != nil {
+		if err := cfg.ValidateInput(input); err != nil {
+			return &InputError{Component: name, Input: input, Err: err}
+		}
+	}
+	return nil
+}
+
+/*
+ValidateInputs validates the inputs of all components, e.g. when planning. Runs of the template fail the same way
+before any component is executed. The error is a *ValidationError of *InputError and component validation errors.
+*/
+func (t *Template[CT, C, T]) ValidateInputs() error {
+	validationErr := &ValidationError{}
+	for _, tc := range t.components {
+		if err := validateInput(tc.Name(), tc.definition.Validate, tc.definition.Input, tc.config); err != nil {
+			validationErr.add(err)
+		}
+	}
+	if len(validationErr.Problems) > 0 {
+		return validationErr
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type pageInput struct {
+	Index int
+}
+
+func (p pageInput) Validate() error {
+	if p.Index < 0 {
+		return errors.New("index cannot be negative")
+	}
+	return nil
+}
+
+func TestInputValidation(t *testing.T) {
+	executed := false
+	analyze := func(ctx context.Context, input pageInput, dt *goworkflow.DataTracker[Config, Data]) error {
+		executed = true
+		return nil
+	}
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	template.AddComponent(goworkflow.MakeComponent("Page0", pageInput{Index: 0}, analyze))
+	template.AddComponent(goworkflow.MakeComponent("PageMinus1", pageInput{Index: -1}, analyze))
+	template.AddComponent(goworkflow.MakeComponent("Page100", pageInput{Index: 100}, analyze), &goworkflow.ComponentConfig{
+		ValidateInput: func(input goworkflow.ComponentInput) error {
+			if input.(pageInput).Index >= 10 {
+				return errors.New("document has 10 pages")
+			}
+			return nil
+		},
+	})
+
+	err := template.ValidateInputs()
+	assert.EqualError(t, err, "validation failed: invalid input of PageMinus1: index cannot be negative; invalid input of Page100: document has 10 pages")
+	var inputErr *goworkflow.InputError
+	assert.True(t, errors.As(err, &inputErr))
+	assert.Equal(t, "PageMinus1", inputErr.Component)
+	assert.Equal(t, pageInput{Index: -1}, inputErr.Input)
+
+	_, st, err := template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.True(t, errors.As(err, &inputErr))
+	assert.False(t, executed)
+}
@@ -0,0 +1,179 @@
+/*
+Package mergepatch implements json merge patches (RFC 7396) of the data store, used by the packages
+which execute components outside the process.
+*/
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+ErrConflict: an array of the patch was changed in the data since the snapshot the patch was computed from, in a
+way both changes can't be kept
+*/
+var ErrConflict = errors.New("merge patch conflict")
+
+/* Apply applies patch to data, data is unchanged when the patch is invalid or doesn't fit T */
+func Apply[T any](data *T, patch []byte) error {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return fmt.Errorf("invalid data delta: %w", err)
+	}
+	currentValue, err := decode(data)
+	if err != nil {
+		return err
+	}
+	merged, err := json.Marshal(merge(currentValue, patchValue))
+	if err != nil {
+		return err
+	}
+	var updated T
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&updated); err != nil {
+		return fmt.Errorf("invalid data delta: %s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	*data = updated
+	return nil
+}
+
+/*
+ApplyOnto applies patch, computed from the json snapshot base of data, to data which may have changed since.
+Merge patches replace arrays whole, so arrays of the patch which changed in data are merged: elements appended
+to base on both sides are all kept (data first), other concurrent changes fail with ErrConflict. Data is
+unchanged on error.
+*/
+func ApplyOnto[T any](data *T, base []byte, patch []byte) error {
+	var baseValue, patchValue any
+	if err := json.Unmarshal(base, &baseValue); err != nil {
+		return fmt.Errorf("invalid data snapshot: %w", err)
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return fmt.Errorf("invalid data delta: %w", err)
+	}
+	currentValue, err := decode(data)
+	if err != nil {
+		return err
+	}
+	merged, err := merge3("", baseValue, currentValue, patchValue)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return Apply(data, raw)
+}
+
+/* merge3: patch as a patch of current, with the arrays which changed since base merged */
+func merge3(path string, base any, current any, patch any) (any, error) {
+	if patchObject, ok := patch.(map[string]any); ok {
+		baseObject, _ := base.(map[string]any)
+		currentObject, _ := current.(map[string]any)
+		merged := map[string]any{}
+		for key, value := range patchObject {
+			if value == nil {
+				merged[key] = nil
+				continue
+			}
+			var err error
+			if merged[key], err = merge3(path+"/"+key, baseObject[key], currentObject[key], value); err != nil {
+				return nil, err
+			}
+		}
+		return merged, nil
+	}
+	patchArray, ok := patch.([]any)
+	if !ok || reflect.DeepEqual(base, current) {
+		return patch, nil
+	}
+	baseArray, baseOk := base.([]any)
+	currentArray, currentOk := current.([]any)
+	if (baseOk || base == nil) && currentOk && isPrefix(baseArray, currentArray) && isPrefix(baseArray, patchArray) {
+		return append(append([]any{}, currentArray...), patchArray[len(baseArray):]...), nil
+	}
+	return nil, fmt.Errorf("%w: %s changed concurrently", ErrConflict, path)
+}
+
+func isPrefix(prefix []any, values []any) bool {
+	if len(prefix) > len(values) {
+		return false
+	}
+	for i := range prefix {
+		if !reflect.DeepEqual(prefix[i], values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/* Create: patch turning before into after */
+func Create[T any](before, after *T) ([]byte, error) {
+	beforeValue, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValue, err := decode(after)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(diff(beforeValue, afterValue))
+}
+
+func decode(data any) (any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	return value, json.Unmarshal(raw, &value)
+}
+
+func merge(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = merge(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+func diff(before any, after any) any {
+	beforeObject, beforeOk := before.(map[string]any)
+	afterObject, afterOk := after.(map[string]any)
+	if !beforeOk || !afterOk {
+		return after
+	}
+	patch := map[string]any{}
+	for key, value := range afterObject {
+		previous, ok := beforeObject[key]
+		if !ok {
+			patch[key] = value
+		} else if !reflect.DeepEqual(previous, value) {
+			patch[key] = diff(previous, value)
+		}
+	}
+	for key := range beforeObject {
+		if _, ok := afterObject[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
@@ -0,0 +1,59 @@
+package mergepatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Document struct {
+	Text     string
+	Entities []string `json:",omitempty"`
+	Meta     map[string]string
+}
+
+func TestApply(t *testing.T) {
+	data := &Document{Text: "a", Entities: []string{"x"}, Meta: map[string]string{"lang": "en", "pages": "2"}}
+	assert.NoError(t, Apply(data, []byte(`{"Text":"b","Entities":null,"Meta":{"pages":null}}`)))
+	assert.Equal(t, &Document{Text: "b", Meta: map[string]string{"lang": "en"}}, data)
+
+	assert.Error(t, Apply(data, []byte(`{"Unknown":1}`)))
+	assert.Error(t, Apply(data, []byte(`{`)))
+	assert.Equal(t, &Document{Text: "b", Meta: map[string]string{"lang": "en"}}, data)
+}
+
+func TestCreate(t *testing.T) {
+	before := &Document{Text: "a", Entities: []string{"x"}, Meta: map[string]string{"lang": "en", "pages": "2"}}
+	after := &Document{Text: "a", Meta: map[string]string{"lang": "de"}}
+	patch, err := Create(before, after)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Entities":null,"Meta":{"lang":"de","pages":null}}`, string(patch))
+
+	assert.NoError(t, Apply(before, patch))
+	assert.Equal(t, after, before)
+}
+
+func TestApplyOnto(t *testing.T) {
+	base := []byte(`{"Text":"a","Entities":["x"],"Meta":{"lang":"en"}}`)
+	// another component appended and set a key since the snapshot
+	data := &Document{Text: "a", Entities: []string{"x", "y"}, Meta: map[string]string{"lang": "en", "pages": "2"}}
+	assert.NoError(t, ApplyOnto(data, base, []byte(`{"Text":"b","Entities":["x","z"],"Meta":{"lang":null}}`)))
+	assert.Equal(t, &Document{Text: "b", Entities: []string{"x", "y", "z"}, Meta: map[string]string{"pages": "2"}}, data)
+
+	// appended to a field which was empty
+	data = &Document{Entities: []string{"y"}}
+	assert.NoError(t, ApplyOnto(data, []byte(`{}`), []byte(`{"Entities":["z"]}`)))
+	assert.Equal(t, []string{"y", "z"}, data.Entities)
+
+	// unchanged arrays are replaced
+	data = &Document{Entities: []string{"x"}}
+	assert.NoError(t, ApplyOnto(data, []byte(`{"Entities":["x"]}`), []byte(`{"Entities":["z"]}`)))
+	assert.Equal(t, []string{"z"}, data.Entities)
+
+	// changes other than appends conflict, data is unchanged
+	data = &Document{Entities: []string{"y"}}
+	err := ApplyOnto(data, []byte(`{"Entities":["x"]}`), []byte(`{"Entities":["x","z"]}`))
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.EqualError(t, err, "merge patch conflict: /Entities changed concurrently")
+	assert.Equal(t, []string{"y"}, data.Entities)
+}
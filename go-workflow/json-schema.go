@@ -0,0 +1,270 @@
+package goworkflow
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+/*
+JSONSchema: JSON Schema (draft 2020-12) of the json encoding of a Go type, see SchemaOf. Named struct types are
+in Defs and referenced by Ref, so recursive types are supported.
+*/
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+/*
+SchemaOf generates the schema of V by reflection, following the json tags. Fields are described by struct tags:
+
+	DPI      int    `json:"dpi" description:"resolution of the scans" jsonschema:"required,minimum=72,maximum=1200,default=300"`
+	Language string `json:"language,omitempty" jsonschema:"enum=en|de|fr"`
+
+jsonschema options: required, minimum=, maximum=, enum= (values separated by |), format=, default=. Enum and
+default values are parsed as json, falling back to strings. Types with a custom json encoding accept any value,
+except time.Time (a date-time string).
+*/
+func SchemaOf[V any]() *JSONSchema {
+	g := &schemaGenerator{defs: map[string]*JSONSchema{}, names: map[reflect.Type]string{}}
+	root := reflect.TypeOf((*V)(nil)).Elem()
+	for root.Kind() == reflect.Pointer {
+		root = root.Elem()
+	}
+	var schema *JSONSchema
+	if root.Kind() == reflect.Struct && !isCustomJSON(root) {
+		// the root struct is inlined, types nested in it are defs
+		g.names[root] = "#"
+		schema = g.structSchema(root)
+	} else {
+		schema = g.schema(root)
+	}
+	schema.Schema = jsonSchemaDialect
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	return schema
+}
+
+type schemaGenerator struct {
+	defs map[string]*JSONSchema
+	// names: $ref of the named struct types
+	names map[reflect.Type]string
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+/* isCustomJSON: the type encodes itself, its schema is unknown */
+func isCustomJSON(t reflect.Type) bool {
+	return t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &JSONSchema{Type: "integer", Description: "duration in nanoseconds"}
+	case t == rawJSONType, isCustomJSON(t):
+		return &JSONSchema{}
+	case t.Implements(textType) || reflect.PointerTo(t).Implements(textType):
+		return &JSONSchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &JSONSchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &JSONSchema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if ref, ok := g.names[t]; ok {
+			return &JSONSchema{Ref: ref}
+		}
+		name := t.Name()
+		for i := 2; g.defs[name] != nil; i++ {
+			name = t.Name() + strconv.Itoa(i)
+		}
+		ref := "#/$defs/" + name
+		g.names[t] = ref
+		// registered before generating the fields, for recursive types
+		g.defs[name] = &JSONSchema{}
+		*g.defs[name] = *g.structSchema(t)
+		return &JSONSchema{Ref: ref}
+	}
+	// interfaces, channels, funcs
+	return &JSONSchema{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *schemaGenerator) addFields(schema *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		// embedded structs without a json name are flattened like encoding/json does
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(schema, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		field := g.schema(f.Type)
+		if strings.Contains(","+options+",", ",string,") && (field.Type == "integer" || field.Type == "number" || field.Type == "boolean") {
+			field = &JSONSchema{Type: "string"}
+		}
+		field.Description = firstNonEmpty(f.Tag.Get("description"), field.Description)
+		if applySchemaOptions(field, f.Tag.Get("jsonschema")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = field
+	}
+}
+
+/* applySchemaOptions: the options of a jsonschema tag, true when the field is required */
+func applySchemaOptions(schema *JSONSchema, tag string) bool {
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "required":
+			required = true
+		case "minimum", "maximum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				if key == "minimum" {
+					schema.Minimum = &n
+				} else {
+					schema.Maximum = &n
+				}
+			}
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				schema.Enum = append(schema.Enum, schemaValue(v, schema.Type))
+			}
+		case "format":
+			schema.Format = value
+		case "default":
+			schema.Default = schemaValue(value, schema.Type)
+		}
+	}
+	return required
+}
+
+/* schemaValue: value as json, strings of string fields stay strings (e.g. "true") */
+func schemaValue(value string, schemaType string) any {
+	if schemaType == "string" {
+		return value
+	}
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+	return v
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+/* ConfigSchema: schema of the config of the template, e.g. to validate start run payloads of other languages */
+func (t *Template[CT, C, T]) ConfigSchema() *JSONSchema {
+	schema := SchemaOf[C]()
+	schema.Title = strings.TrimSpace(t.Name + " config")
+	return schema
+}
+
+/* DataSchema: schema of the data of the template */
+func (t *Template[CT, C, T]) DataSchema() *JSONSchema {
+	schema := SchemaOf[T]()
+	schema.Title = strings.TrimSpace(t.Name + " data")
+	return schema
+}
+
+/* SchemaHandler serves ConfigSchema on GET, DataSchema on GET ?type=data */
+func (t *Template[CT, C, T]) SchemaHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var schema *JSONSchema
+		switch r.URL.Query().Get("type") {
+		case "", "config":
+			schema = t.ConfigSchema()
+		case "data":
+			schema = t.DataSchema()
+		default:
+			http.Error(w, "type should be config or data", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		json.NewEncoder(w).Encode(schema)
+	})
+}
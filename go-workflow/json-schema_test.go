@@ -0,0 +1,95 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type ScanSettings struct {
+	DPI      int    `json:"dpi" description:"resolution of the scans" jsonschema:"required,minimum=72,maximum=1200,default=300"`
+	Language string `json:"language,omitempty" jsonschema:"enum=en|de"`
+}
+
+type Section struct {
+	Title    string
+	Children []Section `json:"children"`
+}
+
+type SchemaConfig struct {
+	OCR      ScanSettings      `json:"ocr"`
+	Timeout  time.Duration     `json:"timeout"`
+	Since    time.Time         `json:"since"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Image    []byte            `json:"image"`
+	Pages    uint              `json:"pages,string"`
+	Sections []Section         `json:"sections"`
+	Extra    json.RawMessage   `json:"extra"`
+	internal string
+	Ignored  string `json:"-"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := goworkflow.SchemaOf[SchemaConfig]()
+	encoded, err := json.Marshal(schema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"ocr": {"$ref": "#/$defs/ScanSettings"},
+			"timeout": {"type": "integer", "description": "duration in nanoseconds"},
+			"since": {"type": "string", "format": "date-time"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"image": {"type": "string", "contentEncoding": "base64"},
+			"pages": {"type": "string"},
+			"sections": {"type": "array", "items": {"$ref": "#/$defs/Section"}},
+			"extra": {}
+		},
+		"$defs": {
+			"ScanSettings": {
+				"type": "object",
+				"properties": {
+					"dpi": {"type": "integer", "description": "resolution of the scans", "minimum": 72, "maximum": 1200, "default": 300},
+					"language": {"type": "string", "enum": ["en", "de"]}
+				},
+				"required": ["dpi"]
+			},
+			"Section": {
+				"type": "object",
+				"properties": {
+					"Title": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/Section"}}
+				}
+			}
+		}
+	}`, string(encoded))
+}
+
+func TestSchemaHandler(t *testing.T) {
+	template := goworkflow.NewTemplate[context.Context, SchemaConfig, Data]("invoices")
+	server := httptest.NewServer(template.SchemaHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?type=data")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/schema+json", resp.Header.Get("Content-Type"))
+	schema := goworkflow.JSONSchema{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&schema))
+	assert.Equal(t, "invoices data", schema.Title)
+	assert.Len(t, schema.Properties, 4)
+	assert.Equal(t, "string", schema.Properties["Combined"].Type)
+	assert.Equal(t, "invoices config", template.ConfigSchema().Title)
+
+	resp, err = http.Get(server.URL + "?type=secrets")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
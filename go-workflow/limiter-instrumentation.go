@@ -0,0 +1,57 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/* LimiterWait: time a component waited for the ticket of a limiter, see ComponentTiming.LimiterWaits */
+type LimiterWait struct {
+	Limiter   string
+	StartedAt time.Time
+	Wait      time.Duration
+}
+
+/* SetLimiterInstrumentation: inst observes the acquisitions and releases of all limiters of the run */
+func (wf *Workflow[CT, C, T]) SetLimiterInstrumentation(inst limiter.Instrumentation) {
+	wf.limiterInstrumentation = inst
+}
+
+func limiterName(l limiter.Limiter) string {
+	if named, ok := l.(limiter.Named); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", l)
+}
+
+/*
+acquireLimiter: calls acquire, records the wait in the timing of the component and reports it to the
+instrumentation. The returned function releases the ticket.
+*/
+func (wf *Workflow[CT, C, T]) acquireLimiter(ctx context.Context, c *component[CT, C, T], name string, acquire func(), release func()) func() {
+	inst := wf.limiterInstrumentation
+	e := limiter.Event{Limiter: name, Component: c.Name}
+	if inst != nil {
+		inst.AcquireStarted(ctx, e)
+	}
+	started := time.Now()
+	acquire()
+	acquired := time.Now()
+	e.Wait = acquired.Sub(started)
+	c.statusLock.Lock()
+	c.timing.LimiterWaits = append(c.timing.LimiterWaits, LimiterWait{Limiter: name, StartedAt: started, Wait: e.Wait})
+	c.statusLock.Unlock()
+	if inst != nil {
+		inst.Acquired(ctx, e)
+	}
+	return func() {
+		release()
+		if inst != nil {
+			e.Held = time.Since(acquired)
+			inst.Released(ctx, e)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package goworkflow_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenLimiter struct {
+	tokens chan struct{}
+}
+
+func (l *tokenLimiter) Acquire()     { l.tokens <- struct{}{} }
+func (l *tokenLimiter) Release()     { <-l.tokens }
+func (l *tokenLimiter) Name() string { return "tokens" }
+
+type recordingInstrumentation struct {
+	lock   sync.Mutex
+	events []string
+	waits  map[string]time.Duration
+}
+
+func (r *recordingInstrumentation) record(kind string, e limiter.Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, kind+" "+e.Component+" "+e.Limiter)
+	if kind == "acquired" {
+		r.waits[e.Component] = e.Wait
+	}
+}
+
+func (r *recordingInstrumentation) AcquireStarted(ctx context.Context, e limiter.Event) {
+	r.record("started", e)
+}
+func (r *recordingInstrumentation) Acquired(ctx context.Context, e limiter.Event) {
+	r.record("acquired", e)
+}
+func (r *recordingInstrumentation) Released(ctx context.Context, e limiter.Event) {
+	r.record("released", e)
+}
+
+func TestLimiterInstrumentation(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	inst := &recordingInstrumentation{waits: map[string]time.Duration{}}
+	wf.SetLimiterInstrumentation(inst)
+	tokens := &tokenLimiter{tokens: make(chan struct{}, 1)}
+	sleep := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	first := wf.AddComponent(goworkflow.MakeComponent("First", nil, sleep), &goworkflow.ComponentConfig{Limiter: tokens})
+	second := wf.AddComponent(goworkflow.MakeComponent("Second", nil, sleep), &goworkflow.ComponentConfig{
+		Limiter:            tokens,
+		ConcurrencyLimiter: limiter.NewConcurrencyLimiter(1),
+	})
+	third := wf.AddComponent(goworkflow.MakeComponent("Third", nil, sleep), &goworkflow.ComponentConfig{Limiter: tokens})
+	third.AddDependencies(first, second)
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	// every acquisition is started, acquired and released once
+	counts := map[string]int{}
+	for _, e := range inst.events {
+		counts[e]++
+	}
+	for _, e := range []string{"First tokens", "Second tokens", "Second concurrency", "Third tokens"} {
+		for _, kind := range []string{"started", "acquired", "released"} {
+			assert.Equal(t, 1, counts[kind+" "+e], kind+" "+e)
+		}
+	}
+	// First and Second share one token, one of them waited for the other
+	assert.Greater(t, max(inst.waits["First"], inst.waits["Second"]), 10*time.Millisecond)
+
+	for _, ct := range wf.Timeline() {
+		names := []string{}
+		for _, lw := range ct.LimiterWaits {
+			names = append(names, lw.Limiter)
+		}
+		if ct.Component == "Second" {
+			assert.Equal(t, []string{"concurrency", "tokens"}, names)
+		} else {
+			assert.Equal(t, []string{"tokens"}, names)
+		}
+	}
+}
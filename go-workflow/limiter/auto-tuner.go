@@ -0,0 +1,173 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+type AutoTuneOptions struct {
+	// Min and Max: bounds of the capacity set by the operator
+	Min int
+	Max int
+	// Interval between adjustments when started with Run, defaults to a minute
+	Interval time.Duration
+	// MinSamples: windows with fewer observations don't change the capacity, defaults to 10
+	MinSamples int
+	// MaxErrorRate: the capacity shrinks when a larger share of the observations failed, defaults to 0.05
+	MaxErrorRate float64
+	// TargetLatency: the capacity shrinks when the p95 latency is above it, 0 disables the check
+	TargetLatency time.Duration
+	// Step: additive increase, defaults to 1
+	Step int
+	// Backoff: multiplicative decrease, defaults to 0.75
+	Backoff float64
+	// OnAdjust is called after every evaluated window, e.g. for logging
+	OnAdjust func(Adjustment)
+}
+
+/* Adjustment: outcome of one window, From == To when the capacity was kept */
+type Adjustment struct {
+	From       int
+	To         int
+	Reason     string
+	Samples    int
+	ErrorRate  float64
+	P95        time.Duration
+	Throughput float64 // observations per second
+}
+
+/*
+AutoTuner adjusts the capacity of a ConcurrencyLimiter from the observations reported with ConcurrencyLimiter.Observe
+(the workflow reports every component execution). It shrinks multiplicatively on errors or high latency and grows
+additively while the limiter is saturated and growing keeps improving throughput.
+*/
+type AutoTuner struct {
+	limiter *ConcurrencyLimiter
+	opts    AutoTuneOptions
+
+	lock        sync.Mutex
+	windowStart time.Time
+	latencies   []time.Duration
+	errors      int
+
+	now func() time.Time
+
+	lastThroughput float64
+	lastIncreased  bool
+	cooldown       int
+}
+
+func NewAutoTuner(cl *ConcurrencyLimiter, opts AutoTuneOptions) *AutoTuner {
+	if opts.Min <= 0 || opts.Max < opts.Min {
+		panic("auto tuner needs 0 < Min <= Max")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = 10
+	}
+	if opts.MaxErrorRate <= 0 {
+		opts.MaxErrorRate = 0.05
+	}
+	if opts.Step <= 0 {
+		opts.Step = 1
+	}
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.75
+	}
+	a := &AutoTuner{limiter: cl, opts: opts, now: time.Now}
+	a.windowStart = a.now()
+	cl.lock.Lock()
+	cl.observer = a.observe
+	cl.lock.Unlock()
+	cl.SetCapacity(min(max(cl.Capacity(), opts.Min), opts.Max))
+	return a
+}
+
+func (a *AutoTuner) observe(latency time.Duration, err error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.latencies = append(a.latencies, latency)
+	if err != nil {
+		a.errors++
+	}
+}
+
+/* Run adjusts the capacity every Interval until ctx is done */
+func (a *AutoTuner) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Adjust()
+		}
+	}
+}
+
+/* Adjust evaluates the observations since the last call and resizes the limiter */
+func (a *AutoTuner) Adjust() Adjustment {
+	a.lock.Lock()
+	latencies, errs := a.latencies, a.errors
+	now := a.now()
+	elapsed := now.Sub(a.windowStart)
+	a.latencies, a.errors, a.windowStart = nil, 0, now
+	a.lock.Unlock()
+
+	capacity := a.limiter.Capacity()
+	peak := a.limiter.takePeak()
+	adj := Adjustment{From: capacity, To: capacity, Samples: len(latencies)}
+	if len(latencies) > 0 {
+		adj.ErrorRate = float64(errs) / float64(len(latencies))
+		slices.Sort(latencies)
+		adj.P95 = latencies[int(math.Ceil(0.95*float64(len(latencies))))-1]
+	}
+	if elapsed > 0 {
+		adj.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+
+	increased := false
+	switch {
+	case len(latencies) < a.opts.MinSamples:
+		adj.Reason = "not enough samples"
+	case adj.ErrorRate > a.opts.MaxErrorRate:
+		adj.To = int(float64(capacity) * a.opts.Backoff)
+		adj.Reason = "error rate above limit"
+	case a.opts.TargetLatency > 0 && adj.P95 > a.opts.TargetLatency:
+		adj.To = int(float64(capacity) * a.opts.Backoff)
+		adj.Reason = "p95 latency above target"
+	case a.lastIncreased && adj.Throughput < a.lastThroughput*1.05:
+		// the last increase didn't pay off, go back and stay there for a while
+		adj.To = capacity - a.opts.Step
+		adj.Reason = "increase did not improve throughput"
+		a.cooldown = 3
+	case a.cooldown > 0:
+		a.cooldown--
+		adj.Reason = "cooling down"
+	case peak < capacity:
+		adj.Reason = "not saturated"
+	default:
+		adj.To = capacity + a.opts.Step
+		adj.Reason = "saturated"
+		increased = true
+	}
+	adj.To = min(max(adj.To, a.opts.Min), a.opts.Max)
+	increased = increased && adj.To > capacity
+	if len(latencies) >= a.opts.MinSamples {
+		a.lastThroughput = adj.Throughput
+		a.lastIncreased = increased
+	}
+	if adj.To != capacity {
+		a.limiter.SetCapacity(adj.To)
+	}
+	if a.opts.OnAdjust != nil {
+		a.opts.OnAdjust(adj)
+	}
+	return adj
+}
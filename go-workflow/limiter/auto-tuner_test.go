@@ -0,0 +1,120 @@
+package limiter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func saturate(cl *ConcurrencyLimiter, n int, latency time.Duration, err error) {
+	for i := 0; i < cl.Capacity(); i++ {
+		cl.Acquire()
+	}
+	for i := 0; i < n; i++ {
+		cl.Observe(latency, err)
+	}
+	for i := cl.InUse(); i > 0; i-- {
+		cl.Release()
+	}
+}
+
+func TestAutoTunerGrowsWhileSaturated(t *testing.T) {
+	cl := NewConcurrencyLimiter(4)
+	tuner := NewAutoTuner(cl, AutoTuneOptions{Min: 2, Max: 6, Step: 1})
+	clock := tuner.windowStart
+	tuner.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	saturate(cl, 20, time.Millisecond, nil)
+	adj := tuner.Adjust()
+	assert.Equal(t, "saturated", adj.Reason)
+	assert.Equal(t, 20.0, adj.Throughput)
+	assert.Equal(t, 5, cl.Capacity())
+
+	// the increase paid off
+	saturate(cl, 40, time.Millisecond, nil)
+	adj = tuner.Adjust()
+	assert.Equal(t, "saturated", adj.Reason)
+	assert.Equal(t, 6, cl.Capacity())
+
+	// the increase didn't pay off, step back
+	saturate(cl, 40, time.Millisecond, nil)
+	adj = tuner.Adjust()
+	assert.Equal(t, "increase did not improve throughput", adj.Reason)
+	assert.Equal(t, 5, cl.Capacity())
+
+	saturate(cl, 40, time.Millisecond, nil)
+	assert.Equal(t, "cooling down", tuner.Adjust().Reason)
+
+	// bounded by Max
+	tuner.cooldown = 0
+	saturate(cl, 200, time.Millisecond, nil)
+	tuner.Adjust()
+	saturate(cl, 400, time.Millisecond, nil)
+	adj = tuner.Adjust()
+	assert.Equal(t, 6, adj.To)
+	assert.Equal(t, 6, cl.Capacity())
+}
+
+func TestAutoTunerShrinksOnErrorsAndLatency(t *testing.T) {
+	cl := NewConcurrencyLimiter(8)
+	adjustments := []Adjustment{}
+	tuner := NewAutoTuner(cl, AutoTuneOptions{Min: 3, Max: 10, TargetLatency: 100 * time.Millisecond, OnAdjust: func(a Adjustment) {
+		adjustments = append(adjustments, a)
+	}})
+
+	saturate(cl, 10, time.Millisecond, errors.New("overloaded"))
+	tuner.Adjust()
+	assert.Equal(t, 6, cl.Capacity())
+
+	saturate(cl, 10, time.Second, nil)
+	tuner.Adjust()
+	assert.Equal(t, 4, cl.Capacity())
+
+	saturate(cl, 10, time.Second, nil)
+	tuner.Adjust()
+	assert.Equal(t, 3, cl.Capacity(), "bounded by Min")
+
+	assert.Equal(t, []string{"error rate above limit", "p95 latency above target", "p95 latency above target"},
+		[]string{adjustments[0].Reason, adjustments[1].Reason, adjustments[2].Reason})
+	assert.Equal(t, 1.0, adjustments[0].ErrorRate)
+	assert.Equal(t, time.Second, adjustments[1].P95)
+}
+
+func TestAutoTunerHolds(t *testing.T) {
+	cl := NewConcurrencyLimiter(4)
+	tuner := NewAutoTuner(cl, AutoTuneOptions{Min: 1, Max: 10})
+
+	cl.Observe(time.Millisecond, nil)
+	assert.Equal(t, "not enough samples", tuner.Adjust().Reason)
+
+	for i := 0; i < 20; i++ {
+		cl.Acquire()
+		cl.Observe(time.Millisecond, nil)
+		cl.Release()
+	}
+	assert.Equal(t, "not saturated", tuner.Adjust().Reason)
+	assert.Equal(t, 4, cl.Capacity())
+}
+
+func TestSetCapacity(t *testing.T) {
+	cl := NewConcurrencyLimiter(1)
+	cl.Acquire()
+	acquired := make(chan struct{})
+	go func() {
+		cl.Acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired above capacity")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cl.SetCapacity(2)
+	<-acquired
+	assert.Equal(t, 2, cl.InUse())
+}
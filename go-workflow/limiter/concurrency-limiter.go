@@ -1,19 +1,127 @@
 package limiter
 
+import (
+	"sync"
+	"time"
+)
+
+/*
+ConcurrencyLimiter hands out up to capacity tickets. It is fair: waiting Acquire calls get their tickets in the
+order they started waiting (FIFO), also when the capacity grows or a pause ends, and a new Acquire never takes
+a ticket ahead of a waiting one. Components acquiring in order (e.g. pages front to back) start in that order.
+*/
 type ConcurrencyLimiter struct {
-	tickets chan struct{}
+	lock     sync.Mutex
+	capacity int
+	inUse    int
+	// waiting Acquire calls in arrival order, a ticket is handed over by closing the channel
+	waiting []chan struct{}
+	// highest inUse since the last takePeak, see AutoTuner
+	peak     int
+	observer func(latency time.Duration, err error)
+	// Acquire blocks until then, see Pause
+	pausedUntil time.Time
 }
 
 func NewConcurrencyLimiter(maxConcurrency int) *ConcurrencyLimiter {
-	return &ConcurrencyLimiter{
-		tickets: make(chan struct{}, maxConcurrency),
-	}
+	return &ConcurrencyLimiter{capacity: maxConcurrency}
 }
 
 func (cl *ConcurrencyLimiter) Acquire() {
-	cl.tickets <- struct{}{} // acquire a ticket
+	cl.lock.Lock()
+	if len(cl.waiting) == 0 && cl.available() {
+		cl.take()
+		cl.lock.Unlock()
+		return
+	}
+	granted := make(chan struct{})
+	cl.waiting = append(cl.waiting, granted)
+	cl.lock.Unlock()
+	<-granted
 }
 
 func (cl *ConcurrencyLimiter) Release() {
-	<-cl.tickets // release a ticket
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	cl.inUse--
+	cl.grant()
+}
+
+func (cl *ConcurrencyLimiter) available() bool {
+	return cl.inUse < cl.capacity && !time.Now().Before(cl.pausedUntil)
+}
+
+func (cl *ConcurrencyLimiter) take() {
+	cl.inUse++
+	cl.peak = max(cl.peak, cl.inUse)
+}
+
+/* grant: hands free tickets to the longest waiting Acquire calls, called with the lock held */
+func (cl *ConcurrencyLimiter) grant() {
+	for len(cl.waiting) > 0 && cl.available() {
+		cl.take()
+		close(cl.waiting[0])
+		cl.waiting = cl.waiting[1:]
+	}
+}
+
+/*
+Pause: no tickets are handed out for d, e.g. when the rate limited dependency asked to retry after d.
+Holders keep their tickets, overlapping pauses end with the latest one.
+*/
+func (cl *ConcurrencyLimiter) Pause(d time.Duration) {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	until := time.Now().Add(d)
+	if !until.After(cl.pausedUntil) {
+		return
+	}
+	cl.pausedUntil = until
+	time.AfterFunc(d, func() {
+		cl.lock.Lock()
+		defer cl.lock.Unlock()
+		cl.grant()
+	})
+}
+
+/* InUse: tickets currently held */
+func (cl *ConcurrencyLimiter) InUse() int {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	return cl.inUse
+}
+
+func (cl *ConcurrencyLimiter) Capacity() int {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	return cl.capacity
+}
+
+/* SetCapacity resizes the limiter, holders above a reduced capacity keep their tickets until they release them */
+func (cl *ConcurrencyLimiter) SetCapacity(capacity int) {
+	if capacity <= 0 {
+		panic("capacity must be positive")
+	}
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	cl.capacity = capacity
+	cl.grant()
+}
+
+/* Observe reports the latency and outcome of work done while holding a ticket, consumed by an AutoTuner */
+func (cl *ConcurrencyLimiter) Observe(latency time.Duration, err error) {
+	cl.lock.Lock()
+	observer := cl.observer
+	cl.lock.Unlock()
+	if observer != nil {
+		observer(latency, err)
+	}
+}
+
+func (cl *ConcurrencyLimiter) takePeak() int {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	peak := cl.peak
+	cl.peak = cl.inUse
+	return peak
 }
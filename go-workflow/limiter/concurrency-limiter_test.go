@@ -43,3 +43,52 @@ func TestConcurrencyLimiter(t *testing.T) {
 	assert.True(t, math.Abs(float64(timeElapsed.Milliseconds()-10*1000)) < 100, "should have takes equal to 10s")
 	assert.Equal(t, maxConcurrency, maxCount)
 }
+
+func TestConcurrencyLimiterPause(t *testing.T) {
+	cl := NewConcurrencyLimiter(2)
+	cl.Acquire()
+	cl.Pause(30 * time.Millisecond)
+	cl.Pause(time.Millisecond)
+
+	started := time.Now()
+	cl.Acquire()
+	assert.GreaterOrEqual(t, time.Since(started), 30*time.Millisecond)
+	assert.Equal(t, 2, cl.InUse())
+}
+
+func TestConcurrencyLimiterFIFO(t *testing.T) {
+	cl := NewConcurrencyLimiter(1)
+	cl.Acquire()
+
+	lk := sync.Mutex{}
+	order := []int{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cl.Acquire()
+			lk.Lock()
+			order = append(order, i)
+			lk.Unlock()
+			cl.Release()
+		}(i)
+		// wait until the goroutine is queued before starting the next one
+		for {
+			cl.lock.Lock()
+			queued := len(cl.waiting)
+			cl.lock.Unlock()
+			if queued == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	cl.Release()
+	wg.Wait()
+	expected := []int{}
+	for i := 0; i < 20; i++ {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, order)
+}
@@ -0,0 +1,40 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+/* Limiter: anything handing out tickets, ConcurrencyLimiter is one. Custom limiters plug into ComponentConfig.Limiter */
+type Limiter interface {
+	Acquire()
+	Release()
+}
+
+/* Named limiters report their name in Events, others are reported by type */
+type Named interface {
+	Name() string
+}
+
+/*
+Event: one acquisition of a limiter by a component. Wait is set once the ticket was acquired,
+Held once it was released.
+*/
+type Event struct {
+	// Limiter: bulkhead:<name>, concurrency, slots, workflow or the name of a custom limiter
+	Limiter   string
+	Component string
+	Wait      time.Duration
+	Held      time.Duration
+}
+
+/*
+Instrumentation observes every limiter of a run (bulkheads, concurrency limiters, slot pools, the workflow
+parallelism and custom limiters), e.g. to export wait times as metrics or spans. Hooks run on the goroutine
+of the component, ctx is its context, they must not block.
+*/
+type Instrumentation interface {
+	AcquireStarted(ctx context.Context, e Event)
+	Acquired(ctx context.Context, e Event)
+	Released(ctx context.Context, e Event)
+}
@@ -0,0 +1,206 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+/* ErrTicketReleased: the ticket gave its capacity back with Done, it cannot be waited for again */
+var ErrTicketReleased = errors.New("run queue ticket is released")
+
+/*
+RunQueue bounds how many runs execute at once, runs beyond the bound wait in the queue.
+Waiting runs are admitted by priority (higher first) and FIFO within the same priority.
+*/
+type RunQueue struct {
+	lock       sync.Mutex
+	maxRunning int
+	running    int
+	waiting    []*Ticket
+}
+
+type Ticket struct {
+	queue    *RunQueue
+	priority int
+	admitted chan struct{}
+	queued   bool
+	released bool
+	// err: cancellation which released the ticket
+	err error
+}
+
+func NewRunQueue(maxRunning int) *RunQueue {
+	if maxRunning <= 0 {
+		panic("maxRunning should be greater than 0")
+	}
+	return &RunQueue{maxRunning: maxRunning}
+}
+
+/* Enqueue: ticket is admitted immediately if there is capacity, otherwise it waits in the queue */
+func (q *RunQueue) Enqueue() *Ticket {
+	return q.EnqueueWithPriority(0)
+}
+
+func (q *RunQueue) EnqueueWithPriority(priority int) *Ticket {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	t := &Ticket{queue: q, priority: priority, admitted: make(chan struct{})}
+	q.insert(t, false)
+	q.admit()
+	return t
+}
+
+/* insert keeps waiting sorted by priority, ahead places t before tickets of the same priority */
+func (q *RunQueue) insert(t *Ticket, ahead bool) {
+	i := 0
+	for i < len(q.waiting) {
+		w := q.waiting[i]
+		if w.priority < t.priority || (ahead && w.priority == t.priority) {
+			break
+		}
+		i++
+	}
+	q.waiting = append(q.waiting, nil)
+	copy(q.waiting[i+1:], q.waiting[i:])
+	q.waiting[i] = t
+	t.queued = true
+}
+
+func (q *RunQueue) remove(t *Ticket) {
+	for i, w := range q.waiting {
+		if w == t {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			break
+		}
+	}
+	t.queued = false
+}
+
+func (q *RunQueue) admit() {
+	for q.running < q.maxRunning && len(q.waiting) > 0 {
+		t := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		t.queued = false
+		q.running++
+		close(t.admitted)
+	}
+}
+
+func (q *RunQueue) Running() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.running
+}
+
+func (q *RunQueue) Queued() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.waiting)
+}
+
+/* Position: 0 once admitted, otherwise 1 based position in the queue */
+func (t *Ticket) Position() int {
+	t.queue.lock.Lock()
+	defer t.queue.lock.Unlock()
+
+	for i, w := range t.queue.waiting {
+		if w == t {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+/* Wait blocks until the ticket is admitted, a cancelled ctx removes the ticket from the queue */
+func (t *Ticket) Wait(ctx context.Context) error {
+	t.queue.lock.Lock()
+	if t.released {
+		t.queue.lock.Unlock()
+		return t.releasedErr()
+	}
+	admitted := t.admitted
+	t.queue.lock.Unlock()
+
+	select {
+	case <-admitted:
+		return nil
+	default:
+	}
+	select {
+	case <-admitted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	t.queue.lock.Lock()
+	defer t.queue.lock.Unlock()
+	if t.released {
+		return t.releasedErr()
+	}
+	t.err = ctx.Err()
+	if t.queued {
+		t.queue.remove(t)
+		t.released = true
+		return t.err
+	}
+	// admitted concurrently with the cancellation, give the capacity back
+	t.release()
+	return t.err
+}
+
+/*
+Yield is called by an admitted run before it starts more work. If a higher priority ticket is waiting,
+the run gives its capacity to it and blocks until it is admitted again. Work that already started is not interrupted.
+Once the ticket is released, e.g. by a cancelled Yield, the run holds no capacity and Yield returns the cancellation
+error or ErrTicketReleased.
+*/
+func (t *Ticket) Yield(ctx context.Context) error {
+	t.queue.lock.Lock()
+	if t.released {
+		t.queue.lock.Unlock()
+		return t.releasedErr()
+	}
+	if !t.queued {
+		if len(t.queue.waiting) == 0 || t.queue.waiting[0].priority <= t.priority {
+			t.queue.lock.Unlock()
+			return nil
+		}
+		t.queue.running--
+		t.admitted = make(chan struct{})
+		t.queue.insert(t, true)
+		t.queue.admit()
+	}
+	t.queue.lock.Unlock()
+	return t.Wait(ctx)
+}
+
+/* Done releases the capacity held by an admitted ticket */
+func (t *Ticket) Done() {
+	t.queue.lock.Lock()
+	defer t.queue.lock.Unlock()
+	if t.queued {
+		t.queue.remove(t)
+		t.released = true
+		return
+	}
+	t.release()
+}
+
+/* releasedErr: error of a released ticket, requires the queue lock */
+func (t *Ticket) releasedErr() error {
+	if t.err != nil {
+		return t.err
+	}
+	return ErrTicketReleased
+}
+
+func (t *Ticket) release() {
+	if t.released {
+		return
+	}
+	t.released = true
+	t.queue.running--
+	t.queue.admit()
+}
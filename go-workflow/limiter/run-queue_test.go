@@ -0,0 +1,108 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunQueuePositions(t *testing.T) {
+	q := NewRunQueue(1)
+
+	first := q.Enqueue()
+	second := q.Enqueue()
+	third := q.Enqueue()
+
+	assert.NoError(t, first.Wait(context.Background()))
+	assert.Equal(t, 0, first.Position())
+	assert.Equal(t, 1, second.Position())
+	assert.Equal(t, 2, third.Position())
+	assert.Equal(t, 1, q.Running())
+	assert.Equal(t, 2, q.Queued())
+
+	first.Done()
+	assert.NoError(t, second.Wait(context.Background()))
+	assert.Equal(t, 1, third.Position())
+}
+
+func TestRunQueueCancelWhileQueued(t *testing.T) {
+	q := NewRunQueue(1)
+
+	first := q.Enqueue()
+	second := q.Enqueue()
+	third := q.Enqueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, second.Wait(ctx), context.Canceled)
+	assert.Equal(t, 1, third.Position())
+
+	first.Done()
+	assert.NoError(t, third.Wait(context.Background()))
+	assert.Equal(t, 1, q.Running())
+	assert.Equal(t, 0, q.Queued())
+}
+
+func TestRunQueuePriority(t *testing.T) {
+	q := NewRunQueue(1)
+
+	running := q.Enqueue()
+	low := q.EnqueueWithPriority(-1)
+	normal := q.Enqueue()
+	high := q.EnqueueWithPriority(10)
+
+	assert.Equal(t, 0, running.Position())
+	assert.Equal(t, 1, high.Position())
+	assert.Equal(t, 2, normal.Position())
+	assert.Equal(t, 3, low.Position())
+}
+
+func TestRunQueueYield(t *testing.T) {
+	q := NewRunQueue(1)
+	ctx := context.Background()
+
+	background := q.EnqueueWithPriority(-1)
+	assert.NoError(t, background.Yield(ctx), "nothing is waiting, yield should not block")
+
+	high := q.EnqueueWithPriority(1)
+	yielded := make(chan error)
+	go func() {
+		yielded <- background.Yield(ctx)
+	}()
+
+	assert.NoError(t, high.Wait(ctx))
+	assert.Equal(t, 1, background.Position())
+	high.Done()
+
+	assert.NoError(t, <-yielded)
+	assert.Equal(t, 0, background.Position())
+	background.Done()
+	assert.Equal(t, 0, q.Running())
+}
+
+func TestRunQueueYieldAfterRelease(t *testing.T) {
+	q := NewRunQueue(1)
+	background := q.EnqueueWithPriority(-1)
+	high := q.EnqueueWithPriority(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	yielded := make(chan error)
+	go func() {
+		yielded <- background.Yield(ctx)
+	}()
+	assert.NoError(t, high.Wait(context.Background()))
+	cancel()
+	assert.ErrorIs(t, <-yielded, context.Canceled)
+
+	// the run holds no capacity anymore
+	high.Done()
+	assert.Equal(t, 0, q.Running())
+	assert.ErrorIs(t, background.Yield(context.Background()), context.Canceled)
+	assert.ErrorIs(t, background.Wait(context.Background()), context.Canceled)
+	assert.Equal(t, 0, q.Running())
+
+	done := q.Enqueue()
+	done.Done()
+	assert.ErrorIs(t, done.Yield(context.Background()), ErrTicketReleased)
+}
@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+SlotPool hands out named slots (e.g. GPU indices) so that no two holders share one.
+Acquire prefers the slot last handed out for the same affinity key, so a component
+keeps landing on the device where its model is already loaded whenever that device is free.
+*/
+type SlotPool struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	slots    []string
+	free     map[string]bool
+	affinity map[string]string
+}
+
+func NewSlotPool(slots ...string) *SlotPool {
+	if len(slots) == 0 {
+		panic("slot pool needs at least one slot")
+	}
+	sp := &SlotPool{
+		slots:    slots,
+		free:     map[string]bool{},
+		affinity: map[string]string{},
+	}
+	for _, s := range slots {
+		if sp.free[s] {
+			panic("duplicate slot: " + s)
+		}
+		sp.free[s] = true
+	}
+	sp.cond = sync.NewCond(&sp.lock)
+	return sp
+}
+
+/* Acquire blocks until a slot is free and returns it */
+func (sp *SlotPool) Acquire(affinityKey string) string {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	for {
+		if preferred, ok := sp.affinity[affinityKey]; ok && sp.free[preferred] {
+			sp.free[preferred] = false
+			return preferred
+		}
+		for _, s := range sp.slots {
+			if sp.free[s] {
+				sp.free[s] = false
+				sp.affinity[affinityKey] = s
+				return s
+			}
+		}
+		sp.cond.Wait()
+	}
+}
+
+func (sp *SlotPool) Release(slot string) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	if _, ok := sp.free[slot]; !ok {
+		panic("unknown slot: " + slot)
+	}
+	sp.free[slot] = true
+	sp.cond.Broadcast()
+}
+
+type slotContextKey struct{}
+
+func ContextWithSlot(ctx context.Context, slot string) context.Context {
+	return context.WithValue(ctx, slotContextKey{}, slot)
+}
+
+/* SlotFromContext: slot assigned to the running component, if any */
+func SlotFromContext(ctx context.Context) (string, bool) {
+	slot, ok := ctx.Value(slotContextKey{}).(string)
+	return slot, ok
+}
+
+/* InUse: slots currently handed out */
+func (sp *SlotPool) InUse() int {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	inUse := 0
+	for _, free := range sp.free {
+		if !free {
+			inUse++
+		}
+	}
+	return inUse
+}
+
+func (sp *SlotPool) Capacity() int {
+	return len(sp.slots)
+}
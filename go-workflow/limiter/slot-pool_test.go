@@ -0,0 +1,59 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotPoolExclusive(t *testing.T) {
+	sp := NewSlotPool("gpu0", "gpu1")
+
+	lk := sync.Mutex{}
+	inUse := map[string]bool{}
+	shared := false
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slot := sp.Acquire("inference")
+			lk.Lock()
+			if inUse[slot] {
+				shared = true
+			}
+			inUse[slot] = true
+			lk.Unlock()
+
+			lk.Lock()
+			inUse[slot] = false
+			lk.Unlock()
+			sp.Release(slot)
+		}()
+	}
+	wg.Wait()
+	assert.False(t, shared, "two holders should never share a slot")
+}
+
+func TestSlotPoolAffinity(t *testing.T) {
+	sp := NewSlotPool("gpu0", "gpu1", "gpu2")
+
+	first := sp.Acquire("modelA")
+	other := sp.Acquire("modelB")
+	sp.Release(first)
+	sp.Release(other)
+
+	assert.Equal(t, other, sp.Acquire("modelB"))
+	assert.Equal(t, first, sp.Acquire("modelA"))
+
+	ctx := ContextWithSlot(context.Background(), first)
+	slot, ok := SlotFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, first, slot)
+
+	_, ok = SlotFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,133 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+TokenLimiter enforces requests per minute and tokens per minute at once, like the rate limits of LLM providers.
+Both budgets refill continuously, a full minute of budget can be spent in a burst. A request reserves its
+estimated tokens up front and reports the actual usage once the response arrived: unused tokens go back to the
+budget, tokens used beyond the estimate are taken from the budget of the next requests.
+Reservations are served one at a time in arrival order, so large requests aren't starved by small ones.
+*/
+type TokenLimiter struct {
+	name string
+	// head: held by the reservation waiting for budget
+	head chan struct{}
+
+	lock              sync.Mutex
+	requestsPerMinute float64
+	tokensPerMinute   float64
+	requests          float64
+	tokens            float64
+	refilled          time.Time
+	usage             TokenUsage
+}
+
+/* TokenUsage: requests and actual tokens (estimated ones until reported) spent through a TokenLimiter */
+type TokenUsage struct {
+	Requests int
+	Tokens   int
+}
+
+/* TokenReservation: budget taken by one request, see TokenLimiter.Reserve */
+type TokenReservation struct {
+	limiter *TokenLimiter
+	lock    sync.Mutex
+	charged int
+}
+
+func NewTokenLimiter(name string, requestsPerMinute int, tokensPerMinute int) *TokenLimiter {
+	if requestsPerMinute <= 0 || tokensPerMinute <= 0 {
+		panic("requests and tokens per minute must be positive")
+	}
+	return &TokenLimiter{
+		name:              name,
+		head:              make(chan struct{}, 1),
+		requestsPerMinute: float64(requestsPerMinute),
+		tokensPerMinute:   float64(tokensPerMinute),
+		requests:          float64(requestsPerMinute),
+		tokens:            float64(tokensPerMinute),
+		refilled:          time.Now(),
+	}
+}
+
+func (l *TokenLimiter) Name() string {
+	return l.name
+}
+
+/* refill: budget earned since the last refill, called with the lock held */
+func (l *TokenLimiter) refill(now time.Time) {
+	minutes := now.Sub(l.refilled).Minutes()
+	l.requests = min(l.requestsPerMinute, l.requests+minutes*l.requestsPerMinute)
+	l.tokens = min(l.tokensPerMinute, l.tokens+minutes*l.tokensPerMinute)
+	l.refilled = now
+}
+
+/*
+Reserve blocks until a request and the estimated tokens are available or ctx is done. Estimates above the
+tokens per minute wait for a full budget and leave a debt.
+*/
+func (l *TokenLimiter) Reserve(ctx context.Context, estimatedTokens int) (*TokenReservation, error) {
+	select {
+	case l.head <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-l.head }()
+	estimatedTokens = max(estimatedTokens, 0)
+	needed := min(float64(estimatedTokens), l.tokensPerMinute)
+	for {
+		l.lock.Lock()
+		l.refill(time.Now())
+		if l.requests >= 1 && l.tokens >= needed {
+			l.requests--
+			l.tokens -= float64(estimatedTokens)
+			l.usage.Requests++
+			l.usage.Tokens += estimatedTokens
+			l.lock.Unlock()
+			return &TokenReservation{limiter: l, charged: estimatedTokens}, nil
+		}
+		wait := max(l.waitFor(1-l.requests, l.requestsPerMinute), l.waitFor(needed-l.tokens, l.tokensPerMinute))
+		l.lock.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/* waitFor: time until missing budget refilled at perMinute */
+func (l *TokenLimiter) waitFor(missing float64, perMinute float64) time.Duration {
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / perMinute * float64(time.Minute))
+}
+
+/* Usage: requests and tokens spent so far */
+func (l *TokenLimiter) Usage() TokenUsage {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.usage
+}
+
+/* Report: actual tokens used by the request, the difference to the estimate is given back or taken from the budget */
+func (r *TokenReservation) Report(actualTokens int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	actualTokens = max(actualTokens, 0)
+	l := r.limiter
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.refill(time.Now())
+	l.tokens = min(l.tokensPerMinute, l.tokens-float64(actualTokens-r.charged))
+	l.usage.Tokens += actualTokens - r.charged
+	r.charged = actualTokens
+}
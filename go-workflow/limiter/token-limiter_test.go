@@ -0,0 +1,51 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenLimiter(t *testing.T) {
+	// 10000 tokens per second
+	l := NewTokenLimiter("llm", 6000, 600000)
+	started := time.Now()
+	r, err := l.Reserve(context.TODO(), 600000)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(started), 10*time.Millisecond)
+
+	// the budget is spent, 1000 tokens refill in 100ms
+	started = time.Now()
+	_, err = l.Reserve(context.TODO(), 1000)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(started), 90*time.Millisecond)
+
+	// the first request used less than estimated, the rest goes back to the budget
+	r.Report(100000)
+	started = time.Now()
+	_, err = l.Reserve(context.TODO(), 400000)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(started), 10*time.Millisecond)
+	assert.Equal(t, TokenUsage{Requests: 3, Tokens: 501000}, l.Usage())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.Reserve(ctx, 600000)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTokenLimiterRequests(t *testing.T) {
+	// 10 requests per second
+	l := NewTokenLimiter("llm", 600, 1000000)
+	for i := 0; i < 600; i++ {
+		_, err := l.Reserve(context.TODO(), 0)
+		assert.NoError(t, err)
+	}
+	started := time.Now()
+	_, err := l.Reserve(context.TODO(), 0)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+	assert.Panics(t, func() { NewTokenLimiter("llm", 0, 1) })
+}
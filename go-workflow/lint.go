@@ -0,0 +1,206 @@
+package goworkflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/* LintFinding: topology smell of a workflow, with the simplification suggested for it */
+type LintFinding struct {
+	// Rule: global-barrier, redundant-edge or fan-in-gate
+	Rule       string
+	Components []string
+	Message    string
+	Suggestion string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s; %s", f.Rule, f.Message, f.Suggestion)
+}
+
+type LintOptions struct {
+	// MaxFanIn: dependencies from which a component is reported as a fan-in gate, 32 when 0
+	MaxFanIn int
+}
+
+/*
+topology: dependency graph by node key (component id or name). Plain edges require the success of the
+dependency and aren't part of an any-of group, only they order components unconditionally.
+*/
+type topology struct {
+	nodes []string
+	plain map[string][]string
+	all   map[string][]string
+	name  func(string) string
+	// ancestors by node through plain or all edges, computed on demand
+	plainAncestors map[string]map[string]bool
+	allAncestors   map[string]map[string]bool
+}
+
+func (g *topology) ancestors(node string, plain bool) map[string]bool {
+	memo, deps := g.allAncestors, g.all
+	if plain {
+		memo, deps = g.plainAncestors, g.plain
+	}
+	if a, ok := memo[node]; ok {
+		return a
+	}
+	a := map[string]bool{}
+	// cycles are reported by validation, they must not recurse forever here
+	memo[node] = a
+	for _, dep := range deps[node] {
+		a[dep] = true
+		for ancestor := range g.ancestors(dep, plain) {
+			a[ancestor] = true
+		}
+	}
+	return a
+}
+
+/* redundantDependencies: plain dependencies of node implied by another plain dependency, in dependency order */
+func (g *topology) redundantDependencies(node string) []string {
+	redundant := []string{}
+	for _, dep := range g.plain[node] {
+		for _, other := range g.plain[node] {
+			if other != dep && g.ancestors(other, true)[dep] {
+				redundant = append(redundant, dep)
+				break
+			}
+		}
+	}
+	return redundant
+}
+
+func (g *topology) names(nodes []string) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = g.name(node)
+	}
+	return names
+}
+
+func (g *topology) lint(opts []*LintOptions) []LintFinding {
+	if len(opts) > 1 {
+		panic("only one LintOptions is allowed")
+	}
+	maxFanIn := 32
+	if len(opts) == 1 && opts[0] != nil && opts[0].MaxFanIn > 0 {
+		maxFanIn = opts[0].MaxFanIn
+	}
+	findings := []LintFinding{}
+	for _, node := range g.nodes {
+		deps := slices.Clone(g.all[node])
+		slices.Sort(deps)
+		deps = slices.Compact(deps)
+		if len(deps) >= 3 && len(g.nodes) >= 4 && g.isBarrier(node, deps) {
+			sinks := []string{}
+			for _, dep := range deps {
+				if !slices.ContainsFunc(deps, func(other string) bool { return g.ancestors(other, false)[dep] }) {
+					sinks = append(sinks, dep)
+				}
+			}
+			sinkNames := g.names(sinks)
+			slices.Sort(sinkNames)
+			findings = append(findings, LintFinding{
+				Rule:       "global-barrier",
+				Components: []string{g.name(node)},
+				Message:    fmt.Sprintf("%s depends on all other components", g.name(node)),
+				Suggestion: fmt.Sprintf("depend on the components whose output it reads, or only on %s", strings.Join(sinkNames, ", ")),
+			})
+		} else if len(deps) >= maxFanIn {
+			findings = append(findings, LintFinding{
+				Rule:       "fan-in-gate",
+				Components: []string{g.name(node)},
+				Message:    fmt.Sprintf("%s waits for %d components, one slow or failing member holds it back", g.name(node), len(deps)),
+				Suggestion: "aggregate in stages (e.g. per batch), collect results incrementally or make the members Optional",
+			})
+		}
+		if redundant := g.redundantDependencies(node); len(redundant) > 0 {
+			findings = append(findings, LintFinding{
+				Rule:       "redundant-edge",
+				Components: append([]string{g.name(node)}, g.names(redundant)...),
+				Message:    fmt.Sprintf("dependencies of %s on %s are implied by its other dependencies", g.name(node), strings.Join(g.names(redundant), ", ")),
+				Suggestion: "remove them or call Workflow.ReduceEdges",
+			})
+		}
+	}
+	return findings
+}
+
+/* isBarrier: deps are all nodes except node and its descendants */
+func (g *topology) isBarrier(node string, deps []string) bool {
+	others := 0
+	for _, other := range g.nodes {
+		if other != node && !g.ancestors(other, false)[node] {
+			others++
+		}
+	}
+	return others == len(deps)
+}
+
+func newTopology(name func(string) string) *topology {
+	return &topology{
+		plain:          map[string][]string{},
+		all:            map[string][]string{},
+		name:           name,
+		plainAncestors: map[string]map[string]bool{},
+		allAncestors:   map[string]map[string]bool{},
+	}
+}
+
+func (t *Template[CT, C, T]) topology() *topology {
+	g := newTopology(func(name string) string { return name })
+	for _, tc := range t.components {
+		g.nodes = append(g.nodes, tc.Name())
+		for _, dep := range tc.dependencies {
+			g.all[tc.Name()] = append(g.all[tc.Name()], dep)
+			if _, ok := tc.outcomes[dep]; !ok {
+				g.plain[tc.Name()] = append(g.plain[tc.Name()], dep)
+			}
+		}
+		for _, group := range tc.anyOf {
+			g.all[tc.Name()] = append(g.all[tc.Name()], group.dependencies...)
+		}
+	}
+	return g
+}
+
+func (wf *Workflow[CT, C, T]) topology() *topology {
+	d := wf.dependencyManager
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	g := newTopology(func(id string) string { return d.componentIdToName[id] })
+	for _, c := range wf.sortedComponents() {
+		g.nodes = append(g.nodes, c.id)
+	}
+	for _, node := range g.nodes {
+		anyOf := map[string]bool{}
+		for _, group := range d.anyOf[node] {
+			for _, dep := range group {
+				anyOf[dep] = true
+			}
+		}
+		// dependencies in declaration order
+		for _, dep := range g.nodes {
+			if !d.dependencyGraph[dep][node] {
+				continue
+			}
+			g.all[node] = append(g.all[node], dep)
+			if _, ok := d.outcomes[dep][node]; !ok && !anyOf[dep] {
+				g.plain[node] = append(g.plain[node], dep)
+			}
+		}
+	}
+	return g
+}
+
+/* Lint reports topology smells: global barriers, redundant edges and large fan-in gates, see LintFinding */
+func (t *Template[CT, C, T]) Lint(opts ...*LintOptions) []LintFinding {
+	return t.topology().lint(opts)
+}
+
+/* Lint: see Template.Lint */
+func (wf *Workflow[CT, C, T]) Lint(opts ...*LintOptions) []LintFinding {
+	return wf.topology().lint(opts)
+}
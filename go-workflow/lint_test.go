@@ -0,0 +1,57 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("invoices")
+	ocr := template.AddComponent(goworkflow.MakeComponent("OCR", nil, noop))
+	extract := template.AddComponent(goworkflow.MakeComponent("Extract", nil, noop))
+	extract.AddDependencies(ocr)
+	validate := template.AddComponent(goworkflow.MakeComponent("Validate", nil, noop))
+	validate.AddDependencies(extract, ocr)
+	alert := template.AddComponent(goworkflow.MakeComponent("Alert", nil, noop))
+	// failure edges don't order unconditionally, they are never redundant
+	alert.AddDependenciesOn(goworkflow.OnFailure, extract)
+	alert.AddDependencies(ocr)
+	store := template.AddComponent(goworkflow.MakeComponent("Store", nil, noop))
+	store.AddDependencies(ocr, extract, validate, alert)
+
+	findings := template.Lint()
+	assert.Equal(t, []string{
+		"redundant-edge: dependencies of Validate on OCR are implied by its other dependencies; remove them or call Workflow.ReduceEdges",
+		"global-barrier: Store depends on all other components; depend on the components whose output it reads, or only on Alert, Validate",
+		"redundant-edge: dependencies of Store on OCR, Extract are implied by its other dependencies; remove them or call Workflow.ReduceEdges",
+	}, lintStrings(findings))
+	assert.Equal(t, []string{"Store", "OCR", "Extract"}, findings[2].Components)
+
+	// workflows built directly are linted the same way
+	wf := template.NewWorkflow(context.TODO())
+	assert.Equal(t, lintStrings(findings), lintStrings(wf.Lint()))
+
+	fanIn := goworkflow.NewTemplate[context.Context, Config, Data]("pages")
+	merge := fanIn.AddComponent(goworkflow.MakeComponent("Merge", nil, noop))
+	for i := 0; i < 5; i++ {
+		merge.AddDependencies(fanIn.AddComponent(goworkflow.MakeComponent(fmt.Sprintf("Page%d", i), nil, noop)))
+	}
+	fanIn.AddComponent(goworkflow.MakeComponent("Cover", nil, noop))
+	assert.Empty(t, fanIn.Lint())
+	assert.Equal(t, []string{
+		"fan-in-gate: Merge waits for 5 components, one slow or failing member holds it back; aggregate in stages (e.g. per batch), collect results incrementally or make the members Optional",
+	}, lintStrings(fanIn.Lint(&goworkflow.LintOptions{MaxFanIn: 5})))
+}
+
+func lintStrings(findings []goworkflow.LintFinding) []string {
+	s := []string{}
+	for _, f := range findings {
+		s = append(s, f.String())
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+const DefaultChatTemplate = `{{if eq .Status "DONE"}}:white_check_mark:{{else if eq .Status "DONE_WITH_WARNINGS"}}:warning:{{else}}:x:{{end}} {{.Workflow}} run {{.WorkflowId}} finished with {{.Status}} in {{.Duration}}
+{{- if .FailedComponents}}
+Failed {{if eq .Status "DONE_WITH_WARNINGS"}}optional {{end}}components: {{join .FailedComponents ", "}}{{end}}
+{{- if .DashboardURL}}
+{{.DashboardURL}}{{end}}`
+
+/* MessageData is the data the message template is executed with */
+type MessageData struct {
+	goworkflow.RunResult
+	DashboardURL string
+}
+
+type ChatOptions struct {
+	// Template: text/template over MessageData, DefaultChatTemplate when empty
+	Template string
+	// DashboardURL: text/template over the RunResult, e.g. https://dashboard/runs/{{.WorkflowId}}
+	DashboardURL string
+	// OnlyFailures: don't notify successful runs, runs DONE_WITH_WARNINGS are still notified
+	OnlyFailures bool
+	Client       *http.Client
+}
+
+/* ChatNotifier posts run results to a chat webhook (Slack or Teams) */
+type ChatNotifier struct {
+	webhookURL   string
+	message      *template.Template
+	dashboardURL *template.Template
+	onlyFailures bool
+	client       *http.Client
+	payload      func(text string, result goworkflow.RunResult) any
+}
+
+func newChatNotifier(webhookURL string, opts ChatOptions, payload func(string, goworkflow.RunResult) any) (*ChatNotifier, error) {
+	if opts.Template == "" {
+		opts.Template = DefaultChatTemplate
+	}
+	funcs := template.FuncMap{"join": strings.Join}
+	message, err := template.New("message").Funcs(funcs).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+	dashboardURL, err := template.New("dashboard").Parse(opts.DashboardURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dashboard url template: %w", err)
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ChatNotifier{
+		webhookURL:   webhookURL,
+		message:      message,
+		dashboardURL: dashboardURL,
+		onlyFailures: opts.OnlyFailures,
+		client:       client,
+		payload:      payload,
+	}, nil
+}
+
+/* NewSlackNotifier: notifier for Slack incoming webhooks */
+func NewSlackNotifier(webhookURL string, opts ChatOptions) (*ChatNotifier, error) {
+	return newChatNotifier(webhookURL, opts, func(text string, result goworkflow.RunResult) any {
+		return map[string]string{"text": text}
+	})
+}
+
+/* NewTeamsNotifier: notifier for Microsoft Teams incoming webhooks (MessageCard) */
+func NewTeamsNotifier(webhookURL string, opts ChatOptions) (*ChatNotifier, error) {
+	return newChatNotifier(webhookURL, opts, func(text string, result goworkflow.RunResult) any {
+		color := "2EB886"
+		if result.Status == goworkflow.DONE_WITH_WARNINGS {
+			color = "ECB22E"
+		} else if result.Status != goworkflow.DONE {
+			color = "D00000"
+		}
+		return map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    fmt.Sprintf("%s %s", result.Workflow, result.Status),
+			"themeColor": color,
+			"text":       strings.ReplaceAll(text, "\n", "<br>"),
+		}
+	})
+}
+
+/* Render executes the message template for result */
+func (n *ChatNotifier) Render(result goworkflow.RunResult) (string, error) {
+	url := &bytes.Buffer{}
+	if err := n.dashboardURL.Execute(url, result); err != nil {
+		return "", err
+	}
+	text := &bytes.Buffer{}
+	if err := n.message.Execute(text, MessageData{RunResult: result, DashboardURL: url.String()}); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
+
+func (n *ChatNotifier) Notify(ctx context.Context, result goworkflow.RunResult) error {
+	if n.onlyFailures && result.Status == goworkflow.DONE {
+		return nil
+	}
+	text, err := n.Render(result)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(n.payload(text, result))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
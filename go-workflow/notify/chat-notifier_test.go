@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{}
+type Data struct{}
+
+func TestSlackNotifier(t *testing.T) {
+	received := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer server.Close()
+
+	slack, err := NewSlackNotifier(server.URL, ChatOptions{DashboardURL: "https://dashboard/runs/{{.WorkflowId}}"})
+	assert.NoError(t, err)
+
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("document")
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("timeout")
+	}))
+	wf.AddNotifier(slack)
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+
+	text := (<-received)["text"]
+	assert.Contains(t, text, ":x: document run "+wf.Id()+" finished with ERROR")
+	assert.Contains(t, text, "Failed components: OCR")
+	assert.Contains(t, text, "https://dashboard/runs/"+wf.Id())
+}
+
+func TestTeamsNotifier(t *testing.T) {
+	received := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer server.Close()
+
+	teams, err := NewTeamsNotifier(server.URL, ChatOptions{Template: "{{.Workflow}}: {{.Status}}"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, teams.Notify(context.TODO(), goworkflow.RunResult{Workflow: "batch", Status: goworkflow.DONE}))
+	body := <-received
+	assert.Equal(t, "MessageCard", body["@type"])
+	assert.Equal(t, "batch: DONE", body["text"])
+
+	failuresOnly, err := NewTeamsNotifier(server.URL, ChatOptions{OnlyFailures: true})
+	assert.NoError(t, err)
+	assert.NoError(t, failuresOnly.Notify(context.TODO(), goworkflow.RunResult{Status: goworkflow.DONE}))
+	assert.Len(t, received, 0)
+
+	// failed optional components are a warning, not a failure
+	slack, err := NewSlackNotifier(server.URL, ChatOptions{})
+	assert.NoError(t, err)
+	text, err := slack.Render(goworkflow.RunResult{Workflow: "batch", WorkflowId: "run-1", Status: goworkflow.DONE_WITH_WARNINGS, FailedComponents: []string{"Thumbnail"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text, ":warning: batch run run-1 finished with DONE_WITH_WARNINGS"), text)
+	assert.Contains(t, text, "Failed optional components: Thumbnail")
+
+	_, err = NewSlackNotifier(server.URL, ChatOptions{Template: "{{"})
+	assert.Error(t, err)
+}
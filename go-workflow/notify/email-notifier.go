@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+const DefaultEmailSubject = `[{{.Status}}] {{.Workflow}} run {{.WorkflowId}}`
+
+const DefaultEmailBody = `{{.Workflow}} run {{.WorkflowId}} finished with {{.Status}} in {{.Duration}}.
+{{range .FailedComponents}}
+- {{.}}: {{index $.Errors .}}{{end}}
+{{- if .DashboardURL}}
+
+{{.DashboardURL}}{{end}}
+{{- if .Suppressed}}
+
+{{.Suppressed}} further notifications were suppressed by throttling.{{end}}
+`
+
+type EmailData struct {
+	MessageData
+	// Suppressed: notifications dropped by throttling since the previous mail
+	Suppressed int
+}
+
+type EmailOptions struct {
+	// Addr of the SMTP server, host:port
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+	// Subject and Body: text/templates over EmailData, defaults when empty
+	Subject string
+	Body    string
+	// DashboardURL: text/template over the RunResult
+	DashboardURL string
+	OnlyFailures bool
+	// at most MaxPerWindow mails are sent per Window, zero disables throttling
+	MaxPerWindow int
+	Window       time.Duration
+	// SendMail defaults to smtp.SendMail
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+/* EmailNotifier mails run results, for environments where chat webhooks are not allowed */
+type EmailNotifier struct {
+	opts         EmailOptions
+	subject      *template.Template
+	body         *template.Template
+	dashboardURL *template.Template
+
+	lock       sync.Mutex
+	sent       []time.Time
+	suppressed int
+}
+
+var headerLineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+var bodyLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func NewEmailNotifier(opts EmailOptions) (*EmailNotifier, error) {
+	if opts.Addr == "" || opts.From == "" || len(opts.To) == 0 {
+		return nil, fmt.Errorf("addr, from and to are required")
+	}
+	if opts.Subject == "" {
+		opts.Subject = DefaultEmailSubject
+	}
+	if opts.Body == "" {
+		opts.Body = DefaultEmailBody
+	}
+	if opts.SendMail == nil {
+		opts.SendMail = smtp.SendMail
+	}
+	funcs := template.FuncMap{"join": strings.Join}
+	subject, err := template.New("subject").Funcs(funcs).Parse(opts.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	body, err := template.New("body").Funcs(funcs).Parse(opts.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	dashboardURL, err := template.New("dashboard").Parse(opts.DashboardURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dashboard url template: %w", err)
+	}
+	return &EmailNotifier{opts: opts, subject: subject, body: body, dashboardURL: dashboardURL}, nil
+}
+
+/* allow: throttling, returns the number of suppressed notifications to report when the mail can be sent */
+func (n *EmailNotifier) allow(now time.Time) (bool, int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.opts.MaxPerWindow > 0 {
+		recent := n.sent[:0]
+		for _, t := range n.sent {
+			if now.Sub(t) < n.opts.Window {
+				recent = append(recent, t)
+			}
+		}
+		n.sent = recent
+		if len(n.sent) >= n.opts.MaxPerWindow {
+			n.suppressed++
+			return false, 0
+		}
+		n.sent = append(n.sent, now)
+	}
+	suppressed := n.suppressed
+	n.suppressed = 0
+	return true, suppressed
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, result goworkflow.RunResult) error {
+	if n.opts.OnlyFailures && result.Status == goworkflow.DONE {
+		return nil
+	}
+	ok, suppressed := n.allow(time.Now())
+	if !ok {
+		return nil
+	}
+
+	url := &bytes.Buffer{}
+	if err := n.dashboardURL.Execute(url, result); err != nil {
+		return err
+	}
+	data := EmailData{MessageData: MessageData{RunResult: result, DashboardURL: url.String()}, Suppressed: suppressed}
+	subject := &bytes.Buffer{}
+	if err := n.subject.Execute(subject, data); err != nil {
+		return err
+	}
+	body := &bytes.Buffer{}
+	if err := n.body.Execute(body, data); err != nil {
+		return err
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(n.opts.To, ", "))
+	// the subject renders run data, line breaks would inject headers
+	fmt.Fprintf(msg, "Subject: %s\r\n", headerLineBreaks.Replace(subject.String()))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(bodyLineBreaks.Replace(body.String()), "\n", "\r\n"))
+	return n.opts.SendMail(n.opts.Addr, n.opts.Auth, n.opts.From, n.opts.To, msg.Bytes())
+}
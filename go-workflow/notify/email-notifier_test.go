@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailNotifierThrottling(t *testing.T) {
+	mails := []string{}
+	email, err := NewEmailNotifier(EmailOptions{
+		Addr:         "smtp.example.com:587",
+		From:         "workflows@example.com",
+		To:           []string{"owner@example.com"},
+		OnlyFailures: true,
+		MaxPerWindow: 2,
+		Window:       50 * time.Millisecond,
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			mails = append(mails, string(msg))
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	failed := goworkflow.RunResult{WorkflowId: "run-1", Workflow: "document", Status: goworkflow.ERROR, FailedComponents: []string{"OCR"}, Errors: map[string]string{"OCR": "timeout"}}
+	assert.NoError(t, email.Notify(context.TODO(), goworkflow.RunResult{Status: goworkflow.DONE}))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, email.Notify(context.TODO(), failed))
+	}
+	assert.Len(t, mails, 2)
+	assert.Contains(t, mails[0], "Subject: [ERROR] document run run-1\r\n")
+	assert.Contains(t, mails[0], "- OCR: timeout")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, email.Notify(context.TODO(), failed))
+	assert.Len(t, mails, 3)
+	assert.Contains(t, mails[2], "3 further notifications were suppressed by throttling.")
+}
+
+func TestEmailNotifierSubjectHeader(t *testing.T) {
+	mails := []string{}
+	email, err := NewEmailNotifier(EmailOptions{
+		Addr: "smtp.example.com:587",
+		From: "workflows@example.com",
+		To:   []string{"owner@example.com"},
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			mails = append(mails, string(msg))
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	crafted := goworkflow.RunResult{WorkflowId: "run-1", Workflow: "document\rBcc: attacker@example.com\r\nX: y\nZ: z", Status: goworkflow.ERROR}
+	assert.NoError(t, email.Notify(context.TODO(), crafted))
+	assert.Len(t, mails, 1)
+	assert.Contains(t, mails[0], "Subject: [ERROR] document Bcc: attacker@example.com X: y Z: z run run-1\r\n")
+	headers, body, _ := strings.Cut(mails[0], "\r\n\r\n")
+	assert.Len(t, strings.Split(headers, "\r\n"), 5)
+	assert.Contains(t, body, "document\r\nBcc: attacker@example.com\r\nX: y\r\nZ: z run run-1 finished with ERROR")
+}
+
+func TestEmailNotifierValidation(t *testing.T) {
+	_, err := NewEmailNotifier(EmailOptions{Addr: "smtp.example.com:25"})
+	assert.Error(t, err)
+	_, err = NewEmailNotifier(EmailOptions{Addr: "smtp.example.com:25", From: "a@example.com", To: []string{"b@example.com"}, Body: "{{"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+/*
+Package openlineage emits OpenLineage run events for workflow runs and their components, so data governance tooling
+(e.g. Marquez) tracks which documents fed which extracted datasets.
+
+The run of a workflow is the job <workflow name>, every component is the job <workflow name>.<component> whose run
+has the workflow run as parent. Datasets are declared with Options.Datasets.
+*/
+package openlineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+const (
+	DefaultProducer = "https://github.com/metaphi-org/go-workflow"
+	runEventSchema  = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	parentSchema    = "https://openlineage.io/spec/facets/1-0-1/ParentRunFacet.json#/$defs/ParentRunFacet"
+	errorSchema     = "https://openlineage.io/spec/facets/1-0-1/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"
+)
+
+type Dataset struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Facets    map[string]any `json:"facets,omitempty"`
+}
+
+type Job struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type Run struct {
+	RunId  string         `json:"runId"`
+	Facets map[string]any `json:"facets,omitempty"`
+}
+
+type RunEvent struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+}
+
+/* Transport delivers the events, e.g. HTTPTransport */
+type Transport interface {
+	Emit(ctx context.Context, event RunEvent) error
+}
+
+type Options struct {
+	// Namespace of the jobs, "default" when empty
+	Namespace string
+	// Producer: URI identifying the producer of the events, DefaultProducer when empty
+	Producer string
+	// Datasets: input and output datasets of a component, component is empty for the workflow run
+	Datasets func(component string) (inputs []Dataset, outputs []Dataset)
+}
+
+/* Emitter sends the events of one workflow run in order, without blocking the workflow */
+type Emitter struct {
+	transport Transport
+	events    chan RunEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+/* Observe emits the events of the run of wf to transport, it must be called before wf.Execute */
+func Observe[CT context.Context, C any, T any](wf *goworkflow.Workflow[CT, C, T], transport Transport, opts Options) *Emitter {
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	if opts.Producer == "" {
+		opts.Producer = DefaultProducer
+	}
+	e := &Emitter{transport: transport, events: make(chan RunEvent, 1024), done: make(chan struct{})}
+	go e.send()
+	wf.AddStateListener(func(change goworkflow.StateChange) {
+		event, ok := newEvent(wf.Name(), change, opts)
+		if !ok {
+			return
+		}
+		select {
+		case e.events <- event:
+		default:
+			log.Println("openlineage.Emitter:Error:queue full, dropping event of", event.Job.Name)
+		}
+		if change.Component == "" && event.EventType != "START" {
+			e.close()
+		}
+	})
+	return e
+}
+
+func (e *Emitter) close() {
+	e.closeOnce.Do(func() { close(e.events) })
+}
+
+func (e *Emitter) send() {
+	defer close(e.done)
+	for event := range e.events {
+		if err := e.transport.Emit(context.Background(), event); err != nil {
+			log.Println("openlineage.Emitter:Error:", err)
+		}
+	}
+}
+
+/* Wait blocks until the events of the finished run were delivered or ctx is done */
+func (e *Emitter) Wait(ctx context.Context) error {
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func eventType(status goworkflow.Status) (string, bool) {
+	switch status {
+	case goworkflow.RUNNING:
+		return "START", true
+	case goworkflow.DONE, goworkflow.DONE_WITH_WARNINGS:
+		return "COMPLETE", true
+	case goworkflow.ERROR, goworkflow.QUARANTINED:
+		return "FAIL", true
+	case goworkflow.SKIPPED:
+		return "ABORT", true
+	}
+	return "", false
+}
+
+func newEvent(workflow string, change goworkflow.StateChange, opts Options) (RunEvent, bool) {
+	eventType, ok := eventType(change.NewStatus)
+	if !ok {
+		return RunEvent{}, false
+	}
+	event := RunEvent{
+		EventType: eventType,
+		EventTime: change.Time,
+		Producer:  opts.Producer,
+		SchemaURL: runEventSchema,
+		Run:       Run{RunId: change.WorkflowId, Facets: map[string]any{}},
+		Job:       Job{Namespace: opts.Namespace, Name: workflow},
+		Inputs:    []Dataset{},
+		Outputs:   []Dataset{},
+	}
+	if change.Component != "" {
+		event.Run.RunId = change.ComponentId
+		event.Job.Name = fmt.Sprintf("%s.%s", workflow, change.Component)
+		event.Run.Facets["parent"] = map[string]any{
+			"_producer":  opts.Producer,
+			"_schemaURL": parentSchema,
+			"run":        map[string]string{"runId": change.WorkflowId},
+			"job":        Job{Namespace: opts.Namespace, Name: workflow},
+		}
+	}
+	if eventType == "FAIL" && change.Cause != "" {
+		event.Run.Facets["errorMessage"] = map[string]any{
+			"_producer":           opts.Producer,
+			"_schemaURL":          errorSchema,
+			"message":             change.Cause,
+			"programmingLanguage": "go",
+		}
+	}
+	if opts.Datasets != nil {
+		inputs, outputs := opts.Datasets(change.Component)
+		event.Inputs = append(event.Inputs, inputs...)
+		event.Outputs = append(event.Outputs, outputs...)
+	}
+	return event, true
+}
+
+/* HTTPTransport posts the events to an OpenLineage endpoint, e.g. http://marquez:5000/api/v1/lineage */
+type HTTPTransport struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+func (t *HTTPTransport) Emit(ctx context.Context, event RunEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("openlineage endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package openlineage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{}
+
+type Data struct{}
+
+type recordingTransport struct {
+	lock   sync.Mutex
+	events []RunEvent
+}
+
+func (r *recordingTransport) Emit(ctx context.Context, event RunEvent) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestObserve(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("invoices")
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("no table found")
+	})).AddDependencies(ocr)
+
+	transport := &recordingTransport{}
+	emitter := Observe(wf, transport, Options{
+		Namespace: "documents",
+		Datasets: func(component string) ([]Dataset, []Dataset) {
+			if component == "Extract" {
+				return []Dataset{{Namespace: "s3://scans", Name: "invoice-42.pdf"}}, []Dataset{{Namespace: "postgres://warehouse", Name: "invoice_lines"}}
+			}
+			return nil, nil
+		},
+	})
+	wf.Execute(context.TODO(), Config{}, &Data{})
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	assert.NoError(t, emitter.Wait(ctx))
+
+	summary := []string{}
+	for _, e := range transport.events {
+		summary = append(summary, e.Job.Name+" "+e.EventType)
+	}
+	assert.Equal(t, []string{
+		"invoices START", "invoices.OCR START", "invoices.OCR COMPLETE",
+		"invoices.Extract START", "invoices.Extract FAIL", "invoices FAIL",
+	}, summary)
+
+	extractFail := transport.events[4]
+	assert.Equal(t, "documents", extractFail.Job.Namespace)
+	assert.Equal(t, wf.Id(), extractFail.Run.Facets["parent"].(map[string]any)["run"].(map[string]string)["runId"])
+	assert.Equal(t, "no table found", extractFail.Run.Facets["errorMessage"].(map[string]any)["message"])
+	assert.Equal(t, "invoice_lines", extractFail.Outputs[0].Name)
+	assert.Equal(t, wf.Id(), transport.events[0].Run.RunId)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{URL: server.URL, APIKey: "secret"}
+	err := transport.Emit(context.TODO(), RunEvent{EventType: "START", Job: Job{Namespace: "documents", Name: "invoices"}, Run: Run{RunId: "r1"}})
+	assert.NoError(t, err)
+	body := <-received
+	assert.Equal(t, "START", body["eventType"])
+	assert.Equal(t, map[string]any{"namespace": "documents", "name": "invoices"}, body["job"])
+}
@@ -0,0 +1,122 @@
+package goworkflow
+
+import (
+	"log"
+	"slices"
+	"sync"
+)
+
+/* PartialResult: state of the run once a component finished, see Workflow.OnPartialResult */
+type PartialResult[T any] struct {
+	Component   string
+	ComponentId string
+	Status      Status
+	// Snapshot: copy of the data store right after the component finished
+	Snapshot *T
+	// Changes: fields changed by the component, only set with PartialResultOptions.Changes
+	Changes []FieldChange
+}
+
+type PartialResultOptions struct {
+	// Changes: record the fields every component changes, every Update copies the data store through json
+	Changes bool
+}
+
+type partialResults[T any] struct {
+	lock sync.Mutex
+	// emitLock: hooks are called one at a time
+	emitLock sync.Mutex
+	hooks    []func(PartialResult[T])
+	changes  bool
+	// pending: fields changed by the running components, by component name
+	pending map[string][]FieldChange
+	closers []func()
+}
+
+/*
+OnPartialResult calls hook every time a component finished (DONE, ERROR or SKIPPED), e.g. to stream page
+results to a client while the rest of the document is still processed. Hooks are called one at a time, before
+the dependents of the component start, so they must be fast. Register hooks before Execute.
+*/
+func (wf *Workflow[CT, C, T]) OnPartialResult(hook func(PartialResult[T]), opts ...*PartialResultOptions) {
+	if hook == nil {
+		panic("hook cannot be nil")
+	}
+	if len(opts) > 1 {
+		panic("only one PartialResultOptions is allowed")
+	}
+	p := &wf.partialResults
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.hooks = append(p.hooks, hook)
+	if len(opts) == 1 && opts[0] != nil && opts[0].Changes {
+		p.changes = true
+	}
+}
+
+/*
+PartialResults: like OnPartialResult, delivered on a channel with the given buffer which is closed when Execute
+returns. A consumer which doesn't keep up stalls the run once the buffer is full.
+*/
+func (wf *Workflow[CT, C, T]) PartialResults(buffer int, opts ...*PartialResultOptions) <-chan PartialResult[T] {
+	results := make(chan PartialResult[T], buffer)
+	wf.OnPartialResult(func(result PartialResult[T]) {
+		results <- result
+	}, opts...)
+	wf.partialResults.lock.Lock()
+	defer wf.partialResults.lock.Unlock()
+	wf.partialResults.closers = append(wf.partialResults.closers, func() { close(results) })
+	return results
+}
+
+/* recordChanges: receives the changes of every Update when SetRecordDataChanges or partial result changes are on */
+func (wf *Workflow[CT, C, T]) recordChanges(component string, changes []FieldChange) {
+	if wf.recordDataChanges {
+		wf.addDataChanges(component, changes)
+	}
+	p := &wf.partialResults
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.changes {
+		if p.pending == nil {
+			p.pending = map[string][]FieldChange{}
+		}
+		p.pending[component] = append(p.pending[component], changes...)
+	}
+}
+
+func (wf *Workflow[CT, C, T]) emitPartialResult(c *component[CT, C, T], status Status, store *dataStore[T]) {
+	p := &wf.partialResults
+	p.lock.Lock()
+	hooks := slices.Clone(p.hooks)
+	changes := p.pending[c.Name]
+	delete(p.pending, c.Name)
+	p.lock.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+	// Update holds the data store lock while recording changes, so it is never taken with p.lock held
+	store.lock.Lock()
+	snapshot, err := copyData(store.data)
+	store.lock.Unlock()
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Partial result snapshot failed for component:", c.id, err)
+	}
+	result := PartialResult[T]{Component: c.Name, ComponentId: c.id, Status: status, Snapshot: snapshot, Changes: changes}
+	p.emitLock.Lock()
+	defer p.emitLock.Unlock()
+	for _, hook := range hooks {
+		hook(result)
+	}
+}
+
+/* closePartialResults: closes the channels of PartialResults once the run ended */
+func (wf *Workflow[CT, C, T]) closePartialResults() {
+	p := &wf.partialResults
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, closeResults := range p.closers {
+		closeResults()
+	}
+	p.closers = nil
+}
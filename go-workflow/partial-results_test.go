@@ -0,0 +1,49 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialResults(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "a" })
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.B = d.A + "b" })
+		return nil
+	}))
+	c := wf.AddComponent(goworkflow.MakeComponent("C", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("failed")
+	}))
+	b.AddDependencies(a)
+	c.AddDependencies(b)
+
+	results := wf.PartialResults(3, &goworkflow.PartialResultOptions{Changes: true})
+	components := []string{}
+	wf.OnPartialResult(func(result goworkflow.PartialResult[Data]) {
+		components = append(components, result.Component)
+	})
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, []string{"A", "B", "C"}, components)
+
+	received := []goworkflow.PartialResult[Data]{}
+	for result := range results {
+		received = append(received, result)
+	}
+	assert.Len(t, received, 3)
+	assert.Equal(t, goworkflow.DONE, received[0].Status)
+	assert.Equal(t, Data{A: "a"}, *received[0].Snapshot)
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "B", Before: "", After: "ab"}}, received[1].Changes)
+	assert.Equal(t, Data{A: "a", B: "ab"}, *received[1].Snapshot)
+	assert.Equal(t, goworkflow.ERROR, received[2].Status)
+	assert.Empty(t, received[2].Changes)
+}
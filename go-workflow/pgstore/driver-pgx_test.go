@@ -0,0 +1,6 @@
+//go:build pgx
+
+package pgstore
+
+// registers the pgx driver for the integration tests: go get github.com/jackc/pgx/v5 && go test -tags pgx ./pgstore
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,66 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+)
+
+/* migrations are applied in order and recorded in <prefix>schema_migrations, never edit an applied migration */
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS {{prefix}}runs (
+		workflow_id TEXT PRIMARY KEY,
+		workflow TEXT NOT NULL,
+		version TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ,
+		duration_ms BIGINT NOT NULL DEFAULT 0,
+		failed_components JSONB NOT NULL DEFAULT '[]',
+		errors JSONB NOT NULL DEFAULT '{}',
+		metadata JSONB NOT NULL DEFAULT '{}'
+	)`,
+	`CREATE INDEX IF NOT EXISTS {{prefix}}runs_workflow_started_idx ON {{prefix}}runs (workflow, started_at DESC, workflow_id)`,
+	`CREATE INDEX IF NOT EXISTS {{prefix}}runs_metadata_idx ON {{prefix}}runs USING GIN (metadata)`,
+	`CREATE TABLE IF NOT EXISTS {{prefix}}checkpoints (
+		workflow_id TEXT PRIMARY KEY,
+		template TEXT NOT NULL,
+		version TEXT NOT NULL DEFAULT '',
+		completed JSONB NOT NULL DEFAULT '[]',
+		data JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		locked_by TEXT,
+		locked_until TIMESTAMPTZ
+	)`,
+	`ALTER TABLE {{prefix}}runs ADD COLUMN IF NOT EXISTS component_errors JSONB NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE {{prefix}}checkpoints ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ`,
+}
+
+/* Migrate brings the schema up to date, concurrent callers are serialized by an advisory lock */
+func (s *Store[T]) Migrate(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, s.prefix+"schema_migrations"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.sql(`CREATE TABLE IF NOT EXISTS {{prefix}}schema_migrations (version INT PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())`)); err != nil {
+		return err
+	}
+	var current int
+	if err := tx.QueryRowContext(ctx, s.sql(`SELECT COALESCE(MAX(version), 0) FROM {{prefix}}schema_migrations`)).Scan(&current); err != nil {
+		return err
+	}
+	for version := current + 1; version <= len(migrations); version++ {
+		if _, err := tx.ExecContext(ctx, s.sql(migrations[version-1])); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, s.sql(`INSERT INTO {{prefix}}schema_migrations (version) VALUES ($1)`), version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,142 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+testDB connects to the database of GOWORKFLOW_TEST_PG_DSN, the tests are skipped when it is not set. The driver
+(GOWORKFLOW_TEST_PG_DRIVER, pgx by default) must be registered, e.g. with -tags pgx, see driver-pgx_test.go.
+Every test creates its own tables and drops them.
+*/
+func testDB(t *testing.T) (*sql.DB, string) {
+	dsn := os.Getenv("GOWORKFLOW_TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("GOWORKFLOW_TEST_PG_DSN is not set")
+	}
+	driver := os.Getenv("GOWORKFLOW_TEST_PG_DRIVER")
+	if driver == "" {
+		driver = "pgx"
+	}
+	if !slices.Contains(sql.Drivers(), driver) {
+		t.Fatalf("sql driver %s is not registered, run the tests with -tags pgx", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := fmt.Sprintf("gwtest_%d_", time.Now().UnixNano())
+	t.Cleanup(func() {
+		for _, table := range []string{"runs", "checkpoints", "schema_migrations"} {
+			db.Exec(`DROP TABLE IF EXISTS ` + prefix + table)
+		}
+		db.Close()
+	})
+	return db, prefix
+}
+
+func TestPostgresRuns(t *testing.T) {
+	db, prefix := testDB(t)
+	ctx := context.TODO()
+	s := New[Data](db, prefix)
+	assert.NoError(t, s.Migrate(ctx))
+	assert.NoError(t, s.Migrate(ctx))
+
+	started := time.Now().UTC().Truncate(time.Millisecond)
+	run := goworkflow.RunResult{WorkflowId: "run-1", Workflow: "document", Status: goworkflow.ERROR, StartedAt: started,
+		FailedComponents: []string{"OCR"}, Errors: map[string]string{"OCR": "timeout"}, Metadata: map[string]string{"tenant": "acme"}}
+	assert.NoError(t, s.SaveRun(ctx, run))
+	saved, err := s.GetRun(ctx, "run-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OCR"}, saved.FailedComponents)
+	assert.True(t, started.Equal(saved.StartedAt))
+	_, err = s.GetRun(ctx, "run-2")
+	assert.ErrorIs(t, err, goworkflow.ErrRunNotFound)
+
+	page, err := s.QueryRuns(ctx, goworkflow.RunQuery{Workflow: "document", Metadata: map[string]string{"tenant": "acme"}, FailedComponent: "OCR"})
+	assert.NoError(t, err)
+	assert.Len(t, page.Runs, 1)
+
+	claim := goworkflow.RunResult{WorkflowId: "run-3", Workflow: "document", Status: goworkflow.RUNNING, StartedAt: started,
+		Metadata: map[string]string{goworkflow.DedupMetadataKey: "invoice-7"}}
+	_, claimed, err := s.ClaimRun(ctx, claim)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claim.WorkflowId = "run-4"
+	existing, claimed, err := s.ClaimRun(ctx, claim)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "run-3", existing.WorkflowId)
+
+	// the process of run-5 died
+	claim = goworkflow.RunResult{WorkflowId: "run-5", Workflow: "document", Status: goworkflow.RUNNING, StartedAt: started,
+		Metadata: map[string]string{goworkflow.DedupMetadataKey: "invoice-8", goworkflow.DedupExpiresMetadataKey: started.Add(-time.Minute).Format(time.RFC3339Nano)}}
+	assert.NoError(t, s.SaveRun(ctx, claim))
+	claim.WorkflowId = "run-6"
+	_, claimed, err = s.ClaimRun(ctx, claim)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestPostgresCheckpointLeases(t *testing.T) {
+	db, prefix := testDB(t)
+	ctx := context.TODO()
+	a := New[Data](db, prefix, &Options{Owner: "a", Lease: 300 * time.Millisecond})
+	b := New[Data](db, prefix, &Options{Owner: "b", Lease: 300 * time.Millisecond})
+	assert.NoError(t, a.Migrate(ctx))
+
+	cp := goworkflow.Checkpoint[Data]{WorkflowId: "run-1", Template: "document", Completed: []string{"OCR"}, Time: time.Now()}
+	assert.NoError(t, a.SaveCheckpoint(ctx, cp))
+	// the run is live on a
+	_, err := b.ClaimCheckpoint(ctx, "b", time.Minute)
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+	assert.ErrorIs(t, b.SaveCheckpoint(ctx, cp), ErrLeaseLost)
+	assert.NoError(t, a.RenewCheckpoint(ctx, "run-1"))
+
+	// a died
+	time.Sleep(400 * time.Millisecond)
+	claimed, err := b.ClaimCheckpoint(ctx, "b", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OCR"}, claimed.Completed)
+	assert.ErrorIs(t, a.RenewCheckpoint(ctx, "run-1"), ErrLeaseLost)
+	assert.ErrorIs(t, a.SaveCheckpoint(ctx, cp), ErrLeaseLost)
+	assert.NoError(t, b.SaveCheckpoint(ctx, cp))
+
+	// failed runs are kept for Resume but never claimed
+	assert.NoError(t, b.FinishCheckpoint(ctx, "run-1"))
+	_, err = a.ClaimCheckpoint(ctx, "a", time.Minute)
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+	_, err = a.LoadCheckpoint(ctx, "run-1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.DeleteCheckpoint(ctx, "run-1"))
+	_, err = a.LoadCheckpoint(ctx, "run-1")
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+}
+
+func TestPostgresCheckpointStore(t *testing.T) {
+	db, prefix := testDB(t)
+	ctx := context.TODO()
+	s := New[Data](db, prefix, &Options{Owner: "a"})
+	assert.NoError(t, s.Migrate(ctx))
+
+	wf := goworkflow.NewWorkflow[context.Context, struct{}, Data](ctx)
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[struct{}, Data]) error {
+		return nil
+	}))
+	wf.SetCheckpointStore(s)
+	_, st, _ := wf.Execute(ctx, struct{}{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	// deleted once the run is DONE, other instances never resume it
+	_, err := s.LoadCheckpoint(ctx, wf.Id())
+	assert.ErrorIs(t, err, goworkflow.ErrCheckpointNotFound)
+}
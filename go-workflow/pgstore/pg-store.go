@@ -0,0 +1,461 @@
+/*
+Package pgstore is a Postgres implementation of goworkflow.RunStore and goworkflow.CheckpointStore[T].
+
+It only depends on database/sql, register a Postgres driver (pgx, lib/pq) in the application. Run Migrate on startup.
+Multiple service instances can resume runs concurrently: SaveCheckpoint leases the checkpoint to the saving instance
+(Options.Owner) and the workflow renews the lease while the run is live (see goworkflow.CheckpointLeaser), so
+ClaimCheckpoint only hands out checkpoints of runs whose instance died, to one instance at a time.
+With Options.Envelope checkpoint data and run errors are encrypted, they are stored as a json string in their JSONB column.
+Plaintext values written before encryption was enabled are only read by envelopes in migration mode, see
+goworkflow.EnvelopeOptions.
+Metadata stays plaintext since runs are queried by it.
+*/
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+type Options struct {
+	// Envelope encrypts checkpoint data and run errors at rest
+	Envelope *goworkflow.Envelope
+	// Owner identifies the service instance in checkpoint leases, hostname and process id by default
+	Owner string
+	// Lease: lease of the instance on the checkpoints it saves or claims, 1 minute by default
+	Lease time.Duration
+}
+
+/* ErrLeaseLost: the checkpoint is leased by another instance, e.g. it resumed the run after the lease expired */
+var ErrLeaseLost = errors.New("checkpoint is leased by another instance")
+
+type Store[T any] struct {
+	db       *sql.DB
+	prefix   string
+	envelope *goworkflow.Envelope
+	owner    string
+	lease    time.Duration
+}
+
+/* New: tablePrefix is prepended to all table names, e.g. "goworkflow_" */
+func New[T any](db *sql.DB, tablePrefix string, opts ...*Options) *Store[T] {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	s := &Store[T]{db: db, prefix: tablePrefix, lease: time.Minute}
+	if len(opts) == 1 && opts[0] != nil {
+		s.envelope = opts[0].Envelope
+		s.owner = opts[0].Owner
+		if opts[0].Lease < 0 {
+			panic("Lease cannot be negative")
+		}
+		if opts[0].Lease > 0 {
+			s.lease = opts[0].Lease
+		}
+	}
+	if s.owner == "" {
+		host, _ := os.Hostname()
+		s.owner = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return s
+}
+
+/* seal json encoded value b into a json string, when an envelope is set */
+func (s *Store[T]) seal(ctx context.Context, b []byte) ([]byte, error) {
+	if s.envelope == nil {
+		return b, nil
+	}
+	sealed, err := s.envelope.SealText(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+/* open reverses seal, plaintext values written without an envelope are returned as is while migrating */
+func (s *Store[T]) open(ctx context.Context, b []byte) ([]byte, error) {
+	var text string
+	if json.Unmarshal(b, &text) != nil || !goworkflow.IsSealedText(text) {
+		if s.envelope != nil && !s.envelope.Migrating() {
+			return nil, goworkflow.ErrPlaintext
+		}
+		return b, nil
+	}
+	if s.envelope == nil {
+		return nil, errors.New("value is encrypted, create the store with an Envelope")
+	}
+	return s.envelope.OpenText(ctx, text)
+}
+
+func (s *Store[T]) sql(query string) string {
+	return strings.ReplaceAll(query, "{{prefix}}", s.prefix)
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+/* execer: *sql.DB or *sql.Tx */
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (s *Store[T]) SaveRun(ctx context.Context, r goworkflow.RunResult) error {
+	return s.saveRun(ctx, s.db, r)
+}
+
+func (s *Store[T]) saveRun(ctx context.Context, db execer, r goworkflow.RunResult) error {
+	failed, _ := json.Marshal(append([]string{}, r.FailedComponents...))
+	errs, _ := json.Marshal(r.Errors)
+	metadata, _ := json.Marshal(r.Metadata)
+	errs, err := s.seal(ctx, errs)
+	if err != nil {
+		return err
+	}
+	componentErrs, _ := json.Marshal(r.ComponentErrors)
+	if r.ComponentErrors == nil {
+		componentErrs = []byte("{}")
+	}
+	if componentErrs, err = s.seal(ctx, componentErrs); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, s.sql(`
+		INSERT INTO {{prefix}}runs (workflow_id, workflow, version, status, started_at, finished_at, duration_ms, failed_components, errors, metadata, component_errors)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (workflow_id) DO UPDATE SET
+			workflow = EXCLUDED.workflow, version = EXCLUDED.version, status = EXCLUDED.status,
+			started_at = EXCLUDED.started_at, finished_at = EXCLUDED.finished_at, duration_ms = EXCLUDED.duration_ms,
+			failed_components = EXCLUDED.failed_components, errors = EXCLUDED.errors, metadata = EXCLUDED.metadata,
+			component_errors = EXCLUDED.component_errors`),
+		r.WorkflowId, r.Workflow, r.Version, string(r.Status), nullTime(r.StartedAt), nullTime(r.FinishedAt),
+		r.Duration.Milliseconds(), string(failed), string(errs), string(metadata), string(componentErrs),
+	)
+	return err
+}
+
+const runColumns = `workflow_id, workflow, version, status, started_at, finished_at, duration_ms, failed_components, errors, metadata, component_errors`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *Store[T]) scanRun(ctx context.Context, row scanner) (goworkflow.RunResult, error) {
+	r := goworkflow.RunResult{}
+	var status string
+	var startedAt, finishedAt sql.NullTime
+	var durationMs int64
+	var failed, errs, metadata, componentErrs []byte
+	if err := row.Scan(&r.WorkflowId, &r.Workflow, &r.Version, &status, &startedAt, &finishedAt, &durationMs, &failed, &errs, &metadata, &componentErrs); err != nil {
+		return r, err
+	}
+	r.Status = goworkflow.Status(status)
+	r.StartedAt = startedAt.Time
+	r.FinishedAt = finishedAt.Time
+	r.Duration = time.Duration(durationMs) * time.Millisecond
+	if err := json.Unmarshal(failed, &r.FailedComponents); err != nil {
+		return r, err
+	}
+	errs, err := s.open(ctx, errs)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(errs, &r.Errors); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(metadata, &r.Metadata); err != nil {
+		return r, err
+	}
+	if componentErrs, err = s.open(ctx, componentErrs); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(componentErrs, &r.ComponentErrors); err != nil {
+		return r, err
+	}
+	if len(r.ComponentErrors) == 0 {
+		r.ComponentErrors = nil
+	}
+	return r, nil
+}
+
+func (s *Store[T]) GetRun(ctx context.Context, workflowId string) (goworkflow.RunResult, error) {
+	row := s.db.QueryRowContext(ctx, s.sql(`SELECT `+runColumns+` FROM {{prefix}}runs WHERE workflow_id = $1`), workflowId)
+	r, err := s.scanRun(ctx, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, goworkflow.ErrRunNotFound
+	}
+	return r, err
+}
+
+/*
+ClaimRun: see goworkflow.RunClaimer, claims of the same workflow and dedup key are serialized by a transaction
+scoped advisory lock, so concurrent duplicates are detected across service instances. Expired claims are compared
+to the database clock.
+*/
+func (s *Store[T]) ClaimRun(ctx context.Context, r goworkflow.RunResult) (goworkflow.RunResult, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return r, false, err
+	}
+	defer tx.Rollback()
+
+	key := r.Metadata[goworkflow.DedupMetadataKey]
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, s.prefix+r.Workflow+"/"+key); err != nil {
+		return r, false, err
+	}
+	metadata, _ := json.Marshal(map[string]string{goworkflow.DedupMetadataKey: key})
+	row := tx.QueryRowContext(ctx, s.sql(`
+		SELECT `+runColumns+` FROM {{prefix}}runs
+		WHERE workflow = $1 AND metadata @> $2::jsonb AND (status IN ('DONE', 'DONE_WITH_WARNINGS') OR
+			status IN ('PENDING', 'RUNNING') AND COALESCE((metadata->>$3)::timestamptz > now(), true))
+		ORDER BY started_at DESC
+		LIMIT 1`), r.Workflow, string(metadata), goworkflow.DedupExpiresMetadataKey)
+	existing, err := s.scanRun(ctx, row)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return r, false, err
+	}
+	if err := s.saveRun(ctx, tx, r); err != nil {
+		return r, false, err
+	}
+	return r, true, tx.Commit()
+}
+
+/* page tokens are the (started_at, workflow_id) of the last run of the previous page (keyset pagination) */
+type pageToken struct {
+	StartedAt  time.Time `json:"s"`
+	WorkflowId string    `json:"w"`
+}
+
+func encodePageToken(r goworkflow.RunResult) string {
+	b, _ := json.Marshal(pageToken{StartedAt: r.StartedAt, WorkflowId: r.WorkflowId})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(token string) (pageToken, error) {
+	t := pageToken{}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(b, &t)
+	}
+	if err != nil {
+		return t, errors.New("invalid page token")
+	}
+	return t, nil
+}
+
+/* buildRunQuery: sql and arguments for the query, one more row than the page size is fetched to detect the last page */
+func (s *Store[T]) buildRunQuery(q goworkflow.RunQuery) (string, []any, int, error) {
+	conditions := []string{}
+	args := []any{}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.Workflow != "" {
+		conditions = append(conditions, "workflow = "+arg(q.Workflow))
+	}
+	if q.Status != "" {
+		conditions = append(conditions, "status = "+arg(string(q.Status)))
+	}
+	if !q.StartedAfter.IsZero() {
+		conditions = append(conditions, "started_at >= "+arg(q.StartedAfter))
+	}
+	if !q.StartedBefore.IsZero() {
+		conditions = append(conditions, "started_at < "+arg(q.StartedBefore))
+	}
+	if len(q.Metadata) > 0 {
+		metadata, _ := json.Marshal(q.Metadata)
+		conditions = append(conditions, "metadata @> "+arg(string(metadata))+"::jsonb")
+	}
+	if q.FailedComponent != "" {
+		conditions = append(conditions, "failed_components @> jsonb_build_array("+arg(q.FailedComponent)+"::text)")
+	}
+	if q.PageToken != "" {
+		token, err := decodePageToken(q.PageToken)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		startedAt := arg(token.StartedAt)
+		conditions = append(conditions, fmt.Sprintf("(started_at < %s OR (started_at = %s AND workflow_id > %s))", startedAt, startedAt, arg(token.WorkflowId)))
+	}
+
+	size := q.PageSize
+	if size <= 0 {
+		size = 50
+	}
+	query := `SELECT ` + runColumns + ` FROM {{prefix}}runs`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(` ORDER BY started_at DESC, workflow_id ASC LIMIT %d`, size+1)
+	return s.sql(query), args, size, nil
+}
+
+func (s *Store[T]) QueryRuns(ctx context.Context, q goworkflow.RunQuery) (goworkflow.RunPage, error) {
+	query, args, size, err := s.buildRunQuery(q)
+	if err != nil {
+		return goworkflow.RunPage{}, err
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return goworkflow.RunPage{}, err
+	}
+	defer rows.Close()
+
+	page := goworkflow.RunPage{Runs: []goworkflow.RunResult{}}
+	for rows.Next() {
+		r, err := s.scanRun(ctx, rows)
+		if err != nil {
+			return page, err
+		}
+		page.Runs = append(page.Runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+	if len(page.Runs) > size {
+		page.Runs = page.Runs[:size]
+		page.NextPageToken = encodePageToken(page.Runs[size-1])
+	}
+	return page, nil
+}
+
+/* SaveCheckpoint saves the checkpoint leased to the owner of the store, ErrLeaseLost when another instance holds it */
+func (s *Store[T]) SaveCheckpoint(ctx context.Context, cp goworkflow.Checkpoint[T]) error {
+	completed, _ := json.Marshal(cp.Completed)
+	data, err := json.Marshal(cp.Data)
+	if err != nil {
+		return err
+	}
+	if data, err = s.seal(ctx, data); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, s.sql(`
+		INSERT INTO {{prefix}}checkpoints (workflow_id, template, version, completed, data, created_at, updated_at, locked_by, locked_until)
+		VALUES ($1, $2, $3, $4, $5, $6, now(), $7, now() + $8 * interval '1 millisecond')
+		ON CONFLICT (workflow_id) DO UPDATE SET
+			template = EXCLUDED.template, version = EXCLUDED.version, completed = EXCLUDED.completed,
+			data = EXCLUDED.data, created_at = EXCLUDED.created_at, updated_at = now(),
+			locked_by = EXCLUDED.locked_by, locked_until = EXCLUDED.locked_until, finished_at = NULL
+		WHERE {{prefix}}checkpoints.locked_by = EXCLUDED.locked_by OR {{prefix}}checkpoints.locked_until IS NULL
+			OR {{prefix}}checkpoints.locked_until < now()`),
+		cp.WorkflowId, cp.Template, cp.Version, string(completed), string(data), cp.Time, s.owner, s.lease.Milliseconds(),
+	)
+	return leaseResult(result, err)
+}
+
+/* leaseResult: ErrLeaseLost when the statement didn't update the checkpoint */
+func leaseResult(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+/* CheckpointLease: see goworkflow.CheckpointLeaser */
+func (s *Store[T]) CheckpointLease() time.Duration {
+	return s.lease
+}
+
+/* RenewCheckpoint extends the lease of the owner of the store, ErrLeaseLost when it doesn't hold it anymore */
+func (s *Store[T]) RenewCheckpoint(ctx context.Context, workflowId string) error {
+	result, err := s.db.ExecContext(ctx, s.sql(`UPDATE {{prefix}}checkpoints SET locked_until = now() + $1 * interval '1 millisecond' WHERE workflow_id = $2 AND locked_by = $3`),
+		s.lease.Milliseconds(), workflowId, s.owner)
+	return leaseResult(result, err)
+}
+
+/* FinishCheckpoint: the run failed, the checkpoint is kept for LoadCheckpoint but never claimed */
+func (s *Store[T]) FinishCheckpoint(ctx context.Context, workflowId string) error {
+	_, err := s.db.ExecContext(ctx, s.sql(`UPDATE {{prefix}}checkpoints SET finished_at = now(), locked_by = NULL, locked_until = NULL WHERE workflow_id = $1`), workflowId)
+	return err
+}
+
+const checkpointColumns = `workflow_id, template, version, completed, data, created_at`
+
+func (s *Store[T]) scanCheckpoint(ctx context.Context, row scanner) (goworkflow.Checkpoint[T], error) {
+	cp := goworkflow.Checkpoint[T]{}
+	var completed, data []byte
+	if err := row.Scan(&cp.WorkflowId, &cp.Template, &cp.Version, &completed, &data, &cp.Time); err != nil {
+		return cp, err
+	}
+	if err := json.Unmarshal(completed, &cp.Completed); err != nil {
+		return cp, err
+	}
+	data, err := s.open(ctx, data)
+	if err != nil {
+		return cp, err
+	}
+	return cp, json.Unmarshal(data, &cp.Data)
+}
+
+func (s *Store[T]) LoadCheckpoint(ctx context.Context, workflowId string) (goworkflow.Checkpoint[T], error) {
+	row := s.db.QueryRowContext(ctx, s.sql(`SELECT `+checkpointColumns+` FROM {{prefix}}checkpoints WHERE workflow_id = $1`), workflowId)
+	cp, err := s.scanCheckpoint(ctx, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, goworkflow.ErrCheckpointNotFound
+	}
+	return cp, err
+}
+
+func (s *Store[T]) DeleteCheckpoint(ctx context.Context, workflowId string) error {
+	_, err := s.db.ExecContext(ctx, s.sql(`DELETE FROM {{prefix}}checkpoints WHERE workflow_id = $1`), workflowId)
+	return err
+}
+
+/*
+ClaimCheckpoint leases the oldest checkpoint of a live run whose lease expired (its instance died) to owner for lease,
+rows locked by other instances are skipped. Finished checkpoints are never claimed. Returns ErrCheckpointNotFound
+when there is nothing to resume. Pass the Owner of the store and resume with ResumeOptions.CheckpointStore, the
+resumed run then renews the lease and deletes the checkpoint once DONE. An expired lease makes the checkpoint
+claimable again.
+*/
+func (s *Store[T]) ClaimCheckpoint(ctx context.Context, owner string, lease time.Duration) (goworkflow.Checkpoint[T], error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return goworkflow.Checkpoint[T]{}, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, s.sql(`
+		SELECT `+checkpointColumns+` FROM {{prefix}}checkpoints
+		WHERE finished_at IS NULL AND (locked_until IS NULL OR locked_until < now())
+		ORDER BY updated_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`))
+	cp, err := s.scanCheckpoint(ctx, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, goworkflow.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return cp, err
+	}
+	if _, err := tx.ExecContext(ctx, s.sql(`UPDATE {{prefix}}checkpoints SET locked_by = $1, locked_until = now() + $2 * interval '1 millisecond' WHERE workflow_id = $3`),
+		owner, lease.Milliseconds(), cp.WorkflowId); err != nil {
+		return cp, err
+	}
+	return cp, tx.Commit()
+}
+
+/* ReleaseCheckpoint gives up the lease of owner on the checkpoint */
+func (s *Store[T]) ReleaseCheckpoint(ctx context.Context, workflowId string, owner string) error {
+	_, err := s.db.ExecContext(ctx, s.sql(`UPDATE {{prefix}}checkpoints SET locked_by = NULL, locked_until = NULL WHERE workflow_id = $1 AND locked_by = $2`), workflowId, owner)
+	return err
+}
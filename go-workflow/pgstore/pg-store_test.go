@@ -0,0 +1,90 @@
+package pgstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct{}
+
+func TestBuildRunQuery(t *testing.T) {
+	s := New[Data](nil, "wf_")
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	query, args, size, err := s.buildRunQuery(goworkflow.RunQuery{
+		Workflow:        "document",
+		Status:          goworkflow.ERROR,
+		StartedAfter:    after,
+		Metadata:        map[string]string{"tenant": "acme"},
+		FailedComponent: "OCR",
+		PageSize:        20,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, size)
+	assert.Equal(t, "SELECT "+runColumns+" FROM wf_runs WHERE workflow = $1 AND status = $2 AND started_at >= $3 AND "+
+		"metadata @> $4::jsonb AND failed_components @> jsonb_build_array($5::text) ORDER BY started_at DESC, workflow_id ASC LIMIT 21", query)
+	assert.Equal(t, []any{"document", "ERROR", after, `{"tenant":"acme"}`, "OCR"}, args)
+
+	query, _, size, err = s.buildRunQuery(goworkflow.RunQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, size)
+	assert.NotContains(t, query, "WHERE")
+
+	_, _, _, err = s.buildRunQuery(goworkflow.RunQuery{PageToken: "not a token"})
+	assert.Error(t, err)
+}
+
+func TestPageToken(t *testing.T) {
+	s := New[Data](nil, "")
+	last := goworkflow.RunResult{WorkflowId: "run-7", StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	token := encodePageToken(last)
+	decoded, err := decodePageToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "run-7", decoded.WorkflowId)
+	assert.True(t, last.StartedAt.Equal(decoded.StartedAt))
+
+	query, args, _, err := s.buildRunQuery(goworkflow.RunQuery{Workflow: "document", PageToken: token})
+	assert.NoError(t, err)
+	assert.Contains(t, query, "(started_at < $2 OR (started_at = $2 AND workflow_id > $3))")
+	assert.Len(t, args, 3)
+}
+
+func TestMigrationsUsePrefix(t *testing.T) {
+	s := New[Data](nil, "tenant_a_")
+	for _, m := range migrations {
+		sql := s.sql(m)
+		assert.NotContains(t, sql, "{{prefix}}")
+		assert.True(t, strings.Contains(sql, "tenant_a_"))
+	}
+}
+
+func TestSealValues(t *testing.T) {
+	kms, _ := goworkflow.NewLocalKMS("key-1", []byte("0123456789abcdef0123456789abcdef"))
+	s := New[Data](nil, "", &Options{Envelope: goworkflow.NewEnvelope(kms)})
+
+	sealed, err := s.seal(context.TODO(), []byte(`{"OCR":"failed on patient name"}`))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "patient name")
+	assert.True(t, strings.HasPrefix(string(sealed), `"`))
+
+	opened, err := s.open(context.TODO(), sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"OCR":"failed on patient name"}`, string(opened))
+
+	// plaintext rows written before encryption was enabled, only read while migrating
+	_, err = s.open(context.TODO(), []byte(`{"OCR":"timeout"}`))
+	assert.ErrorIs(t, err, goworkflow.ErrPlaintext)
+	migrating := New[Data](nil, "", &Options{Envelope: goworkflow.NewEnvelope(kms, &goworkflow.EnvelopeOptions{Migration: true})})
+	opened, err = migrating.open(context.TODO(), []byte(`{"OCR":"timeout"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"OCR":"timeout"}`, string(opened))
+
+	_, err = New[Data](nil, "").open(context.TODO(), sealed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+/*
+Package plugins loads component executors from Go plugins (go build -buildmode=plugin) into a
+goworkflow.ComponentRegistry, so workflows can use new components without rebuilding the host binary.
+
+A plugin exports a RegisterComponents function taking the registry of the host:
+
+	func RegisterComponents(r *goworkflow.ComponentRegistry[context.Context, Config, Data]) {
+		goworkflow.Register(r, "ocr", ocr)
+	}
+
+Config and Data must be the very same types as in the host, i.e. come from a package both import, and the plugin
+must be built with the same toolchain and dependency versions. Go plugins are supported on linux, freebsd and macOS.
+
+Loading WASM modules is out of scope of this package: it would need a WASM runtime dependency, and executors would have
+to exchange Config and Data as bytes instead of Go values. A host which needs them can embed a runtime of its choice
+and register a ComponentRegistry.AddResolver which resolves names, e.g. "wasm:summarize", to functions of its modules.
+*/
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"plugin"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* RegisterSymbol: name of the function a plugin exports */
+const RegisterSymbol = "RegisterComponents"
+
+/* Load opens the plugin at path and registers its executors in r */
+func Load[CT context.Context, C any, T any](r *goworkflow.ComponentRegistry[CT, C, T], path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+	symbol, err := p.Lookup(RegisterSymbol)
+	if err != nil {
+		return err
+	}
+	register, ok := symbol.(func(*goworkflow.ComponentRegistry[CT, C, T]))
+	if !ok {
+		return fmt.Errorf("plugin %s: %s has type %T, expected %T", path, RegisterSymbol, symbol, register)
+	}
+	register(r)
+	return nil
+}
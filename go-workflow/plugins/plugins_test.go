@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/plugins/testdata/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin")
+	}
+	path := filepath.Join(t.TempDir(), "ocr.so")
+	build := exec.Command("go", "build", "-buildmode=plugin", "-o", path, "./testdata/ocr")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Skipf("plugins can't be built here: %s %s", err, out)
+	}
+
+	registry := goworkflow.NewComponentRegistry[context.Context, shared.Config, shared.Data]()
+	if err := Load(registry, path); err != nil {
+		t.Skipf("plugin can't be loaded into the test binary: %s", err)
+	}
+	assert.Equal(t, []string{"ocr"}, registry.Names())
+
+	component, err := registry.Component("OCR", "ocr", map[string]any{"Number": 2})
+	assert.NoError(t, err)
+	wf := goworkflow.NewWorkflow[context.Context, shared.Config, shared.Data](context.TODO())
+	wf.AddComponent(component)
+	data, st, _ := wf.Execute(context.TODO(), shared.Config{Language: "fr"}, &shared.Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "page 2 in fr", data.Text)
+}
+
+func TestLoadMissing(t *testing.T) {
+	registry := goworkflow.NewComponentRegistry[context.Context, shared.Config, shared.Data]()
+	assert.Error(t, Load(registry, filepath.Join(t.TempDir(), "missing.so")))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/plugins/testdata/shared"
+)
+
+type Page struct {
+	Number int
+}
+
+func RegisterComponents(r *goworkflow.ComponentRegistry[context.Context, shared.Config, shared.Data]) {
+	goworkflow.Register(r, "ocr", func(ctx context.Context, page Page, dt *goworkflow.DataTracker[shared.Config, shared.Data]) error {
+		dt.Update(func(d *shared.Data) {
+			d.Text = "page " + string(rune('0'+page.Number)) + " in " + dt.Config.Language
+		})
+		return nil
+	})
+}
+
+func main() {}
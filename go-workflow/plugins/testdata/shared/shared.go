@@ -0,0 +1,10 @@
+/* Package shared has the types both the test host and the test plugin use */
+package shared
+
+type Config struct {
+	Language string
+}
+
+type Data struct {
+	Text string
+}
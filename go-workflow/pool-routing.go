@@ -0,0 +1,75 @@
+package goworkflow
+
+import (
+	"maps"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/*
+PoolRoute: limiter pools of the runs whose metadata has all Labels, e.g. {"tier": "premium"}. Unset pools
+keep the ones of the workflow.
+*/
+type PoolRoute struct {
+	Labels map[string]string
+	// Bulkheads replace the bulkheads of the workflow, they need the same names
+	Bulkheads *Bulkheads
+	// Workers: shared by all runs of the route, their components execute at most its capacity at once.
+	// Replaces WorkflowOptions.MaxParallelComponents
+	Workers *limiter.ConcurrencyLimiter
+	// Queue: run queue of the routed runs submitted to a RunExecutor, instead of the queue of the executor
+	Queue *limiter.RunQueue
+}
+
+/*
+PoolRouter picks the pools of a run from its metadata when it starts, so runs of the same workflow get
+different concurrency without separate code paths. The first route matching the metadata wins, runs matching
+none keep the pools of the workflow.
+*/
+type PoolRouter struct {
+	Routes []PoolRoute
+}
+
+/* Route: first route matching metadata, false when none matches */
+func (r *PoolRouter) Route(metadata map[string]string) (PoolRoute, bool) {
+	for _, route := range r.Routes {
+		matches := true
+		for k, v := range route.Labels {
+			matches = matches && metadata[k] == v
+		}
+		if matches {
+			return route, true
+		}
+	}
+	return PoolRoute{}, false
+}
+
+/* SetPoolRouter: routes the run to pools by its metadata (see SetMetadata), set the metadata before Execute */
+func (wf *Workflow[CT, C, T]) SetPoolRouter(router *PoolRouter) {
+	wf.poolRouter = router
+}
+
+/* poolRoute: route of the run by its current metadata */
+func (wf *Workflow[CT, C, T]) poolRoute() (PoolRoute, bool) {
+	if wf.poolRouter == nil {
+		return PoolRoute{}, false
+	}
+	wf.stateLock.Lock()
+	metadata := maps.Clone(wf.metadata)
+	wf.stateLock.Unlock()
+	return wf.poolRouter.Route(metadata)
+}
+
+/* routePools: swaps the pools of the workflow for the ones of its route, called before the build checks */
+func (wf *Workflow[CT, C, T]) routePools() {
+	route, ok := wf.poolRoute()
+	if !ok {
+		return
+	}
+	if route.Bulkheads != nil {
+		wf.bulkheads = route.Bulkheads
+	}
+	if route.Workers != nil {
+		wf.parallelism = route.Workers
+	}
+}
@@ -0,0 +1,66 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolRouter(t *testing.T) {
+	var running, peak atomic.Int32
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("pages")
+	for i := 0; i < 8; i++ {
+		template.AddComponent(goworkflow.MakeComponent(fmt.Sprint("Page", i), nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}), &goworkflow.ComponentConfig{Bulkhead: "ocr"})
+	}
+	premiumQueue := limiter.NewRunQueue(4)
+	router := &goworkflow.PoolRouter{Routes: []goworkflow.PoolRoute{
+		{Labels: map[string]string{"tier": "premium"}, Bulkheads: goworkflow.NewBulkheads(map[string]int{"ocr": 4}), Queue: premiumQueue},
+		{Labels: map[string]string{"tier": "free"}, Workers: limiter.NewConcurrencyLimiter(1)},
+	}}
+	run := func(tier string) int32 {
+		peak.Store(0)
+		wf := template.NewWorkflow(context.TODO())
+		wf.SetBulkheads(goworkflow.NewBulkheads(map[string]int{"ocr": 2}))
+		wf.SetPoolRouter(router)
+		wf.SetMetadata("tier", tier)
+		_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		return peak.Load()
+	}
+	assert.Equal(t, int32(4), run("premium"))
+	assert.Equal(t, int32(1), run("free"))
+	assert.Equal(t, int32(2), run("standard"))
+
+	route, ok := router.Route(map[string]string{"tier": "premium", "region": "eu"})
+	assert.True(t, ok)
+	assert.Same(t, premiumQueue, route.Queue)
+	_, ok = router.Route(map[string]string{})
+	assert.False(t, ok)
+
+	// premium runs skip the full queue of the executor
+	busy := limiter.NewRunQueue(1)
+	blocker := busy.Enqueue()
+	executor := goworkflow.NewRunExecutor[context.Context, Config, Data](busy)
+	wf := template.NewWorkflow(context.TODO())
+	wf.SetBulkheads(goworkflow.NewBulkheads(map[string]int{"ocr": 2}))
+	wf.SetPoolRouter(router)
+	wf.SetMetadata("tier", "premium")
+	_, st, err := executor.Execute(context.TODO(), wf, Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	blocker.Done()
+}
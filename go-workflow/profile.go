@@ -0,0 +1,115 @@
+package goworkflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/* ComponentOverride: settings of a component replaced by a profile, nil fields keep the ComponentConfig value */
+type ComponentOverride struct {
+	Timeout            *time.Duration
+	Retry              *RetryPolicy
+	ConcurrencyLimiter *limiter.ConcurrencyLimiter
+	Optional           *bool
+	// Skip: the component is SKIPPED, e.g. notifications in dev. Components depending on it with OnSuccess are SKIPPED as well
+	Skip bool
+}
+
+/*
+Profile overrides component settings for an environment (dev, staging, prod), so one workflow definition serves
+all of them. Limiters are shared by the runs of a profile, create them once with the profile.
+*/
+type Profile struct {
+	Name string
+	// Components: overrides by component name
+	Components map[string]ComponentOverride
+}
+
+/* ApplyProfile overrides the settings of the components, it must be called before Execute. Unknown components are errors */
+func (wf *Workflow[CT, C, T]) ApplyProfile(profile Profile) error {
+	byName := map[string]*component[CT, C, T]{}
+	for _, c := range wf.componentsMap {
+		byName[c.Name] = c
+	}
+	unknown := []string{}
+	for name := range profile.Components {
+		if _, ok := byName[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("profile %s: unknown components %s", profile.Name, strings.Join(unknown, ", "))
+	}
+	for name, override := range profile.Components {
+		c := byName[name]
+		// the config may be shared with other components or workflows
+		cfg := &ComponentConfig{}
+		if c.addComponentCfg != nil {
+			copied := *c.addComponentCfg
+			cfg = &copied
+		}
+		if override.Timeout != nil {
+			cfg.Timeout = *override.Timeout
+		}
+		if override.Retry != nil {
+			cfg.Retry = override.Retry
+		}
+		if override.ConcurrencyLimiter != nil {
+			cfg.ConcurrencyLimiter = override.ConcurrencyLimiter
+		}
+		if override.Optional != nil {
+			cfg.Optional = *override.Optional
+			wf.dependencyManager.optional[c.id] = cfg.Optional
+		}
+		c.addComponentCfg = cfg
+		if override.Skip {
+			c.skipReason = "skipped by profile " + profile.Name
+		}
+	}
+	wf.SetMetadata("profile", profile.Name)
+	return nil
+}
+
+/* AddProfile registers a profile, see ExecuteWithProfile */
+func (t *Template[CT, C, T]) AddProfile(profile Profile) {
+	t.mustNotBeFrozen()
+	if profile.Name == "" {
+		panic("profile name cannot be empty")
+	}
+	if t.profiles == nil {
+		t.profiles = map[string]Profile{}
+	}
+	if _, ok := t.profiles[profile.Name]; ok {
+		panic(fmt.Sprintf("profile %s already exists in template %s", profile.Name, t.Name))
+	}
+	t.profiles[profile.Name] = profile
+}
+
+/* Profiles: names of the registered profiles, sorted */
+func (t *Template[CT, C, T]) Profiles() []string {
+	names := make([]string, 0, len(t.profiles))
+	for name := range t.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/* ExecuteWithProfile runs a fresh workflow with the settings of the named profile, e.g. from an APP_ENV variable */
+func (t *Template[CT, C, T]) ExecuteWithProfile(ctx CT, profile string, config C, data *T) (*T, Status, error) {
+	p, ok := t.profiles[profile]
+	if !ok {
+		return data, ERROR, errors.New("unknown profile " + profile)
+	}
+	wf := t.NewWorkflow(ctx)
+	if err := wf.ApplyProfile(p); err != nil {
+		return data, ERROR, err
+	}
+	return wf.Execute(ctx, config, data)
+}
@@ -0,0 +1,90 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func profileTemplate(attempts *int) *goworkflow.Template[context.Context, Config, Data] {
+	t := goworkflow.NewTemplate[context.Context, Config, Data]("invoices")
+	extract := t.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		*attempts++
+		select {
+		case <-time.After(50 * time.Millisecond):
+			dt.Update(func(d *Data) { d.A = "extracted" })
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 2}})
+	t.AddComponent(goworkflow.MakeComponent("Notify", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.B = "notified" })
+		return nil
+	})).AddDependencies(extract)
+	timeout := 10 * time.Millisecond
+	optional := true
+	t.AddProfile(goworkflow.Profile{Name: "dev", Components: map[string]goworkflow.ComponentOverride{
+		"Extract": {Timeout: &timeout, Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}, Optional: &optional},
+		"Notify":  {Skip: true},
+	}})
+	t.AddProfile(goworkflow.Profile{Name: "prod", Components: map[string]goworkflow.ComponentOverride{
+		"Extract": {ConcurrencyLimiter: limiter.NewConcurrencyLimiter(4)},
+	}})
+	return t
+}
+
+func TestExecuteWithProfile(t *testing.T) {
+	attempts := 0
+	template := profileTemplate(&attempts)
+	assert.Equal(t, []string{"dev", "prod"}, template.Profiles())
+
+	data, st, err := template.ExecuteWithProfile(context.TODO(), "prod", Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, Data{A: "extracted", B: "notified"}, *data)
+
+	attempts = 0
+	data, st, err = template.ExecuteWithProfile(context.TODO(), "dev", Config{}, &Data{})
+	assert.NoError(t, err)
+	// every attempt times out, the optional failure becomes a warning, Notify is skipped
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, Data{}, *data)
+
+	// the template itself is unchanged
+	attempts = 0
+	_, st, _ = template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestApplyProfileErrors(t *testing.T) {
+	attempts := 0
+	template := profileTemplate(&attempts)
+	_, st, err := template.ExecuteWithProfile(context.TODO(), "staging", Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.EqualError(t, err, "unknown profile staging")
+
+	wf := template.NewWorkflow(context.TODO())
+	err = wf.ApplyProfile(goworkflow.Profile{Name: "qa", Components: map[string]goworkflow.ComponentOverride{"OCR": {Skip: true}, "Classify": {}}})
+	assert.EqualError(t, err, "profile qa: unknown components Classify, OCR")
+	assert.NoError(t, wf.ApplyProfile(goworkflow.Profile{Name: "qa"}))
+	assert.Equal(t, "qa", wf.Result().Metadata["profile"])
+	assert.Panics(t, func() { template.AddProfile(goworkflow.Profile{Name: "dev"}) })
+}
+
+func TestComponentTimeout(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	c := wf.AddComponent(goworkflow.MakeComponent("Slow", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), &goworkflow.ComponentConfig{Timeout: 5 * time.Millisecond})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, "component timed out after 5ms: context deadline exceeded", c.Status().ErrorMessage)
+}
@@ -0,0 +1,32 @@
+package goworkflow
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+/* SetName: name of the workflow, used in profiles and exports. Workflows created from a template use the template name */
+func (wf *Workflow[CT, C, T]) SetName(name string) {
+	wf.name = name
+}
+
+func (wf *Workflow[CT, C, T]) Name() string {
+	return wf.name
+}
+
+/*
+invokeExecutor runs the component with pprof labels (workflow, component, run_id), so CPU and goroutine
+profiles can be attributed to workflow components. Goroutines started by the component inherit the labels.
+*/
+func (wf *Workflow[CT, C, T]) invokeExecutor(ctx CT, componentCtx context.Context, c *component[CT, C, T], input ComponentInput, dataTracker *DataTracker[C, T]) error {
+	var err error
+	labels := pprof.Labels("workflow", wf.name, "component", c.Name, "run_id", wf.id)
+	pprof.Do(componentCtx, labels, func(labeledCtx context.Context) {
+		tracker, checkConfig := wf.isolateConfig(c, dataTracker.forComponent(labeledCtx))
+		err = c.executor(componentContext(ctx, labeledCtx), input, tracker)
+		if err == nil {
+			err = checkConfig()
+		}
+	})
+	return err
+}
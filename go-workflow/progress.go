@@ -0,0 +1,205 @@
+package goworkflow
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/* DurationEstimator estimates the execution time of a component, e.g. from the durations of previous runs */
+type DurationEstimator interface {
+	EstimateDuration(workflow string, component string) (time.Duration, bool)
+}
+
+/*
+Progress of a run. Percent weighs components by their estimated duration, ETA follows the longest remaining path
+through the dependency graph. Components without an estimate (ComponentConfig.ExpectedDuration or the
+DurationEstimator) count with the mean of the known estimates.
+*/
+type Progress struct {
+	WorkflowId string
+	Completed  int
+	Total      int
+	Percent    float64
+	Elapsed    time.Duration
+	Remaining  time.Duration
+	ETA        time.Time
+}
+
+/* SetDurationEstimator: estimator for components without ComponentConfig.ExpectedDuration */
+func (wf *Workflow[CT, C, T]) SetDurationEstimator(estimator DurationEstimator) {
+	wf.durationEstimator = estimator
+}
+
+/* Progress: live progress and ETA of the run */
+func (wf *Workflow[CT, C, T]) Progress() Progress {
+	wf.stateLock.Lock()
+	startedAt := wf.startedAt
+	wf.stateLock.Unlock()
+	return wf.progress(startedAt, time.Now())
+}
+
+/* OnProgress registers hook, invoked with the progress every time a component starts or finishes */
+func (wf *Workflow[CT, C, T]) OnProgress(hook func(Progress)) {
+	var startedAt time.Time
+	wf.addStateListener(func(change StateChange) {
+		if change.Component == "" {
+			if change.NewStatus == RUNNING {
+				startedAt = change.Time
+			}
+			return
+		}
+		hook(wf.progress(startedAt, change.Time))
+	})
+}
+
+func (wf *Workflow[CT, C, T]) estimate(c *component[CT, C, T]) (time.Duration, bool) {
+	if c.addComponentCfg != nil && c.addComponentCfg.ExpectedDuration > 0 {
+		return c.addComponentCfg.ExpectedDuration, true
+	}
+	if wf.durationEstimator != nil {
+		return wf.durationEstimator.EstimateDuration(wf.name, c.Name)
+	}
+	return 0, false
+}
+
+func (wf *Workflow[CT, C, T]) progress(startedAt time.Time, now time.Time) Progress {
+	p := Progress{WorkflowId: wf.id, Total: len(wf.componentsMap)}
+	if !startedAt.IsZero() {
+		p.Elapsed = now.Sub(startedAt)
+	}
+
+	estimates := map[string]time.Duration{}
+	var known time.Duration
+	for id, c := range wf.componentsMap {
+		if d, ok := wf.estimate(c); ok {
+			estimates[id] = d
+			known += d
+		}
+	}
+	var fallback time.Duration
+	if len(estimates) > 0 {
+		fallback = known / time.Duration(len(estimates))
+	}
+
+	// remaining execution time of every component on its own
+	remaining := map[string]time.Duration{}
+	var totalWeight, doneWeight float64
+	for id, c := range wf.componentsMap {
+		d, ok := estimates[id]
+		if !ok {
+			d = fallback
+		}
+		// weigh by estimate, or count components when nothing is known
+		weight := float64(d)
+		if fallback == 0 {
+			weight = 1
+		}
+		totalWeight += weight
+
+		c.statusLock.Lock()
+		status, started := c.status.Status, c.timing.StartedAt
+		c.statusLock.Unlock()
+		switch status {
+		case DONE, ERROR, SKIPPED:
+			p.Completed++
+			doneWeight += weight
+			remaining[id] = 0
+		case RUNNING:
+			left := d - now.Sub(started)
+			if left < 0 {
+				left = 0
+			}
+			remaining[id] = left
+			if d > 0 {
+				doneWeight += weight * float64(d-left) / float64(d)
+			}
+		default:
+			remaining[id] = d
+		}
+	}
+	if totalWeight > 0 {
+		p.Percent = 100 * doneWeight / totalWeight
+	}
+
+	// longest remaining path, finish[id] is the time from now until the component is expected to finish
+	dependencies := map[string][]string{}
+	for dependencyId, dependents := range wf.dependencyManager.dependencyGraph {
+		for componentId := range dependents {
+			dependencies[componentId] = append(dependencies[componentId], dependencyId)
+		}
+	}
+	finish := map[string]time.Duration{}
+	var finishAt func(id string) time.Duration
+	finishAt = func(id string) time.Duration {
+		if f, ok := finish[id]; ok {
+			return f
+		}
+		var start time.Duration
+		for _, dep := range dependencies[id] {
+			if f := finishAt(dep); f > start {
+				start = f
+			}
+		}
+		finish[id] = start + remaining[id]
+		return finish[id]
+	}
+	for id := range wf.componentsMap {
+		if f := finishAt(id); f > p.Remaining {
+			p.Remaining = f
+		}
+	}
+	p.ETA = now.Add(p.Remaining)
+	return p
+}
+
+/*
+DurationHistory is a DurationEstimator learning from finished runs, estimates are an exponentially weighted moving
+average of the durations of DONE components. Share one history between runs and register it with Observe.
+*/
+type DurationHistory struct {
+	lock      sync.Mutex
+	alpha     float64
+	estimates map[[2]string]time.Duration
+}
+
+/* NewDurationHistory: alpha in (0, 1] is the weight of the latest duration, e.g. 0.3 */
+func NewDurationHistory(alpha float64) *DurationHistory {
+	if alpha <= 0 || alpha > 1 {
+		panic("alpha must be in (0, 1]")
+	}
+	return &DurationHistory{alpha: alpha, estimates: map[[2]string]time.Duration{}}
+}
+
+func (h *DurationHistory) Record(workflow string, component string, d time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	key := [2]string{workflow, component}
+	if prev, ok := h.estimates[key]; ok {
+		d = time.Duration(h.alpha*float64(d) + (1-h.alpha)*float64(prev))
+	}
+	h.estimates[key] = d
+}
+
+func (h *DurationHistory) EstimateDuration(workflow string, component string) (time.Duration, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	d, ok := h.estimates[[2]string{workflow, component}]
+	return d, ok
+}
+
+/* Observe records the durations of the components of wf once they are DONE, and uses h as its estimator */
+func Observe[CT context.Context, C any, T any](h *DurationHistory, wf *Workflow[CT, C, T]) {
+	wf.SetDurationEstimator(h)
+	wf.addStateListener(func(change StateChange) {
+		if change.Component == "" || change.NewStatus != DONE {
+			return
+		}
+		if c, ok := wf.componentsMap[change.ComponentId]; ok {
+			c.statusLock.Lock()
+			d := c.timing.Duration()
+			c.statusLock.Unlock()
+			h.Record(wf.name, c.Name, d)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 20*time.Millisecond, nil)),
+		&goworkflow.ComponentConfig{ExpectedDuration: 100 * time.Millisecond})
+	wf.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("parse", 20*time.Millisecond, nil)),
+		&goworkflow.ComponentConfig{ExpectedDuration: 300 * time.Millisecond}).AddDependencies(ocr)
+
+	before := wf.Progress()
+	assert.Equal(t, 2, before.Total)
+	assert.Equal(t, 0.0, before.Percent)
+	assert.Equal(t, 400*time.Millisecond, before.Remaining)
+
+	updates := []goworkflow.Progress{}
+	wf.OnProgress(func(p goworkflow.Progress) {
+		updates = append(updates, p)
+	})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+
+	// OCR running, OCR done, Parse running, Parse done
+	assert.Len(t, updates, 4)
+	assert.Equal(t, 1, updates[1].Completed)
+	assert.InDelta(t, 25.0, updates[1].Percent, 0.01)
+	assert.Equal(t, 300*time.Millisecond, updates[1].Remaining)
+	assert.Equal(t, 100.0, updates[3].Percent)
+	assert.Equal(t, time.Duration(0), updates[3].Remaining)
+	assert.Greater(t, updates[3].Elapsed, time.Duration(0))
+}
+
+func TestDurationHistory(t *testing.T) {
+	history := goworkflow.NewDurationHistory(0.5)
+	for i := 0; i < 2; i++ {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("document")
+		wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 20*time.Millisecond, nil)))
+		goworkflow.Observe(history, wf)
+		if i == 1 {
+			// learnt from the first run
+			assert.InDelta(t, float64(20*time.Millisecond), float64(wf.Progress().Remaining), float64(15*time.Millisecond))
+		}
+		wf.Execute(context.TODO(), Config{}, &Data{})
+	}
+
+	d, ok := history.EstimateDuration("document", "OCR")
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, d, 20*time.Millisecond)
+	_, ok = history.EstimateDuration("document", "Parse")
+	assert.False(t, ok)
+
+	history.Record("document", "Parse", 100*time.Millisecond)
+	history.Record("document", "Parse", 200*time.Millisecond)
+	d, _ = history.EstimateDuration("document", "Parse")
+	assert.Equal(t, 150*time.Millisecond, d)
+}
@@ -0,0 +1,86 @@
+package goworkflow
+
+import (
+	"context"
+)
+
+/*
+Promise is the future output of a typed component. Dependents are declared with the promises they need and
+receive the resolved values as arguments, the dependency edges are derived from the promises passed in.
+*/
+type Promise[CT context.Context, C any, T any, O any] struct {
+	*Handle[CT, C, T, O]
+}
+
+/* Async adds a component without dependencies, its output resolves the returned promise */
+func Async[CT context.Context, C any, T any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Promise[CT, C, T, O] {
+	return &Promise[CT, C, T, O]{AddProducer(wf, name, fn, cfgs...)}
+}
+
+/* Then adds a component which runs once a is resolved */
+func Then[CT context.Context, C any, T any, A any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	a *Promise[CT, C, T, A],
+	fn func(CT, A, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Promise[CT, C, T, O] {
+	c := AddConsumer1(wf, name, fn, cfgs...)
+	c.AddDependencies(a.Handle)
+	return &Promise[CT, C, T, O]{c.Handle}
+}
+
+/* Then2 adds a component which runs once a and b are resolved */
+func Then2[CT context.Context, C any, T any, A any, B any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	a *Promise[CT, C, T, A],
+	b *Promise[CT, C, T, B],
+	fn func(CT, A, B, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Promise[CT, C, T, O] {
+	c := AddConsumer2(wf, name, fn, cfgs...)
+	c.AddDependencies(a.Handle, b.Handle)
+	return &Promise[CT, C, T, O]{c.Handle}
+}
+
+/* Then3 adds a component which runs once a, b and d are resolved */
+func Then3[CT context.Context, C any, T any, A any, B any, D any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	a *Promise[CT, C, T, A],
+	b *Promise[CT, C, T, B],
+	d *Promise[CT, C, T, D],
+	fn func(CT, A, B, D, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Promise[CT, C, T, O] {
+	c := AddConsumer3(wf, name, fn, cfgs...)
+	c.AddDependencies(a.Handle, b.Handle, d.Handle)
+	return &Promise[CT, C, T, O]{c.Handle}
+}
+
+/* All adds a component resolving to the values of all promises, in the same order (fan-in) */
+func All[CT context.Context, C any, T any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	promises []*Promise[CT, C, T, O],
+	cfgs ...*ComponentConfig,
+) *Promise[CT, C, T, []O] {
+	promises = append([]*Promise[CT, C, T, O]{}, promises...)
+	h := addTypedComponent(wf, name, func(ctx CT, dt *DataTracker[C, T]) ([]O, error) {
+		values := make([]O, len(promises))
+		for i, p := range promises {
+			values[i] = p.Value()
+		}
+		return values, nil
+	}, cfgs...)
+	for _, p := range promises {
+		h.Component.AddDependencies(p.Component)
+	}
+	return &Promise[CT, C, T, []O]{h}
+}
@@ -0,0 +1,68 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Page struct {
+	Visual string
+	Text   string
+}
+
+func TestPromises(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+
+	pages := []*goworkflow.Promise[context.Context, Config, Data, Page]{}
+	for i := 0; i < 3; i++ {
+		i := i
+		visual := goworkflow.Async(wf, "Visual", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+			return fmt.Sprintf("visual%d", i), nil
+		})
+		text := goworkflow.Async(wf, "Text", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+			return fmt.Sprintf("text%d", i), nil
+		})
+		pages = append(pages, goworkflow.Then2(wf, "Page", visual, text, func(ctx context.Context, v string, t string, dt *goworkflow.DataTracker[Config, Data]) (Page, error) {
+			return Page{Visual: v, Text: t}, nil
+		}))
+	}
+	all := goworkflow.All(wf, "AllPages", pages)
+	summary := goworkflow.Then(wf, "Summary", all, func(ctx context.Context, pages []Page, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+		parts := []string{}
+		for _, p := range pages {
+			parts = append(parts, p.Visual+p.Text)
+		}
+		dt.Update(func(d *Data) {
+			d.Combined = strings.Join(parts, ",")
+		})
+		return dt.GetData().Combined, nil
+	})
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "visual0text0,visual1text1,visual2text2", data.Combined)
+	assert.Equal(t, data.Combined, summary.Value())
+}
+
+func TestPromiseFailurePropagates(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+
+	failing := goworkflow.Async(wf, "OCR", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+		return "", errors.New("ocr failed")
+	})
+	dependent := goworkflow.Then(wf, "Parse", failing, func(ctx context.Context, text string, dt *goworkflow.DataTracker[Config, Data]) (int, error) {
+		return len(text), nil
+	})
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, goworkflow.ERROR, dependent.Component.Status().Status)
+}
@@ -0,0 +1,162 @@
+package goworkflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* QualityGateFailed: ComponentError code of a failed QualityGate */
+const QualityGateFailed = "QUALITY_GATE_FAILED"
+
+/* QualityRule: Check returns why the data violates the rule, nil when it passes */
+type QualityRule[C any, T any] struct {
+	Name  string
+	Check func(config C, data T) error
+}
+
+/*
+QualityGate evaluates all rules on the data store. When a rule fails the gate fails permanently with a
+ComponentError (code QUALITY_GATE_FAILED, details: rule name -> violation), its dependents don't run and the run
+finishes QUARANTINED instead of DONE or ERROR, with its data sent to the dead-letter sink (see
+SetDeadLetterSink), so bad extractions never reach the consumers of the run.
+*/
+func QualityGate[CT context.Context, I any, C any, T any](rules ...QualityRule[C, T]) ComponentFunction[CT, I, C, T] {
+	if len(rules) == 0 {
+		panic("a quality gate needs rules")
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Check == nil {
+			panic("quality rules need a name and a check")
+		}
+	}
+	return func(ctx CT, input I, dt *DataTracker[C, T]) error {
+		data := dt.GetData()
+		violations := map[string]string{}
+		failed := []string{}
+		for _, rule := range rules {
+			if err := rule.Check(dt.Config, data); err != nil {
+				violations[rule.Name] = err.Error()
+				failed = append(failed, rule.Name+": "+err.Error())
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		return &ComponentError{Code: QualityGateFailed, Message: strings.Join(failed, "; "), Details: violations}
+	}
+}
+
+/* QualityViolation: rule of a quality gate failed by a quarantined run */
+type QualityViolation struct {
+	Gate    string `json:"gate"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+/* DeadLetter: quarantined run with the data which failed its quality gates */
+type DeadLetter struct {
+	WorkflowId    string             `json:"workflowId"`
+	Workflow      string             `json:"workflow,omitempty"`
+	Version       string             `json:"version,omitempty"`
+	Violations    []QualityViolation `json:"violations"`
+	Data          json.RawMessage    `json:"data"`
+	Metadata      map[string]string  `json:"metadata,omitempty"`
+	QuarantinedAt time.Time          `json:"quarantinedAt"`
+}
+
+/* DeadLetterSink receives the quarantined runs, e.g. a queue reviewed by operators */
+type DeadLetterSink interface {
+	Quarantine(ctx context.Context, letter DeadLetter) error
+}
+
+/* SetDeadLetterSink: sink of the runs quarantined by a quality gate, failures to send are logged */
+func (wf *Workflow[CT, C, T]) SetDeadLetterSink(sink DeadLetterSink) {
+	wf.deadLetterSink = sink
+}
+
+/* failedQualityGates: sorted names of the quality gates which failed */
+func (wf *Workflow[CT, C, T]) failedQualityGates() []string {
+	gates := []string{}
+	for _, c := range wf.sortedComponents() {
+		if ce := c.ComponentError(); ce != nil && ce.Code == QualityGateFailed {
+			gates = append(gates, c.Name)
+		}
+	}
+	slices.Sort(gates)
+	return gates
+}
+
+func (wf *Workflow[CT, C, T]) deadLetter(ctx context.Context, store *dataStore[T]) {
+	if wf.deadLetterSink == nil {
+		return
+	}
+	result := wf.Result()
+	letter := DeadLetter{
+		WorkflowId:    result.WorkflowId,
+		Workflow:      result.Workflow,
+		Version:       result.Version,
+		Metadata:      result.Metadata,
+		QuarantinedAt: result.FinishedAt,
+	}
+	for _, gate := range wf.failedQualityGates() {
+		ce := result.ComponentErrors[gate]
+		rules := []string{}
+		for rule := range ce.Details {
+			rules = append(rules, rule)
+		}
+		slices.Sort(rules)
+		for _, rule := range rules {
+			letter.Violations = append(letter.Violations, QualityViolation{Gate: gate, Rule: rule, Message: ce.Details[rule]})
+		}
+	}
+	store.lock.Lock()
+	data, err := json.Marshal(store.data)
+	store.lock.Unlock()
+	if err != nil {
+		log.Println("Workflow.DeadLetter:Error:", err)
+		return
+	}
+	letter.Data = data
+	if err := wf.deadLetterSink.Quarantine(ctx, letter); err != nil {
+		log.Println("Workflow.DeadLetter:Error:", err)
+	}
+}
+
+/* MemoryDeadLetterSink keeps the quarantined runs in memory */
+type MemoryDeadLetterSink struct {
+	lock    sync.Mutex
+	letters []DeadLetter
+}
+
+func (m *MemoryDeadLetterSink) Quarantine(ctx context.Context, letter DeadLetter) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.letters = append(m.letters, letter)
+	return nil
+}
+
+/* Letters: quarantined runs in quarantine order */
+func (m *MemoryDeadLetterSink) Letters() []DeadLetter {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Clone(m.letters)
+}
+
+/* Release removes the quarantined run workflowId, e.g. once it was reviewed or reprocessed */
+func (m *MemoryDeadLetterSink) Release(workflowId string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, letter := range m.letters {
+		if letter.WorkflowId == workflowId {
+			m.letters = slices.Delete(m.letters, i, i+1)
+			return nil
+		}
+	}
+	return fmt.Errorf("run %s is not quarantined", workflowId)
+}
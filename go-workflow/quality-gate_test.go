@@ -0,0 +1,74 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualityGate(t *testing.T) {
+	build := func(a string) (*goworkflow.Workflow[context.Context, Config, Data], *goworkflow.MemoryDeadLetterSink) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("extraction")
+		sink := &goworkflow.MemoryDeadLetterSink{}
+		wf.SetDeadLetterSink(sink)
+		extract := wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = a })
+			return nil
+		}))
+		gate := wf.AddComponent(goworkflow.MakeComponent("Gate", nil, goworkflow.QualityGate[context.Context, any, Config, Data](
+			goworkflow.QualityRule[Config, Data]{Name: "A set", Check: func(config Config, data Data) error {
+				if data.A == "" {
+					return errors.New("A is empty")
+				}
+				return nil
+			}},
+			goworkflow.QualityRule[Config, Data]{Name: "A short", Check: func(config Config, data Data) error {
+				if len(data.A) > 3 {
+					return errors.New("A is longer than 3")
+				}
+				return nil
+			}},
+		)), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+		publish := wf.AddComponent(goworkflow.MakeComponent("Publish", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.Combined = "published " + d.A })
+			return nil
+		}))
+		gate.AddDependencies(extract)
+		publish.AddDependencies(gate)
+		return wf, sink
+	}
+
+	wf, sink := build("abc")
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "published abc", data.Combined)
+	assert.Empty(t, sink.Letters())
+
+	wf, sink = build("abcdef")
+	data, st, err = wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.QUARANTINED, st)
+	assert.Equal(t, "", data.Combined)
+	assert.Equal(t, 1, wf.Timeline()[1].Attempts)
+	result := wf.Result()
+	assert.Equal(t, goworkflow.QUARANTINED, result.Status)
+	assert.False(t, result.FinishedAt.IsZero())
+	assert.Equal(t, goworkflow.QualityGateFailed, result.ComponentErrors["Gate"].Code)
+	assert.Equal(t, "QUALITY_GATE_FAILED: A short: A is longer than 3", result.Errors["Gate"])
+
+	letters := sink.Letters()
+	assert.Len(t, letters, 1)
+	assert.Equal(t, wf.Id(), letters[0].WorkflowId)
+	assert.Equal(t, "extraction", letters[0].Workflow)
+	assert.Equal(t, []goworkflow.QualityViolation{{Gate: "Gate", Rule: "A short", Message: "A is longer than 3"}}, letters[0].Violations)
+	assert.JSONEq(t, `{"A":"abcdef","B":"","C":"","Combined":""}`, string(letters[0].Data))
+
+	assert.NoError(t, sink.Release(wf.Id()))
+	assert.Empty(t, sink.Letters())
+	assert.Error(t, sink.Release(wf.Id()))
+}
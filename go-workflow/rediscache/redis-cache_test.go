@@ -0,0 +1,127 @@
+package rediscache
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+/* fakeRedis: GET, SET with PX, AUTH and SELECT of a Redis server */
+type fakeRedis struct {
+	lock     sync.Mutex
+	password string
+	values   map[string]string
+	expires  map[string]time.Time
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	f := &fakeRedis{password: password, values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			nc, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(nc)
+		}
+	}()
+	return f, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := []string{}
+		for i := 0; i < n; i++ {
+			sizeLine, _ := r.ReadString('\n')
+			size, _ := strconv.Atoi(strings.TrimSpace(sizeLine[1:]))
+			arg := make([]byte, size+2)
+			io.ReadFull(r, arg)
+			args = append(args, string(arg[:size]))
+		}
+		io.WriteString(nc, f.handle(args))
+	}
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.commands = append(f.commands, args[0])
+	switch args[0] {
+	case "AUTH":
+		if args[1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		f.values[args[1]] = args[2]
+		delete(f.expires, args[1])
+		if len(args) == 5 && args[3] == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			f.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[1]]
+		if expires, set := f.expires[args[1]]; set && time.Now().After(expires) {
+			ok = false
+		}
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestCache(t *testing.T) {
+	server, addr := startFakeRedis(t, "secret")
+	var cache goworkflow.ResultCache = New(Options{Addr: addr, Password: "secret", DB: 2, Prefix: "wf:"})
+	defer cache.(*Cache).Close()
+	ctx := context.TODO()
+
+	_, ok, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, cache.Set(ctx, "a", []byte("line 1\r\nline 2"), 0))
+	value, ok, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "line 1\r\nline 2", string(value))
+
+	assert.NoError(t, cache.Set(ctx, "b", []byte("{}"), 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	_, ok, err = cache.Get(ctx, "b")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	server.lock.Lock()
+	assert.Equal(t, "line 1\r\nline 2", server.values["wf:a"])
+	// one connection is reused for all commands
+	assert.Equal(t, []string{"AUTH", "SELECT", "GET", "SET", "GET", "SET", "GET"}, server.commands)
+	server.lock.Unlock()
+
+	_, _, err = New(Options{Addr: addr, Password: "wrong"}).Get(ctx, "a")
+	assert.EqualError(t, err, "redis: WRONGPASS invalid password")
+}
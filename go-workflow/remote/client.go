@@ -0,0 +1,147 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/internal/mergepatch"
+)
+
+type Options struct {
+	// Client: HTTP/2 client, http.DefaultClient negotiates HTTP/2 for https targets only.
+	// Plaintext (h2c) services need a client with an h2c transport, e.g. golang.org/x/net/http2
+	Client *http.Client
+	// Metadata: request headers, e.g. authorization
+	Metadata map[string]string
+}
+
+/* ErrConflict: the data delta of the service conflicts with changes of other components, see Component */
+var ErrConflict = mergepatch.ErrConflict
+
+/* StatusError: the rpc failed, Code is the grpc status code */
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", e.Code, e.Message)
+}
+
+/* Execute calls the ExecuteComponent rpc of the service at target, e.g. https://ocr.internal:8443 */
+func Execute(ctx context.Context, target string, req *ExecuteComponentRequest, opts ...*Options) (*ExecuteComponentResponse, error) {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	opt := &Options{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint, err := url.JoinPath(target, executePath)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(frame(req.Marshal())))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range opt.Metadata {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	httpReq.Header.Set("TE", "trailers")
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("component service returned http status %d", httpResp.StatusCode)
+	}
+	message, readErr := readFrame(httpResp.Body)
+	// the status is in the trailers, or in the headers of a response without message
+	io.Copy(io.Discard, httpResp.Body)
+	if err := grpcStatus(httpResp.Trailer, httpResp.Header); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("invalid response of the component service: %w", readErr)
+	}
+	resp := &ExecuteComponentResponse{}
+	if err := resp.Unmarshal(message); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func grpcStatus(headers ...http.Header) error {
+	for _, h := range headers {
+		status := h.Get("Grpc-Status")
+		if status == "" {
+			continue
+		}
+		if status == "0" {
+			return nil
+		}
+		code := 0
+		fmt.Sscanf(status, "%d", &code)
+		message, _ := url.PathUnescape(h.Get("Grpc-Message"))
+		return &StatusError{Code: code, Message: message}
+	}
+	return errors.New("component service response without grpc status")
+}
+
+/*
+Component: component function executed by the service at target. The service gets the component input,
+config and data as json and the data store is updated with the merge patch it returns.
+Merge patches replace arrays whole: arrays the patch changes which other components changed meanwhile are
+merged, elements appended by both are kept, other concurrent changes fail the component with ErrConflict
+(a retry executes it on the current data). Maps are merged key by key.
+*/
+func Component[CT context.Context, I any, C any, T any](target string, opts ...*Options) goworkflow.ComponentFunction[CT, I, C, T] {
+	return func(ctx CT, input I, dt *goworkflow.DataTracker[C, T]) error {
+		info, _ := goworkflow.ComponentInfoFromContext(dt.Context())
+		req := &ExecuteComponentRequest{WorkflowId: info.WorkflowId, Workflow: info.Workflow, Component: info.Component}
+		var err error
+		if req.Input, err = json.Marshal(input); err != nil {
+			return err
+		}
+		if req.Config, err = json.Marshal(dt.Config); err != nil {
+			return err
+		}
+		if req.Data, err = json.Marshal(dt.GetData()); err != nil {
+			return err
+		}
+		resp, err := Execute(dt.Context(), target, req, opts...)
+		if err != nil {
+			return err
+		}
+		if len(resp.ComponentError) > 0 {
+			ce := &goworkflow.ComponentError{}
+			if err := json.Unmarshal(resp.ComponentError, ce); err == nil {
+				return ce
+			}
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if len(bytes.TrimSpace(resp.DataDelta)) == 0 {
+			return nil
+		}
+		dt.Update(func(data *T) {
+			err = mergepatch.ApplyOnto(data, req.Data, resp.DataDelta)
+		})
+		return err
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Package remote delegates the execution of components to remote services implementing the ExecuteComponent rpc
+of component.proto, e.g. Python services serving ML models, so polyglot components run inside Go workflows.
+
+The service receives the component input, a snapshot of the config and a snapshot of the data store as json,
+and answers with a json merge patch of the data store or the error of the component.
+The client speaks gRPC over HTTP/2 with the standard library, see Options.Client for plaintext services.
+*/
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const executePath = "/goworkflow.remote.v1.ComponentService/ExecuteComponent"
+
+type ExecuteComponentRequest struct {
+	WorkflowId string
+	Workflow   string
+	Component  string
+	Input      []byte
+	Config     []byte
+	Data       []byte
+}
+
+type ExecuteComponentResponse struct {
+	DataDelta      []byte
+	Error          string
+	ComponentError []byte
+}
+
+/* protobuf encoding of the messages, field numbers as in component.proto */
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendField(b []byte, field int, value []byte) []byte {
+	if len(value) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func (r *ExecuteComponentRequest) Marshal() []byte {
+	var b []byte
+	b = appendField(b, 1, []byte(r.WorkflowId))
+	b = appendField(b, 2, []byte(r.Workflow))
+	b = appendField(b, 3, []byte(r.Component))
+	b = appendField(b, 4, r.Input)
+	b = appendField(b, 5, r.Config)
+	return appendField(b, 6, r.Data)
+}
+
+func (r *ExecuteComponentRequest) Unmarshal(b []byte) error {
+	return unmarshalFields(b, func(field int, value []byte) {
+		switch field {
+		case 1:
+			r.WorkflowId = string(value)
+		case 2:
+			r.Workflow = string(value)
+		case 3:
+			r.Component = string(value)
+		case 4:
+			r.Input = value
+		case 5:
+			r.Config = value
+		case 6:
+			r.Data = value
+		}
+	})
+}
+
+func (r *ExecuteComponentResponse) Marshal() []byte {
+	var b []byte
+	b = appendField(b, 1, r.DataDelta)
+	b = appendField(b, 2, []byte(r.Error))
+	return appendField(b, 3, r.ComponentError)
+}
+
+func (r *ExecuteComponentResponse) Unmarshal(b []byte) error {
+	return unmarshalFields(b, func(field int, value []byte) {
+		switch field {
+		case 1:
+			r.DataDelta = value
+		case 2:
+			r.Error = string(value)
+		case 3:
+			r.ComponentError = value
+		}
+	})
+}
+
+var errMalformed = errors.New("malformed protobuf message")
+
+/* unmarshalFields calls set for every length delimited field, fields of other wire types are skipped */
+func unmarshalFields(b []byte, set func(field int, value []byte)) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errMalformed
+		}
+		b = b[n:]
+		switch tag & 7 {
+		case wireVarint:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return errMalformed
+			}
+			b = b[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if tag&7 == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return errMalformed
+			}
+			b = b[size:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return errMalformed
+			}
+			set(int(tag>>3), b[n:n+int(length)])
+			b = b[n+int(length):]
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d", errMalformed, tag&7)
+		}
+	}
+	return nil
+}
+
+/* gRPC length prefixed message framing */
+
+const maxMessageSize = 64 << 20
+
+func frame(message []byte) []byte {
+	b := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(b[1:], uint32(len(message)))
+	return append(b, message...)
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed grpc messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("grpc message of %d bytes exceeds the limit of %d", length, maxMessageSize)
+	}
+	message := make([]byte, length)
+	_, err := io.ReadFull(r, message)
+	return message, err
+}
@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct {
+	Model string
+}
+
+type Data struct {
+	Text     string
+	Entities []string
+	Language string
+}
+
+/* python style service: reads the snapshots as json and answers with a merge patch */
+func entityService(t *testing.T) *httptest.Server {
+	server := httptest.NewUnstartedServer(NewServer(func(ctx context.Context, req *ExecuteComponentRequest) ([]byte, error) {
+		var input struct{ Threshold float64 }
+		var config Config
+		var data Data
+		assert.NoError(t, json.Unmarshal(req.Input, &input))
+		assert.NoError(t, json.Unmarshal(req.Config, &config))
+		assert.NoError(t, json.Unmarshal(req.Data, &data))
+		if req.Component == "Protected" {
+			return nil, &goworkflow.ComponentError{Code: "PASSWORD_PROTECTED", Message: "the document is password protected", Details: map[string]string{"pages": "0"}}
+		}
+		if req.Component != "Entities" {
+			return nil, errors.New("unknown component " + req.Component)
+		}
+		return json.Marshal(map[string]any{"Entities": []string{data.Text + "/" + config.Model}, "Language": nil})
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestComponent(t *testing.T) {
+	server := entityService(t)
+	opts := &Options{Client: server.Client()}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Entities", map[string]any{"Threshold": 0.5}, Component[context.Context, map[string]any, Config, Data](server.URL, opts)))
+	wf.AddComponent(goworkflow.MakeComponent("Keywords", map[string]any{}, Component[context.Context, map[string]any, Config, Data](server.URL, opts)))
+	wf.AddComponent(goworkflow.MakeComponent("Protected", map[string]any{}, Component[context.Context, map[string]any, Config, Data](server.URL, opts)))
+
+	data, st, _ := wf.Execute(context.TODO(), Config{Model: "ner-v2"}, &Data{Text: "invoice", Language: "en"})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, []string{"invoice/ner-v2"}, data.Entities)
+	// null removes the field
+	assert.Equal(t, "", data.Language)
+	assert.Equal(t, "invoice", data.Text)
+	// structured errors keep their code across the rpc
+	result := wf.Result()
+	assert.Equal(t, &goworkflow.ComponentError{Code: "PASSWORD_PROTECTED", Message: "the document is password protected", Details: map[string]string{"pages": "0"}}, result.ComponentErrors["Protected"])
+	assert.NotContains(t, result.ComponentErrors, "Keywords")
+}
+
+func TestComponentConcurrentAppends(t *testing.T) {
+	// every tagger appends its entity to the snapshot it got, all snapshots are taken before any answer
+	started := sync.WaitGroup{}
+	started.Add(3)
+	server := httptest.NewUnstartedServer(NewServer(func(ctx context.Context, req *ExecuteComponentRequest) ([]byte, error) {
+		var data Data
+		assert.NoError(t, json.Unmarshal(req.Data, &data))
+		started.Done()
+		started.Wait()
+		return json.Marshal(map[string]any{"Entities": append(data.Entities, req.Component)})
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	opts := &Options{Client: server.Client()}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	for _, name := range []string{"People", "Places", "Dates"} {
+		wf.AddComponent(goworkflow.MakeComponent(name, map[string]any{}, Component[context.Context, map[string]any, Config, Data](server.URL, opts)))
+	}
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{Entities: []string{"seed"}})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	// no append is lost
+	assert.ElementsMatch(t, []string{"seed", "People", "Places", "Dates"}, data.Entities)
+	assert.Equal(t, "seed", data.Entities[0])
+}
+
+func TestExecuteStatus(t *testing.T) {
+	server := entityService(t)
+	req, _ := http.NewRequest(http.MethodPost, server.URL+executePath, nil)
+	resp, err := server.Client().Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.ProtoMajor)
+	resp.Body.Close()
+
+	_, err = Execute(context.TODO(), server.URL+"/unknown", &ExecuteComponentRequest{}, &Options{Client: server.Client()})
+	var status *StatusError
+	assert.ErrorAs(t, err, &status)
+	assert.Equal(t, 12, status.Code)
+}
+
+func TestMessages(t *testing.T) {
+	req := &ExecuteComponentRequest{WorkflowId: "wf-1", Component: "OCR", Input: []byte(`{"dpi":300}`), Data: []byte(`{}`)}
+	decoded := &ExecuteComponentRequest{}
+	assert.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, decoded)
+
+	// fields added to the protocol later are skipped: varint field 7 = 150
+	resp := &ExecuteComponentResponse{}
+	assert.NoError(t, resp.Unmarshal(append((&ExecuteComponentResponse{Error: "boom"}).Marshal(), 7<<3, 0x96, 0x01)))
+	assert.Equal(t, "boom", resp.Error)
+
+	assert.Error(t, resp.Unmarshal([]byte{1<<3 | 2, 10, 'a'}))
+}
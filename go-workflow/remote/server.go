@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* Handler executes a component for a remote workflow, errors fail the component, ComponentErrors keep their code */
+type Handler func(ctx context.Context, req *ExecuteComponentRequest) (dataDelta []byte, err error)
+
+/*
+NewServer serves the ComponentService with handler, for components implemented in Go services.
+gRPC requires HTTP/2: serve it with TLS (http.Server negotiates HTTP/2) or an h2c server.
+*/
+func NewServer(handler Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != executePath {
+			writeStatus(w, 12, "unknown method "+r.URL.Path)
+			return
+		}
+		message, err := readFrame(r.Body)
+		if err != nil {
+			writeStatus(w, 3, "invalid request: "+err.Error())
+			return
+		}
+		req := &ExecuteComponentRequest{}
+		if err := req.Unmarshal(message); err != nil {
+			writeStatus(w, 3, "invalid request: "+err.Error())
+			return
+		}
+		resp := &ExecuteComponentResponse{}
+		if resp.DataDelta, err = handler(r.Context(), req); err != nil {
+			resp = &ExecuteComponentResponse{Error: err.Error()}
+			if ce, ok := goworkflow.AsComponentError(err); ok {
+				resp.ComponentError, _ = json.Marshal(ce)
+			}
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		w.Write(frame(resp.Marshal()))
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set("Grpc-Message", "")
+	})
+}
+
+/* writeStatus: trailers-only response with a grpc status code, e.g. 3 INVALID_ARGUMENT or 12 UNIMPLEMENTED */
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	w.Header().Set("Grpc-Message", url.PathEscape(message))
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,395 @@
+package goworkflow
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ResultCache stores the results of components, see ComponentConfig.Cache. Implementations are safe for concurrent use */
+type ResultCache interface {
+	// Get: false when key is not cached or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set: ttl 0 keeps the value until it is evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+/*
+CachePolicy memoizes a component: its result is the value of the fields it declares in ComponentConfig.Writes.
+On a hit the fields are restored into the data store and the component doesn't execute, on a miss the fields
+are cached once it succeeded. Cache failures are logged, they never fail the component.
+Results are namespaced by the name and version of the workflow (it must be named, see SetName) and by the config
+of the run: the fields of ComponentConfig.ConfigReads, the whole config when they are not declared.
+*/
+type CachePolicy struct {
+	Cache ResultCache
+	// Key: identifies the result among the results of the component, e.g. the document id and the model version.
+	// The result isn't cached when Key returns ""
+	Key func(input ComponentInput) string
+	// Content addresses the result by the sha256 of the bytes the component processes (combined with Key when
+	// set), e.g. the page image, so identical content hits whatever its name or position. See InputContent
+	Content func(ctx context.Context, input ComponentInput) (io.Reader, error)
+	// TTL of the results, kept until evicted when 0
+	TTL time.Duration
+	// Metrics: hits and misses by component, optional and shareable between components
+	Metrics *CacheMetrics
+}
+
+/* CacheStats: lookups of the cached results of a component */
+type CacheStats struct {
+	Hits   int
+	Misses int
+	// Errors: failed lookups and stores
+	Errors int
+}
+
+/* HitRate: share of the lookups which hit, 0 without lookups */
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+/* CacheMetrics counts the cache lookups by component name */
+type CacheMetrics struct {
+	lock  sync.Mutex
+	stats map[string]CacheStats
+}
+
+/* Stats: lookups by component name */
+func (m *CacheMetrics) Stats() map[string]CacheStats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stats := make(map[string]CacheStats, len(m.stats))
+	for component, s := range m.stats {
+		stats[component] = s
+	}
+	return stats
+}
+
+func (m *CacheMetrics) record(component string, update func(s *CacheStats)) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.stats == nil {
+		m.stats = map[string]CacheStats{}
+	}
+	s := m.stats[component]
+	update(&s)
+	m.stats[component] = s
+}
+
+/* cacheKey: key of the result of c, namespaced by workflow, version, component and the config it reads */
+func (wf *Workflow[CT, C, T]) cacheKey(c *component[CT, C, T], config C, key string) (string, error) {
+	digest, err := configDigest(c.addComponentCfg.ConfigReads, config)
+	if err != nil {
+		return "", err
+	}
+	return wf.name + "@" + wf.version + "/" + c.Name + "/" + digest + "/" + key, nil
+}
+
+/* configDigest: short hash of the config fields at paths, of the whole config when paths is nil */
+func configDigest[C any](paths []string, config C) (string, error) {
+	var read any = config
+	if paths != nil {
+		values := map[string]any{}
+		for _, path := range paths {
+			v, ok := field(reflect.ValueOf(&config).Elem(), path, false)
+			if !ok {
+				if !fieldExists(reflect.TypeOf(config), path) {
+					return "", fmt.Errorf("unknown config field %s", path)
+				}
+				continue
+			}
+			values[path] = v.Interface()
+		}
+		read = values
+	}
+	b, err := json.Marshal(read)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+/* executeCached: executes c unless its result is cached, see CachePolicy */
+func (wf *Workflow[CT, C, T]) executeCached(ctx CT, componentCtx context.Context, c *component[CT, C, T], dataTracker *DataTracker[C, T]) error {
+	if c.addComponentCfg == nil || c.addComponentCfg.Cache == nil {
+		return wf.executeWithRetry(ctx, componentCtx, c, dataTracker)
+	}
+	policy := c.addComponentCfg.Cache
+	key, err := resultKey(componentCtx, policy, c.input)
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Cache key failed for component:", c.id, err)
+		policy.Metrics.record(c.Name, func(s *CacheStats) { s.Errors++ })
+	}
+	if key != "" {
+		if key, err = wf.cacheKey(c, dataTracker.Config, key); err != nil {
+			log.Println("Workflow.Execute:Error:Cache key failed for component:", c.id, err)
+			policy.Metrics.record(c.Name, func(s *CacheStats) { s.Errors++ })
+		}
+	}
+	if key == "" {
+		return wf.executeWithRetry(ctx, componentCtx, c, dataTracker)
+	}
+	cached, ok, err := policy.Cache.Get(componentCtx, key)
+	if err == nil && ok {
+		err = restoreFields(dataTracker.forComponent(componentCtx), c.addComponentCfg.Writes, cached)
+		if err == nil {
+			policy.Metrics.record(c.Name, func(s *CacheStats) { s.Hits++ })
+			c.statusLock.Lock()
+			c.timing.Cached = true
+			c.statusLock.Unlock()
+			return nil
+		}
+	}
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Cache lookup failed for component:", c.id, err)
+		policy.Metrics.record(c.Name, func(s *CacheStats) { s.Errors++ })
+	}
+	policy.Metrics.record(c.Name, func(s *CacheStats) { s.Misses++ })
+
+	if err := wf.executeWithRetry(ctx, componentCtx, c, dataTracker); err != nil {
+		return err
+	}
+	result, err := captureFields(dataTracker.store, c.addComponentCfg.Writes)
+	if err == nil {
+		err = policy.Cache.Set(componentCtx, key, result, policy.TTL)
+	}
+	if err != nil {
+		log.Println("Workflow.Execute:Error:Cache store failed for component:", c.id, err)
+		policy.Metrics.record(c.Name, func(s *CacheStats) { s.Errors++ })
+	}
+	return nil
+}
+
+/* resultKey: key of the result of input, "" when it isn't cached */
+func resultKey(ctx context.Context, policy *CachePolicy, input ComponentInput) (string, error) {
+	key := ""
+	if policy.Key != nil {
+		if key = policy.Key(input); key == "" {
+			return "", nil
+		}
+	}
+	if policy.Content == nil {
+		return key, nil
+	}
+	content, err := policy.Content(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if key != "" {
+		key += "/"
+	}
+	return key + "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/*
+InputContent: CachePolicy.Content of the usual inputs, the artifact of an ArtifactRef (read from the artifact
+store of the workflow), the bytes of []byte and string inputs and the json encoding of other inputs.
+*/
+func InputContent(ctx context.Context, input ComponentInput) (io.Reader, error) {
+	switch in := input.(type) {
+	case ArtifactRef:
+		store, ok := ctx.Value(artifactStoreContextKey{}).(ArtifactStore)
+		if !ok {
+			return nil, errors.New("artifact store is not set")
+		}
+		return store.Get(ctx, in)
+	case []byte:
+		return bytes.NewReader(in), nil
+	case string:
+		return strings.NewReader(in), nil
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(encoded), nil
+}
+
+/* addCacheCheck: cached components need declared writes and a named workflow */
+func (wf *Workflow[CT, C, T]) addCacheCheck(c *component[CT, C, T]) {
+	wf.addBuildCheck(func() error {
+		policy := c.addComponentCfg.Cache
+		if policy.Cache == nil || (policy.Key == nil && policy.Content == nil) {
+			return fmt.Errorf("%s: cache policy needs a cache and a key or content", c.Name)
+		}
+		if wf.name == "" {
+			return fmt.Errorf("%s: cached components need a workflow name, see SetName", c.Name)
+		}
+		if len(c.addComponentCfg.Writes) == 0 {
+			return fmt.Errorf("%s: cached components must declare their writes", c.Name)
+		}
+		dataType := reflect.TypeOf((*T)(nil)).Elem()
+		for _, field := range c.addComponentCfg.Writes {
+			if !fieldExists(dataType, field) {
+				return fmt.Errorf("%s writes unknown field %s", c.Name, field)
+			}
+		}
+		return nil
+	})
+}
+
+/* field: the field at the dot separated path of v, nil pointers on the way are allocated when alloc */
+func field(v reflect.Value, path string, alloc bool) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+/* captureFields: json of the fields by path */
+func captureFields[T any](store *dataStore[T], paths []string) ([]byte, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	fields := map[string]any{}
+	root := reflect.ValueOf(store.data).Elem()
+	for _, path := range paths {
+		if v, ok := field(root, path, false); ok {
+			fields[path] = v.Interface()
+		}
+	}
+	return json.Marshal(fields)
+}
+
+/* restoreFields: sets the fields captured by captureFields through an update of the data store */
+func restoreFields[C any, T any](dt *DataTracker[C, T], paths []string, captured []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(captured, &fields); err != nil {
+		return err
+	}
+	// decode into copies first, so a stale result doesn't leave the data store half updated
+	values := map[string]reflect.Value{}
+	var zero T
+	for _, path := range paths {
+		raw, ok := fields[path]
+		if !ok {
+			continue
+		}
+		f, ok := field(reflect.ValueOf(&zero).Elem(), path, true)
+		if !ok {
+			return errors.New("unknown field " + path)
+		}
+		value := reflect.New(f.Type())
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return fmt.Errorf("cached field %s: %w", path, err)
+		}
+		values[path] = value.Elem()
+	}
+	dt.Update(func(data *T) {
+		root := reflect.ValueOf(data).Elem()
+		for path, value := range values {
+			if f, ok := field(root, path, true); ok {
+				f.Set(value)
+			}
+		}
+	})
+	return nil
+}
+
+/*
+LRUCache is an in-memory ResultCache evicting the least recently used results beyond its capacity, results
+are lost when the process exits.
+*/
+type LRUCache struct {
+	lock     sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		panic("cache capacity must be positive")
+	}
+	return &LRUCache{capacity: capacity, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+func (l *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := e.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !time.Now().Before(entry.expires) {
+		l.order.Remove(e)
+		delete(l.entries, key)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(e)
+	return entry.value, true, nil
+}
+
+func (l *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	if e, ok := l.entries[key]; ok {
+		e.Value = entry
+		l.order.MoveToFront(e)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(entry)
+	for l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+/* Len: cached results, including expired ones not looked up since */
+func (l *LRUCache) Len() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,183 @@
+package goworkflow_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache(t *testing.T) {
+	cache := goworkflow.NewLRUCache(10)
+	metrics := &goworkflow.CacheMetrics{}
+	executions := 0
+	run := func(document string) (*goworkflow.Workflow[context.Context, Config, Data], *Data) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("extraction")
+		ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", document, func(ctx context.Context, input string, dt *goworkflow.DataTracker[Config, Data]) error {
+			executions++
+			dt.Update(func(d *Data) { d.A = "text of " + input; d.B = "pages" })
+			return nil
+		}), &goworkflow.ComponentConfig{
+			Writes: []string{"A"},
+			Cache: &goworkflow.CachePolicy{
+				Cache:   cache,
+				Key:     func(input goworkflow.ComponentInput) string { return input.(string) },
+				Metrics: metrics,
+			},
+		})
+		combine := wf.AddComponent(goworkflow.MakeComponent("Combine", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.Combined = d.A + "!" })
+			return nil
+		}))
+		combine.AddDependencies(ocr)
+		data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		return wf, data
+	}
+
+	_, data := run("invoice.pdf")
+	assert.Equal(t, "text of invoice.pdf!", data.Combined)
+	wf, data := run("invoice.pdf")
+	assert.Equal(t, 1, executions)
+	// only the declared writes are cached
+	assert.Equal(t, Data{A: "text of invoice.pdf", Combined: "text of invoice.pdf!"}, *data)
+	timing := wf.Timeline()[0]
+	assert.True(t, timing.Cached)
+	assert.Equal(t, 0, timing.Attempts)
+	run("receipt.pdf")
+	assert.Equal(t, 2, executions)
+
+	stats := metrics.Stats()["OCR"]
+	assert.Equal(t, goworkflow.CacheStats{Hits: 1, Misses: 2}, stats)
+	assert.InDelta(t, 1.0/3, stats.HitRate(), 0.001)
+	assert.Equal(t, 2, cache.Len())
+
+	// cached components declare their writes
+	wf = goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("extraction")
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}), &goworkflow.ComponentConfig{Cache: &goworkflow.CachePolicy{Cache: cache, Key: func(goworkflow.ComponentInput) string { return "k" }}})
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.ErrorContains(t, err, "OCR: cached components must declare their writes")
+
+	// results of unnamed workflows would be shared with any other workflow
+	wf = goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}), &goworkflow.ComponentConfig{Writes: []string{"A"}, Cache: &goworkflow.CachePolicy{Cache: cache, Key: func(goworkflow.ComponentInput) string { return "k" }}})
+	_, _, err = wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.ErrorContains(t, err, "OCR: cached components need a workflow name")
+}
+
+func TestResultCacheNamespace(t *testing.T) {
+	cache := goworkflow.NewLRUCache(10)
+	executions := 0
+	run := func(version string, config TenantConfig, configReads []string) {
+		tpl := goworkflow.NewTemplate[context.Context, TenantConfig, Data]("extraction")
+		tpl.Version = version
+		tpl.AddComponent(goworkflow.MakeComponent("OCR", "invoice.pdf", func(ctx context.Context, input string, dt *goworkflow.DataTracker[TenantConfig, Data]) error {
+			executions++
+			dt.Update(func(d *Data) { d.A = dt.Config.Model })
+			return nil
+		}), &goworkflow.ComponentConfig{
+			Writes:      []string{"A"},
+			ConfigReads: configReads,
+			Cache:       &goworkflow.CachePolicy{Cache: cache, Key: func(input goworkflow.ComponentInput) string { return input.(string) }},
+		})
+		data, st, err := tpl.Execute(context.TODO(), config, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		assert.Equal(t, config.Model, data.A)
+	}
+
+	run("1", TenantConfig{Model: "fast"}, []string{"Model"})
+	run("1", TenantConfig{Model: "fast", MaxPages: 3}, []string{"Model"})
+	assert.Equal(t, 1, executions)
+	// the result depends on the model
+	run("1", TenantConfig{Model: "accurate"}, []string{"Model"})
+	assert.Equal(t, 2, executions)
+	// new versions don't reuse results of the old one
+	run("2", TenantConfig{Model: "fast"}, []string{"Model"})
+	assert.Equal(t, 3, executions)
+	// without declared config reads, any config change misses
+	run("2", TenantConfig{Model: "fast"}, nil)
+	run("2", TenantConfig{Model: "fast", MaxPages: 3}, nil)
+	assert.Equal(t, 5, executions)
+	run("2", TenantConfig{Model: "fast", MaxPages: 3}, nil)
+	assert.Equal(t, 5, executions)
+}
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.TODO()
+	cache := goworkflow.NewLRUCache(2)
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), 0)
+	_, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	// b is the least recently used
+	cache.Set(ctx, "c", []byte("3"), 0)
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok)
+	value, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(value))
+
+	cache.Set(ctx, "d", []byte("4"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestContentAddressedCache(t *testing.T) {
+	artifacts := goworkflow.NewMemoryArtifactStore()
+	upload := func(name string, content string) goworkflow.ArtifactRef {
+		ref, err := artifacts.Put(context.TODO(), name, strings.NewReader(content))
+		assert.NoError(t, err)
+		return ref
+	}
+	cache := goworkflow.NewLRUCache(10)
+	metrics := &goworkflow.CacheMetrics{}
+	executions := 0
+	run := func(page goworkflow.ArtifactRef) (*Data, goworkflow.Status) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.SetName("pages")
+		wf.SetArtifactStore(artifacts)
+		wf.AddComponent(goworkflow.MakeComponent("OCR", page, func(ctx context.Context, input goworkflow.ArtifactRef, dt *goworkflow.DataTracker[Config, Data]) error {
+			executions++
+			content, err := dt.GetArtifact(input)
+			dt.Update(func(d *Data) { d.A = strings.ToUpper(string(content)) })
+			return err
+		}), &goworkflow.ComponentConfig{
+			Writes: []string{"A"},
+			Cache:  &goworkflow.CachePolicy{Cache: cache, Content: goworkflow.InputContent, Metrics: metrics},
+		})
+		data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		return data, st
+	}
+
+	data, st := run(upload("upload-1/page.png", "page one"))
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "PAGE ONE", data.A)
+	// the same page uploaded again under another name
+	data, _ = run(upload("upload-2/scan.png", "page one"))
+	assert.Equal(t, "PAGE ONE", data.A)
+	assert.Equal(t, 1, executions)
+	data, _ = run(upload("upload-3/page.png", "page two"))
+	assert.Equal(t, "PAGE TWO", data.A)
+	assert.Equal(t, 2, executions)
+
+	// content which can't be read isn't cached, the component executes
+	_, st = run("mem://missing")
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 3, executions)
+	assert.Equal(t, goworkflow.CacheStats{Hits: 1, Misses: 2, Errors: 1}, metrics.Stats()["OCR"])
+}
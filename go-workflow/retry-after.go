@@ -0,0 +1,58 @@
+package goworkflow
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* RetryAfterError: the failed dependency asked to retry after After, e.g. a 429 or 503 with a Retry-After header */
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.After
+}
+
+/*
+RetryAfter: the delay requested by err or any error it wraps implementing RetryAfter() time.Duration.
+Retries wait at least that long and pause the ConcurrencyLimiter of the component meanwhile, so the
+other components sharing it slow down too.
+*/
+func RetryAfter(err error) (time.Duration, bool) {
+	var ra interface{ RetryAfter() time.Duration }
+	if errors.As(err, &ra) {
+		return ra.RetryAfter(), true
+	}
+	return 0, false
+}
+
+/* RetryAfterFromResponse parses the Retry-After header of resp, in seconds or as an http date */
+func RetryAfterFromResponse(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,74 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryAfter(t *testing.T) {
+	err := fmt.Errorf("ocr: %w", &goworkflow.RetryAfterError{Err: errOverloaded, After: time.Second})
+	after, ok := goworkflow.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, after)
+	assert.ErrorIs(t, err, errOverloaded)
+
+	_, ok = goworkflow.RetryAfter(errOverloaded)
+	assert.False(t, ok)
+}
+
+func TestRetryAfterFromResponse(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	_, ok := goworkflow.RetryAfterFromResponse(resp)
+	assert.False(t, ok)
+
+	resp.Header.Set("Retry-After", "120")
+	after, ok := goworkflow.RetryAfterFromResponse(resp)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, after)
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	after, ok = goworkflow.RetryAfterFromResponse(resp)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour.Seconds(), after.Seconds(), 2)
+
+	resp.Header.Set("Retry-After", "soon")
+	_, ok = goworkflow.RetryAfterFromResponse(resp)
+	assert.False(t, ok)
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	ocrLimiter := limiter.NewConcurrencyLimiter(2)
+	attempts := 0
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Page1", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		attempts++
+		if attempts == 1 {
+			return &goworkflow.RetryAfterError{Err: errOverloaded, After: 60 * time.Millisecond}
+		}
+		return nil
+	}), &goworkflow.ComponentConfig{ConcurrencyLimiter: ocrLimiter, Retry: &goworkflow.RetryPolicy{MaxAttempts: 2}})
+	split := wf.AddComponent(goworkflow.MakeComponent("Split", nil, ocrProvider("split", 10*time.Millisecond, nil)))
+	sibling := wf.AddComponent(goworkflow.MakeComponent("Page2", nil, ocrProvider("page2", 0, nil)),
+		&goworkflow.ComponentConfig{ConcurrencyLimiter: ocrLimiter})
+	sibling.AddDependencies(split)
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, 2, attempts)
+	for _, timing := range wf.Timeline() {
+		switch timing.Component {
+		case "Page1":
+			assert.GreaterOrEqual(t, timing.Duration(), 60*time.Millisecond)
+		case "Page2":
+			// the sibling was ready after Split but the limiter was paused
+			assert.GreaterOrEqual(t, timing.Wait(), 30*time.Millisecond)
+		}
+	}
+}
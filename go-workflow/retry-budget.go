@@ -0,0 +1,56 @@
+package goworkflow
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+/*
+RetryBudget limits the retries of all components of a run, or of all runs sharing it, so a systemic outage
+doesn't make every component retry up to its own limit and multiply the load on the failing dependency.
+*/
+type RetryBudget struct {
+	lock sync.Mutex
+	// zero means no limit
+	maxRetries   int
+	maxRetryTime time.Duration
+	retries      int
+	retryTime    time.Duration
+}
+
+/* NewRetryBudget: at most maxRetries retries taking at most maxRetryTime (backoff included) in total, zero means no limit */
+func NewRetryBudget(maxRetries int, maxRetryTime time.Duration) *RetryBudget {
+	return &RetryBudget{maxRetries: maxRetries, maxRetryTime: maxRetryTime}
+}
+
+/* SetRetryBudget: budget shared by the retries of all components */
+func (wf *Workflow[CT, C, T]) SetRetryBudget(budget *RetryBudget) {
+	wf.retryBudget = budget
+}
+
+/* Used: retries taken and the time they took so far */
+func (b *RetryBudget) Used() (int, time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.retries, b.retryTime
+}
+
+/* take reserves a retry, false when the budget is exhausted */
+func (b *RetryBudget) take() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if (b.maxRetries > 0 && b.retries >= b.maxRetries) || (b.maxRetryTime > 0 && b.retryTime >= b.maxRetryTime) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+func (b *RetryBudget) spend(d time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.retryTime += d
+}
@@ -0,0 +1,50 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	executions := int32(0)
+	fail := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		atomic.AddInt32(&executions, 1)
+		return errOverloaded
+	}
+	budget := goworkflow.NewRetryBudget(4, 0)
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetRetryBudget(budget)
+	for i := 0; i < 3; i++ {
+		wf.AddComponent(goworkflow.MakeComponent(fmt.Sprintf("Page%d", i), nil, fail), &goworkflow.ComponentConfig{
+			Retry: &goworkflow.RetryPolicy{MaxAttempts: 5},
+		})
+	}
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, int32(3+4), executions)
+	retries, _ := budget.Used()
+	assert.Equal(t, 4, retries)
+
+}
+
+func TestRetryBudgetTime(t *testing.T) {
+	executions := 0
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetRetryBudget(goworkflow.NewRetryBudget(0, 5*time.Millisecond))
+	extract := wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		executions++
+		return errOverloaded
+	}), &goworkflow.ComponentConfig{
+		Retry: &goworkflow.RetryPolicy{MaxAttempts: 10, Backoff: 10 * time.Millisecond},
+	})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 2, executions)
+	assert.Equal(t, "retry budget exhausted: model overloaded", extract.Status().ErrorMessage)
+}
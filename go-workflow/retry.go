@@ -0,0 +1,145 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+/* RetryPolicy of a component, see ComponentConfig.Retry and RetryAfter */
+type RetryPolicy struct {
+	// MaxAttempts: executions including the first one, retries are disabled below 2
+	MaxAttempts int
+	// Backoff: wait before the first retry, doubled on every further retry up to MaxBackoff (when set)
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable: whether the error is worth retrying, all errors are when nil
+	Retryable func(err error) bool
+	// BeforeRetry returns the input of the next attempt (2 for the first retry), so a retry can adjust it,
+	// e.g. switch to a fallback model or a lower DPI, instead of running into the same failure again.
+	// The returned input must have the input type of the component
+	BeforeRetry func(attempt int, lastErr error, input ComponentInput) ComponentInput
+}
+
+/* PermanentError: failure retrying can't fix, e.g. invalid input. It is never retried, whatever the RetryPolicy */
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+/* Permanent marks err as permanent, nil stays nil */
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+/* invokeAttempt executes c once, within the Timeout of its ComponentConfig */
+func (wf *Workflow[CT, C, T]) invokeAttempt(ctx CT, componentCtx context.Context, c *component[CT, C, T], input ComponentInput, dataTracker *DataTracker[C, T]) error {
+	if c.addComponentCfg == nil || c.addComponentCfg.Timeout <= 0 {
+		return wf.invokeExecutor(ctx, componentCtx, c, input, dataTracker)
+	}
+	attemptCtx, cancel := context.WithTimeout(componentCtx, c.addComponentCfg.Timeout)
+	defer cancel()
+	err := wf.invokeExecutor(ctx, attemptCtx, c, input, dataTracker)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && componentCtx.Err() == nil {
+		return fmt.Errorf("component timed out after %s: %w", c.addComponentCfg.Timeout, err)
+	}
+	return err
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return wait
+}
+
+/* executeWithRetry executes c, retrying failed attempts as its RetryPolicy allows */
+func (wf *Workflow[CT, C, T]) executeWithRetry(ctx CT, componentCtx context.Context, c *component[CT, C, T], dataTracker *DataTracker[C, T]) error {
+	var policy *RetryPolicy
+	if c.addComponentCfg != nil {
+		policy = c.addComponentCfg.Retry
+	}
+	var failover *Failover
+	if c.addComponentCfg != nil {
+		failover = c.addComponentCfg.Failover
+	}
+	input := c.input
+	var retryStarted time.Time
+	tried := []string{}
+	for attempt := 1; ; attempt++ {
+		c.statusLock.Lock()
+		c.timing.Attempts = attempt
+		c.statusLock.Unlock()
+
+		attemptCtx, endpoint := componentCtx, ""
+		if failover != nil {
+			endpoint = failover.Pick(tried...)
+			tried = append(tried, endpoint)
+			attemptCtx = context.WithValue(componentCtx, endpointContextKey{}, endpoint)
+		}
+		attemptCtx, releaseTokens, err := wf.reserveTokens(attemptCtx, c, input)
+		if err == nil {
+			err = wf.invokeAttempt(ctx, attemptCtx, c, input, dataTracker)
+		}
+		releaseTokens()
+		if failover != nil && err == nil {
+			failover.ReportSuccess(endpoint)
+		} else if failover != nil && componentCtx.Err() == nil && endpointFailure(err) {
+			log.Println("Workflow.Execute:Error:Endpoint failed for component:", c.id, endpoint, err)
+			failover.ReportFailure(endpoint)
+		}
+		if attempt > 1 && wf.retryBudget != nil {
+			wf.retryBudget.spend(time.Since(retryStarted))
+		}
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || componentCtx.Err() != nil {
+			return err
+		}
+		var permanent *PermanentError
+		if ce, ok := AsComponentError(err); ok && !ce.Retryable {
+			return err
+		}
+		if errors.As(err, &permanent) || (policy.Retryable != nil && !policy.Retryable(err)) {
+			return err
+		}
+		if wf.retryBudget != nil && !wf.retryBudget.take() {
+			log.Println("Workflow.Execute:Error:Retry budget exhausted for component:", c.id)
+			return fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err)
+		}
+		retryStarted = time.Now()
+		log.Println("Workflow.Execute:Error:Retrying component:", c.id, err)
+		wait := policy.backoff(attempt)
+		if after, ok := RetryAfter(err); ok {
+			wait = max(wait, after)
+			if c.addComponentCfg.ConcurrencyLimiter != nil {
+				c.addComponentCfg.ConcurrencyLimiter.Pause(after)
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-componentCtx.Done():
+			if wf.retryBudget != nil {
+				wf.retryBudget.spend(time.Since(retryStarted))
+			}
+			return err
+		}
+		if policy.BeforeRetry != nil {
+			input = policy.BeforeRetry(attempt+1, err, input)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+var errOverloaded = errors.New("model overloaded")
+
+func TestRetryBeforeRetry(t *testing.T) {
+	models := []any{}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	extract := func(ctx context.Context, model any, dt *goworkflow.DataTracker[Config, Data]) error {
+		models = append(models, model)
+		if model != "fallback" {
+			return errOverloaded
+		}
+		dt.Update(func(d *Data) { d.A = "extracted" })
+		return nil
+	}
+	wf.AddComponent(goworkflow.MakeComponent("Extract", "large", extract), &goworkflow.ComponentConfig{
+		Retry: &goworkflow.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			BeforeRetry: func(attempt int, lastErr error, input goworkflow.ComponentInput) goworkflow.ComponentInput {
+				assert.ErrorIs(t, lastErr, errOverloaded)
+				if attempt == 3 {
+					return "fallback"
+				}
+				return input
+			},
+		},
+	})
+	data, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "extracted", data.A)
+	assert.Equal(t, []any{"large", "large", "fallback"}, models)
+	assert.Equal(t, 3, wf.Timeline()[0].Attempts)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	attempts := 0
+	fail := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		attempts++
+		return errOverloaded
+	}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, fail), &goworkflow.ComponentConfig{
+		Retry: &goworkflow.RetryPolicy{MaxAttempts: 2},
+	})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	wf = goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Extract", nil, fail), &goworkflow.ComponentConfig{
+		Retry: &goworkflow.RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool { return false }},
+	})
+	_, st, _ = wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPermanentError(t *testing.T) {
+	attempts := 0
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	c := wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		attempts++
+		return goworkflow.Permanent(errors.New("unsupported document type"))
+	}), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "unsupported document type", c.Status().ErrorMessage)
+	assert.Nil(t, goworkflow.Permanent(nil))
+}
@@ -0,0 +1,142 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/* RunSnapshot: what CompareRuns compares of a finished run, see Workflow.Snapshot */
+type RunSnapshot struct {
+	Result   RunResult
+	Timeline []ComponentTiming
+	// Data: final data store as json
+	Data json.RawMessage `json:",omitempty"`
+}
+
+/* Snapshot: result, timeline and data of the run, for CompareRuns */
+func (wf *Workflow[CT, C, T]) Snapshot() (RunSnapshot, error) {
+	snapshot := RunSnapshot{Result: wf.Result(), Timeline: wf.Timeline()}
+	wf.stateLock.Lock()
+	store := wf.store
+	wf.stateLock.Unlock()
+	if store == nil {
+		return snapshot, nil
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	data, err := json.Marshal(store.data)
+	snapshot.Data = data
+	return snapshot, err
+}
+
+/* ComponentComparison: a component in both runs, zero values on the side of the run it is missing from */
+type ComponentComparison struct {
+	Component      string
+	BeforeStatus   Status
+	AfterStatus    Status
+	BeforeDuration time.Duration
+	AfterDuration  time.Duration
+	BeforeAttempts int
+	AfterAttempts  int
+}
+
+/* DurationChange: relative change of the duration, 0.5 when the component got 50% slower */
+func (c ComponentComparison) DurationChange() float64 {
+	if c.BeforeDuration == 0 {
+		return 0
+	}
+	return float64(c.AfterDuration-c.BeforeDuration) / float64(c.BeforeDuration)
+}
+
+/* RunComparison: differences between two runs of the same workflow, see CompareRuns */
+type RunComparison struct {
+	Workflow     string
+	Before       string
+	After        string
+	BeforeStatus Status
+	AfterStatus  Status
+	// DurationDelta: duration of the after run minus the duration of the before run
+	DurationDelta time.Duration
+	// Components: all components of both runs, in timeline order of the before run then the after run
+	Components []ComponentComparison
+	// AddedComponents, RemovedComponents: components of only the after, only the before run
+	AddedComponents   []string
+	RemovedComponents []string
+	// DataChanges: fields of the final data store which differ, Before is the value of the before run
+	DataChanges []FieldChange
+}
+
+/*
+CompareRuns reports the differences of two runs of the same workflow, e.g. before and after a model change,
+for regression triage. Runs of different workflows can't be compared.
+*/
+func CompareRuns(before, after RunSnapshot) (RunComparison, error) {
+	if before.Result.Workflow != after.Result.Workflow {
+		return RunComparison{}, fmt.Errorf("runs of different workflows: %s and %s", before.Result.Workflow, after.Result.Workflow)
+	}
+	comparison := RunComparison{
+		Workflow:      before.Result.Workflow,
+		Before:        before.Result.WorkflowId,
+		After:         after.Result.WorkflowId,
+		BeforeStatus:  before.Result.Status,
+		AfterStatus:   after.Result.Status,
+		DurationDelta: after.Result.Duration - before.Result.Duration,
+	}
+	index := map[string]int{}
+	for _, timing := range before.Timeline {
+		index[timing.Component] = len(comparison.Components)
+		comparison.Components = append(comparison.Components, ComponentComparison{
+			Component:      timing.Component,
+			BeforeStatus:   timing.Status,
+			BeforeDuration: timing.Duration(),
+			BeforeAttempts: timing.Attempts,
+		})
+	}
+	inAfter := map[string]bool{}
+	for _, timing := range after.Timeline {
+		inAfter[timing.Component] = true
+		i, ok := index[timing.Component]
+		if !ok {
+			comparison.AddedComponents = append(comparison.AddedComponents, timing.Component)
+			i = len(comparison.Components)
+			comparison.Components = append(comparison.Components, ComponentComparison{Component: timing.Component})
+		}
+		comparison.Components[i].AfterStatus = timing.Status
+		comparison.Components[i].AfterDuration = timing.Duration()
+		comparison.Components[i].AfterAttempts = timing.Attempts
+	}
+	for _, timing := range before.Timeline {
+		if !inAfter[timing.Component] {
+			comparison.RemovedComponents = append(comparison.RemovedComponents, timing.Component)
+		}
+	}
+
+	if before.Data != nil || after.Data != nil {
+		changes, err := diffJSON(before.Data, after.Data)
+		if err != nil {
+			return comparison, fmt.Errorf("invalid data: %w", err)
+		}
+		comparison.DataChanges = changes
+	}
+	return comparison, nil
+}
+
+/*
+Regressions: components which failed or were retried more often in the after run, or got slower by more than
+tolerance (0.2: 20% slower), in the order of Components.
+*/
+func (r RunComparison) Regressions(tolerance float64) []ComponentComparison {
+	if tolerance < 0 {
+		panic("tolerance cannot be negative")
+	}
+	regressions := []ComponentComparison{}
+	for _, c := range r.Components {
+		failed := c.AfterStatus == ERROR && c.BeforeStatus != ERROR
+		retried := c.AfterAttempts > c.BeforeAttempts && c.BeforeStatus != ""
+		if failed || retried || c.DurationChange() > tolerance {
+			regressions = append(regressions, c)
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,54 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareRuns(t *testing.T) {
+	run := func(extractErr error, delay time.Duration, text string) goworkflow.RunSnapshot {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			time.Sleep(delay)
+			dt.Update(func(d *Data) { d.A = text })
+			return nil
+		}))
+		extract := wf.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			return extractErr
+		}), &goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}})
+		extract.AddDependencies(ocr)
+		wf.Execute(context.TODO(), Config{}, &Data{})
+		snapshot, err := wf.Snapshot()
+		assert.NoError(t, err)
+		return snapshot
+	}
+	before := run(nil, time.Millisecond, "invoice")
+	after := run(errors.New("no table"), 30*time.Millisecond, "lnvoice")
+
+	comparison, err := goworkflow.CompareRuns(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, before.Result.WorkflowId, comparison.Before)
+	assert.Equal(t, goworkflow.DONE, comparison.BeforeStatus)
+	assert.Equal(t, goworkflow.ERROR, comparison.AfterStatus)
+	assert.Len(t, comparison.Components, 2)
+	assert.Equal(t, "Extract", comparison.Components[1].Component)
+	assert.Equal(t, 1, comparison.Components[1].BeforeAttempts)
+	assert.Equal(t, 2, comparison.Components[1].AfterAttempts)
+	assert.Greater(t, comparison.Components[0].DurationChange(), 1.0)
+	assert.Empty(t, comparison.AddedComponents)
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "A", Before: "invoice", After: "lnvoice"}}, comparison.DataChanges)
+
+	regressions := comparison.Regressions(0.2)
+	assert.Equal(t, []string{"OCR", "Extract"}, []string{regressions[0].Component, regressions[1].Component})
+	assert.Len(t, comparison.Regressions(1000), 1)
+
+	other := before
+	other.Result.Workflow = "receipts"
+	_, err = goworkflow.CompareRuns(other, after)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package goworkflow
+
+import (
+	"context"
+	"log"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/*
+RunExecutor bounds how many workflow runs execute at once, runs beyond the bound are queued.
+The run queue can be shared between executors of different workflow types.
+*/
+type RunExecutor[CT context.Context, C any, T any] struct {
+	queue *limiter.RunQueue
+}
+
+func NewRunExecutor[CT context.Context, C any, T any](queue *limiter.RunQueue) *RunExecutor[CT, C, T] {
+	if queue == nil {
+		panic("queue cannot be nil")
+	}
+	return &RunExecutor[CT, C, T]{queue: queue}
+}
+
+type QueuedRun[T any] struct {
+	ticket *limiter.Ticket
+	done   chan struct{}
+	data   *T
+	status Status
+	err    error
+}
+
+/* Position: 0 once the run started, otherwise 1 based position in the queue */
+func (r *QueuedRun[T]) Position() int {
+	return r.ticket.Position()
+}
+
+/* Wait blocks until the run is finished */
+func (r *QueuedRun[T]) Wait() (*T, Status, error) {
+	<-r.done
+	return r.data, r.status, r.err
+}
+
+type RunOptions struct {
+	// Priority: higher priority runs are admitted first and preempt not yet started components of lower priority runs
+	Priority int
+}
+
+/* Submit queues the workflow run and returns immediately, in the queue of its PoolRoute if it has one */
+func (e *RunExecutor[CT, C, T]) Submit(ctx CT, wf *Workflow[CT, C, T], config C, data *T, opts ...*RunOptions) *QueuedRun[T] {
+	var opt RunOptions
+	if len(opts) > 1 {
+		panic("only one RunOptions is allowed")
+	}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = *opts[0]
+	}
+	queue := e.queue
+	if route, ok := wf.poolRoute(); ok && route.Queue != nil {
+		queue = route.Queue
+	}
+	run := &QueuedRun[T]{ticket: queue.EnqueueWithPriority(opt.Priority), done: make(chan struct{})}
+	wf.runTicket = run.ticket
+	go func() {
+		defer close(run.done)
+		if err := run.ticket.Wait(ctx); err != nil {
+			log.Println("RunExecutor.Submit:Error:", err)
+			run.data, run.status, run.err = data, ERROR, err
+			return
+		}
+		defer run.ticket.Done()
+		run.data, run.status, run.err = wf.Execute(ctx, config, data)
+	}()
+	return run
+}
+
+/* Execute queues the workflow run and blocks until it is finished */
+func (e *RunExecutor[CT, C, T]) Execute(ctx CT, wf *Workflow[CT, C, T], config C, data *T, opts ...*RunOptions) (*T, Status, error) {
+	return e.Submit(ctx, wf, config, data, opts...).Wait()
+}
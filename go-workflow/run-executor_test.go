@@ -0,0 +1,106 @@
+package goworkflow_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExecutor(t *testing.T) {
+	ctx := context.Background()
+	executor := goworkflow.NewRunExecutor[context.Context, Config, Data](limiter.NewRunQueue(1))
+
+	release := make(chan struct{})
+	blocking := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	blocking.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		<-release
+		dt.Update(func(d *Data) {
+			d.A = "A"
+		})
+		return nil
+	}))
+
+	queued := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	queued.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.B = "B"
+		})
+		return nil
+	}))
+
+	run1 := executor.Submit(ctx, blocking, Config{}, &Data{})
+	run2 := executor.Submit(ctx, queued, Config{}, &Data{})
+
+	assert.Equal(t, 0, run1.Position())
+	assert.Equal(t, 1, run2.Position())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, st, err := executor.Execute(cancelled, goworkflow.NewWorkflow[context.Context, Config, Data](ctx), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	data1, st1, err1 := run1.Wait()
+	data2, st2, err2 := run2.Wait()
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, goworkflow.DONE, st1)
+	assert.Equal(t, goworkflow.DONE, st2)
+	assert.Equal(t, "A", data1.A)
+	assert.Equal(t, "B", data2.B)
+}
+
+func TestRunExecutorPreemption(t *testing.T) {
+	ctx := context.Background()
+	executor := goworkflow.NewRunExecutor[context.Context, Config, Data](limiter.NewRunQueue(1))
+
+	lk := sync.Mutex{}
+	order := []string{}
+	record := func(name string) {
+		lk.Lock()
+		defer lk.Unlock()
+		order = append(order, name)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	batch := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	first := batch.AddComponent(goworkflow.MakeComponent("first", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		close(started)
+		<-release
+		record("batch:first")
+		return nil
+	}))
+	second := batch.AddComponent(goworkflow.MakeComponent("second", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		record("batch:second")
+		return nil
+	}))
+	second.AddDependencies(first)
+
+	urgent := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	urgent.AddComponent(goworkflow.MakeComponent("urgent", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		record("urgent")
+		return nil
+	}))
+
+	batchRun := executor.Submit(ctx, batch, Config{}, &Data{}, &goworkflow.RunOptions{Priority: -1})
+	<-started
+	urgentRun := executor.Submit(ctx, urgent, Config{}, &Data{}, &goworkflow.RunOptions{Priority: 1})
+	assert.Equal(t, 1, urgentRun.Position())
+	close(release)
+
+	_, st, err := urgentRun.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	_, st, err = batchRun.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	assert.Equal(t, []string{"batch:first", "urgent", "batch:second"}, order)
+}
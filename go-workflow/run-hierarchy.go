@@ -0,0 +1,43 @@
+package goworkflow
+
+import (
+	"context"
+	"strings"
+)
+
+/* metadata of nested runs, see NewWorkflow. Query child runs with RunQuery.Metadata */
+const (
+	ParentRunMetadataKey       = "parentWorkflowId"
+	ParentComponentMetadataKey = "parentComponent"
+)
+
+/*
+nestUnder: a workflow created from the context of a component (a sub-workflow, e.g. one per page of a document)
+gets the id <parent id>/<uuid>, so its state changes, events, logs and stored result lead back to the parent run.
+*/
+func (wf *Workflow[CT, C, T]) nestUnder(ctx context.Context) {
+	info, ok := ComponentInfoFromContext(ctx)
+	if !ok || info.WorkflowId == "" {
+		return
+	}
+	wf.id = info.WorkflowId + "/" + wf.id
+	wf.metadata = map[string]string{
+		ParentRunMetadataKey:       info.WorkflowId,
+		ParentComponentMetadataKey: info.Component,
+	}
+	registerChildRun(ctx, wf)
+}
+
+/* ParentRunId: id of the run in which the run id was started, empty for top level runs */
+func ParentRunId(id string) string {
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+/* RootRunId: id of the top level run of a nested run id */
+func RootRunId(id string) string {
+	root, _, _ := strings.Cut(id, "/")
+	return root
+}
@@ -0,0 +1,58 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestedRunIds(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+	page := goworkflow.NewTemplate[context.Context, Config, Data]("page")
+	var pageInfo goworkflow.ComponentInfo
+	page.AddComponent(goworkflow.MakeComponent("Analyze", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		pageInfo, _ = goworkflow.ComponentInfoFromContext(ctx)
+		return nil
+	}))
+
+	document := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	document.SetName("document")
+	document.SetRunStore(store)
+	pageIds := []string{}
+	document.AddComponent(goworkflow.MakeComponent("Pages", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		for i := 0; i < 2; i++ {
+			wf := page.NewWorkflow(ctx)
+			wf.SetRunStore(store)
+			wf.SetMetadata("page", fmt.Sprint(i))
+			if _, _, err := wf.Execute(ctx, Config{}, &Data{}); err != nil {
+				return err
+			}
+			pageIds = append(pageIds, wf.Id())
+		}
+		return nil
+	}))
+	_, st, err := document.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+
+	assert.Equal(t, "", goworkflow.ParentRunId(document.Id()))
+	for _, id := range pageIds {
+		assert.Equal(t, document.Id(), goworkflow.ParentRunId(id))
+		assert.Equal(t, document.Id(), goworkflow.RootRunId(id))
+	}
+	assert.Equal(t, pageIds[1], pageInfo.WorkflowId)
+
+	page1, err := store.GetRun(context.TODO(), pageIds[1])
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		goworkflow.ParentRunMetadataKey:       document.Id(),
+		goworkflow.ParentComponentMetadataKey: "Pages",
+		"page":                                "1",
+	}, page1.Metadata)
+	children, err := store.QueryRuns(context.TODO(), goworkflow.RunQuery{Metadata: map[string]string{goworkflow.ParentRunMetadataKey: document.Id()}})
+	assert.NoError(t, err)
+	assert.Len(t, children.Runs, 2)
+}
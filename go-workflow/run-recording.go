@@ -0,0 +1,181 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+/*
+RecordedStep: one component of a recorded run. Before is the data store as the component saw it when it
+started (empty when it didn't run), After the data store when it finished. Components running concurrently
+see each other's updates, so After may contain changes of other components.
+*/
+type RecordedStep struct {
+	Component   string
+	ComponentId string
+	Status      Status
+	Cause       string `json:",omitempty"`
+	FinishedAt  time.Time
+	Before      json.RawMessage `json:",omitempty"`
+	After       json.RawMessage
+}
+
+/* RunRecording: initial data and the steps of a run in the order the components finished, see SetRecording */
+type RunRecording struct {
+	WorkflowId string
+	Workflow   string
+	Initial    json.RawMessage
+	Steps      []RecordedStep
+}
+
+/*
+SetRecording records a snapshot of the data store before and after every component of Execute, to step through
+the run later with a RunDebugger. Every component copies the data store twice through json, keep it for
+debugging runs.
+*/
+func (wf *Workflow[CT, C, T]) SetRecording(record bool) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.recording = nil
+	if record {
+		wf.recording = &RunRecording{}
+	}
+}
+
+/* Recording: the recorded steps so far, empty unless SetRecording was called */
+func (wf *Workflow[CT, C, T]) Recording() RunRecording {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	if wf.recording == nil {
+		return RunRecording{}
+	}
+	recording := *wf.recording
+	recording.WorkflowId, recording.Workflow = wf.id, wf.name
+	recording.Steps = slices.Clone(recording.Steps)
+	return recording
+}
+
+func (wf *Workflow[CT, C, T]) recordingEnabled() bool {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	return wf.recording != nil
+}
+
+/* recordSnapshot: json of the data store, nil unless recording */
+func (wf *Workflow[CT, C, T]) recordSnapshot(store *dataStore[T]) json.RawMessage {
+	if !wf.recordingEnabled() {
+		return nil
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	snapshot, _ := json.Marshal(store.data)
+	return snapshot
+}
+
+func (wf *Workflow[CT, C, T]) recordInitial(store *dataStore[T]) {
+	snapshot := wf.recordSnapshot(store)
+	if snapshot == nil {
+		return
+	}
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.recording.Initial = snapshot
+}
+
+func (wf *Workflow[CT, C, T]) recordStep(c *component[CT, C, T], status Status, cause string, before json.RawMessage, store *dataStore[T]) {
+	after := wf.recordSnapshot(store)
+	if after == nil {
+		return
+	}
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.recording.Steps = append(wf.recording.Steps, RecordedStep{
+		Component:   c.Name,
+		ComponentId: c.id,
+		Status:      status,
+		Cause:       cause,
+		FinishedAt:  time.Now(),
+		Before:      before,
+		After:       after,
+	})
+}
+
+/*
+RunDebugger steps through a recorded run component by component. The position starts before the first step,
+where the data is the initial data of the run.
+*/
+type RunDebugger struct {
+	recording RunRecording
+	position  int
+}
+
+func NewRunDebugger(recording RunRecording) *RunDebugger {
+	return &RunDebugger{recording: recording, position: -1}
+}
+
+func (d *RunDebugger) Steps() []RecordedStep {
+	return d.recording.Steps
+}
+
+/* Position: index of the current step, -1 before the first step */
+func (d *RunDebugger) Position() int {
+	return d.position
+}
+
+/* Seek moves to the step at position, -1 moves before the first step */
+func (d *RunDebugger) Seek(position int) (RecordedStep, bool) {
+	if position < -1 || position >= len(d.recording.Steps) {
+		return RecordedStep{}, false
+	}
+	d.position = position
+	return d.Current()
+}
+
+/* SeekComponent moves to the step of the component name */
+func (d *RunDebugger) SeekComponent(name string) (RecordedStep, bool) {
+	for i, step := range d.recording.Steps {
+		if step.Component == name {
+			return d.Seek(i)
+		}
+	}
+	return RecordedStep{}, false
+}
+
+/* Next moves to the next step, false at the end of the run */
+func (d *RunDebugger) Next() (RecordedStep, bool) {
+	return d.Seek(d.position + 1)
+}
+
+/* Prev moves to the previous step, false before the first step */
+func (d *RunDebugger) Prev() (RecordedStep, bool) {
+	if d.position < 0 {
+		return RecordedStep{}, false
+	}
+	return d.Seek(d.position - 1)
+}
+
+/* Current: the current step, false before the first step */
+func (d *RunDebugger) Current() (RecordedStep, bool) {
+	if d.position < 0 {
+		return RecordedStep{}, false
+	}
+	return d.recording.Steps[d.position], true
+}
+
+/* Data: data store after the current step, the initial data before the first step */
+func (d *RunDebugger) Data() json.RawMessage {
+	if d.position < 0 {
+		return d.recording.Initial
+	}
+	return d.recording.Steps[d.position].After
+}
+
+/* Changes: fields changed between the start and the end of the current step */
+func (d *RunDebugger) Changes() ([]FieldChange, error) {
+	step, ok := d.Current()
+	if !ok || step.Before == nil {
+		return nil, nil
+	}
+	return diffJSON(step.Before, step.After)
+}
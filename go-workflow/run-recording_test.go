@@ -0,0 +1,85 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDebugger(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetRecording(true)
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "a" })
+		return nil
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.B = "b" })
+		return errors.New("boom")
+	}))
+	c := wf.AddComponent(goworkflow.MakeComponent("C", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	b.AddDependencies(a)
+	c.AddDependencies(b)
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{C: "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+
+	recording := wf.Recording()
+	assert.Equal(t, wf.Id(), recording.WorkflowId)
+	// recordings are plain json, e.g. kept in run reports
+	encoded, err := json.Marshal(recording)
+	assert.NoError(t, err)
+	recording = goworkflow.RunRecording{}
+	assert.NoError(t, json.Unmarshal(encoded, &recording))
+
+	d := goworkflow.NewRunDebugger(recording)
+	assert.Len(t, d.Steps(), 3)
+	assert.JSONEq(t, `{"A": "", "B": "", "C": "c", "Combined": ""}`, string(d.Data()))
+	_, ok := d.Prev()
+	assert.False(t, ok)
+
+	step, ok := d.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "A", step.Component)
+	changes, err := d.Changes()
+	assert.NoError(t, err)
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "A", Before: "", After: "a"}}, changes)
+
+	step, _ = d.Next()
+	assert.Equal(t, "B", step.Component)
+	assert.Equal(t, goworkflow.ERROR, step.Status)
+	assert.Equal(t, "boom", step.Cause)
+	assert.JSONEq(t, `{"A": "a", "B": "", "C": "c", "Combined": ""}`, string(step.Before))
+	assert.JSONEq(t, `{"A": "a", "B": "b", "C": "c", "Combined": ""}`, string(d.Data()))
+
+	step, _ = d.Next()
+	assert.Equal(t, "component dependency failed: "+step.ComponentId, step.Cause)
+	assert.Nil(t, step.Before)
+	changes, err = d.Changes()
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+	_, ok = d.Next()
+	assert.False(t, ok)
+	assert.Equal(t, 2, d.Position())
+
+	_, ok = d.SeekComponent("A")
+	assert.True(t, ok)
+	assert.Equal(t, 0, d.Position())
+	_, ok = d.SeekComponent("D")
+	assert.False(t, ok)
+}
+
+func TestRecordingDisabled(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Empty(t, wf.Recording().Steps)
+}
@@ -0,0 +1,99 @@
+package goworkflow
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+/* RunResult: summary of a finished run */
+type RunResult struct {
+	WorkflowId string
+	Workflow   string
+	Version    string
+	Status     Status
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+	// names of the failed components, in declaration order
+	FailedComponents []string
+	// error message by failed component name
+	Errors map[string]string
+	// ComponentErrors: structured errors by failed component name, for components which failed with a ComponentError
+	ComponentErrors map[string]*ComponentError `json:",omitempty"`
+	// FailureDumps: refs of the failure dumps by failed component name, see SetFailureDumps
+	FailureDumps map[string]ArtifactRef `json:",omitempty"`
+	// DebugTrace: ref of the trace of a run sampled for debugging, see SetDebugSampling
+	DebugTrace ArtifactRef `json:",omitempty"`
+	Metadata   map[string]string
+}
+
+/* Notifier is informed about every finished run, e.g. to ping the owner of a batch job */
+type Notifier interface {
+	Notify(ctx context.Context, result RunResult) error
+}
+
+func (wf *Workflow[CT, C, T]) AddNotifier(n Notifier) {
+	if n == nil {
+		panic("notifier cannot be nil")
+	}
+	wf.notifiers = append(wf.notifiers, n)
+}
+
+/* Result: summary of the run */
+func (wf *Workflow[CT, C, T]) Result() RunResult {
+	wf.stateLock.Lock()
+	result := RunResult{
+		WorkflowId: wf.id,
+		Workflow:   wf.name,
+		Version:    wf.version,
+		Status:     wf.status,
+		StartedAt:  wf.startedAt,
+		FinishedAt: wf.finishedAt,
+		Errors:     map[string]string{},
+		Metadata:   map[string]string{},
+	}
+	for k, v := range wf.metadata {
+		result.Metadata[k] = v
+	}
+	if wf.debugSampling != nil {
+		result.DebugTrace = wf.debugSampling.ref
+	}
+	wf.stateLock.Unlock()
+
+	if !result.StartedAt.IsZero() && !result.FinishedAt.IsZero() {
+		result.Duration = result.FinishedAt.Sub(result.StartedAt)
+	}
+	for _, c := range wf.sortedComponents() {
+		st := c.Status()
+		if st.Status == ERROR {
+			result.FailedComponents = append(result.FailedComponents, c.Name)
+			result.Errors[c.Name] = st.ErrorMessage
+			if ce := c.ComponentError(); ce != nil {
+				if result.ComponentErrors == nil {
+					result.ComponentErrors = map[string]*ComponentError{}
+				}
+				result.ComponentErrors[c.Name] = ce
+			}
+			if ref := c.FailureDump(); ref != "" {
+				if result.FailureDumps == nil {
+					result.FailureDumps = map[string]ArtifactRef{}
+				}
+				result.FailureDumps[c.Name] = ref
+			}
+		}
+	}
+	return result
+}
+
+func (wf *Workflow[CT, C, T]) notify(ctx context.Context) {
+	if len(wf.notifiers) == 0 {
+		return
+	}
+	result := wf.Result()
+	for _, n := range wf.notifiers {
+		if err := n.Notify(ctx, result); err != nil {
+			log.Println("Workflow.Notify:Error:", err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	results []goworkflow.RunResult
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, result goworkflow.RunResult) error {
+	r.results = append(r.results, result)
+	return nil
+}
+
+func TestRunResultNotifier(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("bad page")
+	}))
+	wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	})).AddDependencies(a)
+
+	notifier := &recordingNotifier{}
+	wf.AddNotifier(notifier)
+	_, _, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+
+	assert.Len(t, notifier.results, 1)
+	result := notifier.results[0]
+	assert.Equal(t, wf.Id(), result.WorkflowId)
+	assert.Equal(t, goworkflow.ERROR, result.Status)
+	assert.Equal(t, []string{"A", "B"}, result.FailedComponents)
+	assert.Equal(t, "bad page", result.Errors["A"])
+	assert.Equal(t, result.FinishedAt.Sub(result.StartedAt), result.Duration)
+	assert.Equal(t, result, wf.Result())
+}
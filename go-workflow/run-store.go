@@ -0,0 +1,185 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrRunNotFound = errors.New("run not found")
+
+/* RunStore keeps the results of finished runs */
+type RunStore interface {
+	SaveRun(ctx context.Context, result RunResult) error
+	GetRun(ctx context.Context, workflowId string) (RunResult, error)
+	QueryRuns(ctx context.Context, query RunQuery) (RunPage, error)
+}
+
+/* RunQuery: all set fields have to match, results are ordered by start time, newest first */
+type RunQuery struct {
+	Workflow string
+	Status   Status
+	// runs started in [StartedAfter, StartedBefore)
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	// all metadata entries have to match
+	Metadata        map[string]string
+	FailedComponent string
+
+	// PageSize defaults to 50
+	PageSize  int
+	PageToken string
+}
+
+type RunPage struct {
+	Runs []RunResult
+	// NextPageToken is empty on the last page
+	NextPageToken string
+}
+
+const defaultPageSize = 50
+
+/* Matches: result satisfies all filters of the query, useful for stores which filter in memory */
+func (q RunQuery) Matches(r RunResult) bool {
+	if q.Workflow != "" && q.Workflow != r.Workflow {
+		return false
+	}
+	if q.Status != "" && q.Status != r.Status {
+		return false
+	}
+	if !q.StartedAfter.IsZero() && r.StartedAt.Before(q.StartedAfter) {
+		return false
+	}
+	if !q.StartedBefore.IsZero() && !r.StartedAt.Before(q.StartedBefore) {
+		return false
+	}
+	for k, v := range q.Metadata {
+		if r.Metadata[k] != v {
+			return false
+		}
+	}
+	if q.FailedComponent != "" && !slices.Contains(r.FailedComponents, q.FailedComponent) {
+		return false
+	}
+	return true
+}
+
+/* SortRuns orders runs newest first, ties are broken by workflow id */
+func SortRuns(runs []RunResult) {
+	slices.SortFunc(runs, func(a, b RunResult) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.WorkflowId, b.WorkflowId)
+	})
+}
+
+/* PaginateRuns: page of the sorted runs selected by the query, page tokens are offsets */
+func PaginateRuns(runs []RunResult, query RunQuery) (RunPage, error) {
+	size := query.PageSize
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	offset := 0
+	if query.PageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(query.PageToken)
+		if err != nil || offset < 0 {
+			return RunPage{}, errors.New("invalid page token")
+		}
+	}
+	page := RunPage{Runs: []RunResult{}}
+	if offset >= len(runs) {
+		return page, nil
+	}
+	end := min(offset+size, len(runs))
+	page.Runs = append(page.Runs, runs[offset:end]...)
+	if end < len(runs) {
+		page.NextPageToken = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+/* SetMetadata attaches key/value metadata to the run, it is part of the RunResult and can be queried */
+func (wf *Workflow[CT, C, T]) SetMetadata(key string, value string) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	if wf.metadata == nil {
+		wf.metadata = map[string]string{}
+	}
+	wf.metadata[key] = value
+}
+
+type runMetadataContextKey struct{}
+
+/* SetRunMetadata attaches metadata to the run of the component context, false outside of a run */
+func SetRunMetadata(ctx context.Context, key string, value string) bool {
+	set, ok := ctx.Value(runMetadataContextKey{}).(func(string, string))
+	if ok {
+		set(key, value)
+	}
+	return ok
+}
+
+func (d *DataTracker[C, T]) SetRunMetadata(key string, value string) bool {
+	return SetRunMetadata(d.ctx, key, value)
+}
+
+/* SetRunStore: the result of the run is saved to store once it is finished */
+func (wf *Workflow[CT, C, T]) SetRunStore(store RunStore) {
+	wf.runStore = store
+}
+
+func (wf *Workflow[CT, C, T]) saveRun(ctx context.Context) {
+	if wf.runStore == nil {
+		return
+	}
+	if err := wf.runStore.SaveRun(ctx, wf.Result()); err != nil {
+		log.Println("Workflow.SaveRun:Error:", err)
+	}
+}
+
+/* MemoryRunStore keeps runs in memory */
+type MemoryRunStore struct {
+	lock sync.Mutex
+	runs map[string]RunResult
+}
+
+func NewMemoryRunStore() *MemoryRunStore {
+	return &MemoryRunStore{runs: map[string]RunResult{}}
+}
+
+func (m *MemoryRunStore) SaveRun(ctx context.Context, result RunResult) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.runs[result.WorkflowId] = result
+	return nil
+}
+
+func (m *MemoryRunStore) GetRun(ctx context.Context, workflowId string) (RunResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	r, ok := m.runs[workflowId]
+	if !ok {
+		return RunResult{}, ErrRunNotFound
+	}
+	return r, nil
+}
+
+func (m *MemoryRunStore) QueryRuns(ctx context.Context, query RunQuery) (RunPage, error) {
+	m.lock.Lock()
+	matching := []RunResult{}
+	for _, r := range m.runs {
+		if query.Matches(r) {
+			matching = append(matching, r)
+		}
+	}
+	m.lock.Unlock()
+	SortRuns(matching)
+	return PaginateRuns(matching, query)
+}
@@ -0,0 +1,91 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunStoreSavesRuns(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("document")
+	wf.SetMetadata("tenant", "acme")
+	wf.SetRunStore(store)
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("timeout")
+	}))
+	_, _, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+
+	run, err := store.GetRun(context.TODO(), wf.Id())
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", run.Metadata["tenant"])
+	assert.Equal(t, []string{"OCR"}, run.FailedComponents)
+
+	_, err = store.GetRun(context.TODO(), "missing")
+	assert.ErrorIs(t, err, goworkflow.ErrRunNotFound)
+}
+
+func TestQueryRuns(t *testing.T) {
+	ctx := context.TODO()
+	store := goworkflow.NewMemoryRunStore()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		r := goworkflow.RunResult{
+			WorkflowId: string(rune('a' + i)),
+			Workflow:   "document",
+			Status:     goworkflow.DONE,
+			StartedAt:  start.Add(time.Duration(i) * time.Hour),
+			Metadata:   map[string]string{"tenant": "acme"},
+		}
+		if i%3 == 0 {
+			r.Status = goworkflow.ERROR
+			r.FailedComponents = []string{"OCR"}
+		}
+		if i%2 == 0 {
+			r.Metadata["tenant"] = "globex"
+		}
+		assert.NoError(t, store.SaveRun(ctx, r))
+	}
+	assert.NoError(t, store.SaveRun(ctx, goworkflow.RunResult{WorkflowId: "z", Workflow: "invoice", StartedAt: start}))
+
+	page, err := store.QueryRuns(ctx, goworkflow.RunQuery{Workflow: "document", PageSize: 4})
+	assert.NoError(t, err)
+	assert.Len(t, page.Runs, 4)
+	assert.Equal(t, "j", page.Runs[0].WorkflowId, "newest first")
+	assert.Equal(t, "4", page.NextPageToken)
+
+	ids := []string{}
+	token := ""
+	for {
+		page, err := store.QueryRuns(ctx, goworkflow.RunQuery{Workflow: "document", PageSize: 4, PageToken: token})
+		assert.NoError(t, err)
+		for _, r := range page.Runs {
+			ids = append(ids, r.WorkflowId)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	assert.Equal(t, []string{"j", "i", "h", "g", "f", "e", "d", "c", "b", "a"}, ids)
+
+	page, err = store.QueryRuns(ctx, goworkflow.RunQuery{FailedComponent: "OCR", Metadata: map[string]string{"tenant": "acme"}})
+	assert.NoError(t, err)
+	assert.Len(t, page.Runs, 2)
+	assert.Equal(t, "j", page.Runs[0].WorkflowId)
+	assert.Equal(t, "d", page.Runs[1].WorkflowId)
+
+	page, err = store.QueryRuns(ctx, goworkflow.RunQuery{Status: goworkflow.DONE, StartedAfter: start.Add(2 * time.Hour), StartedBefore: start.Add(5 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, page.Runs, 2)
+
+	_, err = store.QueryRuns(ctx, goworkflow.RunQuery{PageToken: "x"})
+	assert.Error(t, err)
+}
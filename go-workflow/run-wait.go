@@ -0,0 +1,175 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrRunWaitTimeout = errors.New("awaited run did not finish in time")
+
+/* RunSelector: the awaited run, by RunId or the newest run of Workflow with all Metadata (e.g. a correlation key) */
+type RunSelector struct {
+	RunId    string
+	Workflow string
+	Metadata map[string]string
+}
+
+func (s RunSelector) String() string {
+	if s.RunId != "" {
+		return "run " + s.RunId
+	}
+	return fmt.Sprintf("run of %s with %v", s.Workflow, s.Metadata)
+}
+
+type RunWaitOptions[T any] struct {
+	// Statuses the run has to finish with, DONE when empty. Other final statuses fail the component permanently
+	Statuses []Status
+	// Timeout of the wait, the component fails with ErrRunWaitTimeout when it is exceeded. None when 0
+	Timeout time.Duration
+	// PollInterval of the run store, 1s when 0
+	PollInterval time.Duration
+	// Output stores the result of the awaited run in the data store
+	Output func(data *T, result RunResult)
+}
+
+/*
+MakeRunWaitComponent: component waiting for another run to finish, e.g. the ingestion run of the document an
+enrichment run works on. The run is selected from config and data when the component starts and looked up
+in store, where runs are saved when they finish (see Workflow.SetRunStore), until it is found.
+*/
+func MakeRunWaitComponent[CT context.Context, C any, T any](name string, store RunStore, selector func(config C, data T) RunSelector, opts ...*RunWaitOptions[T]) makeComponentConfig[CT, C, T] {
+	if len(opts) > 1 {
+		panic("only one RunWaitOptions is allowed")
+	}
+	if store == nil || selector == nil {
+		panic("store and selector cannot be nil")
+	}
+	opt := &RunWaitOptions[T]{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	statuses := opt.Statuses
+	if len(statuses) == 0 {
+		statuses = []Status{DONE}
+	}
+	interval := opt.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	return makeComponentConfig[CT, C, T]{
+		Name: name,
+		Executor: func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+			sel := selector(dt.Config, dt.GetData())
+			if sel.RunId == "" && sel.Workflow == "" && len(sel.Metadata) == 0 {
+				return Permanent(fmt.Errorf("%s: empty run selector", name))
+			}
+			waitCtx := dt.Context()
+			if opt.Timeout > 0 {
+				var cancel context.CancelFunc
+				waitCtx, cancel = context.WithTimeout(waitCtx, opt.Timeout)
+				defer cancel()
+			}
+			result, err := pollRun(waitCtx, store, sel, interval)
+			if err != nil {
+				if waitCtx.Err() != nil && dt.Context().Err() == nil {
+					return fmt.Errorf("%s: %w: %s after %s", name, ErrRunWaitTimeout, sel, opt.Timeout)
+				}
+				if waitCtx.Err() != nil {
+					return waitCtx.Err()
+				}
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if !slices.Contains(statuses, result.Status) {
+				return Permanent(fmt.Errorf("%s: %s finished with status %s", name, sel, result.Status))
+			}
+			if opt.Output != nil {
+				dt.Update(func(data *T) { opt.Output(data, result) })
+			}
+			return nil
+		},
+	}
+}
+
+func findRun(ctx context.Context, store RunStore, sel RunSelector) (RunResult, bool, error) {
+	if sel.RunId != "" {
+		result, err := store.GetRun(ctx, sel.RunId)
+		if errors.Is(err, ErrRunNotFound) {
+			return RunResult{}, false, nil
+		}
+		return result, err == nil && finished(result), err
+	}
+	page, err := store.QueryRuns(ctx, RunQuery{Workflow: sel.Workflow, Metadata: sel.Metadata, PageSize: 1})
+	if err != nil || len(page.Runs) == 0 {
+		return RunResult{}, false, err
+	}
+	return page.Runs[0], finished(page.Runs[0]), nil
+}
+
+/* finished: runs with a dedup key are saved when they start, see SetDedupKey */
+func finished(r RunResult) bool {
+	return r.Status != PENDING && r.Status != RUNNING
+}
+
+/* pollRun polls store until the selected run is saved as finished, store errors end the poll */
+func pollRun(ctx context.Context, store RunStore, sel RunSelector, interval time.Duration) (RunResult, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, found, err := findRun(ctx, store, sel)
+		if err != nil && ctx.Err() == nil {
+			return result, err
+		}
+		if err == nil && found {
+			return result, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return RunResult{}, ctx.Err()
+		}
+	}
+}
+
+type AwaitRunOptions struct {
+	// Timeout of the wait, AwaitRun fails with ErrRunWaitTimeout when it is exceeded. None when 0
+	Timeout time.Duration
+	// PollInterval of the run store, 1s when 0
+	PollInterval time.Duration
+}
+
+/*
+AwaitRun blocks until the run runId is finished in store and returns its result, for synchronous callers of
+runs started elsewhere, e.g. by a trigger. Unknown runs are awaited too: runs are saved when they finish
+(when they start with a dedup key), so the run may just not be saved yet.
+*/
+func AwaitRun(ctx context.Context, store RunStore, runId string, opts ...*AwaitRunOptions) (RunResult, error) {
+	if len(opts) > 1 {
+		panic("only one AwaitRunOptions is allowed")
+	}
+	if store == nil || runId == "" {
+		panic("store and run id cannot be empty")
+	}
+	opt := &AwaitRunOptions{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	interval := opt.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	waitCtx := ctx
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opt.Timeout)
+		defer cancel()
+	}
+	result, err := pollRun(waitCtx, store, RunSelector{RunId: runId}, interval)
+	if err != nil && waitCtx.Err() != nil && ctx.Err() == nil {
+		return result, fmt.Errorf("%w: run %s after %s", ErrRunWaitTimeout, runId, opt.Timeout)
+	}
+	return result, err
+}
@@ -0,0 +1,80 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWaitComponent(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+	ingestion := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ingestion.SetName("ingestion")
+	ingestion.SetRunStore(store)
+	ingestion.SetMetadata("document", "doc-1")
+	ingestion.AddComponent(goworkflow.MakeComponent("Parse", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}))
+
+	enrichment := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	enrichment.AddComponent(goworkflow.MakeRunWaitComponent[context.Context, Config, Data]("WaitIngestion", store,
+		func(config Config, data Data) goworkflow.RunSelector {
+			return goworkflow.RunSelector{Workflow: "ingestion", Metadata: map[string]string{"document": data.A}}
+		},
+		&goworkflow.RunWaitOptions[Data]{
+			PollInterval: 5 * time.Millisecond,
+			Timeout:      time.Second,
+			Output:       func(data *Data, result goworkflow.RunResult) { data.B = result.WorkflowId },
+		},
+	))
+	go ingestion.Execute(context.TODO(), Config{}, &Data{})
+	data, st, err := enrichment.Execute(context.TODO(), Config{}, &Data{A: "doc-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, ingestion.Id(), data.B)
+
+	var cause string
+	waitFor := func(sel goworkflow.RunSelector, statuses ...goworkflow.Status) goworkflow.Status {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddStateListener(func(change goworkflow.StateChange) {
+			if change.Component != "" && change.NewStatus == goworkflow.ERROR {
+				cause = change.Cause
+			}
+		})
+		wf.AddComponent(goworkflow.MakeRunWaitComponent[context.Context, Config, Data]("Wait", store,
+			func(config Config, data Data) goworkflow.RunSelector { return sel },
+			&goworkflow.RunWaitOptions[Data]{PollInterval: 5 * time.Millisecond, Timeout: 30 * time.Millisecond, Statuses: statuses},
+		))
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		return st
+	}
+	assert.Equal(t, goworkflow.ERROR, waitFor(goworkflow.RunSelector{RunId: "missing"}))
+	assert.Contains(t, cause, goworkflow.ErrRunWaitTimeout.Error())
+	assert.Equal(t, goworkflow.ERROR, waitFor(goworkflow.RunSelector{RunId: ingestion.Id()}, goworkflow.ERROR))
+	assert.Contains(t, cause, "finished with status DONE")
+	assert.Equal(t, goworkflow.DONE, waitFor(goworkflow.RunSelector{RunId: ingestion.Id()}))
+}
+
+func TestAwaitRun(t *testing.T) {
+	store := goworkflow.NewMemoryRunStore()
+	store.SaveRun(context.TODO(), goworkflow.RunResult{WorkflowId: "run-1", Status: goworkflow.RUNNING})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		store.SaveRun(context.TODO(), goworkflow.RunResult{WorkflowId: "run-1", Status: goworkflow.DONE})
+	}()
+	result, err := goworkflow.AwaitRun(context.TODO(), store, "run-1", &goworkflow.AwaitRunOptions{PollInterval: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, result.Status)
+
+	_, err = goworkflow.AwaitRun(context.TODO(), store, "run-2", &goworkflow.AwaitRunOptions{PollInterval: time.Millisecond, Timeout: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, goworkflow.ErrRunWaitTimeout)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = goworkflow.AwaitRun(ctx, store, "run-2")
+	assert.ErrorIs(t, err, context.Canceled)
+}
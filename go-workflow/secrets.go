@@ -0,0 +1,68 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+/*
+SecretsProvider resolves secrets (API keys etc.) at execution time. Secrets are only reachable through
+the component context, they are never part of the workflow config or data, so they don't end up in snapshots or exports.
+*/
+type SecretsProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+type secretsContextKey struct{}
+
+var ErrSecretsProviderNotSet = errors.New("secrets provider is not set")
+
+func (wf *Workflow[CT, C, T]) SetSecretsProvider(provider SecretsProvider) {
+	wf.secretsProvider = provider
+}
+
+/* Secret resolves name using the secrets provider of the running workflow */
+func Secret(ctx context.Context, name string) (string, error) {
+	provider, ok := ctx.Value(secretsContextKey{}).(SecretsProvider)
+	if !ok {
+		return "", ErrSecretsProviderNotSet
+	}
+	return provider.GetSecret(ctx, name)
+}
+
+func (d *DataTracker[C, T]) Secret(name string) (string, error) {
+	return Secret(d.ctx, name)
+}
+
+func contextWithSecrets(ctx context.Context, provider SecretsProvider) context.Context {
+	if provider == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, secretsContextKey{}, provider)
+}
+
+/* MapSecretsProvider: static secrets, mostly useful for tests */
+type MapSecretsProvider map[string]string
+
+func (m MapSecretsProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	secret, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("secret not found: %s", name)
+	}
+	return secret, nil
+}
+
+/* EnvSecretsProvider reads secrets from environment variables, name is prefixed with Prefix */
+type EnvSecretsProvider struct {
+	Prefix string
+}
+
+func (e EnvSecretsProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	secret, ok := os.LookupEnv(e.Prefix + name)
+	if !ok {
+		return "", fmt.Errorf("secret not found: %s", name)
+	}
+	return secret, nil
+}
@@ -0,0 +1,47 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsProvider(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetSecretsProvider(goworkflow.MapSecretsProvider{"OCR_API_KEY": "secret-key"})
+
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		key, err := goworkflow.Secret(ctx, "OCR_API_KEY")
+		if err != nil {
+			return err
+		}
+		_, err = dt.Secret("MISSING")
+		assert.Error(t, err)
+		dt.Update(func(d *Data) {
+			d.A = key
+		})
+		return nil
+	}))
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "secret-key", data.A)
+
+	_, err = goworkflow.Secret(context.TODO(), "OCR_API_KEY")
+	assert.ErrorIs(t, err, goworkflow.ErrSecretsProviderNotSet)
+}
+
+func TestEnvSecretsProvider(t *testing.T) {
+	t.Setenv("WF_LLM_KEY", "llm-key")
+	provider := goworkflow.EnvSecretsProvider{Prefix: "WF_"}
+
+	secret, err := provider.GetSecret(context.TODO(), "LLM_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, "llm-key", secret)
+
+	_, err = provider.GetSecret(context.TODO(), "OTHER")
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+)
+
+/*
+Services holds typed services (HTTP clients, DB pools, model clients) components look up by type with
+ServiceOf, instead of every component closure capturing its clients. One Services is usually shared by all
+runs of a template, tests Clone it and Provide fakes.
+*/
+type Services struct {
+	lock     sync.RWMutex
+	services map[reflect.Type]any
+}
+
+type servicesContextKey struct{}
+
+func NewServices() *Services {
+	return &Services{services: map[reflect.Type]any{}}
+}
+
+/*
+Provide registers service under the type S, replacing the service previously provided for S. Interfaces are
+provided explicitly, e.g. Provide[ModelClient](s, openai), so components look up the interface.
+*/
+func Provide[S any](s *Services, service S) {
+	if s == nil {
+		panic("services cannot be nil")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.services[reflect.TypeOf((*S)(nil)).Elem()] = service
+}
+
+/* Clone: copy of the services, services provided to the copy don't affect s */
+func (s *Services) Clone() *Services {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return &Services{services: maps.Clone(s.services)}
+}
+
+/* SetServices: services of the components, available through the component context, see ServiceOf */
+func (wf *Workflow[CT, C, T]) SetServices(services *Services) {
+	wf.services = services
+}
+
+/* SetServices: services of the workflows created from the template */
+func (t *Template[CT, C, T]) SetServices(services *Services) {
+	t.mustNotBeFrozen()
+	t.services = services
+}
+
+func contextWithServices(ctx context.Context, services *Services) context.Context {
+	if services == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, servicesContextKey{}, services)
+}
+
+/* ServiceOf: the service provided for S to the running workflow, fails when none was provided */
+func ServiceOf[S any](ctx context.Context) (S, error) {
+	var service S
+	t := reflect.TypeOf((*S)(nil)).Elem()
+	if s, ok := ctx.Value(servicesContextKey{}).(*Services); ok {
+		s.lock.RLock()
+		provided, found := s.services[t]
+		s.lock.RUnlock()
+		if found {
+			return provided.(S), nil
+		}
+	}
+	return service, fmt.Errorf("no service provided for %s", t)
+}
+
+/* MustServiceOf: like ServiceOf, panics when no service was provided for S */
+func MustServiceOf[S any](ctx context.Context) S {
+	service, err := ServiceOf[S](ctx)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
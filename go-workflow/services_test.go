@@ -0,0 +1,57 @@
+package goworkflow_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type ModelClient interface {
+	Complete(prompt string) string
+}
+
+type echoModel struct{ prefix string }
+
+func (m echoModel) Complete(prompt string) string {
+	return m.prefix + prompt
+}
+
+func TestServices(t *testing.T) {
+	services := goworkflow.NewServices()
+	goworkflow.Provide[ModelClient](services, echoModel{prefix: "model: "})
+	goworkflow.Provide(services, &http.Client{Timeout: time.Minute})
+
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("extraction")
+	template.SetServices(services)
+	template.AddComponent(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		model := goworkflow.MustServiceOf[ModelClient](ctx)
+		client, err := goworkflow.ServiceOf[*http.Client](dt.Context())
+		if err != nil {
+			return err
+		}
+		dt.Update(func(d *Data) { d.A = model.Complete("invoice"); d.B = client.Timeout.String() })
+		return nil
+	}))
+	data, st, err := template.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "model: invoice", data.A)
+	assert.Equal(t, "1m0s", data.B)
+
+	// tests swap implementations on a copy
+	fakes := services.Clone()
+	goworkflow.Provide[ModelClient](fakes, echoModel{prefix: "fake: "})
+	wf := template.NewWorkflow(context.TODO())
+	wf.SetServices(fakes)
+	data, _, _ = wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, "fake: invoice", data.A)
+	data, _, _ = template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, "model: invoice", data.A)
+
+	_, err = goworkflow.ServiceOf[ModelClient](context.TODO())
+	assert.EqualError(t, err, "no service provided for goworkflow_test.ModelClient")
+}
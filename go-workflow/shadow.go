@@ -0,0 +1,133 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+/* ShadowResult: outcome of a shadow execution compared with the live execution of the component */
+type ShadowResult struct {
+	WorkflowId string
+	Component  string
+	// Err: failure of the shadow, its output isn't compared
+	Err          error
+	LiveErr      error
+	Duration     time.Duration
+	LiveDuration time.Duration
+	// Differences: fields where the output of the shadow (After) differs from the live output (Before)
+	Differences []FieldChange
+}
+
+func (r ShadowResult) Match() bool {
+	return r.Err == nil && r.LiveErr == nil && len(r.Differences) == 0
+}
+
+type ShadowOptions struct {
+	// Fields: data store paths compared (including their sub fields), all fields when empty. Fields written by
+	// components running concurrently with the live one show up as differences unless they are excluded this way
+	Fields []string
+	// Timeout of the shadow, none when 0
+	Timeout time.Duration
+	// Report receives every comparison, mismatches are logged when nil. It runs in the goroutine of the shadow
+	Report func(ctx context.Context, result ShadowResult)
+}
+
+/*
+WithShadow: executor running live and, in the background, shadow with the same input on a copy of the data
+store, e.g. a new TextExtractor validated against production traffic. The shadow never affects the run: its
+writes stay in the copy, its failures and panics are only reported and the live execution doesn't wait for it.
+Data stores which can't be copied through json skip the shadow.
+*/
+func WithShadow[CT context.Context, I any, C any, T any](live ComponentFunction[CT, I, C, T], shadow ComponentFunction[CT, I, C, T], opts ...*ShadowOptions) ComponentFunction[CT, I, C, T] {
+	if len(opts) > 1 {
+		panic("only one ShadowOptions is allowed")
+	}
+	if live == nil || shadow == nil {
+		panic("live and shadow cannot be nil")
+	}
+	opt := &ShadowOptions{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	return func(ctx CT, input I, dt *DataTracker[C, T]) error {
+		info, _ := ComponentInfoFromContext(dt.Context())
+		result := ShadowResult{WorkflowId: info.WorkflowId, Component: info.Component}
+		dt.store.lock.Lock()
+		shadowData, err := copyData(dt.store.data)
+		dt.store.lock.Unlock()
+		if err != nil {
+			log.Println("WithShadow:Error:Data store cannot be copied, shadow skipped:", info.Component, err)
+			return live(ctx, input, dt)
+		}
+
+		// the shadow outlives the live execution, it must not record run metadata
+		reportCtx := context.WithValue(context.WithoutCancel(dt.Context()), runMetadataContextKey{}, nil)
+		shadowCtx := reportCtx
+		cancel := context.CancelFunc(func() {})
+		if opt.Timeout > 0 {
+			shadowCtx, cancel = context.WithTimeout(shadowCtx, opt.Timeout)
+		}
+		shadowTracker := &DataTracker[C, T]{Config: dt.Config, store: &dataStore[T]{data: shadowData}, ctx: shadowCtx}
+		shadowDone := make(chan struct{})
+		go func() {
+			defer close(shadowDone)
+			defer cancel()
+			defer func() {
+				if r := recover(); r != nil {
+					result.Err = fmt.Errorf("shadow panicked: %v", r)
+				}
+			}()
+			started := time.Now()
+			result.Err = shadow(componentContext(ctx, shadowCtx), input, shadowTracker)
+			result.Duration = time.Since(started)
+		}()
+
+		started := time.Now()
+		liveErr := live(ctx, input, dt)
+		liveDuration := time.Since(started)
+		dt.store.lock.Lock()
+		liveData, err := copyData(dt.store.data)
+		dt.store.lock.Unlock()
+
+		go func() {
+			<-shadowDone
+			result.LiveErr, result.LiveDuration = liveErr, liveDuration
+			if result.Err == nil && liveErr == nil && err == nil {
+				result.Differences = shadowDifferences(liveData, shadowData, opt.Fields)
+			}
+			if opt.Report != nil {
+				opt.Report(reportCtx, result)
+			} else if !result.Match() {
+				log.Println("WithShadow:Mismatch:", result.Component, result.WorkflowId, "shadow error:", result.Err, "live error:", result.LiveErr, "differences:", len(result.Differences))
+			}
+		}()
+		return liveErr
+	}
+}
+
+/* shadowDifferences: fields (of the compared ones) whose values differ between the live and the shadow output */
+func shadowDifferences[T any](live, shadow *T, fields []string) []FieldChange {
+	differences := []FieldChange{}
+	changes, err := DiffData(live, shadow)
+	if err != nil {
+		return differences
+	}
+	for _, change := range changes {
+		if len(fields) == 0 || hasFieldPrefix(change.Path, fields) {
+			differences = append(differences, change)
+		}
+	}
+	return differences
+}
+
+func hasFieldPrefix(path string, fields []string) bool {
+	for _, field := range fields {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithShadow(t *testing.T) {
+	results := make(chan goworkflow.ShadowResult, 1)
+	live := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A, d.B = "invoice", "total 10" })
+		return nil
+	}
+	run := func(shadow goworkflow.ComponentFunction[context.Context, any, Config, Data], fields ...string) (Data, goworkflow.ShadowResult) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("TextExtractor", nil, goworkflow.WithShadow(live, shadow, &goworkflow.ShadowOptions{
+			Fields: fields,
+			Report: func(ctx context.Context, result goworkflow.ShadowResult) { results <- result },
+		})))
+		data, st, err := wf.Execute(context.TODO(), Config{}, &Data{C: "input"})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		select {
+		case result := <-results:
+			assert.Equal(t, wf.Id(), result.WorkflowId)
+			assert.Equal(t, "TextExtractor", result.Component)
+			return *data, result
+		case <-time.After(time.Second):
+			t.Fatal("shadow not reported")
+		}
+		return *data, goworkflow.ShadowResult{}
+	}
+
+	data, result := run(func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		assert.Equal(t, "input", dt.GetData().C)
+		dt.Update(func(d *Data) { d.A, d.B = "invoice", "total 12" })
+		return nil
+	})
+	// the live data store is untouched by the shadow
+	assert.Equal(t, Data{A: "invoice", B: "total 10", C: "input"}, data)
+	assert.False(t, result.Match())
+	assert.Equal(t, []goworkflow.FieldChange{{Path: "B", Before: "total 10", After: "total 12"}}, result.Differences)
+
+	_, result = run(func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A, d.B = "invoice", "total 12" })
+		return nil
+	}, "A")
+	assert.True(t, result.Match())
+
+	data, result = run(func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		panic("unsupported layout")
+	})
+	assert.Equal(t, "invoice", data.A)
+	assert.EqualError(t, result.Err, "shadow panicked: unsupported layout")
+
+	_, result = run(func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("model unavailable")
+	})
+	assert.EqualError(t, result.Err, "model unavailable")
+	assert.Empty(t, result.Differences)
+}
@@ -0,0 +1,301 @@
+package goworkflow
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+/* DurationDistribution draws a synthetic component duration */
+type DurationDistribution func(r *rand.Rand) time.Duration
+
+func FixedDuration(d time.Duration) DurationDistribution {
+	return func(r *rand.Rand) time.Duration { return d }
+}
+
+func UniformDuration(min, max time.Duration) DurationDistribution {
+	return func(r *rand.Rand) time.Duration { return min + time.Duration(r.Int63n(int64(max-min)+1)) }
+}
+
+/* NormalDuration: negative draws are clamped to 0 */
+func NormalDuration(mean, stddev time.Duration) DurationDistribution {
+	return func(r *rand.Rand) time.Duration {
+		return max(0, time.Duration(r.NormFloat64()*float64(stddev))+mean)
+	}
+}
+
+/* EmpiricalDuration draws from observed durations, e.g. of previous runs */
+func EmpiricalDuration(samples []time.Duration) DurationDistribution {
+	if len(samples) == 0 {
+		panic("empirical distribution needs samples")
+	}
+	return func(r *rand.Rand) time.Duration { return samples[r.Intn(len(samples))] }
+}
+
+type SimulationOptions struct {
+	// Durations per component name, components without one use ComponentConfig.ExpectedDuration
+	Durations map[string]DurationDistribution
+	// ConcurrentRuns: runs started together, sharing the limiters and slot pools, defaults to 1
+	ConcurrentRuns int
+	// Trials: number of simulations, defaults to 100
+	Trials int
+	Seed   int64
+}
+
+/* ResourceUtilization of a limiter or slot pool, averaged over the trials */
+type ResourceUtilization struct {
+	// Name: name the limiter was registered with (RegisterLimiter), or the components using it
+	Name        string
+	Capacity    int
+	Utilization float64
+	// MeanWait: mean time from ready to start of the components using it
+	MeanWait  time.Duration
+	MaxQueued int
+}
+
+type SimulationReport struct {
+	Trials int
+	// Makespan: time from the start until all concurrent runs are done
+	Makespan  DurationStats
+	Resources []ResourceUtilization
+}
+
+type simResource struct {
+	name       string
+	capacity   int
+	inUse      int
+	queue      []*simTask
+	lastChange time.Duration
+	busy       float64 // integral of inUse over time
+	waits      time.Duration
+	waiters    int
+	maxQueued  int
+	// totals over trials
+	utilization float64
+}
+
+type simTask struct {
+	run       int
+	component int
+	readyAt   time.Duration
+	finishAt  time.Duration
+	acquired  int
+	pending   int
+	// resolved any of groups of the run, indexed like simComponent.anyOf
+	groupsDone []bool
+}
+
+type simComponent struct {
+	name       string
+	duration   DurationDistribution
+	resources  []*simResource
+	dependents []int
+	// dependents waiting on this component as part of an any of group: component -> group index
+	groupDependents [][2]int
+	dependencies    int
+	groups          int
+}
+
+type simEvents []*simTask
+
+func (e simEvents) Len() int           { return len(e) }
+func (e simEvents) Less(i, j int) bool { return e[i].finishAt < e[j].finishAt }
+func (e simEvents) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e *simEvents) Push(x any)        { *e = append(*e, x.(*simTask)) }
+func (e *simEvents) Pop() any          { old := *e; t := old[len(old)-1]; *e = old[:len(old)-1]; return t }
+
+/*
+Simulate executes the template with synthetic durations instead of the component functions (discrete event
+simulation, no time passes) and reports the expected makespan and limiter utilization, to evaluate topology and
+limiter changes before shipping them. Limiter and slot pool capacities are read from the component configs.
+Components are assumed to succeed.
+*/
+func (t *Template[CT, C, T]) Simulate(opts SimulationOptions) (SimulationReport, error) {
+	if opts.ConcurrentRuns <= 0 {
+		opts.ConcurrentRuns = 1
+	}
+	if opts.Trials <= 0 {
+		opts.Trials = 100
+	}
+
+	components, resources, err := t.simulationModel(opts)
+	if err != nil {
+		return SimulationReport{}, err
+	}
+
+	r := rand.New(rand.NewSource(opts.Seed))
+	makespans := []time.Duration{}
+	for trial := 0; trial < opts.Trials; trial++ {
+		makespans = append(makespans, simulateTrial(components, resources, opts.ConcurrentRuns, r))
+	}
+
+	report := SimulationReport{Trials: opts.Trials, Makespan: ComputeDurationStats(makespans)}
+	for _, res := range resources {
+		u := ResourceUtilization{Name: res.name, Capacity: res.capacity, Utilization: res.utilization / float64(opts.Trials), MaxQueued: res.maxQueued}
+		if res.waiters > 0 {
+			u.MeanWait = res.waits / time.Duration(res.waiters)
+		}
+		report.Resources = append(report.Resources, u)
+	}
+	slices.SortFunc(report.Resources, func(a, b ResourceUtilization) int { return strings.Compare(a.Name, b.Name) })
+	return report, nil
+}
+
+func (t *Template[CT, C, T]) simulationModel(opts SimulationOptions) ([]*simComponent, []*simResource, error) {
+	registered := map[any]string{}
+	engineStats.lock.Lock()
+	for name, l := range engineStats.limiters {
+		registered[l] = name
+	}
+	engineStats.lock.Unlock()
+
+	index := map[string]int{}
+	components := []*simComponent{}
+	for i, tc := range t.components {
+		index[tc.Name()] = i
+		sc := &simComponent{name: tc.Name(), duration: opts.Durations[tc.Name()]}
+		if sc.duration == nil && tc.config != nil && tc.config.ExpectedDuration > 0 {
+			sc.duration = FixedDuration(tc.config.ExpectedDuration)
+		}
+		if sc.duration == nil {
+			return nil, nil, fmt.Errorf("no duration for component %s", tc.Name())
+		}
+		components = append(components, sc)
+	}
+
+	byLimiter := map[any]*simResource{}
+	users := map[*simResource][]string{}
+	kinds := map[*simResource]string{}
+	resource := func(l UtilizationReporter, kind string, component string) *simResource {
+		res, ok := byLimiter[l]
+		if !ok {
+			res = &simResource{capacity: l.Capacity(), name: registered[l]}
+			byLimiter[l] = res
+			kinds[res] = kind
+		}
+		users[res] = append(users[res], component)
+		return res
+	}
+	for i, tc := range t.components {
+		if cfg := tc.config; cfg != nil {
+			if cfg.ConcurrencyLimiter != nil {
+				components[i].resources = append(components[i].resources, resource(cfg.ConcurrencyLimiter, "limiter", tc.Name()))
+			}
+			if cfg.SlotPool != nil {
+				components[i].resources = append(components[i].resources, resource(cfg.SlotPool, "slots", tc.Name()))
+			}
+		}
+		for _, dep := range tc.dependencies {
+			components[index[dep]].dependents = append(components[index[dep]].dependents, i)
+			components[i].dependencies++
+		}
+		for g, group := range tc.anyOf {
+			for _, dep := range group.dependencies {
+				components[index[dep]].groupDependents = append(components[index[dep]].groupDependents, [2]int{i, g})
+			}
+			components[i].dependencies++
+			components[i].groups++
+		}
+	}
+
+	resources := []*simResource{}
+	for res, names := range users {
+		if res.name == "" {
+			res.name = fmt.Sprintf("%s(%s)", kinds[res], strings.Join(names, ", "))
+		}
+		resources = append(resources, res)
+	}
+	return components, resources, nil
+}
+
+func simulateTrial(components []*simComponent, resources []*simResource, runs int, r *rand.Rand) time.Duration {
+	for _, res := range resources {
+		res.inUse, res.queue, res.lastChange, res.busy = 0, nil, 0, 0
+	}
+	events := &simEvents{}
+	account := func(res *simResource, now time.Duration) {
+		res.busy += float64(res.inUse) * float64(now-res.lastChange)
+		res.lastChange = now
+	}
+
+	// acquire the resources of the task in order, queueing at the first one which is exhausted
+	var acquire func(task *simTask, now time.Duration)
+	acquire = func(task *simTask, now time.Duration) {
+		sc := components[task.component]
+		for task.acquired < len(sc.resources) {
+			res := sc.resources[task.acquired]
+			if res.inUse >= res.capacity {
+				res.queue = append(res.queue, task)
+				res.maxQueued = max(res.maxQueued, len(res.queue))
+				return
+			}
+			account(res, now)
+			res.inUse++
+			task.acquired++
+		}
+		for _, res := range sc.resources {
+			res.waits += now - task.readyAt
+			res.waiters++
+		}
+		task.finishAt = now + sc.duration(r)
+		heap.Push(events, task)
+	}
+
+	runTasks := make([][]*simTask, runs)
+	for run := range runTasks {
+		for i, sc := range components {
+			task := &simTask{run: run, component: i, pending: sc.dependencies, groupsDone: make([]bool, sc.groups)}
+			runTasks[run] = append(runTasks[run], task)
+		}
+		for _, task := range runTasks[run] {
+			if task.pending == 0 {
+				acquire(task, 0)
+			}
+		}
+	}
+
+	var now time.Duration
+	for events.Len() > 0 {
+		task := heap.Pop(events).(*simTask)
+		now = task.finishAt
+		sc := components[task.component]
+		for _, res := range sc.resources {
+			account(res, now)
+			res.inUse--
+			if len(res.queue) > 0 {
+				next := res.queue[0]
+				res.queue = res.queue[1:]
+				acquire(next, now)
+			}
+		}
+		run := task.run
+		ready := func(dependent *simTask) {
+			dependent.pending--
+			if dependent.pending == 0 {
+				dependent.readyAt = now
+				acquire(dependent, now)
+			}
+		}
+		for _, i := range sc.dependents {
+			ready(runTasks[run][i])
+		}
+		for _, dg := range sc.groupDependents {
+			dependent := runTasks[run][dg[0]]
+			if !dependent.groupsDone[dg[1]] {
+				dependent.groupsDone[dg[1]] = true
+				ready(dependent)
+			}
+		}
+	}
+
+	for _, res := range resources {
+		account(res, now)
+		if now > 0 {
+			res.utilization += res.busy / (float64(res.capacity) * float64(now))
+		}
+	}
+	return now
+}
@@ -0,0 +1,75 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	pages := limiter.NewConcurrencyLimiter(2)
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := []*goworkflow.TemplateComponent[context.Context, Config, Data]{}
+	for i := 0; i < 10; i++ {
+		ocr = append(ocr, tpl.AddComponent(goworkflow.MakeComponent(fmt.Sprint("OCR", i), nil, ocrProvider("", 0, nil)),
+			&goworkflow.ComponentConfig{ConcurrencyLimiter: pages, ExpectedDuration: time.Second}))
+	}
+	tpl.AddComponent(goworkflow.MakeComponent("Merge", nil, ocrProvider("", 0, nil))).AddDependencies(ocr...)
+
+	report, err := tpl.Simulate(goworkflow.SimulationOptions{
+		Durations: map[string]goworkflow.DurationDistribution{"Merge": goworkflow.FixedDuration(500 * time.Millisecond)},
+		Trials:    3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Trials)
+	assert.Equal(t, 5500*time.Millisecond, report.Makespan.P50)
+	assert.Len(t, report.Resources, 1)
+	res := report.Resources[0]
+	assert.Equal(t, 2, res.Capacity)
+	assert.InDelta(t, 10.0/11, res.Utilization, 0.001)
+	assert.Equal(t, 8, res.MaxQueued)
+	assert.Contains(t, res.Name, "limiter(OCR0")
+
+	// the same topology with a larger limiter
+	pages.SetCapacity(5)
+	report, _ = tpl.Simulate(goworkflow.SimulationOptions{
+		Durations: map[string]goworkflow.DurationDistribution{"Merge": goworkflow.FixedDuration(500 * time.Millisecond)},
+		Trials:    1,
+	})
+	assert.Equal(t, 2500*time.Millisecond, report.Makespan.P50)
+}
+
+func TestSimulateConcurrentRuns(t *testing.T) {
+	gpu := limiter.NewConcurrencyLimiter(2)
+	goworkflow.RegisterLimiter("gpu-sim", gpu)
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	fast := tpl.AddComponent(goworkflow.MakeComponent("FastOCR", nil, ocrProvider("", 0, nil)))
+	slow := tpl.AddComponent(goworkflow.MakeComponent("SlowOCR", nil, ocrProvider("", 0, nil)))
+	tpl.AddComponent(goworkflow.MakeComponent("Layout", nil, ocrProvider("", 0, nil)),
+		&goworkflow.ComponentConfig{ConcurrencyLimiter: gpu}).AddAnyOfDependencies(fast, slow)
+
+	report, err := tpl.Simulate(goworkflow.SimulationOptions{
+		Durations: map[string]goworkflow.DurationDistribution{
+			"FastOCR": goworkflow.UniformDuration(100*time.Millisecond, 200*time.Millisecond),
+			"SlowOCR": goworkflow.NormalDuration(time.Second, 100*time.Millisecond),
+			"Layout":  goworkflow.EmpiricalDuration([]time.Duration{time.Second}),
+		},
+		ConcurrentRuns: 3,
+		Trials:         50,
+		Seed:           1,
+	})
+	assert.NoError(t, err)
+	// Layout starts after FastOCR, the third run waits for a gpu slot
+	assert.GreaterOrEqual(t, report.Makespan.P50, 2100*time.Millisecond)
+	assert.LessOrEqual(t, report.Makespan.P99, 2400*time.Millisecond)
+	assert.Equal(t, "gpu-sim", report.Resources[0].Name)
+	assert.Equal(t, 1, report.Resources[0].MaxQueued)
+
+	_, err = tpl.Simulate(goworkflow.SimulationOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package goworkflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+/* SLO of a component (ComponentConfig.SLO) or of the whole run (Workflow.SetSLO) */
+type SLO struct {
+	// MaxDuration: latency objective, zero means no latency objective
+	MaxDuration time.Duration
+	// NoErrors: a failure is a breach
+	NoErrors bool
+}
+
+const SLOBreachLatency = "latency"
+const SLOBreachError = "error"
+
+type SLOBreach struct {
+	WorkflowId string
+	Workflow   string
+	// Component is empty for a breach of the run SLO
+	Component string
+	Kind      string
+	Limit     time.Duration
+	Actual    time.Duration
+	// Over: by how much the latency objective was exceeded
+	Over  time.Duration
+	Error string
+}
+
+func (b SLOBreach) String() string {
+	target := "run"
+	if b.Component != "" {
+		target = "component " + b.Component
+	}
+	if b.Kind == SLOBreachError {
+		return fmt.Sprintf("%s of %s %s failed: %s", target, b.Workflow, b.WorkflowId, b.Error)
+	}
+	return fmt.Sprintf("%s of %s %s took %s, %s over the %s objective", target, b.Workflow, b.WorkflowId, b.Actual, b.Over, b.Limit)
+}
+
+/* SetSLO: objective of the whole run */
+func (wf *Workflow[CT, C, T]) SetSLO(slo SLO) {
+	wf.runSLO = &slo
+}
+
+/*
+OnSLOBreach registers the alert callback, invoked synchronously when a component or the run breaches its SLO.
+Slow alerting (e.g. webhooks) should not block the workflow, see WebhookAlert.
+*/
+func (wf *Workflow[CT, C, T]) OnSLOBreach(alert func(SLOBreach)) {
+	var runStartedAt time.Time
+	wf.addStateListener(func(change StateChange) {
+		if change.NewStatus == RUNNING {
+			if change.Component == "" {
+				runStartedAt = change.Time
+			}
+			return
+		}
+		if change.NewStatus != DONE && change.NewStatus != ERROR && change.NewStatus != DONE_WITH_WARNINGS && change.NewStatus != QUARANTINED {
+			return
+		}
+
+		var slo *SLO
+		breach := SLOBreach{WorkflowId: wf.id, Workflow: wf.name, Component: change.Component}
+		if change.Component == "" {
+			slo = wf.runSLO
+			if !runStartedAt.IsZero() {
+				breach.Actual = change.Time.Sub(runStartedAt)
+			}
+		} else if c, ok := wf.componentsMap[change.ComponentId]; ok && c.addComponentCfg != nil {
+			slo = c.addComponentCfg.SLO
+			c.statusLock.Lock()
+			breach.Actual = c.timing.Duration()
+			c.statusLock.Unlock()
+		}
+		if slo == nil {
+			return
+		}
+
+		if slo.NoErrors && (change.NewStatus == ERROR || change.NewStatus == QUARANTINED) {
+			errBreach := breach
+			errBreach.Kind = SLOBreachError
+			errBreach.Error = change.Cause
+			alert(errBreach)
+		}
+		if slo.MaxDuration > 0 && breach.Actual > slo.MaxDuration {
+			breach.Kind = SLOBreachLatency
+			breach.Limit = slo.MaxDuration
+			breach.Over = breach.Actual - slo.MaxDuration
+			alert(breach)
+		}
+	})
+}
+
+/* WebhookAlert posts every breach as json to url, asynchronously */
+func WebhookAlert(url string, client *http.Client) func(SLOBreach) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(breach SLOBreach) {
+		go func() {
+			body, _ := json.Marshal(map[string]any{
+				"workflowId": breach.WorkflowId,
+				"workflow":   breach.Workflow,
+				"component":  breach.Component,
+				"kind":       breach.Kind,
+				"limitMs":    breach.Limit.Milliseconds(),
+				"actualMs":   breach.Actual.Milliseconds(),
+				"overMs":     breach.Over.Milliseconds(),
+				"error":      breach.Error,
+				"text":       breach.String(),
+			})
+			resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Println("WebhookAlert:Error:", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				log.Println("WebhookAlert:Error:unexpected status", resp.StatusCode)
+			}
+		}()
+	}
+}
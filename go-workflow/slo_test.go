@@ -0,0 +1,71 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOBreaches(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.SetName("document")
+	wf.SetSLO(goworkflow.SLO{MaxDuration: 5 * time.Millisecond})
+
+	wf.AddComponent(goworkflow.MakeComponent("Slow", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}), &goworkflow.ComponentConfig{SLO: &goworkflow.SLO{MaxDuration: 10 * time.Millisecond}})
+	wf.AddComponent(goworkflow.MakeComponent("Failing", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("rate limited")
+	}), &goworkflow.ComponentConfig{SLO: &goworkflow.SLO{NoErrors: true, MaxDuration: time.Second}})
+	wf.AddComponent(goworkflow.MakeComponent("Fast", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}), &goworkflow.ComponentConfig{SLO: &goworkflow.SLO{MaxDuration: time.Second}})
+
+	breaches := map[string]goworkflow.SLOBreach{}
+	wf.OnSLOBreach(func(b goworkflow.SLOBreach) {
+		breaches[b.Component] = b
+	})
+
+	_, _, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+
+	assert.Len(t, breaches, 3)
+	slow := breaches["Slow"]
+	assert.Equal(t, goworkflow.SLOBreachLatency, slow.Kind)
+	assert.Equal(t, 10*time.Millisecond, slow.Limit)
+	assert.GreaterOrEqual(t, slow.Over, 10*time.Millisecond)
+	assert.Contains(t, slow.String(), "component Slow of document")
+
+	failing := breaches["Failing"]
+	assert.Equal(t, goworkflow.SLOBreachError, failing.Kind)
+	assert.Equal(t, "rate limited", failing.Error)
+
+	run := breaches[""]
+	assert.Equal(t, goworkflow.SLOBreachLatency, run.Kind)
+	assert.GreaterOrEqual(t, run.Actual, 20*time.Millisecond)
+}
+
+func TestWebhookAlert(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer server.Close()
+
+	alert := goworkflow.WebhookAlert(server.URL, nil)
+	alert(goworkflow.SLOBreach{WorkflowId: "run-1", Component: "OCR", Kind: goworkflow.SLOBreachLatency, Limit: time.Second, Actual: 3 * time.Second, Over: 2 * time.Second})
+
+	body := <-received
+	assert.Equal(t, "OCR", body["component"])
+	assert.Equal(t, float64(2000), body["overMs"])
+}
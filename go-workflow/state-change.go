@@ -0,0 +1,112 @@
+package goworkflow
+
+import (
+	"time"
+)
+
+/* StateChange describes a status transition of the workflow (Component is empty) or of one of its components */
+type StateChange struct {
+	WorkflowId  string
+	Component   string
+	ComponentId string
+	OldStatus   Status
+	NewStatus   Status
+	// Cause: why the transition happened, e.g. the component error message
+	Cause string
+	Time  time.Time
+}
+
+type StateChangeHook func(StateChange)
+
+/*
+OnStateChange registers the hook invoked on every state change of the workflow and its components.
+Calls are serialized and in the order of the changes, so the hook can mirror the state into an external state
+machine without extra locking. The hook is called outside the state lock by a dispatcher goroutine: it may call
+back into the workflow (Status, Result, Checkpoint...) and a slow hook doesn't hold up the components, Execute
+returns once the hook received the final status. Registering a new hook replaces the previous one.
+*/
+func (wf *Workflow[CT, C, T]) OnStateChange(hook StateChangeHook) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.stateChangeHook = hook
+}
+
+/* Status: current status of the workflow */
+func (wf *Workflow[CT, C, T]) Status() Status {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	return wf.status
+}
+
+func (wf *Workflow[CT, C, T]) setWorkflowStatus(status Status, cause string) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+
+	change := StateChange{WorkflowId: wf.id, OldStatus: wf.status, NewStatus: status, Cause: cause, Time: time.Now()}
+	wf.status = status
+	if status == RUNNING {
+		wf.startedAt = change.Time
+	} else if status == DONE || status == ERROR || status == DONE_WITH_WARNINGS || status == QUARANTINED {
+		wf.finishedAt = change.Time
+	}
+	wf.notifyStateChange(change)
+}
+
+func (wf *Workflow[CT, C, T]) setComponentStatus(c *component[CT, C, T], status Status, cause string) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+
+	c.statusLock.Lock()
+	change := StateChange{
+		WorkflowId:  wf.id,
+		Component:   c.Name,
+		ComponentId: c.id,
+		OldStatus:   c.status.Status,
+		NewStatus:   status,
+		Cause:       cause,
+		Time:        time.Now(),
+	}
+	c.status = componentStatus{Status: status}
+	if status == ERROR {
+		c.status.ErrorMessage = cause
+	}
+	c.timing.Status = status
+	if status == RUNNING {
+		c.timing.StartedAt = change.Time
+	} else if status == DONE || status == ERROR {
+		c.timing.FinishedAt = change.Time
+	}
+	c.statusLock.Unlock()
+	wf.notifyStateChange(change)
+}
+
+/* should be called with stateLock held */
+func (wf *Workflow[CT, C, T]) notifyStateChange(change StateChange) {
+	engineStats.record(change)
+	for _, listener := range wf.stateListeners {
+		listener(change)
+	}
+	if wf.stateChangeHook != nil {
+		hook := wf.stateChangeHook
+		wf.stateChangeQueue.dispatch(func() { hook(change) })
+	}
+}
+
+/*
+AddStateListener registers a listener invoked on every state change, before the OnStateChange hook, for integrations
+which must not replace the hook of the user. Listeners are called with the state locked: they must not block
+and must not call back into the workflow.
+*/
+func (wf *Workflow[CT, C, T]) AddStateListener(listener StateChangeHook) {
+	if listener == nil {
+		panic("listener cannot be nil")
+	}
+	wf.addStateListener(listener)
+}
+
+/* internal listeners (audit log, tracing etc.) are invoked before the user hook */
+func (wf *Workflow[CT, C, T]) addStateListener(listener StateChangeHook) {
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.stateListeners = append(wf.stateListeners, listener)
+}
@@ -0,0 +1,105 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnStateChange(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	a := wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("ocr unavailable")
+	}))
+	b := wf.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+	b.AddDependencies(a)
+
+	changes := map[string][]goworkflow.StateChange{}
+	wf.OnStateChange(func(change goworkflow.StateChange) {
+		assert.Equal(t, wf.Id(), change.WorkflowId)
+		changes[change.Component] = append(changes[change.Component], change)
+	})
+
+	assert.Equal(t, goworkflow.PENDING, wf.Status())
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.Equal(t, goworkflow.ERROR, wf.Status())
+
+	workflowChanges := changes[""]
+	assert.Len(t, workflowChanges, 2)
+	assert.Equal(t, goworkflow.PENDING, workflowChanges[0].OldStatus)
+	assert.Equal(t, goworkflow.RUNNING, workflowChanges[0].NewStatus)
+	assert.Equal(t, goworkflow.RUNNING, workflowChanges[1].OldStatus)
+	assert.Equal(t, goworkflow.ERROR, workflowChanges[1].NewStatus)
+	assert.Equal(t, "components failed: A, B", workflowChanges[1].Cause)
+
+	aChanges := changes["A"]
+	assert.Len(t, aChanges, 2)
+	assert.Equal(t, goworkflow.RUNNING, aChanges[0].NewStatus)
+	assert.Equal(t, goworkflow.ERROR, aChanges[1].NewStatus)
+	assert.Equal(t, "ocr unavailable", aChanges[1].Cause)
+
+	// B never started, it goes straight from PENDING to ERROR
+	bChanges := changes["B"]
+	assert.Len(t, bChanges, 1)
+	assert.Equal(t, goworkflow.PENDING, bChanges[0].OldStatus)
+	assert.Equal(t, goworkflow.ERROR, bChanges[0].NewStatus)
+}
+
+func TestAddStateListener(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}))
+
+	listened, hooked := []string{}, []string{}
+	wf.AddStateListener(func(change goworkflow.StateChange) {
+		listened = append(listened, change.Component)
+	})
+	wf.OnStateChange(func(change goworkflow.StateChange) {
+		hooked = append(hooked, change.Component)
+	})
+	wf.Execute(context.TODO(), Config{}, &Data{})
+
+	// the hook is called after the state lock is released, in the order of the changes
+	assert.Equal(t, []string{"", "A", "A", ""}, listened)
+	assert.Equal(t, []string{"", "A", "A", ""}, hooked)
+	assert.Panics(t, func() { wf.AddStateListener(nil) })
+}
+
+func TestStateChangeHookCallsWorkflow(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	for _, name := range []string{"A", "B", "C"} {
+		wf.AddComponent(goworkflow.MakeComponent(name, nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			return nil
+		}))
+	}
+	statuses := []goworkflow.Status{}
+	wf.OnStateChange(func(change goworkflow.StateChange) {
+		wf.Checkpoint()
+		wf.Result()
+		if change.Component == "" {
+			statuses = append(statuses, wf.Status())
+		}
+		time.Sleep(time.Millisecond)
+	})
+	done := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+		done <- st
+	}()
+	select {
+	case st := <-done:
+		assert.Equal(t, goworkflow.DONE, st)
+		assert.Equal(t, goworkflow.DONE, statuses[len(statuses)-1])
+	case <-time.After(5 * time.Second):
+		t.Fatal("the hook deadlocked the workflow")
+	}
+}
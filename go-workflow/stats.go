@@ -0,0 +1,107 @@
+package goworkflow
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/* UtilizationReporter is implemented by limiter.ConcurrencyLimiter and limiter.SlotPool */
+type UtilizationReporter interface {
+	InUse() int
+	Capacity() int
+}
+
+type LimiterStats struct {
+	InUse       int     `json:"inUse"`
+	Capacity    int     `json:"capacity"`
+	Utilization float64 `json:"utilization"`
+}
+
+type QueueStats struct {
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+}
+
+/* EngineStats: live stats of all workflows of the process */
+type EngineStats struct {
+	ActiveRuns        int64                   `json:"activeRuns"`
+	RunningComponents int64                   `json:"runningComponents"`
+	Goroutines        int                     `json:"goroutines"`
+	Limiters          map[string]LimiterStats `json:"limiters"`
+	Queues            map[string]QueueStats   `json:"queues"`
+}
+
+type statsRegistry struct {
+	activeRuns        atomic.Int64
+	runningComponents atomic.Int64
+
+	lock     sync.Mutex
+	limiters map[string]UtilizationReporter
+	queues   map[string]*limiter.RunQueue
+}
+
+var engineStats = &statsRegistry{
+	limiters: map[string]UtilizationReporter{},
+	queues:   map[string]*limiter.RunQueue{},
+}
+
+func (s *statsRegistry) record(change StateChange) {
+	counter := &s.activeRuns
+	if change.Component != "" {
+		counter = &s.runningComponents
+	}
+	if change.NewStatus == RUNNING && change.OldStatus != RUNNING {
+		counter.Add(1)
+	} else if change.OldStatus == RUNNING && change.NewStatus != RUNNING {
+		counter.Add(-1)
+	}
+}
+
+/* RegisterLimiter makes the utilization of the limiter part of Stats */
+func RegisterLimiter(name string, l UtilizationReporter) {
+	engineStats.lock.Lock()
+	defer engineStats.lock.Unlock()
+	engineStats.limiters[name] = l
+}
+
+/* RegisterRunQueue makes the queue length of the run queue part of Stats */
+func RegisterRunQueue(name string, q *limiter.RunQueue) {
+	engineStats.lock.Lock()
+	defer engineStats.lock.Unlock()
+	engineStats.queues[name] = q
+}
+
+func Stats() EngineStats {
+	stats := EngineStats{
+		ActiveRuns:        engineStats.activeRuns.Load(),
+		RunningComponents: engineStats.runningComponents.Load(),
+		Goroutines:        runtime.NumGoroutine(),
+		Limiters:          map[string]LimiterStats{},
+		Queues:            map[string]QueueStats{},
+	}
+
+	engineStats.lock.Lock()
+	defer engineStats.lock.Unlock()
+	for name, l := range engineStats.limiters {
+		ls := LimiterStats{InUse: l.InUse(), Capacity: l.Capacity()}
+		if ls.Capacity > 0 {
+			ls.Utilization = float64(ls.InUse) / float64(ls.Capacity)
+		}
+		stats.Limiters[name] = ls
+	}
+	for name, q := range engineStats.queues {
+		stats.Queues[name] = QueueStats{Running: q.Running(), Queued: q.Queued()}
+	}
+	return stats
+}
+
+/* PublishStats publishes Stats as an expvar variable (served on /debug/vars), should be called once per name */
+func PublishStats(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return Stats()
+	}))
+}
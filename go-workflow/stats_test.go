@@ -0,0 +1,56 @@
+package goworkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineStats(t *testing.T) {
+	ctx := context.Background()
+	ocrLimiter := limiter.NewConcurrencyLimiter(4)
+	goworkflow.RegisterLimiter("ocr", ocrLimiter)
+	queue := limiter.NewRunQueue(1)
+	goworkflow.RegisterRunQueue("documents", queue)
+	goworkflow.PublishStats("goworkflow-test")
+
+	baseline := goworkflow.Stats()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](ctx)
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		close(started)
+		<-release
+		return nil
+	}), &goworkflow.ComponentConfig{ConcurrencyLimiter: ocrLimiter})
+
+	executor := goworkflow.NewRunExecutor[context.Context, Config, Data](queue)
+	run := executor.Submit(ctx, wf, Config{}, &Data{})
+	queued := executor.Submit(ctx, goworkflow.NewWorkflow[context.Context, Config, Data](ctx), Config{}, &Data{})
+	<-started
+
+	stats := goworkflow.Stats()
+	assert.Equal(t, baseline.ActiveRuns+1, stats.ActiveRuns)
+	assert.Equal(t, baseline.RunningComponents+1, stats.RunningComponents)
+	assert.Greater(t, stats.Goroutines, 0)
+	assert.Equal(t, goworkflow.LimiterStats{InUse: 1, Capacity: 4, Utilization: 0.25}, stats.Limiters["ocr"])
+	assert.Equal(t, goworkflow.QueueStats{Running: 1, Queued: 1}, stats.Queues["documents"])
+
+	published := goworkflow.EngineStats{}
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("goworkflow-test").String()), &published))
+	assert.Equal(t, stats.ActiveRuns, published.ActiveRuns)
+
+	close(release)
+	run.Wait()
+	queued.Wait()
+
+	stats = goworkflow.Stats()
+	assert.Equal(t, baseline.ActiveRuns, stats.ActiveRuns)
+	assert.Equal(t, baseline.RunningComponents, stats.RunningComponents)
+}
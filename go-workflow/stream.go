@@ -0,0 +1,258 @@
+package goworkflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+/* StreamRef identifies a component added to a Stream, dependencies refer to it */
+type StreamRef int
+
+type StreamOptions struct {
+	// Name of the run, used in state changes and profiles
+	Name string
+	// MaxActive: added components which haven't finished yet, Add blocks while the limit is reached. 1024 when 0
+	MaxActive int
+	// Bulkheads of the components with a ComponentConfig.Bulkhead, see Workflow.SetBulkheads
+	Bulkheads *Bulkheads
+}
+
+var ErrStreamClosed = errors.New("stream is closed")
+
+/*
+Stream runs a graph built while it executes, e.g. one component per row of a big table: a component starts as
+soon as its dependencies finished, even while later components are still being added. Dependencies always
+refer to components added before, so streams have no cycles. Memory is bounded by MaxActive and the components
+which didn't succeed: finished components are released, only the status of the failed and skipped ones is kept
+for the components which may still depend on them.
+Components hold their ConcurrencyLimiter, SlotPool, Limiter, TokenLimiter and Bulkhead while they execute like in
+Execute. Branch bulkheads (AssignBulkhead), result caching and MaxParallelComponents don't apply to streams.
+*/
+type Stream[CT context.Context, C any, T any] struct {
+	ctx       CT
+	wf        *Workflow[CT, C, T]
+	tracker   *DataTracker[C, T]
+	maxActive int
+
+	lock    sync.Mutex
+	changed *sync.Cond
+	closed  bool
+	next    int
+	active  map[StreamRef]chan struct{}
+	// unsuccessful: outcomes of the finished components which aren't DONE, the others succeeded
+	unsuccessful map[StreamRef]streamOutcome
+	failed       int
+	warnings     int
+	running      sync.WaitGroup
+}
+
+type streamOutcome struct {
+	status   Status
+	optional bool
+}
+
+func NewStream[CT context.Context, C any, T any](ctx CT, config C, data *T, opts ...*StreamOptions) *Stream[CT, C, T] {
+	if len(opts) > 1 {
+		panic("only one StreamOptions is allowed")
+	}
+	if data == nil {
+		panic("data cannot be nil")
+	}
+	opt := &StreamOptions{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	s := &Stream[CT, C, T]{
+		ctx:          ctx,
+		wf:           NewWorkflow[CT, C, T](ctx),
+		maxActive:    opt.MaxActive,
+		active:       map[StreamRef]chan struct{}{},
+		unsuccessful: map[StreamRef]streamOutcome{},
+	}
+	if s.maxActive <= 0 {
+		s.maxActive = 1024
+	}
+	s.changed = sync.NewCond(&s.lock)
+	// wake up Add calls waiting for a slot
+	context.AfterFunc(ctx, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.changed.Broadcast()
+	})
+	s.wf.name = opt.Name
+	s.wf.bulkheads = opt.Bulkheads
+	s.wf.executed = true
+	s.tracker = &DataTracker[C, T]{Config: config, store: &dataStore[T]{data: data}, ctx: ctx}
+	s.wf.setWorkflowStatus(RUNNING, "")
+	return s
+}
+
+/* Id: unique id of the run */
+func (s *Stream[CT, C, T]) Id() string {
+	return s.wf.id
+}
+
+/* OnStateChange: see Workflow.OnStateChange, register hooks before adding components */
+func (s *Stream[CT, C, T]) OnStateChange(hook StateChangeHook) {
+	s.wf.AddStateListener(hook)
+}
+
+/*
+Add schedules a component which runs once all deps finished, like Component.AddDependencies. It blocks while
+MaxActive components are unfinished and fails when the stream is closed, its context is done or the input is
+invalid (see InputError).
+Unknown dependencies panic.
+*/
+func (s *Stream[CT, C, T]) Add(componentCfg makeComponentConfig[CT, C, T], deps []StreamRef, cfgs ...*ComponentConfig) (StreamRef, error) {
+	if len(cfgs) > 1 {
+		panic("only one AddComponentConfig is allowed")
+	}
+	if componentCfg.Name == "" || componentCfg.Executor == nil {
+		panic("name and executor cannot be empty")
+	}
+	var cfg *ComponentConfig
+	if len(cfgs) == 1 {
+		cfg = cfgs[0]
+	}
+	if err := validateInput(componentCfg.Name, componentCfg.Validate, componentCfg.Input, cfg); err != nil {
+		return 0, err
+	}
+	var bulkhead *limiter.ConcurrencyLimiter
+	if cfg != nil && cfg.Bulkhead != "" {
+		if s.wf.bulkheads != nil {
+			bulkhead = s.wf.bulkheads.Limiter(cfg.Bulkhead)
+		}
+		if bulkhead == nil {
+			return 0, fmt.Errorf("%s: unknown bulkhead %s", componentCfg.Name, cfg.Bulkhead)
+		}
+	}
+	s.lock.Lock()
+	for !s.closed && len(s.active) >= s.maxActive && s.ctx.Err() == nil {
+		s.changed.Wait()
+	}
+	if s.closed {
+		s.lock.Unlock()
+		return 0, ErrStreamClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.lock.Unlock()
+		return 0, err
+	}
+	for _, dep := range deps {
+		if dep < 0 || int(dep) >= s.next {
+			s.lock.Unlock()
+			panic(fmt.Sprintf("unknown dependency %d of %s", dep, componentCfg.Name))
+		}
+	}
+	ref := StreamRef(s.next)
+	s.next++
+	done := make(chan struct{})
+	s.active[ref] = done
+	s.lock.Unlock()
+
+	c := &component[CT, C, T]{
+		id:       s.wf.id + "/" + strconv.Itoa(int(ref)),
+		seq:      int(ref),
+		Name:     componentCfg.Name,
+		input:    componentCfg.Input,
+		executor: componentCfg.Executor,
+		status:   componentStatus{Status: PENDING},
+	}
+	c.addComponentCfg = cfg
+	s.running.Add(1)
+	go s.run(ref, c, bulkhead, append([]StreamRef{}, deps...), done)
+	return ref, nil
+}
+
+/* dependency: outcome of dep once it finished */
+func (s *Stream[CT, C, T]) dependency(dep StreamRef) streamOutcome {
+	s.lock.Lock()
+	done, ok := s.active[dep]
+	s.lock.Unlock()
+	if ok {
+		<-done
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if outcome, ok := s.unsuccessful[dep]; ok {
+		return outcome
+	}
+	return streamOutcome{status: DONE}
+}
+
+func (s *Stream[CT, C, T]) run(ref StreamRef, c *component[CT, C, T], bulkhead *limiter.ConcurrencyLimiter, deps []StreamRef, done chan struct{}) {
+	defer s.running.Done()
+	status, cause := DONE, ""
+	for _, dep := range deps {
+		outcome := s.dependency(dep)
+		if outcome.status == ERROR && !outcome.optional {
+			status, cause = ERROR, fmt.Sprintf("component dependency failed: %d", dep)
+			break
+		}
+		if outcome.status != DONE {
+			status, cause = SKIPPED, "dependency outcome not met"
+		}
+	}
+	if status == DONE {
+		c.markReady()
+		componentCtx := s.wf.scopedContext(s.ctx, c)
+		releaseBulkhead := func() {}
+		if bulkhead != nil {
+			releaseBulkhead = s.wf.acquireLimiter(componentCtx, c, "bulkhead:"+c.addComponentCfg.Bulkhead, bulkhead.Acquire, bulkhead.Release)
+		}
+		componentCtx, release := s.wf.acquireComponentLimiters(componentCtx, c)
+		s.wf.setComponentStatus(c, RUNNING, "")
+		if err := s.wf.executeWithRetry(s.ctx, componentCtx, c, s.tracker); err != nil {
+			log.Println("Stream.Add:Error:Component execution failed for component:", c.id, err)
+			c.setFailure(err)
+			s.wf.captureFailure(componentCtx, c, s.tracker, err)
+			status, cause = ERROR, err.Error()
+		}
+		release()
+		releaseBulkhead()
+	}
+	s.wf.setComponentStatus(c, status, cause)
+
+	optional := c.addComponentCfg != nil && c.addComponentCfg.Optional
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if status != DONE {
+		s.unsuccessful[ref] = streamOutcome{status: status, optional: optional}
+	}
+	delete(s.active, ref)
+	if status == ERROR && optional {
+		s.warnings++
+	} else if status == ERROR {
+		s.failed++
+	}
+	close(done)
+	s.changed.Broadcast()
+}
+
+/*
+Close ends the stream and waits for the added components: ERROR when a component failed, DONE_WITH_WARNINGS
+when only optional components failed. Add fails after Close.
+*/
+func (s *Stream[CT, C, T]) Close() Status {
+	s.lock.Lock()
+	s.closed = true
+	s.changed.Broadcast()
+	s.lock.Unlock()
+	s.running.Wait()
+
+	status, cause := DONE, ""
+	if s.failed > 0 {
+		status, cause = ERROR, fmt.Sprintf("%d components failed", s.failed)
+	} else if s.warnings > 0 {
+		status, cause = DONE_WITH_WARNINGS, fmt.Sprintf("%d optional components failed", s.warnings)
+	}
+	s.wf.setWorkflowStatus(status, cause)
+	s.wf.flushBackground()
+	return status
+}
@@ -0,0 +1,134 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	data := &Data{}
+	s := goworkflow.NewStream[context.Context, Config, Data](context.TODO(), Config{}, data, &goworkflow.StreamOptions{Name: "rows", MaxActive: 4})
+	var active, maxActive atomic.Int32
+	statuses := map[goworkflow.Status]int{}
+	s.OnStateChange(func(change goworkflow.StateChange) {
+		if change.Component != "" {
+			statuses[change.NewStatus]++
+		}
+	})
+	row := func(i int, err error) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for m := maxActive.Load(); n > m && !maxActive.CompareAndSwap(m, n); m = maxActive.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			return err
+		}
+	}
+	refs := []goworkflow.StreamRef{}
+	for i := 0; i < 20; i++ {
+		ref, err := s.Add(goworkflow.MakeComponent(fmt.Sprint("Row", i), nil, row(i, nil)), nil)
+		assert.NoError(t, err)
+		refs = append(refs, ref)
+	}
+	summary, err := s.Add(goworkflow.MakeComponent("Summary", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = "summarized" })
+		return nil
+	}), refs)
+	assert.NoError(t, err)
+	failing, _ := s.Add(goworkflow.MakeComponent("Enrich", nil, row(0, errors.New("lookup failed"))), []goworkflow.StreamRef{summary}, &goworkflow.ComponentConfig{Optional: true})
+	s.Add(goworkflow.MakeComponent("Publish", nil, row(0, nil)), []goworkflow.StreamRef{failing})
+
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, s.Close())
+	assert.Equal(t, "summarized", data.A)
+	assert.LessOrEqual(t, maxActive.Load(), int32(4))
+	assert.Equal(t, 21, statuses[goworkflow.DONE])
+	assert.Equal(t, 1, statuses[goworkflow.ERROR])
+	assert.Equal(t, 1, statuses[goworkflow.SKIPPED])
+
+	_, err = s.Add(goworkflow.MakeComponent("Late", nil, row(0, nil)), nil)
+	assert.ErrorIs(t, err, goworkflow.ErrStreamClosed)
+	assert.Panics(t, func() {
+		goworkflow.NewStream[context.Context, Config, Data](context.TODO(), Config{}, &Data{}).Add(goworkflow.MakeComponent("A", nil, row(0, nil)), []goworkflow.StreamRef{3})
+	})
+}
+
+func TestStreamFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := goworkflow.NewStream[context.Context, Config, Data](ctx, Config{}, &Data{}, &goworkflow.StreamOptions{MaxActive: 1})
+	block := make(chan struct{})
+	first, _ := s.Add(goworkflow.MakeComponent("Extract", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		<-block
+		return errors.New("corrupt row")
+	}), nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	// no slot until the first component finished, the cancelled context ends the wait
+	_, err := s.Add(goworkflow.MakeComponent("Load", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}), []goworkflow.StreamRef{first})
+	assert.ErrorIs(t, err, context.Canceled)
+	close(block)
+	assert.Equal(t, goworkflow.ERROR, s.Close())
+}
+
+/* countingLimiter counts the tickets it hands out */
+type countingLimiter struct {
+	acquired atomic.Int32
+	held     atomic.Int32
+}
+
+func (l *countingLimiter) Acquire() {
+	l.acquired.Add(1)
+	l.held.Add(1)
+}
+
+func (l *countingLimiter) Release() { l.held.Add(-1) }
+
+func TestStreamLimiters(t *testing.T) {
+	s := goworkflow.NewStream[context.Context, Config, Data](context.TODO(), Config{}, &Data{}, &goworkflow.StreamOptions{
+		Bulkheads: goworkflow.NewBulkheads(map[string]int{"ocr": 1}),
+	})
+	pool := limiter.NewSlotPool("gpu-0", "gpu-1")
+	custom := &countingLimiter{}
+	var active, maxActive atomic.Int32
+	slots := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		_, err := s.Add(goworkflow.MakeComponent(fmt.Sprint("Page", i), nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for m := maxActive.Load(); n > m && !maxActive.CompareAndSwap(m, n); m = maxActive.Load() {
+			}
+			slot, _ := limiter.SlotFromContext(ctx)
+			slots <- slot
+			time.Sleep(time.Millisecond)
+			return nil
+		}), nil, &goworkflow.ComponentConfig{Bulkhead: "ocr", SlotPool: pool, Limiter: custom})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, goworkflow.DONE, s.Close())
+	assert.Equal(t, int32(1), maxActive.Load())
+	assert.Equal(t, int32(10), custom.acquired.Load())
+	assert.Zero(t, custom.held.Load())
+	close(slots)
+	for slot := range slots {
+		assert.Contains(t, []string{"gpu-0", "gpu-1"}, slot)
+	}
+
+	s = goworkflow.NewStream[context.Context, Config, Data](context.TODO(), Config{}, &Data{})
+	_, err := s.Add(goworkflow.MakeComponent("Page", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return nil
+	}), nil, &goworkflow.ComponentConfig{Bulkhead: "ocr"})
+	assert.EqualError(t, err, "Page: unknown bulkhead ocr")
+	s.Close()
+}
@@ -0,0 +1,227 @@
+/*
+Package subprocess executes components in a child process, so a crash of untrusted or crash-prone native code
+(e.g. a PDF rasterizer) fails the component instead of killing the whole service.
+
+The child is the same binary started again: functions are registered by name with Register (e.g. in init)
+and main calls Main before anything else, which runs the requested function when the process is a child.
+Input and output are serialized as json.
+*/
+package subprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+/* env variable naming the function a child process runs */
+const functionEnv = "GOWORKFLOW_SUBPROCESS_FUNCTION"
+
+/* the child writes its response to file descriptor 3, stdout and stderr stay usable by the function */
+const responseFd = 3
+
+type function func(ctx context.Context, input json.RawMessage) (any, error)
+
+var registry = struct {
+	lock      sync.Mutex
+	functions map[string]function
+}{functions: map[string]function{}}
+
+type response struct {
+	Output json.RawMessage `json:"output,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+/* Register makes fn runnable in a child process under name, the parent and the child must register the same names */
+func Register[In any, Out any](name string, fn func(ctx context.Context, input In) (Out, error)) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if _, ok := registry.functions[name]; ok {
+		panic("function already registered: " + name)
+	}
+	registry.functions[name] = func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var input In
+		if err := json.Unmarshal(raw, &input); err != nil {
+			return nil, fmt.Errorf("invalid input: %w", err)
+		}
+		return fn(ctx, input)
+	}
+}
+
+/* IsChild: the process was started to run a registered function */
+func IsChild() bool {
+	return os.Getenv(functionEnv) != ""
+}
+
+/* Main runs the requested function and exits when the process is a child, it returns immediately otherwise */
+func Main() {
+	if !IsChild() {
+		return
+	}
+	out := os.NewFile(responseFd, "response")
+	resp := runChild(os.Getenv(functionEnv), os.Stdin)
+	if err := json.NewEncoder(out).Encode(resp); err != nil {
+		fmt.Fprintln(os.Stderr, "subprocess.Main:Error:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func runChild(name string, stdin io.Reader) response {
+	registry.lock.Lock()
+	fn, ok := registry.functions[name]
+	registry.lock.Unlock()
+	if !ok {
+		return response{Error: "function not registered: " + name}
+	}
+	input, err := io.ReadAll(stdin)
+	if err != nil {
+		return response{Error: err.Error()}
+	}
+	output, err := fn(context.Background(), input)
+	if err != nil {
+		return response{Error: err.Error()}
+	}
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return response{Error: fmt.Sprintf("invalid output: %s", err)}
+	}
+	return response{Output: raw}
+}
+
+type Options struct {
+	// Command: binary and arguments of the child, the current executable when empty.
+	// It must call Main and register the function
+	Command []string
+	// Env: additional environment of the child
+	Env []string
+	// Stdout: output of the child, os.Stderr when nil
+	Stdout io.Writer
+}
+
+/* ErrCrashed: the child process exited without a response, e.g. on a segfault */
+var ErrCrashed = errors.New("component process crashed")
+
+/* the last lines of stderr are part of the crash error */
+const stderrTail = 2048
+
+/* Run executes the function registered under name in a child process, the child is killed when ctx is done */
+func Run[In any, Out any](ctx context.Context, name string, input In, opts ...*Options) (Out, error) {
+	var out Out
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	opt := &Options{}
+	if len(opts) == 1 && opts[0] != nil {
+		opt = opts[0]
+	}
+	command := opt.Command
+	if len(command) == 0 {
+		executable, err := os.Executable()
+		if err != nil {
+			return out, err
+		}
+		command = []string{executable}
+	}
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return out, fmt.Errorf("invalid input: %w", err)
+	}
+
+	responseReader, responseWriter, err := os.Pipe()
+	if err != nil {
+		return out, err
+	}
+	defer responseReader.Close()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(append(os.Environ(), opt.Env...), functionEnv+"="+name)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = opt.Stdout
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stderr
+	}
+	stderr := &tailBuffer{max: stderrTail}
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+	cmd.ExtraFiles = []*os.File{responseWriter}
+	if err := cmd.Start(); err != nil {
+		responseWriter.Close()
+		return out, err
+	}
+	// only the child keeps the write end open, reading ends when it exits
+	responseWriter.Close()
+	raw, readErr := io.ReadAll(responseReader)
+	waitErr := cmd.Wait()
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+
+	var resp response
+	if waitErr != nil || readErr != nil || json.Unmarshal(raw, &resp) != nil {
+		cause := waitErr
+		if cause == nil {
+			cause = errors.New("no response")
+		}
+		return out, fmt.Errorf("%w: %s: %s", ErrCrashed, cause, strings.TrimSpace(stderr.String()))
+	}
+	if resp.Error != "" {
+		return out, errors.New(resp.Error)
+	}
+	if err := json.Unmarshal(resp.Output, &out); err != nil {
+		return out, fmt.Errorf("invalid output: %w", err)
+	}
+	return out, nil
+}
+
+/*
+Component: component function running the function registered under name in a child process.
+input builds the input of the function from the component input, the config and the data,
+apply stores its output in the data store.
+*/
+func Component[CT context.Context, I any, C any, T any, In any, Out any](
+	name string,
+	input func(I, C, T) In,
+	apply func(*T, Out),
+	opts ...*Options,
+) goworkflow.ComponentFunction[CT, I, C, T] {
+	return func(ctx CT, ci I, dt *goworkflow.DataTracker[C, T]) error {
+		out, err := Run[In, Out](dt.Context(), name, input(ci, dt.Config, dt.GetData()), opts...)
+		if err != nil {
+			return err
+		}
+		dt.Update(func(data *T) {
+			apply(data, out)
+		})
+		return nil
+	}
+}
+
+type tailBuffer struct {
+	lock sync.Mutex
+	buf  []byte
+	max  int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return string(b.buf)
+}
@@ -0,0 +1,101 @@
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Page struct {
+	Document string
+	DPI      int
+}
+
+func init() {
+	Register("rasterize", func(ctx context.Context, page Page) (string, error) {
+		// stdout of the function doesn't interfere with the response
+		fmt.Println("rasterizing", page.Document)
+		return fmt.Sprintf("%s@%d", page.Document, page.DPI), nil
+	})
+	Register("reject", func(ctx context.Context, page Page) (string, error) {
+		return "", errors.New("unsupported document " + page.Document)
+	})
+	Register("crash", func(ctx context.Context, page Page) (string, error) {
+		fmt.Fprintln(os.Stderr, "fatal error: unexpected signal")
+		os.Exit(2)
+		return "", nil
+	})
+	Register("hang", func(ctx context.Context, page Page) (string, error) {
+		time.Sleep(time.Minute)
+		return "", nil
+	})
+}
+
+/* the test binary is also the child process */
+func TestMain(m *testing.M) {
+	Main()
+	os.Exit(m.Run())
+}
+
+func TestRun(t *testing.T) {
+	out, err := Run[Page, string](context.TODO(), "rasterize", Page{Document: "invoice.pdf", DPI: 300})
+	assert.NoError(t, err)
+	assert.Equal(t, "invoice.pdf@300", out)
+
+	_, err = Run[Page, string](context.TODO(), "reject", Page{Document: "movie.mp4"})
+	assert.EqualError(t, err, "unsupported document movie.mp4")
+
+	_, err = Run[Page, string](context.TODO(), "unknown", Page{})
+	assert.EqualError(t, err, "function not registered: unknown")
+}
+
+func TestRunCrash(t *testing.T) {
+	_, err := Run[Page, string](context.TODO(), "crash", Page{})
+	assert.ErrorIs(t, err, ErrCrashed)
+	assert.ErrorContains(t, err, "exit status 2")
+	assert.ErrorContains(t, err, "fatal error: unexpected signal")
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := Run[Page, string](ctx, "hang", Page{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 10*time.Second)
+}
+
+type Config struct {
+	DPI int
+}
+
+type Data struct {
+	Raster string
+}
+
+func TestComponent(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	rasterize := Component[context.Context, string, Config, Data, Page, string]("rasterize",
+		func(document string, config Config, data Data) Page {
+			return Page{Document: document, DPI: config.DPI}
+		},
+		func(data *Data, raster string) {
+			data.Raster = raster
+		})
+	crash := Component[context.Context, string, Config, Data, Page, string]("crash",
+		func(document string, config Config, data Data) Page { return Page{} },
+		func(data *Data, raster string) {})
+	wf.AddComponent(goworkflow.MakeComponent("Rasterize", "scan.pdf", rasterize))
+	crashing := wf.AddComponent(goworkflow.MakeComponent("Crash", "scan.pdf", crash), &goworkflow.ComponentConfig{Optional: true})
+
+	data, st, _ := wf.Execute(context.TODO(), Config{DPI: 150}, &Data{})
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	assert.Equal(t, "scan.pdf@150", data.Raster)
+	assert.Contains(t, crashing.Status().ErrorMessage, "component process crashed")
+}
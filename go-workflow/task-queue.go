@@ -0,0 +1,74 @@
+package goworkflow
+
+import "sync"
+
+/*
+taskQueue runs tasks in the order they are dispatched, one at a time, on a goroutine started on demand. State
+listeners hand it the work which must not run under the state lock (user hooks, I/O), Execute waits for it.
+*/
+type taskQueue struct {
+	lock    sync.Mutex
+	idle    *sync.Cond
+	tasks   []func()
+	running bool
+}
+
+func (q *taskQueue) dispatch(task func()) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.tasks = append(q.tasks, task)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+func (q *taskQueue) run() {
+	for {
+		q.lock.Lock()
+		if len(q.tasks) == 0 {
+			q.running = false
+			if q.idle != nil {
+				q.idle.Broadcast()
+			}
+			q.lock.Unlock()
+			return
+		}
+		task := q.tasks[0]
+		q.tasks = q.tasks[1:]
+		q.lock.Unlock()
+		task()
+	}
+}
+
+/* flush waits until all dispatched tasks ran */
+func (q *taskQueue) flush() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.idle == nil {
+		q.idle = sync.NewCond(&q.lock)
+	}
+	for q.running {
+		q.idle.Wait()
+	}
+}
+
+/* backgroundQueue: new queue flushed when Execute returns */
+func (wf *Workflow[CT, C, T]) backgroundQueue() *taskQueue {
+	q := &taskQueue{}
+	wf.stateLock.Lock()
+	defer wf.stateLock.Unlock()
+	wf.backgroundQueues = append(wf.backgroundQueues, q)
+	return q
+}
+
+/* flushBackground waits for the background work of the run, then for the OnStateChange hook */
+func (wf *Workflow[CT, C, T]) flushBackground() {
+	wf.stateLock.Lock()
+	queues := wf.backgroundQueues
+	wf.stateLock.Unlock()
+	for _, q := range queues {
+		q.flush()
+	}
+	wf.stateChangeQueue.flush()
+}
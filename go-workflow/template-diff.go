@@ -0,0 +1,152 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+/* Edge: To depends on From */
+type Edge struct {
+	From string
+	To   string
+	// Kind of the dependency in template diffs: "" when To requires the success of From, "failure", "completion",
+	// "any of" or "first of" (numbered when To has several groups of the kind, e.g. "any of 2")
+	Kind string `json:",omitempty"`
+}
+
+func (e Edge) String() string {
+	if e.Kind != "" {
+		return fmt.Sprintf("%s -> %s (%s)", e.From, e.To, e.Kind)
+	}
+	return fmt.Sprintf("%s -> %s", e.From, e.To)
+}
+
+/* Edges: dependency edges of the workflow, sorted */
+func (wf *Workflow[CT, C, T]) Edges() []Edge {
+	d := wf.dependencyManager
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	edges := []Edge{}
+	for dependencyId, dependents := range d.dependencyGraph {
+		for componentId := range dependents {
+			edges = append(edges, Edge{From: d.componentIdToName[dependencyId], To: d.componentIdToName[componentId]})
+		}
+	}
+	sortEdges(edges)
+	return edges
+}
+
+type TemplateDiff struct {
+	AddedComponents   []string
+	RemovedComponents []string
+	// components present in both templates with a different input or ComponentConfig
+	ChangedComponents []string
+	AddedEdges        []Edge
+	RemovedEdges      []Edge
+}
+
+func (d TemplateDiff) Empty() bool {
+	return len(d.AddedComponents) == 0 && len(d.RemovedComponents) == 0 && len(d.ChangedComponents) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+/* String: readable summary of the diff, e.g. for code reviews */
+func (d TemplateDiff) String() string {
+	lines := []string{}
+	for _, c := range d.AddedComponents {
+		lines = append(lines, "+ component "+c)
+	}
+	for _, c := range d.RemovedComponents {
+		lines = append(lines, "- component "+c)
+	}
+	for _, c := range d.ChangedComponents {
+		lines = append(lines, "~ component "+c)
+	}
+	for _, e := range d.AddedEdges {
+		lines = append(lines, "+ edge "+e.String())
+	}
+	for _, e := range d.RemovedEdges {
+		lines = append(lines, "- edge "+e.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *Template[CT, C, T]) edges() map[Edge]bool {
+	edges := map[Edge]bool{}
+	for _, tc := range t.components {
+		cc := CanonicalComponent{Name: tc.Name()}
+		tc.canonicalDependencies(&cc)
+		for _, e := range cc.edges() {
+			edges[e] = true
+		}
+	}
+	return edges
+}
+
+/*
+componentChanged: input or ComponentConfig of the components differ. Configs are compared through their canonical
+form: funcs (retry callbacks, cache keys...) can't be compared, only whether they are set
+*/
+func componentChanged[CT context.Context, C any, T any](a, b *TemplateComponent[CT, C, T]) bool {
+	ca, errA := a.canonical()
+	cb, errB := b.canonical()
+	if errA != nil || errB != nil {
+		// inputs which don't encode to json
+		return errA == nil || errB == nil || !reflect.DeepEqual(a.definition.Input, b.definition.Input)
+	}
+	// dependencies are compared as edges
+	return !reflect.DeepEqual(withoutDependencies(ca), withoutDependencies(cb))
+}
+
+func sortEdges(edges []Edge) {
+	slices.SortFunc(edges, func(a, b Edge) int {
+		if a.From != b.From {
+			return strings.Compare(a.From, b.From)
+		}
+		if a.To != b.To {
+			return strings.Compare(a.To, b.To)
+		}
+		return strings.Compare(a.Kind, b.Kind)
+	})
+}
+
+/* Diff: topological and config changes going from template a to template b */
+func Diff[CT context.Context, C any, T any](a, b *Template[CT, C, T]) TemplateDiff {
+	diff := TemplateDiff{}
+	for _, tc := range b.components {
+		old := a.Component(tc.Name())
+		if old == nil {
+			diff.AddedComponents = append(diff.AddedComponents, tc.Name())
+			continue
+		}
+		if componentChanged(old, tc) {
+			diff.ChangedComponents = append(diff.ChangedComponents, tc.Name())
+		}
+	}
+	for _, tc := range a.components {
+		if b.Component(tc.Name()) == nil {
+			diff.RemovedComponents = append(diff.RemovedComponents, tc.Name())
+		}
+	}
+
+	aEdges, bEdges := a.edges(), b.edges()
+	for e := range bEdges {
+		if !aEdges[e] {
+			diff.AddedEdges = append(diff.AddedEdges, e)
+		}
+	}
+	for e := range aEdges {
+		if !bEdges[e] {
+			diff.RemovedEdges = append(diff.RemovedEdges, e)
+		}
+	}
+	slices.Sort(diff.AddedComponents)
+	slices.Sort(diff.RemovedComponents)
+	slices.Sort(diff.ChangedComponents)
+	sortEdges(diff.AddedEdges)
+	sortEdges(diff.RemovedEdges)
+	return diff
+}
@@ -0,0 +1,113 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateDiff(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+
+	v1 := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := v1.AddComponent(goworkflow.MakeComponent("OCR", nil, noop))
+	layout := v1.AddComponent(goworkflow.MakeComponent("Layout", nil, noop))
+	v1.AddComponent(goworkflow.MakeComponent("Parameter", nil, noop)).AddDependencies(ocr, layout)
+
+	v2 := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr = v2.AddComponent(goworkflow.MakeComponent("OCR", nil, noop), &goworkflow.ComponentConfig{ConcurrencyLimiter: limiter.NewConcurrencyLimiter(5)})
+	tables := v2.AddComponent(goworkflow.MakeComponent("Tables", nil, noop))
+	tables.AddDependencies(ocr)
+	v2.AddComponent(goworkflow.MakeComponent("Parameter", nil, noop)).AddDependencies(ocr, tables)
+
+	diff := goworkflow.Diff(v1, v2)
+	assert.Equal(t, []string{"Tables"}, diff.AddedComponents)
+	assert.Equal(t, []string{"Layout"}, diff.RemovedComponents)
+	assert.Equal(t, []string{"OCR"}, diff.ChangedComponents)
+	assert.Equal(t, []goworkflow.Edge{{From: "OCR", To: "Tables"}, {From: "Tables", To: "Parameter"}}, diff.AddedEdges)
+	assert.Equal(t, []goworkflow.Edge{{From: "Layout", To: "Parameter"}}, diff.RemovedEdges)
+	assert.Equal(t, "+ component Tables\n- component Layout\n~ component OCR\n+ edge OCR -> Tables\n+ edge Tables -> Parameter\n- edge Layout -> Parameter", diff.String())
+
+	assert.True(t, goworkflow.Diff(v1, v1).Empty())
+}
+
+func TestTemplateDiffDependencyKinds(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+	build := func(depend func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data])) *goworkflow.Template[context.Context, Config, Data] {
+		tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+		x := tpl.AddComponent(goworkflow.MakeComponent("x", nil, noop))
+		y := tpl.AddComponent(goworkflow.MakeComponent("y", nil, noop))
+		depend(tpl.AddComponent(goworkflow.MakeComponent("z", nil, noop)), x, y)
+		return tpl
+	}
+	base := build(func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data]) { z.AddDependencies(x, y) })
+	declared, err := base.Canonical()
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		depend func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data])
+		diff   string
+	}{
+		{func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data]) {
+			z.AddDependenciesOn(goworkflow.OnFailure, x)
+			z.AddDependencies(y)
+		}, "+ edge x -> z (failure)\n- edge x -> z"},
+		{func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data]) {
+			z.AddDependenciesOn(goworkflow.OnCompletion, x, y)
+		}, "+ edge x -> z (completion)\n+ edge y -> z (completion)\n- edge x -> z\n- edge y -> z"},
+		{func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data]) {
+			z.AddAnyOfDependencies(x, y)
+		}, "+ edge x -> z (any of)\n+ edge y -> z (any of)\n- edge x -> z\n- edge y -> z"},
+		{func(z, x, y *goworkflow.TemplateComponent[context.Context, Config, Data]) {
+			z.AddFirstOfDependencies(x)
+			z.AddFirstOfDependencies(y)
+		}, "+ edge x -> z (first of 1)\n+ edge y -> z (first of 2)\n- edge x -> z\n- edge y -> z"},
+	} {
+		changed := build(tc.depend)
+		diff := goworkflow.Diff(base, changed)
+		assert.Equal(t, tc.diff, diff.String())
+		// drift reports the same change
+		drift, err := changed.Drift(declared)
+		assert.NoError(t, err)
+		assert.Equal(t, diff, drift.TemplateDiff)
+	}
+}
+
+func TestTemplateDiffClone(t *testing.T) {
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	tpl.AddComponent(goworkflow.MakeComponent("OCR", nil, noop), &goworkflow.ComponentConfig{
+		Retry:         &goworkflow.RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return true }},
+		ValidateInput: func(input goworkflow.ComponentInput) error { return nil },
+		Writes:        []string{"A"},
+		Cache:         &goworkflow.CachePolicy{Cache: goworkflow.NewLRUCache(1), Key: func(input goworkflow.ComponentInput) string { return "" }},
+	})
+	tpl.Freeze()
+	clone := tpl.Clone()
+	assert.True(t, goworkflow.Diff(tpl, clone).Empty())
+
+	// the policies of the clone are its own
+	clone.Component("OCR").Config().Retry.MaxAttempts = 5
+	clone.Component("OCR").Config().Cache.TTL = time.Hour
+	assert.Equal(t, 3, tpl.Component("OCR").Config().Retry.MaxAttempts)
+	assert.Zero(t, tpl.Component("OCR").Config().Cache.TTL)
+	assert.Equal(t, []string{"OCR"}, goworkflow.Diff(tpl, clone).ChangedComponents)
+
+	clone = tpl.Clone()
+	clone.Component("OCR").SetConfig(&goworkflow.ComponentConfig{Retry: &goworkflow.RetryPolicy{MaxAttempts: 3}})
+	assert.Equal(t, []string{"OCR"}, goworkflow.Diff(tpl, clone).ChangedComponents)
+}
+
+func TestWorkflowEdges(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("", 0, nil)))
+	parse := wf.AddComponent(goworkflow.MakeComponent("Parse", nil, ocrProvider("", 0, nil)))
+	parse.AddDependencies(ocr)
+	wf.AddComponent(goworkflow.MakeComponent("Index", nil, ocrProvider("", 0, nil))).AddDependencies(ocr, parse)
+
+	assert.Equal(t, []goworkflow.Edge{{From: "OCR", To: "Index"}, {From: "OCR", To: "Parse"}, {From: "Parse", To: "Index"}}, wf.Edges())
+}
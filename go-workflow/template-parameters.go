@@ -0,0 +1,255 @@
+package goworkflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ParameterType string
+
+const (
+	StringParameter   ParameterType = "string"
+	IntParameter      ParameterType = "int"
+	FloatParameter    ParameterType = "float"
+	BoolParameter     ParameterType = "bool"
+	DurationParameter ParameterType = "duration"
+)
+
+/*
+Parameter of a template, Name is the dot separated path of the config field it sets (json names),
+e.g. ocr.dpi. Values of a DurationParameter are strings like 30s, they set time.Duration fields.
+*/
+type Parameter struct {
+	Name        string
+	Type        ParameterType
+	Description string
+	// Default: value when no source has one, nil for none. Parameters without value and default keep the base config
+	Default  any
+	Required bool
+	// Enum: allowed values, any value when empty
+	Enum []any
+}
+
+/* ParameterSource looks up the raw value of a parameter by name */
+type ParameterSource func(name string) (any, bool)
+
+/* ParametersFromMap: values by parameter name, flat ("ocr.dpi") or nested ({"ocr": {"dpi": 300}}) */
+func ParametersFromMap(values map[string]any) ParameterSource {
+	return func(name string) (any, bool) {
+		if v, ok := values[name]; ok {
+			return v, true
+		}
+		var current any = values
+		for _, key := range strings.Split(name, ".") {
+			m, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = m[key]; !ok {
+				return nil, false
+			}
+		}
+		return current, true
+	}
+}
+
+/* ParametersFromJSON: values of a json object, like ParametersFromMap */
+func ParametersFromJSON(raw []byte) (ParameterSource, error) {
+	values := map[string]any{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("parameters should be a json object: %w", err)
+	}
+	return ParametersFromMap(values), nil
+}
+
+/* ParametersFromEnv: environment variables named prefix + the upper case name, dots replaced by _, e.g. INVOICES_OCR_DPI */
+func ParametersFromEnv(prefix string) ParameterSource {
+	return func(name string) (any, bool) {
+		return os.LookupEnv(prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name)))
+	}
+}
+
+/* AddParameter declares a parameter, invalid declarations panic */
+func (t *Template[CT, C, T]) AddParameter(p Parameter) {
+	t.mustNotBeFrozen()
+	if p.Name == "" {
+		panic("parameter name cannot be empty")
+	}
+	if slices.ContainsFunc(t.parameters, func(existing Parameter) bool { return existing.Name == p.Name }) {
+		panic(fmt.Sprintf("parameter %s already exists in template %s", p.Name, t.Name))
+	}
+	enum := make([]any, len(p.Enum))
+	for i, v := range p.Enum {
+		converted, err := convertParameter(p.Type, v)
+		if err != nil {
+			panic(fmt.Sprintf("parameter %s: invalid enum value: %v", p.Name, err))
+		}
+		enum[i] = converted
+	}
+	p.Enum = enum
+	if p.Default != nil {
+		converted, err := convertParameter(p.Type, p.Default)
+		if err != nil {
+			panic(fmt.Sprintf("parameter %s: invalid default: %v", p.Name, err))
+		}
+		if len(enum) > 0 && !slices.Contains(enum, converted) {
+			panic(fmt.Sprintf("parameter %s: default %v is not one of %v", p.Name, p.Default, p.Enum))
+		}
+	}
+	t.parameters = append(t.parameters, p)
+}
+
+/* Parameters: declared parameters, in declaration order */
+func (t *Template[CT, C, T]) Parameters() []Parameter {
+	return slices.Clone(t.parameters)
+}
+
+/* convertParameter: value of type tp, as json encodes the config field */
+func convertParameter(tp ParameterType, value any) (any, error) {
+	s, isString := value.(string)
+	switch tp {
+	case StringParameter:
+		if !isString {
+			return nil, fmt.Errorf("expected a string, got %v", value)
+		}
+		return s, nil
+	case IntParameter:
+		if isString {
+			return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		}
+		f, ok := number(value)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("expected an int, got %v", value)
+		}
+		return int64(f), nil
+	case FloatParameter:
+		if isString {
+			return strconv.ParseFloat(strings.TrimSpace(s), 64)
+		}
+		f, ok := number(value)
+		if !ok {
+			return nil, fmt.Errorf("expected a float, got %v", value)
+		}
+		return f, nil
+	case BoolParameter:
+		if isString {
+			return strconv.ParseBool(strings.TrimSpace(s))
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool, got %v", value)
+		}
+		return b, nil
+	case DurationParameter:
+		if d, ok := value.(time.Duration); ok {
+			return int64(d), nil
+		}
+		if !isString {
+			return nil, fmt.Errorf("expected a duration like 30s, got %v", value)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return int64(d), err
+	}
+	return nil, fmt.Errorf("unknown parameter type %q", tp)
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+/*
+resolveParameters: config values of the parameters (nested by path), the first source with a value wins over
+the default. All missing and invalid parameters are reported together as a *ValidationError.
+*/
+func (t *Template[CT, C, T]) resolveParameters(sources []ParameterSource) (map[string]any, error) {
+	values := map[string]any{}
+	validationErr := &ValidationError{}
+	for _, p := range t.parameters {
+		var raw any
+		found := false
+		for _, source := range sources {
+			if raw, found = source(p.Name); found {
+				break
+			}
+		}
+		if !found {
+			if p.Default == nil {
+				if p.Required {
+					validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("parameter %s is required", p.Name))
+				}
+				continue
+			}
+			raw = p.Default
+		}
+		value, err := convertParameter(p.Type, raw)
+		if err != nil {
+			validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("parameter %s: %v", p.Name, err))
+			continue
+		}
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, value) {
+			validationErr.Problems = append(validationErr.Problems, fmt.Sprintf("parameter %s: %v is not one of %v", p.Name, raw, p.Enum))
+			continue
+		}
+		path := strings.Split(p.Name, ".")
+		target := values
+		for _, key := range path[:len(path)-1] {
+			next, ok := target[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				target[key] = next
+			}
+			target = next
+		}
+		target[path[len(path)-1]] = value
+	}
+	if len(validationErr.Problems) > 0 {
+		return nil, validationErr
+	}
+	return values, nil
+}
+
+/* ResolveConfig: base with the parameters of the template set from sources, see ExecuteWithParameters */
+func (t *Template[CT, C, T]) ResolveConfig(base C, sources ...ParameterSource) (C, error) {
+	values, err := t.resolveParameters(sources)
+	if err != nil {
+		return base, err
+	}
+	return MergeConfig(base, ConfigOverlay{Name: "parameters", Values: values})
+}
+
+/*
+ExecuteWithParameters runs a fresh workflow with the parameters resolved into base: a parameter takes the value
+of the first source which has one, e.g. ParametersFromMap(flags), ParametersFromEnv("INVOICES_"), else its default.
+Missing required and invalid parameters fail the run like config validation.
+*/
+func (t *Template[CT, C, T]) ExecuteWithParameters(ctx CT, base C, data *T, sources ...ParameterSource) (*T, Status, error) {
+	wf := t.NewWorkflow(ctx)
+	values, err := t.resolveParameters(sources)
+	if err != nil {
+		wf.addBuildCheck(func() error { return err })
+	} else {
+		wf.AddConfigOverlay(ConfigOverlay{Name: "parameters", Values: values})
+	}
+	return wf.Execute(ctx, base, data)
+}
@@ -0,0 +1,90 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type parameterConfig struct {
+	OCR struct {
+		DPI      int    `json:"dpi"`
+		Language string `json:"language"`
+	} `json:"ocr"`
+	Mode    string        `json:"mode"`
+	Timeout time.Duration `json:"timeout"`
+	Tenant  string        `json:"tenant"`
+}
+
+func parameterTemplate(seen *parameterConfig) *goworkflow.Template[context.Context, parameterConfig, Data] {
+	t := goworkflow.NewTemplate[context.Context, parameterConfig, Data]("invoices")
+	t.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[parameterConfig, Data]) error {
+		*seen = dt.Config
+		return nil
+	}))
+	t.AddParameter(goworkflow.Parameter{Name: "ocr.dpi", Type: goworkflow.IntParameter, Default: 300})
+	t.AddParameter(goworkflow.Parameter{Name: "mode", Type: goworkflow.StringParameter, Default: "balanced", Enum: []any{"fast", "balanced", "accurate"}})
+	t.AddParameter(goworkflow.Parameter{Name: "timeout", Type: goworkflow.DurationParameter})
+	t.AddParameter(goworkflow.Parameter{Name: "tenant", Type: goworkflow.StringParameter, Required: true})
+	return t
+}
+
+func TestExecuteWithParameters(t *testing.T) {
+	t.Setenv("INVOICES_OCR_DPI", "600")
+	t.Setenv("INVOICES_MODE", "fast")
+	seen := parameterConfig{}
+	template := parameterTemplate(&seen)
+	base := parameterConfig{}
+	base.OCR.Language = "de"
+
+	flags, err := goworkflow.ParametersFromJSON([]byte(`{"tenant": "acme", "mode": "accurate", "timeout": "90s"}`))
+	assert.NoError(t, err)
+	_, st, err := template.ExecuteWithParameters(context.TODO(), base, &Data{}, flags, goworkflow.ParametersFromEnv("INVOICES_"))
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "acme", seen.Tenant)
+	// the first source with a value wins, the environment only fills the gaps
+	assert.Equal(t, "accurate", seen.Mode)
+	assert.Equal(t, 600, seen.OCR.DPI)
+	assert.Equal(t, 90*time.Second, seen.Timeout)
+	assert.Equal(t, "de", seen.OCR.Language)
+
+	config, err := template.ResolveConfig(base, goworkflow.ParametersFromMap(map[string]any{"tenant": "acme", "ocr": map[string]any{"dpi": 150}}))
+	assert.NoError(t, err)
+	assert.Equal(t, 150, config.OCR.DPI)
+	assert.Equal(t, "balanced", config.Mode)
+	assert.Equal(t, time.Duration(0), config.Timeout)
+}
+
+func TestExecuteWithParametersInvalid(t *testing.T) {
+	seen := parameterConfig{}
+	template := parameterTemplate(&seen)
+	_, st, err := template.ExecuteWithParameters(context.TODO(), parameterConfig{}, &Data{},
+		goworkflow.ParametersFromMap(map[string]any{"ocr.dpi": 72.5, "mode": "cheap", "timeout": "soon"}))
+	assert.Equal(t, goworkflow.ERROR, st)
+	var validationErr *goworkflow.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"parameter ocr.dpi: expected an int, got 72.5",
+		"parameter mode: cheap is not one of [fast balanced accurate]",
+		`parameter timeout: time: invalid duration "soon"`,
+		"parameter tenant is required",
+	}, validationErr.Problems)
+	assert.Equal(t, parameterConfig{}, seen)
+}
+
+func TestAddParameterInvalid(t *testing.T) {
+	template := goworkflow.NewTemplate[context.Context, parameterConfig, Data]("invoices")
+	assert.Panics(t, func() {
+		template.AddParameter(goworkflow.Parameter{Name: "mode", Type: goworkflow.StringParameter, Default: "cheap", Enum: []any{"fast"}})
+	})
+	assert.Panics(t, func() {
+		template.AddParameter(goworkflow.Parameter{Name: "ocr.dpi", Type: goworkflow.IntParameter, Default: "high"})
+	})
+	template.AddParameter(goworkflow.Parameter{Name: "tenant", Type: goworkflow.StringParameter})
+	assert.Panics(t, func() { template.AddParameter(goworkflow.Parameter{Name: "tenant", Type: goworkflow.StringParameter}) })
+	assert.Len(t, template.Clone().Parameters(), 1)
+}
@@ -0,0 +1,191 @@
+/*
+Package temporal runs workflow templates on Temporal (or Cadence): every component becomes an activity executing
+the same component code, and a workflow function schedules the activities along the dependencies of the template.
+Long running workflows get durable execution without rewriting their components.
+
+The package doesn't depend on the Temporal SDK, the workflow function drives it through Executor:
+
+	type executor struct{ ctx workflow.Context }
+
+	func (e executor) ExecuteActivity(name string, input any) temporal.Future {
+		return future{e.ctx, workflow.ExecuteActivity(e.ctx, name, input)}
+	}
+
+	type future struct {
+		ctx workflow.Context
+		f   workflow.Future
+	}
+
+	func (f future) Get(valuePtr any) error { return f.f.Get(f.ctx, valuePtr) }
+
+	// worker setup
+	for name, fn := range adapter.Activities() {
+		w.RegisterActivityWithOptions(fn, activity.RegisterOptions{Name: name})
+	}
+	w.RegisterWorkflowWithOptions(func(ctx workflow.Context, config Config, data Data) (Data, error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Hour})
+		return adapter.Run(executor{ctx}, config, data)
+	}, workflow.RegisterOptions{Name: adapter.WorkflowName()})
+*/
+package temporal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/internal/mergepatch"
+)
+
+/* Future: result of an activity, workflow.Future bound to its workflow.Context */
+type Future interface {
+	Get(valuePtr any) error
+}
+
+/* Executor starts activities, workflow.ExecuteActivity bound to a workflow.Context */
+type Executor interface {
+	ExecuteActivity(name string, input any) Future
+}
+
+/* ActivityInput: snapshot of the run the component activity executes on */
+type ActivityInput[C any, T any] struct {
+	Config C
+	Data   T
+}
+
+/* ActivityOutput: json merge patch of the data written by the component */
+type ActivityOutput struct {
+	DataDelta json.RawMessage
+}
+
+type Options[CT context.Context] struct {
+	// Context: component context from the activity context, required when CT is not context.Context
+	Context func(context.Context) CT
+}
+
+type Adapter[CT context.Context, C any, T any] struct {
+	template   *goworkflow.Template[CT, C, T]
+	components []*goworkflow.TemplateComponent[CT, C, T]
+	context    func(context.Context) CT
+}
+
+/* NewAdapter fails for templates with dependencies which don't require success or any-of dependencies */
+func NewAdapter[CT context.Context, C any, T any](t *goworkflow.Template[CT, C, T], opts ...*Options[CT]) (*Adapter[CT, C, T], error) {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	a := &Adapter[CT, C, T]{template: t, components: t.Components()}
+	if len(opts) == 1 && opts[0] != nil {
+		a.context = opts[0].Context
+	}
+	if a.context == nil {
+		a.context = func(ctx context.Context) CT {
+			ct, ok := ctx.(CT)
+			if !ok {
+				panic("Options.Context is required when the context type is not context.Context")
+			}
+			return ct
+		}
+	}
+	unsupported := []string{}
+	for _, tc := range a.components {
+		if tc.Conditional() {
+			unsupported = append(unsupported, tc.Name())
+		}
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("conditional or any-of dependencies of %s can't be exported", strings.Join(unsupported, ", "))
+	}
+	return a, nil
+}
+
+/* WorkflowName: name to register the workflow function with */
+func (a *Adapter[CT, C, T]) WorkflowName() string {
+	return a.template.Name
+}
+
+/* ActivityName: name of the activity executing the component */
+func (a *Adapter[CT, C, T]) ActivityName(component string) string {
+	return a.template.Name + "." + component
+}
+
+/* Activities: activity functions by name, to register with the worker */
+func (a *Adapter[CT, C, T]) Activities() map[string]func(context.Context, ActivityInput[C, T]) (ActivityOutput, error) {
+	activities := map[string]func(context.Context, ActivityInput[C, T]) (ActivityOutput, error){}
+	for _, tc := range a.components {
+		name := tc.Name()
+		activities[a.ActivityName(name)] = func(ctx context.Context, input ActivityInput[C, T]) (ActivityOutput, error) {
+			return a.executeActivity(ctx, name, input)
+		}
+	}
+	return activities
+}
+
+func (a *Adapter[CT, C, T]) executeActivity(ctx context.Context, component string, input ActivityInput[C, T]) (ActivityOutput, error) {
+	before, err := json.Marshal(input.Data)
+	if err != nil {
+		return ActivityOutput{}, err
+	}
+	data := input.Data
+	if err := a.template.ExecuteComponent(a.context(ctx), component, input.Config, &data); err != nil {
+		return ActivityOutput{}, err
+	}
+	var previous T
+	if err := json.Unmarshal(before, &previous); err != nil {
+		return ActivityOutput{}, err
+	}
+	delta, err := mergepatch.Create(&previous, &data)
+	return ActivityOutput{DataDelta: delta}, err
+}
+
+/*
+Run is the body of the workflow function: it executes the components in waves, every wave starts the activities
+of all components whose dependencies finished and waits for them, which keeps the workflow deterministic.
+The patches of a wave are applied in declaration order. The first failed wave fails the run.
+*/
+func (a *Adapter[CT, C, T]) Run(executor Executor, config C, data T) (T, error) {
+	done := map[string]bool{}
+	for len(done) < len(a.components) {
+		wave := []*goworkflow.TemplateComponent[CT, C, T]{}
+		for _, tc := range a.components {
+			if !done[tc.Name()] && allDone(tc.Dependencies(), done) {
+				wave = append(wave, tc)
+			}
+		}
+		if len(wave) == 0 {
+			return data, errors.New("dependencies can't be satisfied, the template has a cycle")
+		}
+		futures := make([]Future, len(wave))
+		for i, tc := range wave {
+			futures[i] = executor.ExecuteActivity(a.ActivityName(tc.Name()), ActivityInput[C, T]{Config: config, Data: data})
+		}
+		failed := []error{}
+		for i, f := range futures {
+			var out ActivityOutput
+			if err := f.Get(&out); err != nil {
+				failed = append(failed, fmt.Errorf("component %s failed: %w", wave[i].Name(), err))
+				continue
+			}
+			if err := mergepatch.Apply(&data, out.DataDelta); err != nil {
+				failed = append(failed, fmt.Errorf("component %s: %w", wave[i].Name(), err))
+			}
+			done[wave[i].Name()] = true
+		}
+		if len(failed) > 0 {
+			return data, errors.Join(failed...)
+		}
+	}
+	return data, nil
+}
+
+func allDone(names []string, done map[string]bool) bool {
+	for _, name := range names {
+		if !done[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package temporal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct {
+	Language string
+}
+
+type Data struct {
+	Text     string
+	Summary  string
+	Keywords []string
+}
+
+/* fakeExecutor runs the activities in goroutines and round trips inputs and outputs through json like temporal */
+type fakeExecutor struct {
+	activities map[string]func(context.Context, ActivityInput[Config, Data]) (ActivityOutput, error)
+	lock       sync.Mutex
+	started    []string
+}
+
+type fakeFuture struct {
+	done chan struct{}
+	out  []byte
+	err  error
+}
+
+func (f *fakeFuture) Get(valuePtr any) error {
+	<-f.done
+	if f.err != nil {
+		return f.err
+	}
+	return json.Unmarshal(f.out, valuePtr)
+}
+
+func (e *fakeExecutor) ExecuteActivity(name string, input any) Future {
+	e.lock.Lock()
+	e.started = append(e.started, name)
+	e.lock.Unlock()
+	f := &fakeFuture{done: make(chan struct{})}
+	raw, _ := json.Marshal(input)
+	go func() {
+		defer close(f.done)
+		var in ActivityInput[Config, Data]
+		json.Unmarshal(raw, &in)
+		out, err := e.activities[name](context.Background(), in)
+		f.err = err
+		f.out, _ = json.Marshal(out)
+	}()
+	return f
+}
+
+func documentTemplate(failSummary bool) *goworkflow.Template[context.Context, Config, Data] {
+	t := goworkflow.NewTemplate[context.Context, Config, Data]("document")
+	ocr := t.AddComponent(goworkflow.MakeComponent("OCR", "scan.pdf", func(ctx context.Context, input string, dt *goworkflow.DataTracker[Config, Data]) error {
+		info, _ := goworkflow.ComponentInfoFromContext(dt.Context())
+		dt.Update(func(d *Data) { d.Text = input + " in " + dt.Config.Language + " by " + info.Component })
+		return nil
+	}))
+	summary := t.AddComponent(goworkflow.MakeComponent("Summary", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		if failSummary {
+			return errors.New("model unavailable")
+		}
+		dt.Update(func(d *Data) { d.Summary = "summary of " + d.Text })
+		return nil
+	}))
+	keywords := t.AddComponent(goworkflow.MakeComponent("Keywords", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.Keywords = []string{dt.GetData().Text} })
+		return nil
+	}))
+	summary.AddDependencies(ocr)
+	keywords.AddDependencies(ocr)
+	return t
+}
+
+func TestAdapter(t *testing.T) {
+	adapter, err := NewAdapter(documentTemplate(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "document", adapter.WorkflowName())
+	executor := &fakeExecutor{activities: adapter.Activities()}
+	assert.Len(t, executor.activities, 3)
+
+	data, err := adapter.Run(executor, Config{Language: "en"}, Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, Data{
+		Text:     "scan.pdf in en by OCR",
+		Summary:  "summary of scan.pdf in en by OCR",
+		Keywords: []string{"scan.pdf in en by OCR"},
+	}, data)
+	assert.Equal(t, []string{"document.OCR", "document.Summary", "document.Keywords"}, executor.started)
+}
+
+func TestAdapterFailure(t *testing.T) {
+	adapter, _ := NewAdapter(documentTemplate(true))
+	data, err := adapter.Run(&fakeExecutor{activities: adapter.Activities()}, Config{}, Data{})
+	assert.EqualError(t, err, "component Summary failed: model unavailable")
+	// patches of the other components of the wave are applied
+	assert.Len(t, data.Keywords, 1)
+}
+
+func TestAdapterConditional(t *testing.T) {
+	tmpl := documentTemplate(false)
+	tmpl.Component("Summary").AddDependenciesOn(goworkflow.OnFailure, tmpl.Component("Keywords"))
+	_, err := NewAdapter(tmpl)
+	assert.EqualError(t, err, "conditional or any-of dependencies of Summary can't be exported")
+}
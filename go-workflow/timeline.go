@@ -0,0 +1,74 @@
+package goworkflow
+
+import (
+	"slices"
+	"time"
+)
+
+/*
+ComponentTiming: ReadyAt is when all dependencies were resolved, StartedAt when the component started executing
+(after limiters, slots and run priority were granted) and FinishedAt when it reached its final status.
+StartedAt is zero for components which never executed.
+*/
+type ComponentTiming struct {
+	Component   string
+	ComponentId string
+	Status      Status
+	ReadyAt     time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	// Attempts: executions of the component, more than 1 when it was retried
+	Attempts int
+	// Cached: the result was restored from the cache instead of executing the component, see CachePolicy
+	Cached bool
+	// LimiterWaits: acquisitions of limiters before the component started, in acquisition order
+	LimiterWaits []LimiterWait
+}
+
+/* Wait: time between becoming ready and starting, i.e. limiter stalls */
+func (ct ComponentTiming) Wait() time.Duration {
+	if ct.StartedAt.IsZero() || ct.ReadyAt.IsZero() {
+		return 0
+	}
+	return ct.StartedAt.Sub(ct.ReadyAt)
+}
+
+/* Duration: execution time of the component */
+func (ct ComponentTiming) Duration() time.Duration {
+	if ct.StartedAt.IsZero() || ct.FinishedAt.IsZero() {
+		return 0
+	}
+	return ct.FinishedAt.Sub(ct.StartedAt)
+}
+
+func (c *component[CT, C, T]) markReady() {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	c.timing.ReadyAt = time.Now()
+}
+
+/* Timeline: timings of all components, ordered by the time they became ready. See ViewOptions for child runs */
+func (wf *Workflow[CT, C, T]) Timeline(opts ...*ViewOptions) []ComponentTiming {
+	timeline := []ComponentTiming{}
+	for _, c := range wf.sortedComponents() {
+		c.statusLock.Lock()
+		timing := c.timing
+		timing.LimiterWaits = slices.Clone(timing.LimiterWaits)
+		c.statusLock.Unlock()
+		timing.Component = c.Name
+		timing.ComponentId = c.id
+		timeline = append(timeline, timing)
+	}
+	if expandChildren(opts) {
+		for _, child := range wf.childViews() {
+			for _, timing := range child.run.Timeline(opts...) {
+				timing.Component = child.prefix + timing.Component
+				timeline = append(timeline, timing)
+			}
+		}
+	}
+	slices.SortStableFunc(timeline, func(a, b ComponentTiming) int {
+		return a.ReadyAt.Compare(b.ReadyAt)
+	})
+	return timeline
+}
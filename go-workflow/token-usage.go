@@ -0,0 +1,48 @@
+package goworkflow
+
+import (
+	"context"
+
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+)
+
+type tokenReservationContextKey struct{}
+
+/*
+reserveTokens: reservation of a request and the estimated tokens of an attempt of c at its TokenLimiter, the
+returned function ends the reservation
+*/
+func (wf *Workflow[CT, C, T]) reserveTokens(ctx context.Context, c *component[CT, C, T], input ComponentInput) (context.Context, func(), error) {
+	if c.addComponentCfg == nil || c.addComponentCfg.TokenLimiter == nil {
+		return ctx, func() {}, nil
+	}
+	tl := c.addComponentCfg.TokenLimiter
+	estimated := 0
+	if c.addComponentCfg.EstimateTokens != nil {
+		estimated = c.addComponentCfg.EstimateTokens(input)
+	}
+	var reservation *limiter.TokenReservation
+	var err error
+	release := wf.acquireLimiter(ctx, c, "tokens:"+tl.Name(), func() { reservation, err = tl.Reserve(ctx, estimated) }, func() {})
+	if err != nil {
+		return ctx, release, err
+	}
+	return context.WithValue(ctx, tokenReservationContextKey{}, reservation), release, nil
+}
+
+/*
+ReportTokenUsage reports the tokens actually used by the running attempt of the component to its TokenLimiter
+(see ComponentConfig.TokenLimiter), e.g. the usage returned by the LLM. The estimate stands when the attempt
+doesn't report. False when the component has no TokenLimiter.
+*/
+func ReportTokenUsage(ctx context.Context, tokens int) bool {
+	reservation, ok := ctx.Value(tokenReservationContextKey{}).(*limiter.TokenReservation)
+	if ok {
+		reservation.Report(tokens)
+	}
+	return ok
+}
+
+func (d *DataTracker[C, T]) ReportTokenUsage(tokens int) bool {
+	return ReportTokenUsage(d.ctx, tokens)
+}
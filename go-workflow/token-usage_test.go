@@ -0,0 +1,44 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenLimiter(t *testing.T) {
+	tokens := limiter.NewTokenLimiter("llm", 600, 100000)
+	attempts := 0
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Summarize", "a long document", func(ctx context.Context, input string, dt *goworkflow.DataTracker[Config, Data]) error {
+		attempts++
+		if attempts == 1 {
+			assert.True(t, goworkflow.ReportTokenUsage(ctx, 500))
+			return errors.New("overloaded")
+		}
+		assert.True(t, dt.ReportTokenUsage(1200))
+		return nil
+	}), &goworkflow.ComponentConfig{
+		TokenLimiter:   tokens,
+		EstimateTokens: func(input goworkflow.ComponentInput) int { return 100 * len(input.(string)) },
+		Retry:          &goworkflow.RetryPolicy{MaxAttempts: 2},
+	})
+	wf.AddComponent(goworkflow.MakeComponent("Unlimited", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		assert.False(t, goworkflow.ReportTokenUsage(ctx, 10))
+		return nil
+	}))
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, limiter.TokenUsage{Requests: 2, Tokens: 1700}, tokens.Usage())
+	for _, timing := range wf.Timeline() {
+		if timing.Component == "Summarize" {
+			assert.Len(t, timing.LimiterWaits, 2)
+			assert.Equal(t, "tokens:llm", timing.LimiterWaits[0].Limiter)
+		}
+	}
+}
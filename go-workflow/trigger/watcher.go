@@ -0,0 +1,251 @@
+/*
+Package trigger starts workflow runs from external sources. Watcher polls a directory or an S3 prefix and starts
+a run of a template per new file, the classic "drop a PDF in a folder" ingestion:
+
+	w := trigger.NewWatcher(trigger.Dir("/srv/inbox", "*.pdf"), template, func(f trigger.File) (Config, error) {
+		return Config{DocumentPath: f.Path}, nil
+	}, &trigger.Options[context.Context, Config, Data]{Processed: moveToDone})
+	err := w.Run(ctx)
+*/
+package trigger
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/artifacts"
+)
+
+/* File found in a source */
+type File struct {
+	// Path: file path for directory sources, artifact ref (s3://bucket/key) for S3 sources
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Content of the file, only read with Options.ReadContent
+	Content []byte
+}
+
+/* Source lists the files a Watcher triggers runs for */
+type Source interface {
+	List(ctx context.Context) ([]File, error)
+	Read(ctx context.Context, file File) ([]byte, error)
+}
+
+type dirSource struct {
+	dir     string
+	pattern string
+}
+
+/* Dir: regular files of dir (not recursive) whose name matches pattern (filepath.Match), all files when empty */
+func Dir(dir string, pattern string) Source {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		panic("invalid pattern: " + err.Error())
+	}
+	return &dirSource{dir: dir, pattern: pattern}
+}
+
+func (d *dirSource) List(ctx context.Context) ([]File, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []File{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if d.pattern != "" {
+			if ok, _ := filepath.Match(d.pattern, entry.Name()); !ok {
+				continue
+			}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed since ReadDir
+			continue
+		}
+		files = append(files, File{Path: filepath.Join(d.dir, entry.Name()), Name: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+func (d *dirSource) Read(ctx context.Context, file File) ([]byte, error) {
+	return os.ReadFile(file.Path)
+}
+
+type s3Source struct {
+	store   *artifacts.S3Store
+	prefix  string
+	pattern string
+}
+
+/* S3: objects of store under prefix whose base name matches pattern (path.Match), all objects when empty */
+func S3(store *artifacts.S3Store, prefix string, pattern string) Source {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic("invalid pattern: " + err.Error())
+	}
+	return &s3Source{store: store, prefix: prefix, pattern: pattern}
+}
+
+func (s *s3Source) List(ctx context.Context) ([]File, error) {
+	objects, err := s.store.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	files := []File{}
+	for _, o := range objects {
+		name := path.Base(o.Key)
+		if s.pattern != "" {
+			if ok, _ := path.Match(s.pattern, name); !ok {
+				continue
+			}
+		}
+		files = append(files, File{Path: string(o.Ref), Name: name, Size: o.Size, ModTime: o.LastModified})
+	}
+	return files, nil
+}
+
+func (s *s3Source) Read(ctx context.Context, file File) ([]byte, error) {
+	body, err := s.store.Get(ctx, goworkflow.ArtifactRef(file.Path))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+type Options[CT context.Context, C any, T any] struct {
+	// Interval between polls, 5s when 0
+	Interval time.Duration
+	// IncludeExisting: trigger runs for the files present at the first poll, otherwise they are ignored
+	IncludeExisting bool
+	// ReadContent: File.Content holds the bytes of the file
+	ReadContent bool
+	// Executor bounds the concurrent runs, every file runs immediately when nil
+	Executor *goworkflow.RunExecutor[CT, C, T]
+	// Processed is called when the run of a file finished, e.g. to move the file out of the watched directory
+	Processed func(file File, result goworkflow.RunResult)
+}
+
+/*
+Watcher starts a run of its template for every new file of its source. A file is new once it is unchanged
+(size and modification time) for one poll interval, so files still being written are not picked up.
+A file which changes, or is removed and added again, triggers a new run.
+*/
+type Watcher[CT context.Context, C any, T any] struct {
+	source   Source
+	template *goworkflow.Template[CT, C, T]
+	config   func(File) (C, error)
+	opts     Options[CT, C, T]
+	// seen: files of the previous poll, triggered once they are stable
+	seen      map[string]seenFile
+	firstPoll bool
+	runs      sync.WaitGroup
+}
+
+type seenFile struct {
+	size      int64
+	modTime   time.Time
+	triggered bool
+}
+
+func NewWatcher[CT context.Context, C any, T any](source Source, t *goworkflow.Template[CT, C, T], config func(File) (C, error), opts ...*Options[CT, C, T]) *Watcher[CT, C, T] {
+	if len(opts) > 1 {
+		panic("only one Options is allowed")
+	}
+	if source == nil || t == nil || config == nil {
+		panic("source, template and config cannot be nil")
+	}
+	w := &Watcher[CT, C, T]{source: source, template: t, config: config, seen: map[string]seenFile{}, firstPoll: true}
+	if len(opts) == 1 && opts[0] != nil {
+		w.opts = *opts[0]
+	}
+	if w.opts.Interval == 0 {
+		w.opts.Interval = 5 * time.Second
+	}
+	return w
+}
+
+/* Run polls the source until ctx is done, then waits for the started runs */
+func (w *Watcher[CT, C, T]) Run(ctx CT) error {
+	defer w.runs.Wait()
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Poll(ctx); err != nil {
+			log.Println("Watcher.Run:Error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/* Poll lists the source once and starts the runs of the files which became stable */
+func (w *Watcher[CT, C, T]) Poll(ctx CT) error {
+	files, err := w.source.List(ctx)
+	if err != nil {
+		return err
+	}
+	current := map[string]seenFile{}
+	for _, file := range files {
+		previous, ok := w.seen[file.Path]
+		state := seenFile{size: file.Size, modTime: file.ModTime}
+		switch {
+		case w.firstPoll && !w.opts.IncludeExisting:
+			state.triggered = true
+		case ok && previous.triggered && previous.size == file.Size && previous.modTime.Equal(file.ModTime):
+			state.triggered = true
+		case ok && previous.size == file.Size && previous.modTime.Equal(file.ModTime):
+			state.triggered = w.start(ctx, file)
+		}
+		current[file.Path] = state
+	}
+	w.seen = current
+	w.firstPoll = false
+	return nil
+}
+
+/* start starts the run of file, false when it must be retried at the next poll */
+func (w *Watcher[CT, C, T]) start(ctx CT, file File) bool {
+	if w.opts.ReadContent {
+		content, err := w.source.Read(ctx, file)
+		if err != nil {
+			log.Println("Watcher.start:Error:", file.Path, err)
+			return false
+		}
+		file.Content = content
+	}
+	config, err := w.config(file)
+	if err != nil {
+		// the file is invalid, it is not retried until it changes
+		log.Println("Watcher.start:Error:", file.Path, err)
+		return true
+	}
+	wf := w.template.NewWorkflow(ctx)
+	wf.SetMetadata("trigger.file", file.Path)
+	w.runs.Add(1)
+	go func() {
+		defer w.runs.Done()
+		if w.opts.Executor != nil {
+			w.opts.Executor.Execute(ctx, wf, config, new(T))
+		} else {
+			wf.Execute(ctx, config, new(T))
+		}
+		if w.opts.Processed != nil {
+			w.opts.Processed(file, wf.Result())
+		}
+	}()
+	return true
+}
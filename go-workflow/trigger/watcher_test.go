@@ -0,0 +1,109 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct {
+	Path    string
+	Content string
+}
+
+type Data struct {
+	Pages int
+}
+
+func newTemplate() *goworkflow.Template[context.Context, Config, Data] {
+	t := goworkflow.NewTemplate[context.Context, Config, Data]("ingest")
+	t.AddComponent(goworkflow.MakeComponent("OCR", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		if dt.Config.Content == "broken" {
+			return errors.New("unreadable")
+		}
+		return nil
+	}))
+	return t
+}
+
+func TestWatcherDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "existing.pdf"), []byte("old"), 0o644)
+
+	var lock sync.Mutex
+	processed := map[string]goworkflow.Status{}
+	w := NewWatcher(Dir(dir, "*.pdf"), newTemplate(), func(f File) (Config, error) {
+		return Config{Path: f.Path, Content: string(f.Content)}, nil
+	}, &Options[context.Context, Config, Data]{
+		ReadContent: true,
+		Processed: func(file File, result goworkflow.RunResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			assert.Equal(t, file.Path, result.Metadata["trigger.file"])
+			processed[file.Name] = result.Status
+		},
+	})
+	ctx := context.TODO()
+	assert.NoError(t, w.Poll(ctx))
+
+	os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("ok"), 0o644)
+	os.WriteFile(filepath.Join(dir, "scan.pdf"), []byte("broken"), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+	// new files are triggered once they are unchanged for a poll
+	assert.NoError(t, w.Poll(ctx))
+	w.runs.Wait()
+	assert.Empty(t, processed)
+	assert.NoError(t, w.Poll(ctx))
+	w.runs.Wait()
+	assert.Equal(t, map[string]goworkflow.Status{"invoice.pdf": goworkflow.DONE, "scan.pdf": goworkflow.ERROR}, processed)
+
+	// unchanged files are not triggered again, changed ones are
+	os.WriteFile(filepath.Join(dir, "scan.pdf"), []byte("rescanned"), 0o644)
+	os.Chtimes(filepath.Join(dir, "scan.pdf"), time.Now(), time.Now().Add(time.Minute))
+	processed = map[string]goworkflow.Status{}
+	assert.NoError(t, w.Poll(ctx))
+	assert.NoError(t, w.Poll(ctx))
+	w.runs.Wait()
+	assert.Equal(t, map[string]goworkflow.Status{"scan.pdf": goworkflow.DONE}, processed)
+}
+
+type fakeSource struct {
+	files []File
+}
+
+func (s *fakeSource) List(ctx context.Context) ([]File, error) {
+	return s.files, nil
+}
+
+func (s *fakeSource) Read(ctx context.Context, file File) ([]byte, error) {
+	return nil, errors.New("not readable")
+}
+
+func TestWatcherRun(t *testing.T) {
+	source := &fakeSource{files: []File{{Path: "s3://inbox/a.pdf", Name: "a.pdf"}, {Path: "s3://inbox/b.pdf", Name: "b.pdf"}}}
+	var lock sync.Mutex
+	started := []string{}
+	w := NewWatcher(source, newTemplate(), func(f File) (Config, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		started = append(started, f.Name)
+		if f.Name == "b.pdf" {
+			return Config{}, errors.New("unsupported")
+		}
+		return Config{Path: f.Path}, nil
+	}, &Options[context.Context, Config, Data]{Interval: 5 * time.Millisecond, IncludeExisting: true})
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Run(ctx), context.DeadlineExceeded)
+	sort.Strings(started)
+	// existing files are included, invalid ones are not retried
+	assert.Equal(t, []string{"a.pdf", "b.pdf"}, started)
+}
@@ -0,0 +1,163 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+Experimental: typed component handles.
+
+A Handle is parameterized by the output type of its component. Consumers declare the output types they read
+as type parameters, and their AddDependencies only accepts handles with exactly those output types, so wiring the
+wrong component is a compile error. A consumer whose declared dependencies are never wired fails the run before any
+component is executed. Consumers receive the outputs as arguments instead of reading them from the shared data store.
+*/
+type Handle[CT context.Context, C any, T any, O any] struct {
+	Component *component[CT, C, T]
+	output    *O
+}
+
+/* Value: output of the component, only meaningful once it is DONE */
+func (h *Handle[CT, C, T, O]) Value() O {
+	return *h.output
+}
+
+func addTypedComponent[CT context.Context, C any, T any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Handle[CT, C, T, O] {
+	h := &Handle[CT, C, T, O]{output: new(O)}
+	h.Component = wf.AddComponent(makeComponentConfig[CT, C, T]{
+		Name: name,
+		Executor: func(ctx CT, ci ComponentInput, dt *DataTracker[C, T]) error {
+			out, err := fn(ctx, dt)
+			if err != nil {
+				return err
+			}
+			*h.output = out
+			return nil
+		},
+	}, cfgs...)
+	return h
+}
+
+/* AddProducer adds a component without typed dependencies producing O */
+func AddProducer[CT context.Context, C any, T any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Handle[CT, C, T, O] {
+	if fn == nil {
+		panic("executor cannot be nil")
+	}
+	return addTypedComponent(wf, name, fn, cfgs...)
+}
+
+type Consumer1[CT context.Context, C any, T any, A any, O any] struct {
+	*Handle[CT, C, T, O]
+	a *Handle[CT, C, T, A]
+}
+
+/* AddConsumer1 adds a component consuming the output A of one dependency */
+func AddConsumer1[CT context.Context, C any, T any, A any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, A, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Consumer1[CT, C, T, A, O] {
+	if fn == nil {
+		panic("executor cannot be nil")
+	}
+	c := &Consumer1[CT, C, T, A, O]{}
+	c.Handle = addTypedComponent(wf, name, func(ctx CT, dt *DataTracker[C, T]) (O, error) {
+		return fn(ctx, c.a.Value(), dt)
+	}, cfgs...)
+	wf.addBuildCheck(func() error {
+		if c.a == nil {
+			return fmt.Errorf("typed dependencies of %s are not set", name)
+		}
+		return nil
+	})
+	return c
+}
+
+func (c *Consumer1[CT, C, T, A, O]) AddDependencies(a *Handle[CT, C, T, A]) {
+	c.a = a
+	c.Component.AddDependencies(a.Component)
+}
+
+type Consumer2[CT context.Context, C any, T any, A any, B any, O any] struct {
+	*Handle[CT, C, T, O]
+	a *Handle[CT, C, T, A]
+	b *Handle[CT, C, T, B]
+}
+
+/* AddConsumer2 adds a component consuming the outputs A and B of two dependencies */
+func AddConsumer2[CT context.Context, C any, T any, A any, B any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, A, B, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Consumer2[CT, C, T, A, B, O] {
+	if fn == nil {
+		panic("executor cannot be nil")
+	}
+	c := &Consumer2[CT, C, T, A, B, O]{}
+	c.Handle = addTypedComponent(wf, name, func(ctx CT, dt *DataTracker[C, T]) (O, error) {
+		return fn(ctx, c.a.Value(), c.b.Value(), dt)
+	}, cfgs...)
+	wf.addBuildCheck(func() error {
+		if c.a == nil || c.b == nil {
+			return fmt.Errorf("typed dependencies of %s are not set", name)
+		}
+		return nil
+	})
+	return c
+}
+
+func (c *Consumer2[CT, C, T, A, B, O]) AddDependencies(a *Handle[CT, C, T, A], b *Handle[CT, C, T, B]) {
+	c.a = a
+	c.b = b
+	c.Component.AddDependencies(a.Component, b.Component)
+}
+
+type Consumer3[CT context.Context, C any, T any, A any, B any, D any, O any] struct {
+	*Handle[CT, C, T, O]
+	a *Handle[CT, C, T, A]
+	b *Handle[CT, C, T, B]
+	d *Handle[CT, C, T, D]
+}
+
+/* AddConsumer3 adds a component consuming the outputs A, B and D of three dependencies */
+func AddConsumer3[CT context.Context, C any, T any, A any, B any, D any, O any](
+	wf *Workflow[CT, C, T],
+	name string,
+	fn func(CT, A, B, D, *DataTracker[C, T]) (O, error),
+	cfgs ...*ComponentConfig,
+) *Consumer3[CT, C, T, A, B, D, O] {
+	if fn == nil {
+		panic("executor cannot be nil")
+	}
+	c := &Consumer3[CT, C, T, A, B, D, O]{}
+	c.Handle = addTypedComponent(wf, name, func(ctx CT, dt *DataTracker[C, T]) (O, error) {
+		return fn(ctx, c.a.Value(), c.b.Value(), c.d.Value(), dt)
+	}, cfgs...)
+	wf.addBuildCheck(func() error {
+		if c.a == nil || c.b == nil || c.d == nil {
+			return fmt.Errorf("typed dependencies of %s are not set", name)
+		}
+		return nil
+	})
+	return c
+}
+
+func (c *Consumer3[CT, C, T, A, B, D, O]) AddDependencies(a *Handle[CT, C, T, A], b *Handle[CT, C, T, B], d *Handle[CT, C, T, D]) {
+	c.a = a
+	c.b = b
+	c.d = d
+	c.Component.AddDependencies(a.Component, b.Component, d.Component)
+}
@@ -0,0 +1,62 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type VisualInformation []string
+type ExtractedText []string
+
+func TestTypedComponents(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+
+	visual := goworkflow.AddProducer(wf, "VisualInformation", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (VisualInformation, error) {
+		return VisualInformation{"visual1"}, nil
+	})
+	text := goworkflow.AddProducer(wf, "TextExtractor", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (ExtractedText, error) {
+		return ExtractedText{"text1"}, nil
+	})
+	parameter := goworkflow.AddConsumer2(wf, "Parameter1", func(ctx context.Context, v VisualInformation, e ExtractedText, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+		return v[0] + e[0], nil
+	})
+	// parameter.AddDependencies(text, visual) would not compile
+	parameter.AddDependencies(visual, text)
+
+	combined := goworkflow.AddConsumer1(wf, "Combined", func(ctx context.Context, p string, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+		dt.Update(func(d *Data) {
+			d.Combined = strings.ToUpper(p)
+		})
+		return p, nil
+	})
+	combined.AddDependencies(parameter.Handle)
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "visual1text1", parameter.Value())
+	assert.Equal(t, "VISUAL1TEXT1", data.Combined)
+}
+
+func TestTypedComponentsMissingDependency(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	executed := false
+
+	goworkflow.AddProducer(wf, "VisualInformation", func(ctx context.Context, dt *goworkflow.DataTracker[Config, Data]) (VisualInformation, error) {
+		executed = true
+		return VisualInformation{"visual1"}, nil
+	})
+	goworkflow.AddConsumer1(wf, "Parameter1", func(ctx context.Context, v VisualInformation, dt *goworkflow.DataTracker[Config, Data]) (string, error) {
+		return fmt.Sprint(v), nil
+	})
+
+	_, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+	assert.ErrorContains(t, err, "typed dependencies of Parameter1 are not set")
+	assert.False(t, executed)
+}
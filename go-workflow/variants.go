@@ -0,0 +1,129 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/google/uuid"
+)
+
+/* Variant: arm of an experiment, runs are assigned to the variants in proportion to their Weight */
+type Variant[V any] struct {
+	Name   string
+	Weight float64
+	Value  V
+}
+
+/*
+Experiment compares implementations, e.g. extraction models, on live runs. The chosen variant is recorded in the
+run metadata as experiment.<Name>, so results can be grouped by variant offline (see RunStore.ListRuns).
+*/
+type Experiment[V any] struct {
+	Name     string
+	Variants []Variant[V]
+	// Assign: variant name for a key, e.g. pinning a tenant to the control. The weights decide when nil or ""
+	Assign func(ctx context.Context, key string) string
+}
+
+func (e Experiment[V]) metadataKey() string {
+	return "experiment." + e.Name
+}
+
+func (e Experiment[V]) validate() {
+	if e.Name == "" {
+		panic("experiment name cannot be empty")
+	}
+	if len(e.Variants) == 0 {
+		panic(fmt.Sprintf("experiment %s has no variants", e.Name))
+	}
+	names := map[string]bool{}
+	total := 0.0
+	for _, v := range e.Variants {
+		if v.Name == "" || names[v.Name] {
+			panic(fmt.Sprintf("experiment %s: variant names must be unique and not empty", e.Name))
+		}
+		if v.Weight < 0 {
+			panic(fmt.Sprintf("experiment %s: negative weight of variant %s", e.Name, v.Name))
+		}
+		names[v.Name] = true
+		total += v.Weight
+	}
+	if total == 0 {
+		panic(fmt.Sprintf("experiment %s: all weights are 0", e.Name))
+	}
+}
+
+/* Choose: variant of key, the same key always gets the same variant as long as the variants are unchanged */
+func (e Experiment[V]) Choose(ctx context.Context, key string) Variant[V] {
+	if e.Assign != nil {
+		if name := e.Assign(ctx, key); name != "" {
+			for _, v := range e.Variants {
+				if v.Name == name {
+					return v
+				}
+			}
+		}
+	}
+	total := 0.0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	h := fnv.New64a()
+	h.Write([]byte(e.Name + "\x00" + key))
+	point := float64(h.Sum64()%1_000_000) / 1_000_000 * total
+	for _, v := range e.Variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	// rounding
+	return e.Variants[len(e.Variants)-1]
+}
+
+/*
+ExperimentComponent: executor running the variant chosen for key (the run id when key is nil or returns ""),
+e.g. the document id so reprocessed documents keep their variant. Invalid experiments panic.
+*/
+func ExperimentComponent[CT context.Context, I any, C any, T any](e Experiment[ComponentFunction[CT, I, C, T]], key func(CT, I, *DataTracker[C, T]) string) ComponentFunction[CT, I, C, T] {
+	e.validate()
+	for _, v := range e.Variants {
+		if v.Value == nil {
+			panic(fmt.Sprintf("experiment %s: executor of variant %s cannot be nil", e.Name, v.Name))
+		}
+	}
+	return func(ctx CT, input I, dt *DataTracker[C, T]) error {
+		k := ""
+		if key != nil {
+			k = key(ctx, input, dt)
+		}
+		if k == "" {
+			info, _ := ComponentInfoFromContext(dt.Context())
+			k = info.WorkflowId
+		}
+		variant := e.Choose(dt.Context(), k)
+		dt.SetRunMetadata(e.metadataKey(), variant.Name)
+		return variant.Value(ctx, input, dt)
+	}
+}
+
+/*
+ExecuteExperiment runs a fresh workflow of the template of the variant chosen for key, whole pipelines can be
+compared this way. Without key the assignment is random. Invalid experiments panic.
+*/
+func ExecuteExperiment[CT context.Context, C any, T any](ctx CT, e Experiment[*Template[CT, C, T]], key string, config C, data *T) (*T, Status, error) {
+	e.validate()
+	for _, v := range e.Variants {
+		if v.Value == nil {
+			panic(fmt.Sprintf("experiment %s: template of variant %s cannot be nil", e.Name, v.Name))
+		}
+	}
+	if key == "" {
+		key = uuid.New().String()
+	}
+	variant := e.Choose(ctx, key)
+	wf := variant.Value.NewWorkflow(ctx)
+	wf.SetMetadata(e.metadataKey(), variant.Name)
+	return wf.Execute(ctx, config, data)
+}
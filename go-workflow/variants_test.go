@@ -0,0 +1,83 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperimentComponent(t *testing.T) {
+	model := func(name string) goworkflow.ComponentFunction[context.Context, any, Config, Data] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = name })
+			return nil
+		}
+	}
+	experiment := goworkflow.Experiment[goworkflow.ComponentFunction[context.Context, any, Config, Data]]{
+		Name: "extraction",
+		Variants: []goworkflow.Variant[goworkflow.ComponentFunction[context.Context, any, Config, Data]]{
+			{Name: "control", Weight: 3, Value: model("control")},
+			{Name: "candidate", Weight: 1, Value: model("candidate")},
+		},
+		Assign: func(ctx context.Context, key string) string {
+			if key == "pinned" {
+				return "candidate"
+			}
+			return ""
+		},
+	}
+	run := func(document string) (string, map[string]string) {
+		wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+		wf.AddComponent(goworkflow.MakeComponent("Extract", nil, goworkflow.ExperimentComponent(experiment, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) string {
+			return document
+		})))
+		data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		return data.A, wf.Result().Metadata
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		variant, metadata := run(fmt.Sprintf("document-%d", i))
+		assert.Equal(t, variant, metadata["experiment.extraction"])
+		counts[variant]++
+	}
+	assert.InDelta(t, 250, counts["candidate"], 50)
+
+	// assignment is stable per key
+	first, _ := run("document-1")
+	second, _ := run("document-1")
+	assert.Equal(t, first, second)
+	pinned, _ := run("pinned")
+	assert.Equal(t, "candidate", pinned)
+
+	assert.Panics(t, func() {
+		goworkflow.ExperimentComponent(goworkflow.Experiment[goworkflow.ComponentFunction[context.Context, any, Config, Data]]{Name: "empty"}, nil)
+	})
+}
+
+func TestExecuteExperiment(t *testing.T) {
+	pipeline := func(name string) *goworkflow.Template[context.Context, Config, Data] {
+		template := goworkflow.NewTemplate[context.Context, Config, Data](name)
+		template.AddComponent(goworkflow.MakeComponent("Run", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			dt.Update(func(d *Data) { d.A = name })
+			return nil
+		}))
+		return template
+	}
+	experiment := goworkflow.Experiment[*goworkflow.Template[context.Context, Config, Data]]{
+		Name: "pipeline",
+		Variants: []goworkflow.Variant[*goworkflow.Template[context.Context, Config, Data]]{
+			{Name: "v1", Weight: 1, Value: pipeline("v1")},
+			{Name: "v2", Weight: 0, Value: pipeline("v2")},
+		},
+	}
+	data, st, err := goworkflow.ExecuteExperiment(context.TODO(), experiment, "", Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "v1", data.A)
+}
@@ -0,0 +1,195 @@
+package wftest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+type observedUpdate[T any] struct {
+	component string
+	// json of the data before the update, replayed with perturbed fields
+	before  []byte
+	after   T
+	written []string
+	update  func(*T)
+}
+
+/*
+DataAccessChecker records the DataTracker updates of a run and finds components which read or write
+a field another component writes without one of them (transitively) depending on the other.
+Such components see a different value depending on which one the scheduler happens to run first.
+
+Reads are found by replaying each update with the field set to its zero value and to the value the other
+component wrote: when the rest of the update result differs, the update reads the field.
+Only reads inside Update callbacks are seen, the data must round trip through encoding/json
+and the callbacks must not have side effects outside the data, they run more than once.
+*/
+type DataAccessChecker[T any] struct {
+	lock    sync.Mutex
+	updates []*observedUpdate[T]
+	errors  []string
+	edges   func() []goworkflow.Edge
+}
+
+/* CheckDataAccess installs the checker on wf, it must be called before wf.Execute */
+func CheckDataAccess[CT context.Context, C any, T any](wf *goworkflow.Workflow[CT, C, T]) *DataAccessChecker[T] {
+	checker := &DataAccessChecker[T]{edges: wf.Edges}
+	wf.SetDataAccessObserver(checker)
+	return checker
+}
+
+func (c *DataAccessChecker[T]) ObserveUpdate(component string, data *T, update func(*T)) {
+	before, err := json.Marshal(data)
+	update(data)
+	if err != nil {
+		c.addError(fmt.Sprintf("update of %s not checked: %s", component, err))
+		return
+	}
+	after, err := copyData[T](data)
+	if err != nil {
+		c.addError(fmt.Sprintf("update of %s not checked: %s", component, err))
+		return
+	}
+	previous, _ := copyData[T](before)
+	observed := &observedUpdate[T]{component: component, before: before, after: *after, update: update}
+	observed.written = changedFields(reflect.ValueOf(previous).Elem(), reflect.ValueOf(after).Elem(), "")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.updates = append(c.updates, observed)
+}
+
+func (c *DataAccessChecker[T]) addError(msg string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.errors = append(c.errors, msg)
+}
+
+/* Problems: conflicting accesses of components which may run concurrently, empty when there are none */
+func (c *DataAccessChecker[T]) Problems() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	reach := reachability(c.edges())
+	concurrent := func(a, b string) bool {
+		return a != b && !reach[a][b] && !reach[b][a]
+	}
+	problems := append([]string{}, c.errors...)
+	for _, writer := range c.updates {
+		for _, field := range writer.written {
+			for _, u := range c.updates {
+				if !concurrent(u.component, writer.component) {
+					continue
+				}
+				if slices.Contains(u.written, field) {
+					if u.component < writer.component {
+						problems = append(problems, fmt.Sprintf("%s and %s both write %s without depending on each other", u.component, writer.component, field))
+					}
+				} else if u.reads(field, writer.after) {
+					problems = append(problems, fmt.Sprintf("%s reads %s written by %s without depending on it", u.component, field, writer.component))
+				}
+			}
+		}
+	}
+	slices.Sort(problems)
+	return slices.Compact(problems)
+}
+
+/* reads: replays the update with field zeroed and set to its value in written, the results differ when it reads field */
+func (u *observedUpdate[T]) reads(field string, written T) bool {
+	zero, err := u.replay(field, nil)
+	if err != nil {
+		return true
+	}
+	set, err := u.replay(field, &written)
+	if err != nil {
+		return true
+	}
+	// differences in the field itself are the perturbation, not a read
+	fieldValue(reflect.ValueOf(zero).Elem(), field).SetZero()
+	fieldValue(reflect.ValueOf(set).Elem(), field).SetZero()
+	return !reflect.DeepEqual(zero, set)
+}
+
+func (u *observedUpdate[T]) replay(field string, from *T) (data *T, err error) {
+	data, err = copyData[T](u.before)
+	if err != nil {
+		return nil, err
+	}
+	target := fieldValue(reflect.ValueOf(data).Elem(), field)
+	if from == nil {
+		target.SetZero()
+	} else {
+		target.Set(fieldValue(reflect.ValueOf(from).Elem(), field))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("update panicked: %v", r)
+		}
+	}()
+	u.update(data)
+	return data, nil
+}
+
+func copyData[T any](data any) (*T, error) {
+	b, ok := data.([]byte)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+	copied := new(T)
+	return copied, json.Unmarshal(b, copied)
+}
+
+/* changedFields: dotted paths of the leaf fields which differ, nested structs are compared field by field */
+func changedFields(before, after reflect.Value, prefix string) []string {
+	if after.Kind() != reflect.Struct {
+		if reflect.DeepEqual(before.Interface(), after.Interface()) {
+			return nil
+		}
+		if prefix == "" {
+			return []string{"."}
+		}
+		return []string{prefix}
+	}
+	var changed []string
+	for i := 0; i < after.NumField(); i++ {
+		if !after.Type().Field(i).IsExported() {
+			continue
+		}
+		path := after.Type().Field(i).Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		changed = append(changed, changedFields(before.Field(i), after.Field(i), path)...)
+	}
+	return changed
+}
+
+func fieldValue(v reflect.Value, path string) reflect.Value {
+	if path == "." {
+		return v
+	}
+	for _, name := range strings.Split(path, ".") {
+		v = v.FieldByName(name)
+	}
+	return v
+}
+
+/* AssertNoDataRaces fails the test for every conflicting access found by the checker, call it after wf.Execute */
+func AssertNoDataRaces[T any](t testing.TB, checker *DataAccessChecker[T]) {
+	t.Helper()
+	for _, problem := range checker.Problems() {
+		t.Errorf("data race: %s", problem)
+	}
+}
@@ -0,0 +1,101 @@
+package wftest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Document struct {
+	Text     string
+	Language string
+	Summary  struct {
+		Title string
+		Words int
+	}
+}
+
+func update(cb func(*Document)) goworkflow.ComponentFunction[context.Context, any, Config, Document] {
+	return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Document]) error {
+		dt.Update(cb)
+		return nil
+	}
+}
+
+/* Language reads Text, it only depends on OCR when dependent is true */
+func documentDataWorkflow(dependent bool) (*goworkflow.Workflow[context.Context, Config, Document], *DataAccessChecker[Document]) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Document](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, update(func(d *Document) { d.Text = "bonjour" })))
+	language := wf.AddComponent(goworkflow.MakeComponent("Language", nil, update(func(d *Document) {
+		if strings.HasPrefix(d.Text, "bon") {
+			d.Language = "fr"
+		}
+	})))
+	title := wf.AddComponent(goworkflow.MakeComponent("Title", nil, update(func(d *Document) { d.Summary.Title = "greeting" })))
+	title.AddDependencies(ocr)
+	if dependent {
+		language.AddDependencies(ocr)
+	}
+	checker := CheckDataAccess(wf)
+	wf.Execute(context.TODO(), Config{}, &Document{})
+	return wf, checker
+}
+
+func TestCheckDataAccess(t *testing.T) {
+	_, checker := documentDataWorkflow(true)
+	assert.Empty(t, checker.Problems())
+
+	_, checker = documentDataWorkflow(false)
+	assert.Equal(t, []string{"Language reads Text written by OCR without depending on it"}, checker.Problems())
+}
+
+func TestCheckDataAccessConcurrentWrites(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Document](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Counter", nil, update(func(d *Document) { d.Summary.Words = 1 })))
+	wf.AddComponent(goworkflow.MakeComponent("Tokenizer", nil, update(func(d *Document) { d.Summary.Words = 2 })))
+	checker := CheckDataAccess(wf)
+	wf.Execute(context.TODO(), Config{}, &Document{})
+	assert.Equal(t, []string{"Counter and Tokenizer both write Summary.Words without depending on each other"}, checker.Problems())
+}
+
+func TestCheckDataAccessPanickingRead(t *testing.T) {
+	type Pages struct {
+		Pages []string
+		First string
+	}
+	wf := goworkflow.NewWorkflow[context.Context, Config, Pages](context.TODO())
+	write := func(cb func(*Pages)) goworkflow.ComponentFunction[context.Context, any, Config, Pages] {
+		return func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Pages]) error {
+			dt.Update(cb)
+			return nil
+		}
+	}
+	split := wf.AddComponent(goworkflow.MakeComponent("Split", nil, write(func(p *Pages) { p.Pages = []string{"cover"} })))
+	first := wf.AddComponent(goworkflow.MakeComponent("First", nil, write(func(p *Pages) {
+		if len(p.Pages) > 0 {
+			p.First = p.Pages[0]
+		}
+	})))
+	first.AddDependencies(split)
+	checker := CheckDataAccess(wf)
+	wf.Execute(context.TODO(), Config{}, &Pages{})
+	assert.Empty(t, checker.Problems())
+
+	// indexing a zero slice panics in the replay, which counts as a read
+	wf = goworkflow.NewWorkflow[context.Context, Config, Pages](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("Split", nil, write(func(p *Pages) { p.Pages = []string{"cover"} })))
+	wf.AddComponent(goworkflow.MakeComponent("First", nil, write(func(p *Pages) { p.First = p.Pages[0] })))
+	checker = CheckDataAccess(wf)
+	wf.Execute(context.TODO(), Config{}, &Pages{Pages: []string{"draft"}})
+	assert.Equal(t, []string{"First reads Pages written by Split without depending on it"}, checker.Problems())
+}
+
+func TestAssertNoDataRaces(t *testing.T) {
+	_, checker := documentDataWorkflow(false)
+	tb := &recordingTB{}
+	AssertNoDataRaces(tb, checker)
+	assert.Equal(t, []string{"data race: Language reads Text written by OCR without depending on it"}, tb.errors)
+}
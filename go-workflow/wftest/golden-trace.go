@@ -0,0 +1,183 @@
+/*
+Package wftest has helpers for testing workflows.
+*/
+package wftest
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite golden trace files")
+
+/* TraceEvent: start or finish of a component */
+type TraceEvent struct {
+	Component string            `json:"component"`
+	Event     string            `json:"event"`
+	Status    goworkflow.Status `json:"status,omitempty"`
+}
+
+/*
+Trace of a run: its components, dependency edges and the order components started and finished in.
+The order of components which may run concurrently differs between runs, only the edges are enforced.
+*/
+type Trace struct {
+	Components []string          `json:"components"`
+	Edges      []goworkflow.Edge `json:"edges"`
+	Events     []TraceEvent      `json:"events"`
+}
+
+/* RecordTrace: trace of an executed workflow */
+func RecordTrace[CT context.Context, C any, T any](wf *goworkflow.Workflow[CT, C, T]) Trace {
+	trace := Trace{Components: []string{}, Edges: wf.Edges(), Events: []TraceEvent{}}
+	type timed struct {
+		TraceEvent
+		at int64
+	}
+	events := []timed{}
+	for _, timing := range wf.Timeline() {
+		trace.Components = append(trace.Components, timing.Component)
+		if !timing.StartedAt.IsZero() {
+			events = append(events, timed{TraceEvent{Component: timing.Component, Event: "start"}, timing.StartedAt.UnixNano()})
+		}
+		if !timing.FinishedAt.IsZero() {
+			events = append(events, timed{TraceEvent{Component: timing.Component, Event: "finish", Status: timing.Status}, timing.FinishedAt.UnixNano()})
+		}
+	}
+	slices.Sort(trace.Components)
+	slices.SortStableFunc(events, func(a, b timed) int {
+		if a.at != b.at {
+			return cmp.Compare(a.at, b.at)
+		}
+		return strings.Compare(a.Component, b.Component)
+	})
+	for _, e := range events {
+		trace.Events = append(trace.Events, e.TraceEvent)
+	}
+	return trace
+}
+
+/*
+CompareTrace: problems of actual compared to golden. Components and edges must be the same, actual must respect
+its edges, and components which could run concurrently in golden must not be serialized in actual.
+*/
+func CompareTrace(golden Trace, actual Trace) []string {
+	problems := []string{}
+	for _, c := range actual.Components {
+		if !slices.Contains(golden.Components, c) {
+			problems = append(problems, "added component "+c)
+		}
+	}
+	for _, c := range golden.Components {
+		if !slices.Contains(actual.Components, c) {
+			problems = append(problems, "removed component "+c)
+		}
+	}
+	for _, e := range actual.Edges {
+		if !slices.Contains(golden.Edges, e) {
+			problems = append(problems, "added edge "+e.String())
+		}
+	}
+	for _, e := range golden.Edges {
+		if !slices.Contains(actual.Edges, e) {
+			problems = append(problems, "removed edge "+e.String())
+		}
+	}
+
+	// edges respected: a component starts after all its dependencies finished
+	position := map[TraceEvent]int{}
+	for i, e := range actual.Events {
+		position[TraceEvent{Component: e.Component, Event: e.Event}] = i
+	}
+	for _, e := range actual.Edges {
+		start, started := position[TraceEvent{Component: e.To, Event: "start"}]
+		if !started {
+			continue
+		}
+		finish, finished := position[TraceEvent{Component: e.From, Event: "finish"}]
+		if !finished || finish > start {
+			problems = append(problems, fmt.Sprintf("%s started before %s finished", e.To, e.From))
+		}
+	}
+
+	// new serialization: components without a path between them in golden now have one
+	goldenReach, actualReach := reachability(golden.Edges), reachability(actual.Edges)
+	for _, a := range golden.Components {
+		for _, b := range golden.Components {
+			if a != b && !goldenReach[a][b] && !goldenReach[b][a] && actualReach[a][b] {
+				problems = append(problems, fmt.Sprintf("%s and %s ran concurrently, now %s waits for %s", a, b, b, a))
+			}
+		}
+	}
+	return problems
+}
+
+func reachability(edges []goworkflow.Edge) map[string]map[string]bool {
+	next := map[string][]string{}
+	for _, e := range edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+	reach := map[string]map[string]bool{}
+	var visit func(from, node string)
+	visit = func(from, node string) {
+		for _, n := range next[node] {
+			if !reach[from][n] {
+				reach[from][n] = true
+				visit(from, n)
+			}
+		}
+	}
+	for from := range next {
+		reach[from] = map[string]bool{}
+		visit(from, from)
+	}
+	return reach
+}
+
+/*
+AssertGoldenTrace compares the trace of the executed workflow with the golden file at path, e.g. testdata/document.json.
+The file is written when it doesn't exist or the tests run with -update-golden.
+*/
+func AssertGoldenTrace[CT context.Context, C any, T any](t testing.TB, wf *goworkflow.Workflow[CT, C, T], path string) {
+	t.Helper()
+	actual := RecordTrace(wf)
+	b, err := os.ReadFile(path)
+	if *updateGolden || errors.Is(err, os.ErrNotExist) {
+		if err := writeTrace(path, actual); err != nil {
+			t.Fatalf("writing golden trace %s: %v", path, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("reading golden trace %s: %v", path, err)
+	}
+	golden := Trace{}
+	if err := json.Unmarshal(b, &golden); err != nil {
+		t.Fatalf("parsing golden trace %s: %v", path, err)
+	}
+	if problems := CompareTrace(golden, actual); len(problems) > 0 {
+		t.Errorf("run does not conform to golden trace %s (rerun with -update-golden if intended):\n%s", path, strings.Join(problems, "\n"))
+	}
+}
+
+func writeTrace(path string, trace Trace) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,88 @@
+package wftest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{}
+
+type Data struct{}
+
+func noop(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+	return nil
+}
+
+/* document workflow, serialized makes Layout wait for Thumbnail */
+func documentWorkflow(serialized bool) *goworkflow.Workflow[context.Context, Config, Data] {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, noop))
+	thumbnail := wf.AddComponent(goworkflow.MakeComponent("Thumbnail", nil, noop))
+	layout := wf.AddComponent(goworkflow.MakeComponent("Layout", nil, noop))
+	layout.AddDependencies(ocr)
+	if serialized {
+		layout.AddDependencies(thumbnail)
+	}
+	wf.Execute(context.TODO(), Config{}, &Data{})
+	return wf
+}
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecordTrace(t *testing.T) {
+	trace := RecordTrace(documentWorkflow(false))
+	assert.Equal(t, []string{"Layout", "OCR", "Thumbnail"}, trace.Components)
+	assert.Equal(t, []goworkflow.Edge{{From: "OCR", To: "Layout"}}, trace.Edges)
+	assert.Len(t, trace.Events, 6)
+	assert.Empty(t, CompareTrace(trace, trace))
+}
+
+func TestAssertGoldenTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "document.json")
+
+	// first run writes the golden file, the second conforms to it
+	AssertGoldenTrace(t, documentWorkflow(false), path)
+	AssertGoldenTrace(t, documentWorkflow(false), path)
+
+	tb := &recordingTB{TB: t}
+	AssertGoldenTrace(tb, documentWorkflow(true), path)
+	assert.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "added edge Thumbnail -> Layout")
+	assert.Contains(t, tb.errors[0], "Thumbnail and Layout ran concurrently, now Layout waits for Thumbnail")
+}
+
+func TestCompareTraceOrder(t *testing.T) {
+	golden := Trace{
+		Components: []string{"OCR", "Parse"},
+		Edges:      []goworkflow.Edge{{From: "OCR", To: "Parse"}},
+	}
+	actual := golden
+	actual.Events = []TraceEvent{
+		{Component: "OCR", Event: "start"},
+		{Component: "Parse", Event: "start"},
+		{Component: "OCR", Event: "finish", Status: goworkflow.DONE},
+		{Component: "Parse", Event: "finish", Status: goworkflow.DONE},
+	}
+	assert.Equal(t, []string{"Parse started before OCR finished"}, CompareTrace(golden, actual))
+
+	actual.Components = []string{"OCR"}
+	assert.Contains(t, CompareTrace(golden, actual), "removed component Parse")
+}
@@ -2,12 +2,15 @@ package goworkflow
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
@@ -18,27 +21,136 @@ type Status string
 const PENDING Status = "PENDING"
 const DONE Status = "DONE"
 const ERROR Status = "ERROR"
+const RUNNING Status = "RUNNING"
+const SKIPPED Status = "SKIPPED"
+
+/* DONE_WITH_WARNINGS: final status of a run in which only optional components failed */
+const DONE_WITH_WARNINGS Status = "DONE_WITH_WARNINGS"
+
+/* QUARANTINED: final status of a run whose data failed a quality gate, see QualityGate */
+const QUARANTINED Status = "QUARANTINED"
+
+type dataStore[T any] struct {
+	// lock: held exclusively by Update, shared by UpdateIndex which locks the stripe of its index
+	lock     sync.RWMutex
+	stripes  [dataStoreStripes]sync.Mutex
+	data     *T
+	observer DataAccessObserver[T]
+	// recordChanges receives the fields changed by every update, see Workflow.SetRecordDataChanges
+	recordChanges func(component string, changes []FieldChange)
+	watchers      []*dataWatcher[T]
+	// generation: incremented by every update, see Derived
+	generation atomic.Uint64
+}
 
+/* DataTracker: each component gets its own tracker, all trackers of a run share the same data store */
 type DataTracker[C any, T any] struct {
-	lock   sync.Mutex
 	Config C
-	data   *T
+	store  *dataStore[T]
+	ctx    context.Context
 }
 
 func (d *DataTracker[C, T]) GetData() T {
-	return *d.data
+	return *d.store.data
 }
 
 func (d *DataTracker[C, T]) Update(cb func(*T)) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	cb(d.data)
+	d.store.lock.Lock()
+	defer d.store.lock.Unlock()
+	defer d.store.notifyWatchers()
+	defer d.store.generation.Add(1)
+	if d.store.observer != nil {
+		info, _ := ComponentInfoFromContext(d.ctx)
+		d.store.observer.ObserveUpdate(info.Component, d.store.data, cb)
+		return
+	}
+	if d.store.recordChanges != nil {
+		before, err := copyData(d.store.data)
+		cb(d.store.data)
+		if err != nil {
+			log.Println("DataTracker.Update:Error:", err)
+			return
+		}
+		if changes, err := DiffData(before, d.store.data); err == nil && len(changes) > 0 {
+			info, _ := ComponentInfoFromContext(d.ctx)
+			d.store.recordChanges(info.Component, changes)
+		}
+		return
+	}
+	cb(d.store.data)
+}
+
+/* Context: component scoped context, carries values like the assigned limiter slot */
+func (d *DataTracker[C, T]) Context() context.Context {
+	return d.ctx
+}
+
+func (d *DataTracker[C, T]) forComponent(ctx context.Context) *DataTracker[C, T] {
+	return &DataTracker[C, T]{Config: d.Config, store: d.store, ctx: ctx}
+}
+
+/* component scoped context: component info, run metadata, secrets, services, feature flags, artifact store, latches and child runs */
+func (wf *Workflow[CT, C, T]) scopedContext(ctx context.Context, c *component[CT, C, T]) context.Context {
+	ctx = context.WithValue(ctx, componentInfoContextKey{}, ComponentInfo{WorkflowId: wf.id, Workflow: wf.name, Component: c.Name, ComponentId: c.id})
+	ctx = context.WithValue(ctx, runMetadataContextKey{}, wf.SetMetadata)
+	ctx = contextWithSecrets(ctx, wf.secretsProvider)
+	ctx = contextWithServices(ctx, wf.services)
+	ctx = wf.contextWithFlags(ctx, c)
+	if wf.artifactStore != nil {
+		ctx = context.WithValue(ctx, artifactStoreContextKey{}, wf.artifactStore)
+	}
+	ctx = wf.contextWithSync(ctx)
+	return wf.contextWithChildRuns(ctx, c)
+}
+
+/* components receive the component scoped context directly when CT is a plain context.Context */
+func componentContext[CT context.Context](ctx CT, componentCtx context.Context) CT {
+	if c, ok := componentCtx.(CT); ok {
+		return c
+	}
+	return ctx
 }
 
 type ComponentInput interface{}
 
 type ComponentConfig struct {
 	ConcurrencyLimiter *limiter.ConcurrencyLimiter
+	// SlotPool assigns the component a named slot for the duration of its execution, see limiter.SlotFromContext
+	SlotPool *limiter.SlotPool
+	// data store fields (dot separated paths) read and written by the component, see Workflow.InferDependencies
+	Reads  []string
+	Writes []string
+	// ConfigReads: config fields (dot paths) the component depends on, see Template.Affected. nil when unknown,
+	// the component is then affected by any config change
+	ConfigReads []string
+	// SLO: objectives of the component, see Workflow.OnSLOBreach
+	SLO *SLO
+	// Optional: failure of the component doesn't fail the run, it finishes DONE_WITH_WARNINGS instead.
+	// Components depending on it with OnSuccess are SKIPPED
+	Optional bool
+	// MinDuration: the component is not started (and fails) when less time is left before the deadline of the run
+	MinDuration time.Duration
+	// ExpectedDuration: estimate of the execution time, see Workflow.Progress
+	ExpectedDuration time.Duration
+	// Retry: retries of failed executions, none when nil
+	Retry *RetryPolicy
+	// Bulkhead: name of the bulkhead the component holds a slot of while executing, see Workflow.SetBulkheads
+	Bulkhead string
+	// Timeout of every execution attempt, the context of the component is cancelled when it is exceeded. None when 0
+	Timeout time.Duration
+	// Limiter: custom limiter the component holds a ticket of while executing, see Workflow.SetLimiterInstrumentation
+	Limiter limiter.Limiter
+	// ValidateInput checks the input before the run starts, in addition to inputs implementing InputValidator
+	ValidateInput func(input ComponentInput) error
+	// Flag: feature flag gating the component, it is SKIPPED unless the flag is "on" for the run, see Workflow.SetFlagProvider
+	Flag string
+	// Failover: endpoints the attempts of the component are routed to, see EndpointFromContext
+	Failover *Failover
+	// TokenLimiter: every attempt reserves a request and EstimateTokens(input) tokens, see ReportTokenUsage
+	TokenLimiter   *limiter.TokenLimiter
+	EstimateTokens func(input ComponentInput) int
+	// Cache: memoization of the results of the component, see CachePolicy
+	Cache *CachePolicy
 }
 
 type componentFunctionInternal[CT context.Context, C any, T any] func(CT, ComponentInput, *DataTracker[C, T]) error
@@ -50,12 +162,24 @@ type componentStatus struct {
 
 type component[CT context.Context, C any, T any] struct {
 	id              string
+	seq             int // declaration order
 	Name            string
 	input           ComponentInput
-	addDependency   func(d *component[CT, C, T])
+	addDependency   func(d *component[CT, C, T], outcome DependencyOutcome)
+	addAnyOfLinks   func(d []*component[CT, C, T])
+	anyOf           []anyOfGroup[CT, C, T]
+	cancellation    componentCancellation
 	executor        componentFunctionInternal[CT, C, T]
 	addComponentCfg *ComponentConfig
-	status          componentStatus
+	// skipReason: the component is SKIPPED instead of executed, see Workflow.ApplyProfile
+	skipReason string
+	statusLock sync.Mutex
+	status     componentStatus
+	timing     ComponentTiming
+	// failure: structured error of the last execution, see ComponentError
+	failure *ComponentError
+	// failureDump: see Workflow.SetFailureDumps
+	failureDump ArtifactRef
 }
 
 type Component[CT context.Context, C any, T any] *component[CT, C, T]
@@ -63,11 +187,13 @@ type Component[CT context.Context, C any, T any] *component[CT, C, T]
 /* AddDependencies: current component required all d as dependency, so they will be executed before it*/
 func (c *component[CT, C, T]) AddDependencies(d ...Component[CT, C, T]) {
 	for _, dep := range d {
-		c.addDependency(dep)
+		c.addDependency(dep, OnSuccess)
 	}
 }
 
 func (c *component[CT, C, T]) Status() componentStatus {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
 	return c.status
 }
 
@@ -82,9 +208,20 @@ type dependencyManager struct {
 	dependencyGraph    map[string]map[string]bool
 	dependencyChannels map[string]dependencyChannel
 	componentIdToName  map[string]string
+	// dependencyId -> componentId -> outcome, for links which don't require success
+	outcomes map[string]map[string]DependencyOutcome
+	// componentId -> groups of dependencyIds, any one of each group is enough
+	anyOf map[string][][]string
+	// ids of optional components
+	optional map[string]bool
 }
 
 func (d *dependencyManager) AddLink(componentId string, dependencyId string) {
+	d.AddLinkOn(componentId, dependencyId, OnSuccess)
+}
+
+/* AddLinkOn: componentId runs only when dependencyId finishes with outcome */
+func (d *dependencyManager) AddLinkOn(componentId string, dependencyId string, outcome DependencyOutcome) {
 	d.lk.Lock()
 	defer d.lk.Unlock()
 
@@ -92,6 +229,14 @@ func (d *dependencyManager) AddLink(componentId string, dependencyId string) {
 		d.dependencyGraph[dependencyId] = make(map[string]bool)
 	}
 	d.dependencyGraph[dependencyId][componentId] = true
+	if _, ok := d.outcomes[dependencyId]; !ok {
+		d.outcomes[dependencyId] = make(map[string]DependencyOutcome)
+	}
+	if outcome == OnSuccess {
+		delete(d.outcomes[dependencyId], componentId)
+	} else {
+		d.outcomes[dependencyId][componentId] = outcome
+	}
 }
 
 func (d *dependencyManager) hasCircularDependency() (bool, string) {
@@ -179,30 +324,148 @@ func (d *dependencyManager) UpdateStatus(componentId string, status Status) {
 	}
 }
 
-/* await all dependencies of component with componentId as Id */
+/*
+await all dependencies of component with componentId as Id.
+Returns ERROR when a dependency required to succeed failed, SKIPPED when another dependency outcome is not met, DONE otherwise
+*/
 func (d *dependencyManager) WaitDependencies(componentId string) Status {
 	wg := sync.WaitGroup{}
-	overallStatus := DONE
+	lk := sync.Mutex{}
+	failed, skipped := false, false
+	inGroup := map[string]bool{}
+	for _, group := range d.anyOf[componentId] {
+		for _, dependencyId := range group {
+			inGroup[dependencyId] = true
+		}
+		wg.Add(1)
+		go func(group []string) {
+			defer wg.Done()
+			st := d.waitAnyOf(group)
+			lk.Lock()
+			defer lk.Unlock()
+			failed = failed || st == ERROR
+			skipped = skipped || st == SKIPPED
+		}(group)
+	}
 	for dependencyId := range d.dependencyGraph {
-		if _, ok := d.dependencyGraph[dependencyId][componentId]; ok {
+		if _, ok := d.dependencyGraph[dependencyId][componentId]; ok && !inGroup[dependencyId] {
 			wg.Add(1)
 			go func(k string) {
 				defer wg.Done()
 				st := <-d.dependencyChannels[k].Dependency
-				if st == ERROR {
-					overallStatus = ERROR
+				lk.Lock()
+				defer lk.Unlock()
+				switch d.outcomes[k][componentId] {
+				case OnFailure:
+					skipped = skipped || st != ERROR
+				case OnCompletion:
+				default:
+					failed = failed || (st == ERROR && !d.optional[k])
+					skipped = skipped || st == SKIPPED || (st == ERROR && d.optional[k])
 				}
 			}(dependencyId)
 		}
 	}
 	wg.Wait()
-	return overallStatus
+	if failed {
+		return ERROR
+	}
+	if skipped {
+		return SKIPPED
+	}
+	return DONE
 }
 
 type Workflow[CT context.Context, C any, T any] struct {
+	id                string
 	executed          bool
 	componentsMap     map[string]*component[CT, C, T]
 	dependencyManager *dependencyManager
+	// set when the run is queued through a RunExecutor
+	runTicket *limiter.Ticket
+
+	stateLock       sync.Mutex
+	status          Status
+	stateChangeHook StateChangeHook
+	// stateChangeQueue: calls stateChangeHook outside stateLock
+	stateChangeQueue taskQueue
+	// backgroundQueues: work of state listeners which must not run under stateLock, see backgroundQueue
+	backgroundQueues []*taskQueue
+	stateListeners   []StateChangeHook
+	// config of the current run, set when Execute starts
+	config C
+	store  *dataStore[T]
+	audit  *auditLog
+
+	secretsProvider  SecretsProvider
+	configValidators []func(C) error
+	configOverlays   []ConfigOverlay
+	buildChecks      []func() error
+
+	// set for workflows created from a template
+	name    string
+	version string
+	// names of components restored as DONE from a checkpoint
+	restored map[string]bool
+
+	runSLO *SLO
+
+	notifiers         []Notifier
+	runStore          RunStore
+	artifactStore     ArtifactStore
+	dataSizeLimit     *DataSizeLimit
+	durationEstimator DurationEstimator
+	durationStats     DurationStatsStore
+	deterministic     bool
+	retryBudget       *RetryBudget
+	bulkheads         *Bulkheads
+	// bulkhead names -> ids of the roots of their branches, see AssignBulkhead
+	branchBulkheads map[string][]string
+	// componentId -> pool, set when Execute starts
+	componentBulkheads map[string]componentBulkhead
+	// testing hook, see SetDataAccessObserver
+	dataAccessObserver DataAccessObserver[T]
+	metadata           map[string]string
+	flagProvider       FlagProvider
+	// flagKey: key of the run for percentage rollouts, see SetFlagKey
+	flagKey           string
+	recordDataChanges bool
+	// dataChanges: component name -> changed fields, see SetRecordDataChanges. Guarded by dataChangesLock, not
+	// stateLock: changes are recorded while the data store is locked, and checkpoints lock the data store under stateLock
+	dataChanges     map[string][]FieldChange
+	dataChangesLock sync.Mutex
+	watchers        []*dataWatcher[T]
+	// configIsolation: see SetConfigIsolation
+	configIsolation ConfigIsolation
+	partialResults  partialResults[T]
+	// children: runs started inside the components, see ViewOptions
+	children []startedChild
+	// sync: latches and barriers, see AddLatch
+	sync *syncPrimitives
+	// failureDumps: see SetFailureDumps
+	failureDumps *failureDumps
+	// recording: see SetRecording
+	recording *RunRecording
+	// dedup: see SetDedupKey
+	dedup *dedup
+	// services: see SetServices
+	services *Services
+	// deadLetterSink: see SetDeadLetterSink
+	deadLetterSink DeadLetterSink
+	// debugSampling: see SetDebugSampling
+	debugSampling *debugSampling
+	// poolRouter: see SetPoolRouter
+	poolRouter *PoolRouter
+	// parallelism: slots of WorkflowOptions.MaxParallelComponents, nil when unlimited
+	parallelism            *limiter.ConcurrencyLimiter
+	limiterInstrumentation limiter.Instrumentation
+	startedAt              time.Time
+	finishedAt             time.Time
+}
+
+/* Id: unique id of the workflow run */
+func (wf *Workflow[CT, C, T]) Id() string {
+	return wf.id
 }
 
 /* for testing purposes: reset workflow and dependencies*/
@@ -222,6 +485,8 @@ type makeComponentConfig[CT context.Context, C any, T any] struct {
 	Name     string
 	Input    any
 	Executor componentFunctionInternal[CT, C, T]
+	// Validate: checks of the component itself before the run, see ComponentImplementation
+	Validate func() error
 }
 
 func MakeComponent[CT context.Context, I any, C any, T any](
@@ -256,99 +521,303 @@ func (wf *Workflow[CT, C, T]) AddComponent(componentCfg makeComponentConfig[CT,
 	if componentCfg.Executor == nil {
 		panic("executor cannot be nil")
 	}
+	input, name, validate := componentCfg.Input, componentCfg.Name, componentCfg.Validate
+	wf.addBuildCheck(func() error { return validateInput(name, validate, input, cfg) })
 	id := uuid.New().String()
-	var addDependencyWrapper = func(d *component[CT, C, T]) {
-		wf.dependencyManager.AddLink(id, d.id)
+	var addDependencyWrapper = func(d *component[CT, C, T], outcome DependencyOutcome) {
+		wf.dependencyManager.AddLinkOn(id, d.id, outcome)
+	}
+	var addAnyOfLinksWrapper = func(d []*component[CT, C, T]) {
+		ids := []string{}
+		for _, dep := range d {
+			ids = append(ids, dep.id)
+		}
+		wf.dependencyManager.AddAnyOfLinks(id, ids)
 	}
-	component := component[CT, C, T]{
+	component := &component[CT, C, T]{
 		id:              id,
+		seq:             len(wf.componentsMap),
 		Name:            componentCfg.Name,
 		input:           componentCfg.Input,
 		executor:        componentCfg.Executor,
 		status:          componentStatus{Status: PENDING},
 		addDependency:   addDependencyWrapper,
+		addAnyOfLinks:   addAnyOfLinksWrapper,
 		addComponentCfg: cfg,
 	}
-	wf.componentsMap[id] = &component
+	wf.componentsMap[id] = component
+	if cfg != nil && cfg.Optional {
+		wf.dependencyManager.optional[id] = true
+	}
+	if cfg != nil && cfg.Bulkhead != "" {
+		wf.addBulkheadCheck(cfg.Bulkhead)
+	}
+	if cfg != nil && cfg.Cache != nil {
+		wf.addCacheCheck(component)
+	}
 	wf.dependencyManager.componentIdToName[id] = componentCfg.Name
-	return &component
+	return component
+}
+
+/* components in declaration order, for deterministic iteration */
+func (wf *Workflow[CT, C, T]) sortedComponents() []*component[CT, C, T] {
+	components := make([]*component[CT, C, T], 0, len(wf.componentsMap))
+	for _, c := range wf.componentsMap {
+		components = append(components, c)
+	}
+	slices.SortFunc(components, func(a, b *component[CT, C, T]) int {
+		return a.seq - b.seq
+	})
+	return components
 }
 
 func (wf *Workflow[CT, C, T]) Execute(ctx CT, config C, data *T) (*T, Status, error) {
+	defer wf.flushBackground()
+	defer wf.closePartialResults()
 	if wf.executed {
 		return nil, ERROR, errors.New("workflow already executed")
 	}
 	if data == nil {
 		return nil, ERROR, errors.New("data cannot be nil")
 	}
-	dataTracker := DataTracker[C, T]{Config: config, data: data}
-	err := wf.dependencyManager.BuildChannels()
+	config, err := MergeConfig(config, wf.configOverlays...)
 	if err != nil {
 		log.Println("Workflow.Execute:Error:", err)
+		wf.setWorkflowStatus(ERROR, err.Error())
 		return data, ERROR, err
 	}
-
-	wg := sync.WaitGroup{}
-
-	for _, cmp := range wf.componentsMap {
-		wg.Add(1)
-		go func(c *component[CT, C, T]) {
-			defer wg.Done()
-			executionStatus := DONE
-			errMsg := ""
-			// check if all dependencies are done
-			overallStatus := wf.dependencyManager.WaitDependencies(c.id)
-			if overallStatus == ERROR {
-				log.Println("Workflow.Execute:Error:Dependency failed for component:", c.id)
-				executionStatus = ERROR
-				errMsg = fmt.Sprintf("component dependency failed: %s", c.id)
-			}
-
-			// execute the component if dependencies are resolved
-			if executionStatus == DONE {
-				if c.addComponentCfg != nil && c.addComponentCfg.ConcurrencyLimiter != nil {
-					c.addComponentCfg.ConcurrencyLimiter.Acquire()
-				}
-				err := c.executor(ctx, c.input, &dataTracker)
-				if c.addComponentCfg != nil && c.addComponentCfg.ConcurrencyLimiter != nil {
-					defer c.addComponentCfg.ConcurrencyLimiter.Release()
-				}
-				if err != nil {
-					log.Println("Workflow.Execute:Error:Component execution failed for component:", c.id, err)
-					executionStatus = ERROR
-					errMsg = err.Error()
-				}
-			}
-			// update the status of the component
-			c.status = componentStatus{
-				Status:       executionStatus,
-				ErrorMessage: errMsg,
-			}
-			wf.dependencyManager.UpdateStatus(c.id, executionStatus)
-		}(cmp)
+	wf.sampleDebug()
+	wf.routePools()
+	dataTracker := DataTracker[C, T]{Config: config, store: &dataStore[T]{data: data, observer: wf.dataAccessObserver}, ctx: ctx}
+	if wf.recordDataChanges || wf.partialResults.changes {
+		dataTracker.store.recordChanges = wf.recordChanges
+	}
+	for _, w := range wf.watchers {
+		w.last = w.selector(data)
+		dataTracker.store.watchers = append(dataTracker.store.watchers, w)
+	}
+	if err := wf.validateConfig(config); err != nil {
+		log.Println("Workflow.Execute:Error:", err)
+		wf.setWorkflowStatus(ERROR, err.Error())
+		return data, ERROR, err
+	}
+	err = wf.dependencyManager.BuildChannels()
+	if err != nil {
+		log.Println("Workflow.Execute:Error:", err)
+		wf.setWorkflowStatus(ERROR, err.Error())
+		return data, ERROR, err
+	}
+	if status, claimed, err := wf.deduplicate(ctx); err != nil {
+		log.Println("Workflow.Execute:Error:", err)
+		wf.setWorkflowStatus(ERROR, err.Error())
+		return data, ERROR, err
+	} else if claimed {
+		defer wf.releaseClaim()
+	} else {
+		wf.executed = true
+		wf.setWorkflowStatus(status, "duplicate of run "+wf.DuplicateOf())
+		return data, status, nil
+	}
+	wf.resolveBulkheads()
+	wf.stateLock.Lock()
+	wf.config = config
+	wf.store = dataTracker.store
+	wf.stateLock.Unlock()
+	wf.recordInitial(dataTracker.store)
+	wf.setWorkflowStatus(RUNNING, "")
+
+	if wf.deterministic {
+		for _, c := range wf.executionOrder() {
+			wf.runComponent(ctx, c, &dataTracker)
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		for _, cmp := range wf.componentsMap {
+			wg.Add(1)
+			go func(c *component[CT, C, T]) {
+				defer wg.Done()
+				wf.runComponent(ctx, c, &dataTracker)
+			}(cmp)
+		}
+		wg.Wait()
 	}
-	wg.Wait()
 
 	wf.executed = true
 
 	finalStatus := DONE
+	cause := ""
+	failed := []string{}
+	failedOptional := []string{}
 	for _, cmp := range wf.componentsMap {
-		if cmp.status.Status == ERROR {
-			finalStatus = ERROR
-			break
+		if cmp.Status().Status != ERROR {
+			continue
+		}
+		if cmp.addComponentCfg != nil && cmp.addComponentCfg.Optional {
+			failedOptional = append(failedOptional, cmp.Name)
+		} else {
+			failed = append(failed, cmp.Name)
 		}
 	}
+	if gates := wf.failedQualityGates(); len(gates) > 0 {
+		finalStatus = QUARANTINED
+		cause = fmt.Sprintf("quality gates failed: %s", strings.Join(gates, ", "))
+	} else if len(failed) > 0 {
+		finalStatus = ERROR
+		slices.Sort(failed)
+		cause = fmt.Sprintf("components failed: %s", strings.Join(failed, ", "))
+	} else if len(failedOptional) > 0 {
+		finalStatus = DONE_WITH_WARNINGS
+		slices.Sort(failedOptional)
+		cause = fmt.Sprintf("optional components failed: %s", strings.Join(failedOptional, ", "))
+	}
+	wf.setWorkflowStatus(finalStatus, cause)
+	if finalStatus == QUARANTINED {
+		wf.deadLetter(ctx, dataTracker.store)
+	}
+	wf.storeDebugTrace(ctx)
+	wf.releaseClaim()
+	wf.saveRun(ctx)
+	wf.notify(ctx)
 	return data, finalStatus, nil
 }
 
-func NewWorkflow[CT context.Context, C any, T any](ctx CT) *Workflow[CT, C, T] {
-	return &Workflow[CT, C, T]{
+/*
+acquireComponentLimiters acquires the ConcurrencyLimiter, SlotPool and Limiter of the ComponentConfig of c, the
+returned context carries the slot. release gives them back in reverse order
+*/
+func (wf *Workflow[CT, C, T]) acquireComponentLimiters(ctx context.Context, c *component[CT, C, T]) (context.Context, func()) {
+	releases := []func(){}
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	cfg := c.addComponentCfg
+	if cfg == nil {
+		return ctx, release
+	}
+	if cfg.ConcurrencyLimiter != nil {
+		cl := cfg.ConcurrencyLimiter
+		releases = append(releases, wf.acquireLimiter(ctx, c, "concurrency", cl.Acquire, cl.Release))
+	}
+	if cfg.SlotPool != nil {
+		pool, slot := cfg.SlotPool, ""
+		releases = append(releases, wf.acquireLimiter(ctx, c, "slots", func() { slot = pool.Acquire(c.Name) }, func() { pool.Release(slot) }))
+		ctx = limiter.ContextWithSlot(ctx, slot)
+	}
+	if cfg.Limiter != nil {
+		l := cfg.Limiter
+		releases = append(releases, wf.acquireLimiter(ctx, c, limiterName(l), l.Acquire, l.Release))
+	}
+	return ctx, release
+}
+
+/* runComponent waits for the dependencies of c, executes it and publishes its status to the dependents */
+func (wf *Workflow[CT, C, T]) runComponent(ctx CT, c *component[CT, C, T], dataTracker *DataTracker[C, T]) {
+	if wf.restored[c.Name] {
+		wf.setComponentStatus(c, DONE, "restored from checkpoint")
+		wf.recordStep(c, DONE, "restored from checkpoint", nil, dataTracker.store)
+		wf.emitPartialResult(c, DONE, dataTracker.store)
+		wf.dependencyManager.UpdateStatus(c.id, DONE)
+		return
+	}
+	executionStatus := DONE
+	errMsg := ""
+	var before json.RawMessage
+	// check if all dependencies are done
+	overallStatus := wf.dependencyManager.WaitDependencies(c.id)
+	c.markReady()
+	if overallStatus == ERROR {
+		log.Println("Workflow.Execute:Error:Dependency failed for component:", c.id, "run:", wf.id)
+		executionStatus = ERROR
+		errMsg = fmt.Sprintf("component dependency failed: %s", c.id)
+	} else if overallStatus == SKIPPED {
+		executionStatus = SKIPPED
+		errMsg = "dependency outcome not met"
+	} else if c.skipReason != "" {
+		executionStatus = SKIPPED
+		errMsg = c.skipReason
+	} else if reason := wf.flagDisabled(ctx, c); reason != "" {
+		executionStatus = SKIPPED
+		errMsg = reason
+	} else {
+		wf.cancelAnyOfLosers(c)
+	}
+
+	// give way to higher priority runs before starting the component
+	if executionStatus == DONE && wf.runTicket != nil {
+		if err := wf.runTicket.Yield(ctx); err != nil {
+			log.Println("Workflow.Execute:Error:Run cancelled before component:", c.id, err)
+			executionStatus = ERROR
+			errMsg = err.Error()
+		}
+	}
+
+	// execute the component if dependencies are resolved
+	if executionStatus == DONE {
+		componentCtx := wf.scopedContext(ctx, c)
+		// the slot of the run first, components waiting for it must not hold slots shared with other runs
+		if wf.parallelism != nil {
+			defer wf.acquireLimiter(componentCtx, c, "workflow", wf.parallelism.Acquire, wf.parallelism.Release)()
+		}
+		if bulkhead, ok := wf.componentBulkheads[c.id]; ok {
+			defer wf.acquireLimiter(componentCtx, c, "bulkhead:"+bulkhead.name, bulkhead.pool.Acquire, bulkhead.pool.Release)()
+		}
+		componentCtx, releaseLimiters := wf.acquireComponentLimiters(componentCtx, c)
+		defer releaseLimiters()
+		componentCtx, cancel := context.WithCancel(componentCtx)
+		defer cancel()
+		var err error
+		if reason := c.startRun(cancel); reason != "" {
+			executionStatus = SKIPPED
+			errMsg = reason
+		} else if err = c.checkMinDuration(ctx.Deadline()); err == nil {
+			before = wf.recordSnapshot(dataTracker.store)
+			wf.setComponentStatus(c, RUNNING, "")
+			started := time.Now()
+			err = wf.executeCached(ctx, componentCtx, c, dataTracker)
+			if c.addComponentCfg != nil && c.addComponentCfg.ConcurrencyLimiter != nil {
+				c.addComponentCfg.ConcurrencyLimiter.Observe(time.Since(started), err)
+			}
+			if err == nil {
+				err = wf.enforceDataSizeLimit(componentCtx, c)
+			}
+		}
+		// a component cancelled by a first-of dependent is SKIPPED, unless it completed anyway
+		if reason := c.finishRun(); reason != "" && err != nil {
+			executionStatus = SKIPPED
+			errMsg = reason
+		} else if err != nil {
+			log.Println("Workflow.Execute:Error:Component execution failed for component:", c.id, "run:", wf.id, err)
+			c.setFailure(err)
+			wf.captureFailure(componentCtx, c, dataTracker, err)
+			executionStatus = ERROR
+			errMsg = err.Error()
+		}
+	}
+	// update the status of the component
+	wf.setComponentStatus(c, executionStatus, errMsg)
+	wf.recordStep(c, executionStatus, errMsg, before, dataTracker.store)
+	wf.emitPartialResult(c, executionStatus, dataTracker.store)
+	wf.dependencyManager.UpdateStatus(c.id, executionStatus)
+}
+
+func NewWorkflow[CT context.Context, C any, T any](ctx CT, opts ...*WorkflowOptions) *Workflow[CT, C, T] {
+	wf := &Workflow[CT, C, T]{
+		id:            uuid.New().String(),
+		status:        PENDING,
 		executed:      false,
 		componentsMap: map[string]*component[CT, C, T]{},
 		dependencyManager: &dependencyManager{
 			dependencyGraph:    map[string]map[string]bool{},
 			dependencyChannels: map[string]dependencyChannel{},
 			componentIdToName:  map[string]string{},
+			outcomes:           map[string]map[string]DependencyOutcome{},
+			anyOf:              map[string][][]string{},
+			optional:           map[string]bool{},
 		},
 	}
+	wf.nestUnder(ctx)
+	wf.applyOptions(opts)
+	return wf
 }
@@ -3,12 +3,14 @@ package goworkflow_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"math"
 	"testing"
 	"time"
 
 	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -236,3 +238,89 @@ func TestCustomInput(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, st, goworkflow.DONE)
 }
+
+func TestSlotPool(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	pool := limiter.NewSlotPool("gpu0")
+
+	wf.AddComponent(
+		goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			slot, _ := limiter.SlotFromContext(ctx)
+			dt.Update(func(d *Data) {
+				d.A = slot
+			})
+			return nil
+		}),
+		&goworkflow.ComponentConfig{SlotPool: pool},
+	)
+	wf.AddComponent(
+		goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			slot, _ := limiter.SlotFromContext(dt.Context())
+			dt.Update(func(d *Data) {
+				d.B = slot
+			})
+			return nil
+		}),
+		&goworkflow.ComponentConfig{SlotPool: pool},
+	)
+
+	data, st, err := wf.Execute(context.TODO(), Config{}, &Data{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "gpu0", data.A)
+	assert.Equal(t, "gpu0", data.B)
+}
+
+func TestOptionalComponent(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	ocr := wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 0, nil)))
+	thumbnail := wf.AddComponent(goworkflow.MakeComponent("Thumbnail", nil, ocrProvider("thumbnail", 0, errors.New("renderer crashed"))),
+		&goworkflow.ComponentConfig{Optional: true})
+	thumbnail.AddDependencies(ocr)
+	upload := wf.AddComponent(goworkflow.MakeComponent("UploadThumbnail", nil, ocrProvider("upload", 0, nil)))
+	upload.AddDependencies(thumbnail)
+	notify := wf.AddComponent(goworkflow.MakeComponent("NotifyThumbnailFailure", nil, ocrProvider("notify", 0, nil)))
+	notify.AddDependenciesOn(goworkflow.OnFailure, thumbnail)
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	assert.Equal(t, goworkflow.ERROR, thumbnail.Status().Status)
+	assert.Equal(t, goworkflow.SKIPPED, upload.Status().Status)
+	assert.Equal(t, goworkflow.DONE, notify.Status().Status)
+
+	result := wf.Result()
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, result.Status)
+	assert.Equal(t, []string{"Thumbnail"}, result.FailedComponents)
+	assert.False(t, result.FinishedAt.IsZero())
+}
+
+func TestOptionalComponentWithRequiredFailure(t *testing.T) {
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	wf.AddComponent(goworkflow.MakeComponent("OCR", nil, ocrProvider("ocr", 0, errors.New("down"))))
+	wf.AddComponent(goworkflow.MakeComponent("Thumbnail", nil, ocrProvider("thumbnail", 0, errors.New("down"))),
+		&goworkflow.ComponentConfig{Optional: true})
+
+	_, st, _ := wf.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.ERROR, st)
+}
+
+func TestLimiterObservations(t *testing.T) {
+	cl := limiter.NewConcurrencyLimiter(2)
+	tuner := limiter.NewAutoTuner(cl, limiter.AutoTuneOptions{Min: 1, Max: 4, MinSamples: 5})
+	wf := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	for i := 0; i < 6; i++ {
+		var err error
+		if i == 0 {
+			err = errors.New("failed page")
+		}
+		wf.AddComponent(goworkflow.MakeComponent(fmt.Sprint("Page", i), nil, ocrProvider("page", time.Millisecond, err)),
+			&goworkflow.ComponentConfig{ConcurrencyLimiter: cl})
+	}
+	wf.Execute(context.TODO(), Config{}, &Data{})
+
+	adj := tuner.Adjust()
+	assert.Equal(t, 6, adj.Samples)
+	assert.InDelta(t, 1.0/6, adj.ErrorRate, 0.001)
+	assert.Equal(t, 1, adj.To)
+}
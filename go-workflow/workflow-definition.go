@@ -0,0 +1,203 @@
+package goworkflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+WorkflowDefinition: workflow declared in YAML instead of code, e.g.
+
+	name: invoices
+	version: "3"
+	components:
+	  - name: OCR
+	    executor: ocr
+	    input: {dpi: 300}
+	    retry: {maxAttempts: 3, backoff: 2s}
+	  - name: Extract
+	    dependsOn: [OCR]
+	  - name: Alert
+	    dependsOnFailure: [Extract]
+
+The executors are resolved in a ComponentRegistry, see LoadTemplate.
+*/
+type WorkflowDefinition struct {
+	Name       string                `yaml:"name"`
+	Version    string                `yaml:"version,omitempty"`
+	Components []ComponentDefinition `yaml:"components"`
+}
+
+type ComponentDefinition struct {
+	Name string `yaml:"name"`
+	// Executor: name of the registered executor, the component name when empty
+	Executor string `yaml:"executor,omitempty"`
+	// Input of the executor, converted to its input type through json
+	Input               any        `yaml:"input,omitempty"`
+	DependsOn           []string   `yaml:"dependsOn,omitempty"`
+	DependsOnFailure    []string   `yaml:"dependsOnFailure,omitempty"`
+	DependsOnCompletion []string   `yaml:"dependsOnCompletion,omitempty"`
+	AnyOf               [][]string `yaml:"anyOf,omitempty"`
+	FirstOf             [][]string `yaml:"firstOf,omitempty"`
+	Optional            bool       `yaml:"optional,omitempty"`
+	Reads               []string   `yaml:"reads,omitempty"`
+	Writes              []string   `yaml:"writes,omitempty"`
+	ConfigReads         []string   `yaml:"configReads,omitempty"`
+	// ExpectedDuration e.g. 30s
+	ExpectedDuration time.Duration `yaml:"expectedDuration,omitempty"`
+	Retry            *struct {
+		MaxAttempts int           `yaml:"maxAttempts"`
+		Backoff     time.Duration `yaml:"backoff"`
+		MaxBackoff  time.Duration `yaml:"maxBackoff"`
+	} `yaml:"retry,omitempty"`
+}
+
+/* ParseWorkflowDefinition decodes a YAML definition, unknown fields are errors */
+func ParseWorkflowDefinition(definition []byte) (*WorkflowDefinition, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(definition))
+	decoder.KnownFields(true)
+	def := &WorkflowDefinition{}
+	if err := decoder.Decode(def); err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+	return def, nil
+}
+
+/* LoadTemplate: template of the YAML definition with the executors of r, all problems of the definition are reported */
+func LoadTemplate[CT context.Context, C any, T any](r *ComponentRegistry[CT, C, T], definition []byte) (*Template[CT, C, T], error) {
+	def, err := ParseWorkflowDefinition(definition)
+	if err != nil {
+		return nil, err
+	}
+	return TemplateFromDefinition(r, def)
+}
+
+/* TemplateFromDefinition: template of def with the executors of r */
+func TemplateFromDefinition[CT context.Context, C any, T any](r *ComponentRegistry[CT, C, T], def *WorkflowDefinition) (*Template[CT, C, T], error) {
+	if def.Name == "" {
+		return nil, errors.New("invalid workflow definition: name is required")
+	}
+	problems := []error{}
+	t := NewTemplate[CT, C, T](def.Name)
+	t.Version = def.Version
+	for i, cd := range def.Components {
+		if cd.Name == "" {
+			problems = append(problems, fmt.Errorf("component %d: name is required", i+1))
+			continue
+		}
+		if t.Component(cd.Name) != nil {
+			problems = append(problems, fmt.Errorf("component %s: declared twice", cd.Name))
+			continue
+		}
+		executor := cd.Executor
+		if executor == "" {
+			executor = cd.Name
+		}
+		component, err := r.Component(cd.Name, executor, cd.Input)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("component %s: %w", cd.Name, err))
+			continue
+		}
+		cfg := &ComponentConfig{
+			Optional:         cd.Optional,
+			Reads:            cd.Reads,
+			Writes:           cd.Writes,
+			ConfigReads:      cd.ConfigReads,
+			ExpectedDuration: cd.ExpectedDuration,
+		}
+		if cd.Retry != nil {
+			cfg.Retry = &RetryPolicy{MaxAttempts: cd.Retry.MaxAttempts, Backoff: cd.Retry.Backoff, MaxBackoff: cd.Retry.MaxBackoff}
+		}
+		t.AddComponent(component, cfg)
+	}
+
+	lookup := func(cd ComponentDefinition, names []string) []*TemplateComponent[CT, C, T] {
+		deps := []*TemplateComponent[CT, C, T]{}
+		for _, name := range names {
+			dep := t.Component(name)
+			if dep == nil {
+				problems = append(problems, fmt.Errorf("component %s: unknown dependency %s", cd.Name, name))
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		return deps
+	}
+	for _, cd := range def.Components {
+		tc := t.Component(cd.Name)
+		if tc == nil {
+			continue
+		}
+		tc.AddDependenciesOn(OnSuccess, lookup(cd, cd.DependsOn)...)
+		tc.AddDependenciesOn(OnFailure, lookup(cd, cd.DependsOnFailure)...)
+		tc.AddDependenciesOn(OnCompletion, lookup(cd, cd.DependsOnCompletion)...)
+		for _, group := range cd.AnyOf {
+			if deps := lookup(cd, group); len(deps) == len(group) {
+				tc.AddAnyOfDependencies(deps...)
+			}
+		}
+		for _, group := range cd.FirstOf {
+			if deps := lookup(cd, group); len(deps) == len(group) {
+				tc.AddFirstOfDependencies(deps...)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid workflow definition %s: %w", def.Name, errors.Join(problems...))
+	}
+	if cycle := t.cycle(); len(cycle) > 0 {
+		return nil, fmt.Errorf("invalid workflow definition %s: dependency cycle %s", def.Name, strings.Join(cycle, " -> "))
+	}
+	return t, nil
+}
+
+/* cycle: components of a dependency cycle, the first one repeated at the end, nil without cycles */
+func (t *Template[CT, C, T]) cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	path := []string{}
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		tc := t.byName[name]
+		deps := tc.Dependencies()
+		for _, group := range tc.AnyOf() {
+			deps = append(deps, group...)
+		}
+		for _, dep := range deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, tc := range t.components {
+		if cycle := visit(tc.Name()); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
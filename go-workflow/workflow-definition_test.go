@@ -0,0 +1,88 @@
+package goworkflow_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func definitionRegistry() *goworkflow.ComponentRegistry[context.Context, Config, Data] {
+	registry := goworkflow.NewComponentRegistry[context.Context, Config, Data]()
+	goworkflow.Register(registry, "ocr", func(ctx context.Context, input ocrInput, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.A = input.Language })
+		return nil
+	})
+	goworkflow.Register(registry, "fail", func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		return errors.New("no table found")
+	})
+	goworkflow.Register(registry, "alert", func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) { d.C = "alerted" })
+		return nil
+	})
+	return registry
+}
+
+func TestLoadTemplate(t *testing.T) {
+	template, err := goworkflow.LoadTemplate(definitionRegistry(), []byte(`
+name: invoices
+version: "3"
+components:
+  - name: OCR
+    executor: ocr
+    input: {Language: de, Pages: 2}
+    retry: {maxAttempts: 3, backoff: 2s}
+  - name: Extract
+    executor: fail
+    dependsOn: [OCR]
+    optional: true
+    expectedDuration: 1m
+  - name: Alert
+    executor: alert
+    dependsOnFailure: [Extract]
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "invoices", template.Name)
+	assert.Equal(t, "3", template.Version)
+	assert.Equal(t, 3, template.Component("OCR").Config().Retry.MaxAttempts)
+	assert.Equal(t, 2*time.Second, template.Component("OCR").Config().Retry.Backoff)
+	assert.Equal(t, time.Minute, template.Component("Extract").Config().ExpectedDuration)
+	assert.Equal(t, goworkflow.OnFailure, template.Component("Alert").DependencyOutcome("Extract"))
+
+	data, st, _ := template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Equal(t, goworkflow.DONE_WITH_WARNINGS, st)
+	assert.Equal(t, "de", data.A)
+	assert.Equal(t, "alerted", data.C)
+}
+
+func TestLoadTemplateProblems(t *testing.T) {
+	_, err := goworkflow.LoadTemplate(definitionRegistry(), []byte(`
+name: invoices
+components:
+  - name: OCR
+    executor: missing
+  - name: Extract
+    executor: fail
+    dependsOn: [Classify]
+  - name: Extract
+    executor: alert
+`))
+	assert.ErrorContains(t, err, "component OCR: unknown executor missing")
+	assert.ErrorContains(t, err, "component Extract: unknown dependency Classify")
+	assert.ErrorContains(t, err, "component Extract: declared twice")
+
+	_, err = goworkflow.LoadTemplate(definitionRegistry(), []byte(`
+name: invoices
+components:
+  - {name: ocr, dependsOn: [alert]}
+  - {name: alert, anyOf: [[fail, ocr]]}
+  - {name: fail}
+`))
+	assert.EqualError(t, err, "invalid workflow definition invoices: dependency cycle ocr -> alert -> ocr")
+
+	_, err = goworkflow.LoadTemplate(definitionRegistry(), []byte("name: invoices\ncomponents:\n  - {name: ocr, timeout: 1s}\n"))
+	assert.ErrorContains(t, err, "field timeout not found")
+}
@@ -0,0 +1,25 @@
+package goworkflow
+
+import "github.com/metaphi-org/go-workflow/go-workflow/limiter"
+
+/* WorkflowOptions: settings of a run, see NewWorkflow and Template.NewWorkflow */
+type WorkflowOptions struct {
+	// MaxParallelComponents caps the components executing at once in the run, in addition to the limiters of the
+	// components. Components waiting for a slot are not RUNNING yet. Unlimited when 0
+	MaxParallelComponents int
+}
+
+func (wf *Workflow[CT, C, T]) applyOptions(opts []*WorkflowOptions) {
+	if len(opts) > 1 {
+		panic("only one WorkflowOptions is allowed")
+	}
+	if len(opts) == 0 || opts[0] == nil {
+		return
+	}
+	if opts[0].MaxParallelComponents < 0 {
+		panic("MaxParallelComponents cannot be negative")
+	}
+	if opts[0].MaxParallelComponents > 0 {
+		wf.parallelism = limiter.NewConcurrencyLimiter(opts[0].MaxParallelComponents)
+	}
+}
@@ -0,0 +1,78 @@
+package goworkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxParallelComponents(t *testing.T) {
+	template := goworkflow.NewTemplate[context.Context, Config, Data]("pages")
+	var running, peak atomic.Int32
+	for i := 0; i < 12; i++ {
+		template.AddComponent(goworkflow.MakeComponent(fmt.Sprint("Page", i), nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}))
+	}
+	_, st, err := template.NewWorkflow(context.TODO(), &goworkflow.WorkflowOptions{MaxParallelComponents: 3}).Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, int32(3), peak.Load())
+
+	peak.Store(0)
+	template.Execute(context.TODO(), Config{}, &Data{})
+	assert.Greater(t, peak.Load(), int32(3))
+
+	assert.Panics(t, func() {
+		goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO(), &goworkflow.WorkflowOptions{MaxParallelComponents: -1})
+	})
+}
+
+func TestMaxParallelComponentsBeforeSharedLimiters(t *testing.T) {
+	shared := limiter.NewConcurrencyLimiter(1)
+	noop := func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error { return nil }
+	started, release := make(chan struct{}), make(chan struct{})
+
+	busy := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO(), &goworkflow.WorkflowOptions{MaxParallelComponents: 1})
+	busy.AddComponent(goworkflow.MakeComponent("Render", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		close(started)
+		<-release
+		return nil
+	}))
+	busy.AddComponent(goworkflow.MakeComponent("Upload", nil, noop), &goworkflow.ComponentConfig{ConcurrencyLimiter: shared})
+	done := make(chan struct{})
+	go func() {
+		busy.Execute(context.TODO(), Config{}, &Data{})
+		close(done)
+	}()
+	<-started
+	time.Sleep(20 * time.Millisecond)
+
+	// Upload waits for the slot of its run without holding the shared limiter
+	other := goworkflow.NewWorkflow[context.Context, Config, Data](context.TODO())
+	other.AddComponent(goworkflow.MakeComponent("Upload", nil, noop), &goworkflow.ComponentConfig{ConcurrencyLimiter: shared})
+	finished := make(chan goworkflow.Status)
+	go func() {
+		_, st, _ := other.Execute(context.TODO(), Config{}, &Data{})
+		finished <- st
+	}()
+	select {
+	case st := <-finished:
+		assert.Equal(t, goworkflow.DONE, st)
+	case <-time.After(2 * time.Second):
+		t.Error("the shared limiter is held by a component waiting for the slot of its run")
+	}
+	close(release)
+	<-done
+}
@@ -0,0 +1,248 @@
+package goworkflow
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+/*
+Template is a reusable workflow definition. A Workflow can only be executed once, a Template creates a fresh
+Workflow for every run. Component names are unique within a template, they identify components across versions.
+*/
+type Template[CT context.Context, C any, T any] struct {
+	Name string
+	// Version is recorded on every workflow created from the template, see Workflow.Version
+	Version    string
+	components []*TemplateComponent[CT, C, T]
+	byName     map[string]*TemplateComponent[CT, C, T]
+	parameters []Parameter
+	profiles   map[string]Profile
+	services   *Services
+	frozen     bool
+}
+
+type TemplateComponent[CT context.Context, C any, T any] struct {
+	template     *Template[CT, C, T]
+	definition   makeComponentConfig[CT, C, T]
+	config       *ComponentConfig
+	dependencies []string
+	// dependency name -> outcome, for dependencies which don't require success
+	outcomes map[string]DependencyOutcome
+	anyOf    []templateAnyOf
+}
+
+func NewTemplate[CT context.Context, C any, T any](name string) *Template[CT, C, T] {
+	return &Template[CT, C, T]{
+		Name:   name,
+		byName: map[string]*TemplateComponent[CT, C, T]{},
+	}
+}
+
+func (tc *TemplateComponent[CT, C, T]) Name() string {
+	return tc.definition.Name
+}
+
+/* AddDependencies: current component requires all d, they will be executed before it */
+func (tc *TemplateComponent[CT, C, T]) AddDependencies(d ...*TemplateComponent[CT, C, T]) {
+	tc.template.mustNotBeFrozen()
+	for _, dep := range d {
+		if dep.template != tc.template {
+			panic(fmt.Sprintf("dependency %s belongs to another template", dep.Name()))
+		}
+		tc.dependencies = append(tc.dependencies, dep.Name())
+	}
+}
+
+/* SetConfig replaces the ComponentConfig, e.g. to use another limiter in a cloned template */
+func (tc *TemplateComponent[CT, C, T]) SetConfig(cfg *ComponentConfig) {
+	tc.template.mustNotBeFrozen()
+	tc.config = cfg
+}
+
+func (t *Template[CT, C, T]) AddComponent(componentCfg makeComponentConfig[CT, C, T], cfgs ...*ComponentConfig) *TemplateComponent[CT, C, T] {
+	t.mustNotBeFrozen()
+	var cfg *ComponentConfig
+	if len(cfgs) > 1 {
+		panic("only one AddComponentConfig is allowed")
+	}
+	if len(cfgs) == 1 {
+		cfg = cfgs[0]
+	}
+	if len(componentCfg.Name) == 0 {
+		panic("name cannot be empty")
+	}
+	if componentCfg.Executor == nil {
+		panic("executor cannot be nil")
+	}
+	if _, ok := t.byName[componentCfg.Name]; ok {
+		panic(fmt.Sprintf("component %s already exists in template %s", componentCfg.Name, t.Name))
+	}
+	tc := &TemplateComponent[CT, C, T]{template: t, definition: componentCfg, config: cfg}
+	t.components = append(t.components, tc)
+	t.byName[componentCfg.Name] = tc
+	return tc
+}
+
+/* Freeze makes the template immutable, it can still be cloned and specialized */
+func (t *Template[CT, C, T]) Freeze() *Template[CT, C, T] {
+	t.frozen = true
+	return t
+}
+
+func (t *Template[CT, C, T]) Frozen() bool {
+	return t.frozen
+}
+
+func (t *Template[CT, C, T]) mustNotBeFrozen() {
+	if t.frozen {
+		panic(fmt.Sprintf("template %s is frozen", t.Name))
+	}
+}
+
+/*
+Clone: mutable copy of the template, changes to the clone don't affect the original. The clone tracks the health
+of Failover endpoints separately, result caches, their metrics and limiters stay shared.
+*/
+func (t *Template[CT, C, T]) Clone() *Template[CT, C, T] {
+	clone := NewTemplate[CT, C, T](t.Name)
+	clone.Version = t.Version
+	clone.parameters = slices.Clone(t.parameters)
+	clone.profiles = maps.Clone(t.profiles)
+	clone.services = t.services
+	for _, tc := range t.components {
+		var cfg *ComponentConfig
+		if tc.config != nil {
+			c := *tc.config
+			c.Reads = append([]string(nil), tc.config.Reads...)
+			c.Writes = append([]string(nil), tc.config.Writes...)
+			c.ConfigReads = slices.Clone(tc.config.ConfigReads)
+			if c.Retry != nil {
+				retry := *c.Retry
+				c.Retry = &retry
+			}
+			if c.SLO != nil {
+				slo := *c.SLO
+				c.SLO = &slo
+			}
+			if c.Cache != nil {
+				cache := *c.Cache
+				c.Cache = &cache
+			}
+			if c.Failover != nil {
+				c.Failover = c.Failover.clone()
+			}
+			cfg = &c
+		}
+		copied := &TemplateComponent[CT, C, T]{
+			template:     clone,
+			definition:   tc.definition,
+			config:       cfg,
+			dependencies: append([]string(nil), tc.dependencies...),
+		}
+		for _, group := range tc.anyOf {
+			copied.anyOf = append(copied.anyOf, templateAnyOf{dependencies: append([]string(nil), group.dependencies...), cancelOthers: group.cancelOthers})
+		}
+		for dep, outcome := range tc.outcomes {
+			if copied.outcomes == nil {
+				copied.outcomes = map[string]DependencyOutcome{}
+			}
+			copied.outcomes[dep] = outcome
+		}
+		clone.components = append(clone.components, copied)
+		clone.byName[copied.Name()] = copied
+	}
+	return clone
+}
+
+/* Component: component of the template by name, nil if it doesn't exist */
+func (t *Template[CT, C, T]) Component(name string) *TemplateComponent[CT, C, T] {
+	return t.byName[name]
+}
+
+/* Components of the template in declaration order */
+func (t *Template[CT, C, T]) Components() []*TemplateComponent[CT, C, T] {
+	return append([]*TemplateComponent[CT, C, T](nil), t.components...)
+}
+
+/* Dependencies: names of the components tc depends on, members of any-of groups not included */
+func (tc *TemplateComponent[CT, C, T]) Dependencies() []string {
+	return append([]string(nil), tc.dependencies...)
+}
+
+/* DependencyOutcome: outcome of the dependency tc requires, OnSuccess unless added with AddDependenciesOn */
+func (tc *TemplateComponent[CT, C, T]) DependencyOutcome(dependency string) DependencyOutcome {
+	if outcome, ok := tc.outcomes[dependency]; ok {
+		return outcome
+	}
+	return OnSuccess
+}
+
+/* AnyOf: names of the members of the any-of and first-of groups of tc */
+func (tc *TemplateComponent[CT, C, T]) AnyOf() [][]string {
+	groups := [][]string{}
+	for _, group := range tc.anyOf {
+		groups = append(groups, append([]string(nil), group.dependencies...))
+	}
+	return groups
+}
+
+/* Config: the ComponentConfig of tc, nil when it has none */
+func (tc *TemplateComponent[CT, C, T]) Config() *ComponentConfig {
+	return tc.config
+}
+
+/* Conditional: tc has dependencies which don't require success or any-of dependencies */
+func (tc *TemplateComponent[CT, C, T]) Conditional() bool {
+	return len(tc.outcomes) > 0 || len(tc.anyOf) > 0
+}
+
+/*
+ExecuteComponent executes only the named component on data, without waiting for its dependencies and without
+limiters or retries, for engines which schedule the components themselves (see the temporal package).
+*/
+func (t *Template[CT, C, T]) ExecuteComponent(ctx CT, name string, config C, data *T) error {
+	tc, ok := t.byName[name]
+	if !ok {
+		return fmt.Errorf("component %s doesn't exist in template %s", name, t.Name)
+	}
+	componentCtx := context.WithValue(ctx, componentInfoContextKey{}, ComponentInfo{Workflow: t.Name, Component: name})
+	componentCtx = contextWithServices(componentCtx, t.services)
+	dt := &DataTracker[C, T]{Config: config, store: &dataStore[T]{data: data}, ctx: componentCtx}
+	return tc.definition.Executor(componentContext(ctx, componentCtx), tc.definition.Input, dt)
+}
+
+/* NewWorkflow creates a fresh workflow from the template */
+func (t *Template[CT, C, T]) NewWorkflow(ctx CT, opts ...*WorkflowOptions) *Workflow[CT, C, T] {
+	wf := NewWorkflow[CT, C, T](ctx, opts...)
+	wf.name = t.Name
+	wf.version = t.Version
+	wf.services = t.services
+	added := map[string]*component[CT, C, T]{}
+	for _, tc := range t.components {
+		added[tc.Name()] = wf.AddComponent(tc.definition, tc.config)
+	}
+	for _, tc := range t.components {
+		for _, dep := range tc.dependencies {
+			outcome, ok := tc.outcomes[dep]
+			if !ok {
+				outcome = OnSuccess
+			}
+			added[tc.Name()].AddDependenciesOn(outcome, added[dep])
+		}
+		for _, group := range tc.anyOf {
+			members := []Component[CT, C, T]{}
+			for _, dep := range group.dependencies {
+				members = append(members, added[dep])
+			}
+			added[tc.Name()].addAnyOf(members, group.cancelOthers)
+		}
+	}
+	return wf
+}
+
+/* Execute runs a fresh workflow created from the template */
+func (t *Template[CT, C, T]) Execute(ctx CT, config C, data *T) (*T, Status, error) {
+	return t.NewWorkflow(ctx).Execute(ctx, config, data)
+}
@@ -0,0 +1,107 @@
+package goworkflow_test
+
+import (
+	"context"
+	"testing"
+
+	goworkflow "github.com/metaphi-org/go-workflow/go-workflow"
+	"github.com/metaphi-org/go-workflow/go-workflow/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCombineTemplate() *goworkflow.Template[context.Context, Config, Data] {
+	tpl := goworkflow.NewTemplate[context.Context, Config, Data]("combine")
+	a := tpl.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.A = "A"
+		})
+		return nil
+	}))
+	b := tpl.AddComponent(goworkflow.MakeComponent("B", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.B = "B"
+		})
+		return nil
+	}))
+	combine := tpl.AddComponent(goworkflow.MakeComponent("Combine", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.Combined = d.A + d.B
+		})
+		return nil
+	}))
+	combine.AddDependencies(a, b)
+	return tpl
+}
+
+func TestTemplateExecutesManyTimes(t *testing.T) {
+	tpl := newCombineTemplate()
+
+	for i := 0; i < 3; i++ {
+		data, st, err := tpl.Execute(context.TODO(), Config{}, &Data{})
+		assert.NoError(t, err)
+		assert.Equal(t, goworkflow.DONE, st)
+		assert.Equal(t, "AB", data.Combined)
+	}
+
+	assert.NotNil(t, tpl.Component("Combine"))
+	assert.Nil(t, tpl.Component("Missing"))
+	assert.Panics(t, func() {
+		tpl.AddComponent(goworkflow.MakeComponent("A", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			return nil
+		}))
+	})
+}
+
+func TestTemplateCloneAndFreeze(t *testing.T) {
+	base := newCombineTemplate()
+	base.Version = "v1"
+	base.Freeze()
+	assert.True(t, base.Frozen())
+	assert.Panics(t, func() {
+		base.AddComponent(goworkflow.MakeComponent("Extra", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+			return nil
+		}))
+	})
+	assert.Panics(t, func() {
+		base.Component("A").SetConfig(&goworkflow.ComponentConfig{})
+	})
+
+	tenant := base.Clone()
+	assert.False(t, tenant.Frozen())
+	assert.Equal(t, "v1", tenant.Version)
+	tenant.Component("A").SetConfig(&goworkflow.ComponentConfig{ConcurrencyLimiter: limiter.NewConcurrencyLimiter(1)})
+	extra := tenant.AddComponent(goworkflow.MakeComponent("Extra", nil, func(ctx context.Context, input any, dt *goworkflow.DataTracker[Config, Data]) error {
+		dt.Update(func(d *Data) {
+			d.C = "tenant:" + d.Combined
+		})
+		return nil
+	}))
+	extra.AddDependencies(tenant.Component("Combine"))
+
+	data, st, err := tenant.Execute(context.TODO(), Config{}, &Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, goworkflow.DONE, st)
+	assert.Equal(t, "tenant:AB", data.C)
+
+	assert.Nil(t, base.Component("Extra"))
+	diff := goworkflow.Diff(base, tenant)
+	assert.Equal(t, []string{"Extra"}, diff.AddedComponents)
+	assert.Equal(t, []string{"A"}, diff.ChangedComponents)
+}
+
+func TestTemplateExecuteComponent(t *testing.T) {
+	tpl := newCombineTemplate()
+	names := []string{}
+	for _, tc := range tpl.Components() {
+		names = append(names, tc.Name())
+	}
+	assert.Equal(t, []string{"A", "B", "Combine"}, names)
+	assert.Equal(t, []string{"A", "B"}, tpl.Component("Combine").Dependencies())
+	assert.False(t, tpl.Component("Combine").Conditional())
+
+	// only the component itself runs, its dependencies are up to the caller
+	data := &Data{A: "x"}
+	assert.NoError(t, tpl.ExecuteComponent(context.TODO(), "Combine", Config{}, data))
+	assert.Equal(t, &Data{A: "x", Combined: "x"}, data)
+	assert.EqualError(t, tpl.ExecuteComponent(context.TODO(), "Missing", Config{}, data), "component Missing doesn't exist in template combine")
+}